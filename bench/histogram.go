@@ -0,0 +1,42 @@
+package bench
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Histogram records request latencies and reports their percentiles. It is
+// not safe for concurrent use.
+type Histogram struct {
+	samples []time.Duration
+	sorted  bool
+}
+
+// Add records one latency.
+func (h *Histogram) Add(d time.Duration) {
+	h.samples = append(h.samples, d)
+	h.sorted = false
+}
+
+// Count returns the number of latencies recorded.
+func (h *Histogram) Count() int {
+	return len(h.samples)
+}
+
+// Percentile returns the latency below or at which p percent of the recorded
+// latencies fall, using the nearest-rank method. It returns zero when nothing
+// was recorded.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if len(h.samples) == 0 {
+		return 0
+	}
+	if !h.sorted {
+		sort.Slice(h.samples, func(i, j int) bool { return h.samples[i] < h.samples[j] })
+		h.sorted = true
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(h.samples))))
+	rank = min(max(rank, 1), len(h.samples))
+	return h.samples[rank-1]
+}
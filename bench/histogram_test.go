@@ -0,0 +1,30 @@
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	var h Histogram
+	if got := h.Percentile(50); got != 0 {
+		t.Fatalf("empty histogram p50 = %v, want 0", got)
+	}
+
+	for i := 100; i >= 1; i-- {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	cases := map[float64]time.Duration{
+		0:   time.Millisecond,
+		50:  50 * time.Millisecond,
+		95:  95 * time.Millisecond,
+		99:  99 * time.Millisecond,
+		100: 100 * time.Millisecond,
+	}
+	for p, want := range cases {
+		if got := h.Percentile(p); got != want {
+			t.Errorf("p%v = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is a load profile: the mix of Server endpoints its requests go to.
+type Profile struct {
+	Name        string
+	Description string
+	// Paths are the endpoints requested, in turn, relative to the server URL.
+	Paths []string
+}
+
+var profiles = []Profile{
+	{Name: "fast", Description: "immediate 200 responses", Paths: []string{"fast"}},
+	{Name: "slow", Description: "200 responses after 10ms", Paths: []string{"slow"}},
+	{Name: "notfound", Description: "templated 404 pages", Paths: []string{"ghost"}},
+	{
+		Name:        "mixed",
+		Description: "80% fast, 10% slow and 10% not found",
+		Paths:       []string{"fast", "fast", "fast", "fast", "slow", "fast", "fast", "fast", "fast", "ghost"},
+	},
+}
+
+// Profiles returns the built-in load profiles.
+func Profiles() []Profile {
+	return append([]Profile(nil), profiles...)
+}
+
+// LookupProfile returns the built-in load profile called name.
+func LookupProfile(name string) (Profile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return Profile{}, fmt.Errorf("unknown load profile %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// Words returns n wordlist entries spreading requests over the paths of the
+// profile. Every entry is distinct, since a scan requests each URL once.
+func (p Profile) Words(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("%s/%d", p.Paths[i%len(p.Paths)], i)
+	}
+	return words
+}
@@ -8,8 +8,9 @@ import (
 )
 
 // Server provides a lightweight HTTP server with predefined benchmarking
-// endpoints. The server exposes fast and slow handlers as well as a custom 404
-// template response for unknown routes.
+// endpoints. The server exposes fast and slow handlers, also below /fast/ and
+// /slow/ so that every request of a load profile can use a distinct path, as
+// well as a custom 404 template response for unknown routes.
 type Server struct {
 	srv      *httptest.Server
 	notFound *template.Template
@@ -30,7 +31,9 @@ func NewServer() *Server {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/fast", s.fastHandler)
+	mux.HandleFunc("/fast/", s.fastHandler)
 	mux.HandleFunc("/slow", s.slowHandler)
+	mux.HandleFunc("/slow/", s.slowHandler)
 	mux.HandleFunc("/", s.notFoundHandler)
 
 	s.srv = httptest.NewServer(mux)
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hydr0g3n/bench"
+	"hydr0g3n/pkg/engine"
+)
+
+// benchResult is the outcome of one load profile at one concurrency, and a
+// line of the --json output of "hydro bench".
+type benchResult struct {
+	Profile        string  `json:"profile"`
+	Concurrency    int     `json:"concurrency"`
+	Requests       int     `json:"requests"`
+	Errors         int     `json:"errors"`
+	DurationMS     int64   `json:"duration_ms"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	P50MS          float64 `json:"p50_ms"`
+	P95MS          float64 `json:"p95_ms"`
+	P99MS          float64 `json:"p99_ms"`
+	AllocsPerReq   float64 `json:"allocs_per_request"`
+	BytesPerReq    float64 `json:"bytes_per_request"`
+}
+
+// runBenchCommand implements "hydro bench". It scans the built-in benchmark
+// server, or the target given with --url, with every combination of the
+// selected load profiles and concurrency levels and reports latency
+// percentiles, throughput, and allocations, so performance regressions can be
+// measured from one build to the next. It returns the process exit code.
+func runBenchCommand(binaryName string, args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s bench [--profile fast,mixed] [--concurrency 1,16] [--requests 1000] [--url target/FUZZ]\n", binaryName)
+		fmt.Fprintln(flags.Output(), "\nMeasures scan latency, throughput, and allocations under load profiles:")
+		for _, p := range bench.Profiles() {
+			fmt.Fprintf(flags.Output(), "  %-9s %s\n", p.Name, p.Description)
+		}
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	profileList := flags.String("profile", "fast,slow,notfound,mixed", "Comma-separated load profiles to run")
+	concurrencyList := flags.String("concurrency", "1,16,64", "Comma-separated concurrency levels to run each profile at")
+	requests := flags.Int("requests", 1000, "Requests sent per profile and concurrency level")
+	target := flags.String("url", "", "Target URL with FUZZ to benchmark instead of the built-in server")
+	throttle := flags.Duration("throttle", 0, "Minimum delay between requests across all workers")
+	timeout := flags.Duration("timeout", 10*time.Second, "HTTP request timeout")
+	jsonOut := flags.Bool("json", false, "Print one JSON object per result instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 || *requests <= 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	var profiles []bench.Profile
+	for _, name := range strings.Split(*profileList, ",") {
+		p, err := bench.LookupProfile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		profiles = append(profiles, p)
+	}
+
+	var levels []int
+	for _, field := range strings.Split(*concurrencyList, ",") {
+		level, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || level <= 0 {
+			fmt.Fprintf(os.Stderr, "%s: invalid concurrency %q\n", binaryName, strings.TrimSpace(field))
+			return exitUsage
+		}
+		levels = append(levels, level)
+	}
+
+	url := strings.TrimSpace(*target)
+	if url == "" {
+		srv := bench.NewServer()
+		defer srv.Close()
+		url = srv.URL() + "/FUZZ"
+	} else if !strings.Contains(url, "FUZZ") {
+		fmt.Fprintf(os.Stderr, "%s: --url must contain FUZZ\n", binaryName)
+		return exitUsage
+	}
+
+	dir, err := os.MkdirTemp("", "hydro-bench-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	defer os.RemoveAll(dir)
+
+	var results []benchResult
+	for _, p := range profiles {
+		wordlist := filepath.Join(dir, p.Name+".txt")
+		if err := os.WriteFile(wordlist, []byte(strings.Join(p.Words(*requests), "\n")+"\n"), 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: write wordlist: %v\n", binaryName, err)
+			return exitRuntime
+		}
+
+		for _, level := range levels {
+			res, err := runBenchProfile(engine.Config{
+				URL:         url,
+				Wordlist:    wordlist,
+				Concurrency: level,
+				Timeout:     *timeout,
+				Throttle:    *throttle,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+				return exitRuntime
+			}
+			res.Profile = p.Name
+			if *jsonOut {
+				if err := json.NewEncoder(os.Stdout).Encode(res); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+					return exitRuntime
+				}
+			}
+			results = append(results, res)
+		}
+	}
+
+	if !*jsonOut {
+		printBenchResults(results)
+	}
+	return exitOK
+}
+
+// runBenchProfile runs one scan and measures it. Allocations are those of the
+// whole process, which includes the built-in server when it is used.
+func runBenchProfile(cfg engine.Config) (benchResult, error) {
+	res := benchResult{Concurrency: cfg.Concurrency}
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+
+	results, err := engine.Run(context.Background(), cfg)
+	if err != nil {
+		return res, err
+	}
+
+	var latencies bench.Histogram
+	for r := range results {
+		res.Requests++
+		if r.Err != nil {
+			res.Errors++
+			continue
+		}
+		latencies.Add(r.Duration)
+	}
+
+	elapsed := time.Since(started)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	res.DurationMS = elapsed.Milliseconds()
+	if elapsed > 0 {
+		res.RequestsPerSec = float64(res.Requests) / elapsed.Seconds()
+	}
+	res.P50MS = durationMS(latencies.Percentile(50))
+	res.P95MS = durationMS(latencies.Percentile(95))
+	res.P99MS = durationMS(latencies.Percentile(99))
+	if res.Requests > 0 {
+		res.AllocsPerReq = float64(after.Mallocs-before.Mallocs) / float64(res.Requests)
+		res.BytesPerReq = float64(after.TotalAlloc-before.TotalAlloc) / float64(res.Requests)
+	}
+	return res, nil
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func printBenchResults(results []benchResult) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "PROFILE\tCONC\tREQUESTS\tERRORS\tREQ/S\tP50\tP95\tP99\tALLOCS/REQ\tBYTES/REQ\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.0f\t%.2fms\t%.2fms\t%.2fms\t%.0f\t%.0f\t\n",
+			r.Profile, r.Concurrency, r.Requests, r.Errors, r.RequestsPerSec,
+			r.P50MS, r.P95MS, r.P99MS, r.AllocsPerReq, r.BytesPerReq)
+	}
+	tw.Flush()
+}
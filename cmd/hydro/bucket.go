@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"hydr0g3n/pkg/bucket"
+	"hydr0g3n/pkg/engine"
+)
+
+// Scan modes accepted by --mode.
+const (
+	// scanModeDir fuzzes paths on the target.
+	scanModeDir = "dir"
+	// scanModeBucket fuzzes cloud storage buckets named after the target.
+	scanModeBucket = "bucket"
+	// scanModeHeader fuzzes the names of headers sent to the target.
+	scanModeHeader = "header"
+)
+
+// parseScanMode validates a --mode value, defaulting to scanModeDir.
+func parseScanMode(v string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(v)); mode {
+	case "":
+		return scanModeDir, nil
+	case scanModeDir, scanModeBucket, scanModeHeader:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q (choose from dir, bucket, header)", v)
+	}
+}
+
+// bucketPayloads returns a payload transform that turns every word into the
+// URLs probing the buckets named after it and target. Azure containers are
+// only probed when the storage account named after target resolves, since
+// every probe of a missing account would fail.
+func bucketPayloads(ctx context.Context, binaryName, target string) func(string) ([]string, error) {
+	base := bucket.BaseName(target)
+	if base == "" {
+		fmt.Fprintf(os.Stderr, "%s: no bucket name can be derived from %q; probing the words alone\n", binaryName, target)
+	}
+
+	account := bucket.Account(base)
+	if account != "" {
+		if _, err := net.DefaultResolver.LookupHost(ctx, bucket.AzureHost(account)); err != nil {
+			account = ""
+		}
+	}
+
+	return func(word string) ([]string, error) {
+		var urls []string
+		for _, name := range bucket.Names(word, base) {
+			urls = append(urls, bucket.URLs(name, account)...)
+		}
+		return urls, nil
+	}
+}
+
+// checkBucket decides whether the probe res found a bucket, tagging it with
+// the provider and what its answer revealed.
+func checkBucket(res *engine.Result) bool {
+	if res.Err != nil {
+		return false
+	}
+
+	finding, ok := bucket.Interpret(res.URL, res.StatusCode, res.Body)
+	if !ok || !finding.Found() {
+		return false
+	}
+
+	res.Tags = appendTags(res.Tags, []string{"bucket", finding.Provider, finding.State})
+	if finding.Code != "" {
+		res.Notes = append(res.Notes, finding.Provider+" answered "+finding.Code)
+	}
+	return true
+}
+
+// chainTransforms returns a payload transform applying first, when set, and
+// then next to each of its payloads.
+func chainTransforms(first, next func(string) ([]string, error)) func(string) ([]string, error) {
+	if first == nil {
+		return next
+	}
+	return func(payload string) ([]string, error) {
+		payloads, err := first(payload)
+		if err != nil {
+			return nil, err
+		}
+		var out []string
+		for _, p := range payloads {
+			expanded, err := next(p)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+		}
+		return out, nil
+	}
+}
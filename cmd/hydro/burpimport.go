@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"hydr0g3n/pkg/burpxml"
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/templater"
+)
+
+// importBurp loads the Burp export at path and keeps the items in scope,
+// reporting how many were left out.
+func importBurp(binaryName, path string, scope *engine.Scope) ([]burpxml.Item, error) {
+	items, err := burpxml.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := items[:0]
+	for _, item := range items {
+		if scope.Allows(item.URL) {
+			kept = append(kept, item)
+		}
+	}
+	if skipped := len(items) - len(kept); skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s: skipping %d items out of scope\n", binaryName, path, skipped)
+	}
+	return kept, nil
+}
+
+// burpTargets turns the directories reached by items into scan targets
+// using wordlist.
+func burpTargets(items []burpxml.Item, wordlist string) []engine.Target {
+	seeds := burpxml.Seeds(items, templater.DefaultPlaceholder)
+	targets := make([]engine.Target, 0, len(seeds))
+	for _, seed := range seeds {
+		targets = append(targets, engine.Target{URL: seed, Wordlist: wordlist})
+	}
+	return targets
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// tryBypasses requests the variants of the denied hit res at once and
+// returns their results in order. Variants answering 200 are tagged with tag
+// and their technique as bypass findings.
+func tryBypasses(ctx context.Context, executor *engine.FollowUpExecutor, res engine.Result, variants []engine.Bypass, tag string) []engine.Result {
+	results := make([]engine.Result, len(variants))
+
+	var wg sync.WaitGroup
+	for i, b := range variants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt := executor.Execute(ctx, res, b.Request)
+			attempt.Bypass = b.Technique
+			if bypassed(attempt) {
+				attempt.Tags = appendTags(attempt.Tags, []string{tag, b.Technique})
+				note := fmt.Sprintf("bypasses %d at %s", res.StatusCode, res.URL)
+				if sent := describeVariant(res, b.Request); sent != "" {
+					note = sent + " " + note
+				}
+				attempt.Notes = append(attempt.Notes, note)
+			}
+			results[i] = attempt
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// bypassed reports whether the bypass attempt res got past the access
+// control.
+func bypassed(res engine.Result) bool {
+	return res.Err == nil && res.StatusCode == http.StatusOK
+}
+
+// describeVariant names the method and headers req sends on behalf of res,
+// as "POST with X-Http-Method-Override: DELETE", or returns "" when only its
+// URL differs.
+func describeVariant(res engine.Result, req engine.FollowUp) string {
+	if req.Method == "" && len(req.Headers) == 0 {
+		return ""
+	}
+
+	method := req.Method
+	if method == "" {
+		method = res.RequestMethod
+	}
+	if len(req.Headers) == 0 {
+		return method
+	}
+
+	headers := make([]string, 0, len(req.Headers))
+	for name, values := range req.Headers {
+		headers = append(headers, name+": "+strings.Join(values, ", "))
+	}
+	sort.Strings(headers)
+	return method + " with " + strings.Join(headers, ", ")
+}
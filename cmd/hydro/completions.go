@@ -7,6 +7,9 @@ import (
 	"sort"
 	"strings"
 	"text/template"
+
+	"hydr0g3n/pkg/config"
+	"hydr0g3n/pkg/httpclient"
 )
 
 type completionFlag struct {
@@ -14,11 +17,37 @@ type completionFlag struct {
 	Usage   string
 	IsBool  bool
 	IsShort bool
+	// Files completes the value of the flag with file names, and Values
+	// with a fixed list of words.
+	Files  bool
+	Values []string
+}
+
+// valueCompletions returns how the values of flags are completed. Flags
+// missing from it complete any value.
+func valueCompletions() map[string]completionFlag {
+	return map[string]completionFlag{
+		"w":               {Files: true},
+		"output":          {Files: true},
+		"openapi":         {Files: true},
+		"blacklist":       {Files: true},
+		"import-burp":     {Files: true},
+		"json-body":       {Files: true},
+		"profile":         {Values: config.ProfileNames()},
+		"mode":            {Values: []string{scanModeDir, scanModeBucket, scanModeHeader}},
+		"view":            {Values: []string{"table", "tree"}},
+		"sort":            {Values: []string{"found", "severity"}},
+		"min-severity":    {Values: []string{"info", "low", "medium", "high", "critical"}},
+		"color-mode":      {Values: []string{"auto", "always", "never"}},
+		"output-format":   {Values: []string{"jsonl", "json"}},
+		"tls-fingerprint": {Values: httpclient.TLSFingerprints()},
+	}
 }
 
 func collectCompletionFlags() []completionFlag {
 	var flags []completionFlag
 	seen := make(map[string]struct{})
+	values := valueCompletions()
 	flag.CommandLine.VisitAll(func(f *flag.Flag) {
 		if f.Name == "completion-script" {
 			return
@@ -36,6 +65,8 @@ func collectCompletionFlags() []completionFlag {
 			Usage:   usage,
 			IsBool:  isBool,
 			IsShort: len(f.Name) == 1,
+			Files:   values[f.Name].Files,
+			Values:  values[f.Name].Values,
 		})
 		seen[f.Name] = struct{}{}
 	})
@@ -90,21 +121,40 @@ func outputCompletionScript(w io.Writer, shell string) error {
 }
 
 func renderBashCompletion(flags []completionFlag) (string, error) {
-	var opts []string
+	type valueCase struct {
+		Pattern string
+		Files   bool
+		Words   string
+	}
+
+	var (
+		opts  []string
+		cases []valueCase
+	)
 	for _, f := range flags {
 		prefix := "--"
 		if f.IsShort {
 			prefix = "-"
 		}
 		opts = append(opts, prefix+f.Name)
+
+		if f.Files || len(f.Values) > 0 {
+			cases = append(cases, valueCase{
+				Pattern: "-" + f.Name + "|--" + f.Name,
+				Files:   f.Files,
+				Words:   strings.Join(f.Values, " "),
+			})
+		}
 	}
 
 	sort.Strings(opts)
 
 	data := struct {
 		Options string
+		Cases   []valueCase
 	}{
 		Options: strings.Join(opts, " "),
+		Cases:   cases,
 	}
 
 	var buf strings.Builder
@@ -119,6 +169,8 @@ func renderZshCompletion(flags []completionFlag) (string, error) {
 		Option string
 		Usage  string
 		HasArg bool
+		Files  bool
+		Values string
 	}
 
 	var entries []entry
@@ -131,6 +183,8 @@ func renderZshCompletion(flags []completionFlag) (string, error) {
 			Option: option,
 			Usage:  escapeForZsh(f.Usage),
 			HasArg: !f.IsBool,
+			Files:  f.Files,
+			Values: escapeForZsh(strings.Join(f.Values, " ")),
 		})
 	}
 
@@ -153,11 +207,18 @@ func renderFishCompletion(flags []completionFlag) (string, error) {
 		Long   string
 		Usage  string
 		HasArg bool
+		Files  bool
+		Values string
 	}
 
 	var entries []entry
 	for _, f := range flags {
-		e := entry{Usage: escapeForFish(f.Usage), HasArg: !f.IsBool}
+		e := entry{
+			Usage:  escapeForFish(f.Usage),
+			HasArg: !f.IsBool,
+			Files:  f.Files,
+			Values: escapeForFish(strings.Join(f.Values, " ")),
+		}
 		if f.IsShort {
 			e.Short = f.Name
 		} else {
@@ -182,11 +243,28 @@ func renderFishCompletion(flags []completionFlag) (string, error) {
 var bashTemplate = template.Must(template.New("bash").Parse(`# bash completion for hydro
 _hydro_completions()
 {
-    local cur opts
+    local cur prev opts
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
     opts="{{ .Options }}"
 
+    # bash splits "--flag=value" into "--flag", "=" and "value".
+    if [[ ${cur} == "=" ]]; then
+        cur=""
+    elif [[ ${prev} == "=" ]]; then
+        prev="${COMP_WORDS[COMP_CWORD-2]}"
+    fi
+
+    case "${prev}" in
+{{- range .Cases }}
+        {{ .Pattern }})
+            {{ if .Files }}COMPREPLY=( $(compgen -f -- "${cur}") ){{ else }}COMPREPLY=( $(compgen -W "{{ .Words }}" -- "${cur}") ){{ end }}
+            return 0
+            ;;
+{{- end }}
+    esac
+
     if [[ ${cur} == -* ]]; then
         COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
         return 0
@@ -201,14 +279,14 @@ var zshTemplate = template.Must(template.New("zsh").Funcs(template.FuncMap{
 
 _arguments \
 {{- range $index, $flag := .Entries }}
-  '{{ $flag.Option }}{{ if $flag.Usage }}[{{ $flag.Usage }}]{{ end }}{{ if $flag.HasArg }}:value:_guard "^-" "option argument"{{ end }}'{{- if lt (plus $index 1) (len $.Entries) }} \
+  '{{ $flag.Option }}{{ if $flag.Usage }}[{{ $flag.Usage }}]{{ end }}{{ if $flag.Files }}:file:_files{{ else if $flag.Values }}:value:({{ $flag.Values }}){{ else if $flag.HasArg }}:value:_guard "^-" "option argument"{{ end }}'{{- if lt (plus $index 1) (len $.Entries) }} \
 {{- end }}
 {{- end }}
 `))
 
 var fishTemplate = template.Must(template.New("fish").Parse(`# fish completion for hydro
 {{- range .Entries }}
-complete -c hydro{{ if .Short }} -s {{ .Short }}{{ end }}{{ if .Long }} -l {{ .Long }}{{ end }}{{ if .HasArg }} -r{{ end }}{{ if .Usage }} -d '{{ .Usage }}'{{ end }}
+complete -c hydro{{ if .Short }} -s {{ .Short }}{{ end }}{{ if .Long }} -l {{ .Long }}{{ end }}{{ if .Files }} -r -F{{ else if .Values }} -x -a '{{ .Values }}'{{ else if .HasArg }} -r{{ end }}{{ if .Usage }} -d '{{ .Usage }}'{{ end }}
 {{- end }}`))
 
 func escapeForZsh(input string) string {
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderCompletion(t *testing.T) {
+	flags := []completionFlag{
+		{Name: "dry-run", Usage: "Display planned permutations", IsBool: true},
+		{Name: "mode", Usage: "Scan mode: dir or bucket", Values: []string{"dir", "bucket", "header"}},
+		{Name: "output", Usage: "Write results to a file", Files: true},
+		{Name: "sni", Usage: "TLS server name [host]"},
+		{Name: "u", Usage: "Target URL's FUZZ template", IsShort: true},
+	}
+
+	tests := []struct {
+		shell  string
+		render func([]completionFlag) (string, error)
+		want   []string
+	}{
+		{
+			shell:  "bash",
+			render: renderBashCompletion,
+			want: []string{
+				`opts="--dry-run --mode --output --sni -u"`,
+				"-mode|--mode)\n            COMPREPLY=( $(compgen -W \"dir bucket header\" -- \"${cur}\") )",
+				"-output|--output)\n            COMPREPLY=( $(compgen -f -- \"${cur}\") )",
+				"complete -F _hydro_completions hydro",
+			},
+		},
+		{
+			shell:  "zsh",
+			render: renderZshCompletion,
+			want: []string{
+				`'--dry-run[Display planned permutations]' \`,
+				`'--mode[Scan mode\: dir or bucket]:value:(dir bucket header)' \`,
+				`'--output[Write results to a file]:file:_files' \`,
+				`'--sni[TLS server name \[host\]]:value:_guard "^-" "option argument"' \`,
+				`'-u[Target URL'\''s FUZZ template]:value:_guard "^-" "option argument"'` + "\n",
+			},
+		},
+		{
+			shell:  "fish",
+			render: renderFishCompletion,
+			want: []string{
+				"complete -c hydro -l dry-run -d 'Display planned permutations'\n",
+				"complete -c hydro -l mode -x -a 'dir bucket header' -d 'Scan mode: dir or bucket'",
+				"complete -c hydro -l output -r -F -d 'Write results to a file'",
+				"complete -c hydro -l sni -r -d 'TLS server name [host]'",
+				`complete -c hydro -s u -r -d 'Target URL\'s FUZZ template'`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := tt.render(flags)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Fatalf("expected %q in the %s script:\n%s", want, tt.shell, script)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputCompletionScriptRejectsUnknownShell(t *testing.T) {
+	if err := outputCompletionScript(io.Discard, "powershell"); err == nil || !strings.Contains(err.Error(), "unsupported shell") {
+		t.Fatalf("expected an unsupported shell error, got %v", err)
+	}
+}
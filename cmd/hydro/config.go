@@ -0,0 +1,376 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"hydr0g3n/pkg/config"
+	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/output"
+)
+
+// effectiveConfig is the resolved configuration printed by config validate.
+type effectiveConfig struct {
+	Target         string `yaml:"target,omitempty"`
+	Targets        string `yaml:"targets,omitempty"`
+	Wordlist       string `yaml:"wordlist,omitempty"`
+	Profiles       string `yaml:"profiles,omitempty"`
+	config.Profile `yaml:",inline"`
+}
+
+// runConfigCommand implements "hydro config validate". It accepts the same
+// flags as a scan plus profile files as arguments, resolves them exactly as a
+// scan would, reports every problem it finds, and prints the effective
+// configuration. It returns the process exit code.
+func runConfigCommand(binaryName string, args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintf(os.Stderr, "Usage: %s config validate [options] [profile.yaml ...]\n", binaryName)
+		return 2
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s config validate [options] [profile.yaml ...]\n", binaryName)
+		fmt.Fprintln(flag.CommandLine.Output(), "\nChecks profile files, user profiles, and scan flags without sending requests.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Accepts the same flags as a scan.")
+	}
+	_ = flag.CommandLine.Parse(args[1:])
+
+	var problems []string
+	// A profile value is checked again once it has been applied to the
+	// flags, so problems are only reported the first time they are seen.
+	seen := make(map[string]struct{})
+	report := func(source string, err error) {
+		if _, ok := seen[err.Error()]; ok {
+			return
+		}
+		seen[err.Error()] = struct{}{}
+		problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	if _, err := config.ApplyEnvironment(flag.CommandLine); err != nil {
+		report("environment", err)
+	}
+
+	if profileDir, err := config.DefaultProfileDir(); err == nil {
+		checkProfileDir(profileDir, report)
+	}
+
+	var specs []string
+	if selected := strings.TrimSpace(lookupString("profile")); selected != "" {
+		specs = append(specs, selected)
+	}
+	if lookupBool("beginner") {
+		specs = []string{"beginner"}
+	}
+
+	// Profile files given as arguments are composed after --profile in the
+	// order listed, and may extend each other by file name.
+	for _, path := range flag.Args() {
+		profile, errs := config.CheckProfileFile(path)
+		for _, err := range errs {
+			report(path, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := config.RegisterProfile(name, profile); err != nil {
+			report(path, err)
+			continue
+		}
+		specs = append(specs, name)
+	}
+
+	if len(specs) > 0 {
+		resolved, err := config.ResolveProfile(strings.Join(specs, ","))
+		if err != nil {
+			report("profile", err)
+		} else {
+			for _, err := range resolved.Validate() {
+				report("profile", err)
+			}
+			if _, err := config.ApplyProfile(flag.CommandLine, resolved); err != nil {
+				report("profile", err)
+			}
+		}
+	}
+
+	effective := effectiveConfig{
+		Target:   strings.TrimSpace(lookupString("u")),
+		Targets:  strings.TrimSpace(lookupString("targets")),
+		Wordlist: strings.TrimSpace(lookupString("w")),
+		Profiles: strings.Join(specs, ","),
+		Profile: config.Profile{
+			Method:          strings.ToUpper(strings.TrimSpace(lookupString("method"))),
+			Concurrency:     lookupInt("concurrency"),
+			Throttle:        lookupDuration("throttle"),
+			Recursive:       lookupBool("recursive"),
+			Timeout:         lookupDuration("timeout"),
+			FollowRedirects: lookupBool("follow-redirects"),
+			MatchStatus:     strings.TrimSpace(lookupString("match-status")),
+			FilterSize:      strings.TrimSpace(lookupString("filter-size")),
+			Outputs:         []string{"pretty"},
+		},
+	}
+	if lookupString("output") != "" {
+		effective.Outputs = append(effective.Outputs, strings.ToLower(lookupString("output-format")))
+	}
+
+	for _, err := range checkEffectiveConfig(effective) {
+		report("config", err)
+	}
+
+	data, err := yaml.Marshal(effective)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: encode configuration: %v\n", binaryName, err)
+		return 1
+	}
+	fmt.Fprint(os.Stdout, string(data))
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", binaryName, problem)
+		}
+		fmt.Fprintf(os.Stderr, "%s: configuration has %d problem(s)\n", binaryName, len(problems))
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: configuration is valid\n", binaryName)
+	return 0
+}
+
+// checkProfileDir checks and registers every user profile in dir, so that
+// problems in all files are reported rather than only the first.
+func checkProfileDir(dir string, report func(string, error)) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			report(dir, err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		profile, errs := config.CheckProfileFile(path)
+		for _, err := range errs {
+			report(path, err)
+		}
+		if err := config.RegisterProfile(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), profile); err != nil {
+			report(path, err)
+		}
+	}
+}
+
+// checkEffectiveConfig reports problems in the resolved settings that would
+// make a scan fail or behave differently than intended.
+func checkEffectiveConfig(cfg effectiveConfig) []error {
+	var problems []error
+
+	switch cfg.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost:
+	default:
+		problems = append(problems, fmt.Errorf("unsupported method %q", cfg.Method))
+	}
+
+	if cfg.Concurrency <= 0 {
+		problems = append(problems, fmt.Errorf("--concurrency must be positive, got %d", cfg.Concurrency))
+	}
+	if _, err := matcher.ParseStatusList(cfg.MatchStatus); err != nil {
+		problems = append(problems, err)
+	}
+	if _, err := matcher.ParseSizeRange(cfg.FilterSize); err != nil {
+		problems = append(problems, err)
+	}
+	if threshold := lookupFloat("similarity-threshold"); threshold < 0 || threshold > 1 {
+		problems = append(problems, errors.New("--similarity-threshold must be between 0 and 1"))
+	}
+	switch format := strings.ToLower(lookupString("output-format")); format {
+	case "", "jsonl":
+	case "json":
+		if !lookupBool("dry-run") {
+			problems = append(problems, errors.New("--output-format json requires --dry-run"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unsupported output format %q", format))
+	}
+	if size := strings.TrimSpace(lookupString("output-rotate-size")); size != "" {
+		if _, err := output.ParseSize(size); err != nil {
+			problems = append(problems, fmt.Errorf("--output-rotate-size: %w", err))
+		}
+	}
+
+	if (lookupBool("aggressive") || cfg.Recursive || lookupBool("bypass") || lookupBool("method-override") || lookupBool("allow-dangerous")) && !lookupBool("confirm-legal") {
+		problems = append(problems, errors.New("--aggressive, --recursive, --bypass, --method-override, and --allow-dangerous require --confirm-legal"))
+	}
+	if cfg.Target != "" && cfg.Targets != "" {
+		problems = append(problems, errors.New("-u is ignored when --targets is given"))
+	}
+
+	if cfg.Targets == "" {
+		if cfg.Wordlist != "" {
+			if err := checkWordlist(cfg.Wordlist); err != nil {
+				problems = append(problems, err)
+			}
+		}
+		return problems
+	}
+
+	targets, err := config.LoadTargets(cfg.Targets)
+	if err != nil {
+		return append(problems, err)
+	}
+	if _, err := resolveTargets(targets, cfg.Wordlist); err != nil {
+		problems = append(problems, err)
+	}
+
+	checked := make(map[string]struct{})
+	for _, target := range targets {
+		path := target.Wordlist
+		if path == "" {
+			path = cfg.Wordlist
+		}
+		if _, ok := checked[path]; ok || path == "" {
+			continue
+		}
+		checked[path] = struct{}{}
+
+		if err := checkWordlist(path); err != nil {
+			problems = append(problems, fmt.Errorf("target %s: %w", target.URL, err))
+		}
+	}
+
+	return problems
+}
+
+func checkWordlist(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("wordlist %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("wordlist %s is a directory", path)
+	}
+	return nil
+}
+
+func lookupValue(name string) any {
+	f := flag.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return nil
+	}
+	return getter.Get()
+}
+
+func lookupString(name string) string {
+	value, _ := lookupValue(name).(string)
+	return value
+}
+
+func lookupInt(name string) int {
+	value, _ := lookupValue(name).(int)
+	return value
+}
+
+func lookupInt64(name string) int64 {
+	value, _ := lookupValue(name).(int64)
+	return value
+}
+
+func lookupFloat(name string) float64 {
+	value, _ := lookupValue(name).(float64)
+	return value
+}
+
+func lookupBool(name string) bool {
+	value, _ := lookupValue(name).(bool)
+	return value
+}
+
+func lookupDuration(name string) time.Duration {
+	value, _ := lookupValue(name).(time.Duration)
+	return value
+}
+
+// secretFlags hold values that may carry credentials, so --print-config only
+// shows whether they are set.
+var secretFlags = map[string]struct{}{
+	"d":            {},
+	"pre-hook":     {},
+	"cookie":       {},
+	"stream-token": {},
+}
+
+// printEffectiveFlags writes the resolved value of every flag in fs and where
+// it came from: the command line, a HYDRO_* environment variable, the profile,
+// or the default. fromEnv and fromProfile name the flags set by
+// config.ApplyEnvironment and config.ApplyProfile.
+func printEffectiveFlags(w io.Writer, fs *flag.FlagSet, fromEnv, fromProfile []string, profile string) error {
+	sources := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = "command line"
+	})
+	for _, name := range fromEnv {
+		sources[name] = "environment (" + config.EnvName(name) + ")"
+	}
+	for _, name := range fromProfile {
+		sources[name] = "profile " + profile
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tVALUE\tSOURCE")
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "print-config" || f.Name == "completion-script" {
+			return
+		}
+
+		source, ok := sources[f.Name]
+		if !ok {
+			source = "default"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name, redactFlagValue(f.Name, f.Value.String()), source)
+	})
+	return tw.Flush()
+}
+
+// redactFlagValue hides the value of secret flags and the passwords of URLs.
+func redactFlagValue(name, value string) string {
+	if value == "" {
+		return `""`
+	}
+	if _, ok := secretFlags[name]; ok {
+		return "[redacted]"
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
+
+// hashSecret stands in for the value of a secret flag in the run
+// configuration, which is stored in the resume database and the JSONL run
+// header. The hash still tells runs with different values apart.
+func hashSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/output"
+	"hydr0g3n/pkg/store"
+)
+
+// runExportCommand implements "hydro export". It writes the hits a SQLite
+// resume database recorded for one run as Burp Suite items, so they can be
+// imported into Burp long after the scan finished. It returns the process
+// exit code.
+func runExportCommand(binaryName string, args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export --format burp --db resume.db --run <id> [--out items.xml]\n", binaryName)
+		fmt.Fprintln(flags.Output(), "\nWrites the hits stored for a run as Burp Suite items.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "burp", "Export format (burp)")
+	dbPath := flags.String("db", "", "SQLite resume database written with --resume")
+	runID := flags.String("run", "", "Identifier of the run to export, as set with --run-id")
+	outPath := flags.String("out", "", "Path to write the export to (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if strings.TrimSpace(*dbPath) == "" || strings.TrimSpace(*runID) == "" {
+		flags.Usage()
+		return exitUsage
+	}
+	if f := strings.ToLower(strings.TrimSpace(*format)); f != "burp" {
+		fmt.Fprintf(os.Stderr, "%s: unsupported export format %q\n", binaryName, f)
+		return exitUsage
+	}
+
+	db, err := store.OpenSQLiteReadOnly(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	defer db.Close()
+
+	run, err := db.LoadRun(context.Background(), strings.TrimSpace(*runID))
+	if errors.Is(err, store.ErrRunNotFound) {
+		fmt.Fprintf(os.Stderr, "%s: %s has no run %s\n", binaryName, *dbPath, *runID)
+		return exitRuntime
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+
+	var writer *output.BurpWriter
+	method, _ := run.ConfigValue("method")
+	if trimmed := strings.TrimSpace(*outPath); trimmed != "" {
+		writer, err = output.NewBurpFile(trimmed, method)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+	} else {
+		writer = output.NewBurpWriter(os.Stdout, method)
+	}
+
+	if err := exportBurp(writer, run); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: write export: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	return exitOK
+}
+
+// exportBurp writes the hits of run to writer and closes it. Hits recorded
+// for failed requests have no response and are left out. The database keeps
+// no bodies or headers, so the items carry the status line and length only.
+func exportBurp(writer *output.BurpWriter, run store.RunHistory) error {
+	for _, hit := range run.Hits {
+		if hit.StatusCode == 0 {
+			continue
+		}
+		res := engine.Result{
+			URL:           hit.Path,
+			StatusCode:    hit.StatusCode,
+			ContentLength: hit.ContentLength,
+			Duration:      hit.Duration,
+			Tags:          hit.Tags,
+			Notes:         hit.Notes,
+			// The recorded length, not that of the missing body.
+			ResponseHeader: http.Header{"Content-Length": {strconv.FormatInt(hit.ContentLength, 10)}},
+		}
+		if err := writer.Write(res); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/templater"
+)
+
+// formList collects the values of the repeated --form flag.
+type formList []string
+
+func (f *formList) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *formList) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("form field is empty")
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// formTargets turns the --form fields specs into a scan target posting them
+// to url as multipart/form-data, with the placeholders in field values and
+// filenames replaced by each payload.
+func formTargets(url string, specs []string) ([]engine.Target, error) {
+	fields := make([]templater.FormField, 0, len(specs))
+	for _, spec := range specs {
+		field, err := templater.ParseFormField(spec)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	form, err := templater.NewForm(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return []engine.Target{{
+		URL: url,
+		BuildRequest: func(payload string) (engine.PayloadRequest, error) {
+			body, contentType, err := form.Expand(payload)
+			// Every body has its own boundary, so requests are told
+			// apart by payload.
+			return engine.PayloadRequest{Body: body, Header: http.Header{"Content-Type": {contentType}}, Key: payload}, err
+		},
+	}}, nil
+}
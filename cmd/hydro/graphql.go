@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// probeGraphQL sends the introspection query to the endpoint of the hit res
+// and records what its answer revealed on res. It returns the probe.
+func probeGraphQL(ctx context.Context, executor *engine.FollowUpExecutor, res *engine.Result) engine.Result {
+	probe := executor.Execute(ctx, *res, engine.IntrospectionProbe())
+	if probe.Err != nil {
+		return probe
+	}
+	if report, ok := engine.ParseIntrospection(probe.Body); ok {
+		res.GraphQL = &report
+	}
+	return probe
+}
+
+// writeGraphQLWordlist writes names to path as a wordlist, sorted and
+// without duplicates.
+func writeGraphQLWordlist(path string, names []string) error {
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/templater"
+)
+
+// headerProbeValue is the value sent with every header name probed by
+// --mode header.
+const headerProbeValue = "1"
+
+// headerRequest returns the payload request adding the header named payload,
+// for --mode header.
+func headerRequest(payload string) (engine.PayloadRequest, error) {
+	if !validHeaderName(payload) {
+		return engine.PayloadRequest{}, fmt.Errorf("%q is not a valid header name", payload)
+	}
+	return engine.PayloadRequest{
+		Header: http.Header{http.CanonicalHeaderKey(payload): {headerProbeValue}},
+		Key:    strings.ToLower(payload),
+	}, nil
+}
+
+// cookieRequest returns a BuildRequest sending cookie with the placeholders
+// replaced by each payload, for --cookie values holding FUZZ.
+func cookieRequest(cookie string) func(payload string) (engine.PayloadRequest, error) {
+	tpl := templater.New()
+	return func(payload string) (engine.PayloadRequest, error) {
+		expanded := tpl.Expand(cookie, payload)
+		return engine.PayloadRequest{Cookie: expanded, Key: expanded}, nil
+	}
+}
+
+// validHeaderName reports whether name is a token, as header names must be.
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return false
+		default:
+			return !strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+		}
+	})
+}
+
+// headerBaseline is how the target answers without a probed header.
+type headerBaseline struct {
+	status int
+	size   int
+	// stableSize is set when two baseline requests got bodies of the same
+	// size, so that any other size points at a change in behaviour. Pages
+	// that vary on their own are compared by similarity instead.
+	stableSize bool
+}
+
+// checkHeaderChange decides whether the probe res changed how the target
+// answers compared to base, noting what changed. outcome is the evaluation of
+// res by a matcher comparing bodies with the baseline.
+func checkHeaderChange(res *engine.Result, base headerBaseline, outcome matcher.MatchOutcome) bool {
+	if res.Err != nil {
+		return false
+	}
+
+	var change string
+	switch {
+	case res.StatusCode != base.status:
+		change = fmt.Sprintf("status %d instead of %d", res.StatusCode, base.status)
+	case base.stableSize && len(res.Body) != base.size:
+		change = fmt.Sprintf("a body of %d bytes instead of %d", len(res.Body), base.size)
+	case !base.stableSize && outcome.HasSimilarity && outcome.Matched:
+		change = fmt.Sprintf("a body %.0f%% similar to the baseline", outcome.Similarity*100)
+	default:
+		return false
+	}
+
+	res.Notes = append(res.Notes, fmt.Sprintf("%s: %s gave %s", http.CanonicalHeaderKey(res.Payload), headerProbeValue, change))
+
+	res.Tags = appendTags(res.Tags, []string{"header"})
+	return true
+}
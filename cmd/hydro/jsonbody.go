@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/templater"
+)
+
+// jsonBodyTargets turns the JSON document at path into scan targets sending
+// it to url with the value of field replaced by each payload. Without field,
+// every string value of the document is fuzzed in turn, one target per value.
+func jsonBodyTargets(url, path, field string) ([]engine.Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read --json-body: %w", err)
+	}
+	tpl, err := templater.ParseJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	fields := []string{field}
+	if field == "" {
+		if fields = tpl.StringFields(); len(fields) == 0 {
+			return nil, fmt.Errorf("%s has no string values to fuzz", path)
+		}
+	} else if !tpl.Has(field) {
+		return nil, fmt.Errorf("%s has no field %q", path, field)
+	}
+
+	targets := make([]engine.Target, 0, len(fields))
+	for _, field := range fields {
+		targets = append(targets, engine.Target{
+			URL: url,
+			BuildRequest: func(payload string) (engine.PayloadRequest, error) {
+				body, err := tpl.Expand(field, payload)
+				return engine.PayloadRequest{Body: body, Header: http.Header{"Content-Type": {"application/json"}}}, err
+			},
+		})
+	}
+	return targets, nil
+}
@@ -3,23 +3,36 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"hydr0g3n/pkg/burpxml"
 	"hydr0g3n/pkg/config"
 	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/fingerprint"
 	"hydr0g3n/pkg/httpclient"
 	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/openapi"
 	"hydr0g3n/pkg/output"
+	"hydr0g3n/pkg/paths"
+	"hydr0g3n/pkg/plugin"
+	"hydr0g3n/pkg/robots"
 	"hydr0g3n/pkg/store"
 	"hydr0g3n/pkg/templater"
+	"hydr0g3n/pkg/waf"
 )
 
 const asciiBanner = `
@@ -36,7 +49,28 @@ const asciiBanner = `
                                                                                  
 `
 
+// Exit statuses of a scan, so scripts can branch on its outcome.
+const (
+	// exitOK means the scan completed without hits, or with hits and
+	// --fail-on-hits=false.
+	exitOK = 0
+	// exitHits means the scan completed with hits.
+	exitHits = 1
+	// exitUsage means the flags, environment, or profiles are invalid.
+	exitUsage = 2
+	// exitRuntime means the scan could not start, every request failed, or
+	// the results could not be written.
+	exitRuntime = 3
+)
+
 func main() {
+	os.Exit(run())
+}
+
+// run scans with the options on the command line and returns the exit
+// status. Returning rather than exiting lets the deferred cleanup close the
+// plugins, outputs, and resume database first.
+func run() int {
 	const binaryName = "hydro"
 
 	fmt.Fprint(os.Stderr, asciiBanner)
@@ -47,35 +81,107 @@ func main() {
 	var (
 		targetURL           = flag.String("u", "", "Target URL or template (required)")
 		wordlist            = flag.String("w", "", "Path to the wordlist file (required)")
+		modeFlag            = flag.String("mode", scanModeDir, "What to fuzz (dir, bucket, header); bucket probes S3, GCS, and Azure buckets named after -u and each word, header sends each word as a header name to -u")
+		targetsFile         = flag.String("targets", "", "File of target URLs with optional per-target overrides, scanned instead of -u")
+		importBurpFile      = flag.String("import-burp", "", "Burp Suite XML export whose in-scope hosts and directories are scanned as targets")
+		burpBaselines       = flag.Bool("import-burp-baselines", false, "Use the 404 responses recorded in --import-burp as the similarity baseline of their hosts")
+		openapiSpec         = flag.String("openapi", "", "OpenAPI or Swagger spec whose documented routes are scanned, with FUZZ at every path and query parameter")
+		parallelTargets     = flag.Bool("parallel-targets", false, "Scan all --targets at once, sharing --concurrency workers among them")
+		maxWordlistLine     = flag.Int("max-wordlist-line", engine.DefaultMaxWordlistLine, "Longest wordlist line to read, in bytes")
+		scopeInclude        = flag.String("scope-include", "", "Only request URLs matching this regular expression, including redirects and follow-up requests")
+		scopeExclude        = flag.String("scope-exclude", "", "Never request URLs matching this regular expression, including redirects and follow-up requests")
+		blacklistFile       = flag.String("blacklist", "", "File of further dangerous path segments to skip, one per line, on top of the built-in list")
+		allowDangerous      = flag.Bool("allow-dangerous", false, "Request dangerous paths such as logout, delete, and shutdown, which are skipped by default")
+		respectRobots       = flag.Bool("respect-robots", false, "Fetch the robots.txt of every target and skip the paths it disallows")
+		normalizeURLs       = flag.Bool("normalize-urls", false, "Lowercase the scheme and host of request URLs and remove //, ./ and ../ from their paths")
+		orderFlag           = flag.String("order", engine.OrderAsIs, "Order in which words are sent (as-is, alpha, frequency); frequency sends likely hits first")
 		concurrency         = flag.Int("concurrency", 10, "Number of concurrent workers")
+		throttle            = flag.Duration("throttle", 0, "Minimum delay between dispatched requests (e.g. 100ms)")
+		jitter              = flag.Duration("jitter", 0, "Upper bound of a random delay added before each dispatched request (e.g. 500ms)")
+		maxBandwidth        = flag.String("max-bandwidth", "", "Cap the rate at which all workers read responses (e.g. 5MB/s)")
+		breakerThreshold    = flag.Int("breaker-threshold", 10, "Consecutive connection errors or timeouts that pause requests to a host (0 disables)")
+		breakerCooldown     = flag.Duration("breaker-cooldown", 30*time.Second, "How long requests to a failing host are paused")
+		dnsCacheTTL         = flag.Duration("dns-cache-ttl", 30*time.Second, "How long resolved hostnames are cached (0 disables the cache)")
+		noKeepAlive         = flag.Bool("no-keepalive", false, "Close the connection after every request instead of reusing it")
+		ipv4Only            = flag.Bool("4", false, "Connect to targets over IPv4 only")
+		ipv6Only            = flag.Bool("6", false, "Connect to targets over IPv6 only")
 		timeout             = flag.Duration("timeout", 10*time.Second, "Request timeout duration")
-		outputPath          = flag.String("output", "", "Path to write output results")
-		outputFormat        = flag.String("output-format", "jsonl", "Format for --output (jsonl)")
+		outputPath          = flag.String("output", "", "Path to write output results (gzip-compressed when it ends in .gz)")
+		outputRotateSize    = flag.String("output-rotate-size", "", "Split --output into numbered files of this size (e.g. 500MB)")
+		outputFormat        = flag.String("output-format", "jsonl", "Format for --output (jsonl), or json to print the --dry-run plan as JSON")
 		beginner            = flag.Bool("beginner", false, "Enable beginner-friendly defaults")
 		profile             = flag.String("profile", "", "Named execution profile to load")
+		saveProfile         = flag.String("save-profile", "", "Save the effective configuration of this run as a named user profile")
 		matchStatus         = flag.String("match-status", "", "Comma-separated list of HTTP status codes to include in hits")
 		filterSize          = flag.String("filter-size", "", "Filter visible hits by response size range (min-max bytes)")
-		resumePath          = flag.String("resume", "", "Path to a SQLite database for resuming and recording runs")
+		resumePath          = flag.String("resume", "", "Path to a SQLite database for resuming and recording runs (\"default\" uses the data directory)")
+		resumeBackend       = flag.String("resume-backend", store.BackendSQLite, "Storage backend for --resume (sqlite, bolt)")
+		resumeFrom          = flag.String("resume-from", "", "Skip URLs already present in a previous JSONL output file")
+		dedupScopeFlag      = flag.String("dedup-scope", store.DedupScopeRun, "Scope used to skip previously attempted paths with --resume (run, target, global)")
 		methodFlag          = flag.String("method", http.MethodHead, "HTTP method to use for requests (GET, HEAD, POST)")
+		noMethodFallback    = flag.Bool("no-method-fallback", false, "Keep HEAD responses with 405 Method Not Allowed instead of repeating them with GET")
+		smartMethod         = flag.Bool("smart-method", false, "Send HEAD requests and repeat with GET only those answering 2xx, 3xx, 401, or 403")
 		runID               = flag.String("run-id", "", "Override the deterministic run identifier used for persistence")
 		followRedirects     = flag.Bool("follow-redirects", false, "Follow HTTP redirects (up to 5 hops)")
+		proxyFlag           = flag.String("proxy", "", "Send requests through an HTTP, HTTPS, or SOCKS5 proxy URL")
+		acceptEncoding      = flag.String("accept-encoding", engine.DefaultAcceptEncoding, "Accept-Encoding to request; gzip and deflate bodies are decoded before matching (identity disables compression)")
+		hostHeader          = flag.String("host-header", "", "Host header to send while connecting to the host in the URL")
+		cookie              = flag.String("cookie", "", "Cookie header to send with every request, such as 'session=FUZZ'; FUZZ is replaced by each payload")
+		sniFlag             = flag.String("sni", "", "TLS server name to send instead of the URL's host")
+		tlsFingerprintFlag  = flag.String("tls-fingerprint", "", "Send the TLS ClientHello of a browser (chrome, firefox, random) instead of Go's own")
+		data                = flag.String("d", "", "Request body to send with every request; @path streams the file from disk")
+		jsonBody            = flag.String("json-body", "", "JSON document to send as the body, with the value at --fuzz-field replaced by each payload")
+		fuzzField           = flag.String("fuzz-field", "", "Dot-separated path of the --json-body value to fuzz, such as user.name (default: every string value in turn)")
 		similarityThreshold = flag.Float64("similarity-threshold", 0.6, "Hide hits whose bodies are this similar to the baseline (0-1)")
 		noBaseline          = flag.Bool("no-baseline", false, "Disable the automatic baseline request used for similarity filtering")
+		noCharsetDecode     = flag.Bool("no-charset-decode", false, "Compare response bodies as raw bytes instead of transcoding their declared charset to UTF-8")
+		autoWAFSafe         = flag.Bool("auto-waf-safe", false, "Slow down to the waf-safe profile's pacing when the baseline response shows a WAF or CDN")
+		maxBodySize         = flag.Int64("max-body-size", engine.DefaultMaxBodySize, "Bytes of each response body kept for matching, similarity, and plugins")
+		discardBodies       = flag.Bool("discard-bodies", false, "Drop the bodies of non-matching responses as soon as they are checked, keeping their hash")
 		showSimilarity      = flag.Bool("show-similarity", false, "Include similarity scores in output (debug)")
 		viewModeFlag        = flag.String("view", "table", "Pretty output layout (table, tree)")
+		sortFlag            = flag.String("sort", "found", "Order of table rows (found, severity); severity prints the most severe hits first once the scan ends")
+		minSeverityFlag     = flag.String("min-severity", "", "Only report hits of at least this severity (info, low, medium, high, critical)")
 		colorModeFlag       = flag.String("color-mode", "auto", "Color output mode (auto, always, never)")
 		colorPresetFlag     = flag.String("color-preset", "default", "Color palette for pretty output (default, protanopia, tritanopia, blue-light)")
 		burpExport          = flag.String("burp-export", "", "Write matched requests and responses to a Burp-compatible XML file")
-		burpHost            = flag.String("burp-host", "", "POST matched findings to a Burp Collaborator endpoint")
+		burpHost            = flag.String("burp-host", "", "Stream matched findings as they are found to a Burp extension endpoint (e.g. http://127.0.0.1:1337), retrying while it is unavailable")
+		pluginPolicy        = flag.String("plugin-policy", plugin.PolicyAll, "How the verdicts of several --plugin are combined (all, any, weighted)")
+		pluginThreshold     = flag.Float64("plugin-threshold", 0.5, "Confidence a hit needs to be kept with --plugin-policy=weighted (0-1)")
+		pluginWorkers       = flag.Int("plugin-workers", 4, "Number of hits verified by --plugin or captured by --screenshot-cmd at the same time")
+		pluginOrder         = flag.String("plugin-order", pluginOrderOrdered, "Deliver verified hits in arrival order or as soon as they are verified (ordered, unordered)")
+		pluginTransport     = flag.String("plugin-transport", plugin.TransportExec, "How to talk to --plugin (exec, jsonrpc, grpc)")
+		transformPlugin     = flag.String("transform-plugin", "", "Plugin that rewrites each payload into zero or more payloads before requests are built")
+		outputPlugin        = flag.String("output-plugin", "", "Program that receives every hit as JSONL on stdin and delivers it")
+		streamAddr          = flag.String("stream-addr", "", "Serve hits live as server-sent events at http://<addr>/events (e.g. 127.0.0.1:8787)")
+		streamToken         = flag.String("stream-token", "", "Token --stream-addr clients must send as a Bearer token or ?token=; also lets browser pages on other origins subscribe")
+		includeHeaders      = flag.Bool("output-include-headers", false, "Include the Content-Type, Location, and Server response headers in JSONL output")
+		outputHeaders       = flag.String("output-headers", "", "Comma-separated response headers to include in JSONL output (e.g. Server,Content-Type)")
+		matchPlugin         = flag.String("match-plugin", "", "Plugin that decides whether each response is a hit, alongside the built-in matchers")
+		screenshotCmd       = flag.String("screenshot-cmd", "", "Shell command run for every hit to capture a screenshot; {url} and {output} are replaced with the hit URL and an image path")
+		screenshotDir       = flag.String("screenshot-dir", "screenshots", "Directory for the image paths given to --screenshot-cmd")
 		preHook             = flag.String("pre-hook", "", "Shell command to run once before requests to fetch auth headers (stdout JSON)")
 		completionScript    = flag.String("completion-script", "", "Print shell completion script for the specified shell (bash, zsh, fish)")
 		dryRun              = flag.Bool("dry-run", false, "Display planned permutations without sending any requests")
+		printConfig         = flag.Bool("print-config", false, "Print every setting after applying the environment and profile, with its source, and exit")
+		failOnHits          = flag.Bool("fail-on-hits", true, "Exit with status 1 when the scan completes with hits (--fail-on-hits=false exits 0)")
 		progressFile        = flag.String("progress-file", "", "Path to store progress checkpoints for resuming runs")
+		progressMode        = flag.String("progress-mode", engine.ProgressByIndex, "How --progress-file resumes (index, hash); hash detects an edited wordlist")
 		aggressive          = flag.Bool("aggressive", false, "Enable aggressive permutations that may disrupt targets")
 		recursive           = flag.Bool("recursive", false, "Enable recursive discovery that can rapidly expand scope")
-		confirmLegal        = flag.Bool("confirm-legal", false, "Acknowledge that you are authorized for aggressive or recursive scans")
+		recursionDepth      = flag.Int("recursion-depth", engine.DefaultRecursionDepth, "How many directories below the target --recursive scans")
+		recursionPayloads   = flag.Int("recursion-payloads", 0, "Maximum requests sent inside each directory found by --recursive (0 = no limit)")
+		bypassDenied        = flag.Bool("bypass", false, "Retry 401 and 403 hits with path and header tricks, reporting variants answering 200 as bypass findings")
+		methodOverride      = flag.Bool("method-override", false, "Retry 403, 405, and 501 hits with other methods and method override headers, reporting variants answering 200")
+		graphqlWordlist     = flag.String("graphql-wordlist", "", "Write the query and mutation names of GraphQL endpoints that allow introspection to this file, as a wordlist")
+		confirmLegal        = flag.Bool("confirm-legal", false, "Acknowledge that you are authorized for aggressive, recursive, bypass, method override, or --allow-dangerous scans")
 	)
 
+	var pluginSpecs pluginList
+	flag.Var(&pluginSpecs, "plugin", "Plugin executable that verifies each hit and receives run lifecycle events (repeatable, path[=weight])")
+	var formSpecs formList
+	flag.Var(&formSpecs, "form", "Multipart form field to send, name=value or name=@file[;filename=name][;type=mime], with FUZZ in values and filenames (repeatable)")
+
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -u <url> -w <wordlist> [options]\n", binaryName)
 		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
@@ -85,13 +191,88 @@ func main() {
 		fmt.Fprintln(flag.CommandLine.Output(), "\nFor detailed usage, install the man page and run: man hydro")
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		return runConfigCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		return runReplayCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "monitor" {
+		return runMonitorCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		return runReportCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		return runExportCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		return runBenchCommand(binaryName, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "resume" {
+		args, code := runResumeCommand(binaryName, os.Args[2:])
+		if args == nil {
+			return code
+		}
+		os.Args = append([]string{os.Args[0]}, args...)
+	}
+
 	flag.Parse()
+	commandLine := config.SetFlags(flag.CommandLine)
+
+	fromEnv, err := config.ApplyEnvironment(flag.CommandLine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+
+	if profileDir, err := config.DefaultProfileDir(); err == nil {
+		if _, err := config.LoadUserProfiles(profileDir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+
+	selectedProfile := strings.TrimSpace(*profile)
+	if *beginner {
+		selectedProfile = "beginner"
+	}
+
+	var (
+		resolvedProfile config.Profile
+		hasProfile      bool
+		fromProfile     []string
+	)
+	if selectedProfile != "" {
+		resolvedProfile, err = config.ResolveProfile(selectedProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v (available: %s)\n", binaryName, err, strings.Join(config.ProfileNames(), ", "))
+			return exitUsage
+		}
+		hasProfile = true
 
-	destructiveScan := *aggressive || *recursive
+		// Profile values only fill in settings that were not given explicitly
+		// on the command line or through the environment.
+		fromProfile, err = config.ApplyProfile(flag.CommandLine, resolvedProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+
+	if *printConfig {
+		if err := printEffectiveFlags(os.Stdout, flag.CommandLine, fromEnv, fromProfile, selectedProfile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		return exitOK
+	}
+
+	destructiveScan := *aggressive || *recursive || *bypassDenied || *methodOverride || *allowDangerous
 	if destructiveScan {
 		banner := strings.TrimSpace(`
 HYDRO SAFETY NOTICE
-Aggressive or recursive fuzzing can stress or damage target systems and may be illegal without explicit authorization.
+Aggressive, recursive, or access-control bypass fuzzing can stress or damage target systems and may be illegal without explicit authorization.
 Only continue if you are operating within the law and the documented scope of your engagement.`)
 
 		fmt.Fprintln(os.Stderr, banner)
@@ -99,45 +280,171 @@ Only continue if you are operating within the law and the documented scope of yo
 
 		if !*confirmLegal {
 			fmt.Fprintln(os.Stderr, "Refusing to continue without --confirm-legal to acknowledge authorization.")
-			os.Exit(2)
+			return exitUsage
 		}
 	}
 
 	viewMode, err := output.ParseViewMode(*viewModeFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(2)
+		return exitUsage
+	}
+
+	sortOrder, err := output.ParseSortOrder(*sortFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+
+	var minSeverity string
+	if trimmed := strings.TrimSpace(*minSeverityFlag); trimmed != "" {
+		if minSeverity, err = engine.ParseSeverity(trimmed); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
 	}
 
 	colorMode, err := output.ParseColorMode(*colorModeFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(2)
+		return exitUsage
 	}
 
 	colorPreset, err := output.ParseColorPreset(*colorPresetFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(2)
+		return exitUsage
+	}
+
+	policy, err := plugin.ParsePolicy(*pluginPolicy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if *pluginThreshold < 0 || *pluginThreshold > 1 {
+		fmt.Fprintf(os.Stderr, "%s: plugin threshold must be between 0 and 1\n", binaryName)
+		return exitUsage
+	}
+	if *pluginWorkers < 1 {
+		fmt.Fprintf(os.Stderr, "%s: plugin workers must be at least 1\n", binaryName)
+		return exitUsage
+	}
+	orderedPlugins, err := parsePluginOrder(*pluginOrder)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
 	}
 
 	if script := strings.TrimSpace(*completionScript); script != "" {
 		if err := outputCompletionScript(os.Stdout, script); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(2)
+			return exitUsage
 		}
-		return
+		return exitOK
 	}
 
-	if *targetURL == "" {
-		exitWithUsage("a target URL must be provided with -u")
+	var targets []config.Target
+	if trimmed := strings.TrimSpace(*targetsFile); trimmed != "" {
+		targets, err = config.LoadTargets(trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		if len(targets) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: targets file %s lists no targets\n", binaryName, trimmed)
+			return exitUsage
+		}
 	}
 
-	if *wordlist == "" {
-		exitWithUsage("a wordlist must be provided with -w")
+	var apiRequests []openapi.Request
+	if trimmed := strings.TrimSpace(*openapiSpec); trimmed != "" {
+		if len(targets) > 0 {
+			return usageError("--openapi cannot be combined with --targets")
+		}
+		spec, err := openapi.Load(trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		base, err := spec.BaseURL(*targetURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %v\n", binaryName, trimmed, err)
+			return exitUsage
+		}
+		apiRequests = spec.Requests(base, templater.DefaultPlaceholder)
+		if len(apiRequests) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s documents no operations\n", binaryName, trimmed)
+			return exitUsage
+		}
+	}
+
+	scope, err := engine.NewScope(*scopeInclude, *scopeExclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if trimmed := strings.TrimSpace(*blacklistFile); trimmed != "" && *allowDangerous {
+		return usageError("--blacklist cannot be combined with --allow-dangerous")
+	}
+	if !*allowDangerous {
+		segments := engine.DefaultBlacklist
+		if trimmed := strings.TrimSpace(*blacklistFile); trimmed != "" {
+			extra, err := engine.LoadBlacklist(trimmed)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+				return exitUsage
+			}
+			segments = append(append([]string(nil), segments...), extra...)
+		}
+		if scope == nil {
+			scope = &engine.Scope{}
+		}
+		scope.Blacklist = engine.NewBlacklist(segments)
+	}
+
+	var burpItems []burpxml.Item
+	if trimmed := strings.TrimSpace(*importBurpFile); trimmed != "" {
+		if len(targets) > 0 || len(apiRequests) > 0 {
+			return usageError("--import-burp cannot be combined with --targets or --openapi")
+		}
+		burpItems, err = importBurp(binaryName, trimmed, scope)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		if len(burpItems) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s lists no requests in scope\n", binaryName, trimmed)
+			return exitUsage
+		}
+	} else if *burpBaselines {
+		return usageError("--import-burp-baselines requires --import-burp")
+	}
+
+	if *targetURL == "" && len(targets) == 0 && len(apiRequests) == 0 && len(burpItems) == 0 {
+		return usageError("a target URL must be provided with -u or --targets")
+	}
+
+	if *wordlist == "" && len(targets) == 0 {
+		return usageError("a wordlist must be provided with -w")
+	}
+
+	// bodyFlag names the option fuzzing request bodies, if any.
+	bodyFlag := ""
+	switch {
+	case *jsonBody != "" && len(formSpecs) > 0:
+		return usageError("--json-body cannot be combined with --form")
+	case *jsonBody != "":
+		bodyFlag = "--json-body"
+	case len(formSpecs) > 0:
+		bodyFlag = "--form"
 	}
 
 	method := strings.ToUpper(strings.TrimSpace(*methodFlag))
+	methodExplicit := commandLine["method"]
+	if bodyFlag != "" && !methodExplicit {
+		// Fuzzed bodies are posted unless --method was given on the command line.
+		method = http.MethodPost
+	}
 	if method == "" {
 		method = http.MethodHead
 	}
@@ -146,41 +453,326 @@ Only continue if you are operating within the law and the documented scope of yo
 	case http.MethodGet, http.MethodHead, http.MethodPost:
 	default:
 		fmt.Fprintf(os.Stderr, "%s: unsupported HTTP method %q\n", binaryName, method)
-		os.Exit(2)
+		return exitUsage
+	}
+
+	mode, err := parseScanMode(*modeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if mode == scanModeBucket {
+		if len(targets) > 0 {
+			return usageError("--mode bucket cannot be combined with --targets")
+		}
+		if *recursive {
+			return usageError("--mode bucket cannot be combined with --recursive")
+		}
+		if len(apiRequests) > 0 {
+			return usageError("--mode bucket cannot be combined with --openapi")
+		}
+		if len(burpItems) > 0 {
+			return usageError("--mode bucket cannot be combined with --import-burp")
+		}
+		// Providers tell a missing bucket from a private one in the error
+		// document of the body.
+		method = http.MethodGet
+	}
+	if mode == scanModeHeader {
+		switch {
+		case len(targets) > 0 || len(apiRequests) > 0 || len(burpItems) > 0:
+			return usageError("--mode header cannot be combined with --targets, --openapi, or --import-burp")
+		case *recursive:
+			return usageError("--mode header cannot be combined with --recursive")
+		case bodyFlag != "":
+			return usageError("--mode header cannot be combined with " + bodyFlag)
+		case *noBaseline:
+			return usageError("--mode header compares responses with the baseline and cannot be combined with --no-baseline")
+		case templater.New().HasPlaceholder(*targetURL):
+			return usageError("--mode header requests -u as given; remove FUZZ from it")
+		}
+		// Header changes often only show in the body.
+		if !methodExplicit {
+			method = http.MethodGet
+		}
+	}
+	cookieFuzz := templater.New().HasPlaceholder(*cookie)
+	if cookieFuzz {
+		switch {
+		case bodyFlag != "":
+			return usageError("--cookie with FUZZ cannot be combined with " + bodyFlag)
+		case mode != scanModeDir:
+			return usageError("--cookie with FUZZ cannot be combined with --mode " + mode)
+		case *recursive:
+			return usageError("--cookie with FUZZ cannot be combined with --recursive")
+		}
+	}
+
+	if *smartMethod && method != http.MethodHead {
+		return usageError("--smart-method requires --method HEAD")
+	}
+	jsonPlan := strings.EqualFold(strings.TrimSpace(*outputFormat), "json")
+	if jsonPlan && !*dryRun {
+		return usageError("--output-format json requires --dry-run")
+	}
+	if *recursionDepth < 1 {
+		return usageError("--recursion-depth must be at least 1")
+	}
+	if *recursionPayloads < 0 {
+		return usageError("--recursion-payloads must not be negative")
+	}
+
+	tlsFingerprint, err := httpclient.ParseTLSFingerprint(*tlsFingerprintFlag)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	if tlsFingerprint != "" && strings.TrimSpace(*proxyFlag) != "" {
+		return usageError("--tls-fingerprint cannot be combined with --proxy, which makes its own TLS connection to the target")
+	}
+
+	network := ""
+	switch {
+	case *ipv4Only && *ipv6Only:
+		return usageError("-4 and -6 cannot be combined")
+	case *ipv4Only:
+		network = "tcp4"
+	case *ipv6Only:
+		network = "tcp6"
+	}
+
+	var (
+		body     []byte
+		bodyFile string
+	)
+	if *data != "" {
+		if method == http.MethodHead {
+			return usageError("-d requires --method GET or POST")
+		}
+		if path, ok := strings.CutPrefix(*data, "@"); ok {
+			if _, err := os.Stat(path); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: request body: %v\n", binaryName, err)
+				return exitUsage
+			}
+			bodyFile = path
+		} else {
+			body = []byte(*data)
+		}
+	}
+
+	if *fuzzField != "" && *jsonBody == "" {
+		return usageError("--fuzz-field requires --json-body")
+	}
+	if *streamToken != "" && strings.TrimSpace(*streamAddr) == "" {
+		return usageError("--stream-token requires --stream-addr")
+	}
+	var rotateSize int64
+	if trimmed := strings.TrimSpace(*outputRotateSize); trimmed != "" {
+		rotateSize, err = output.ParseSize(trimmed)
+		if err != nil {
+			return usageError("--output-rotate-size: " + err.Error())
+		}
+	}
+	if bodyFlag != "" {
+		switch {
+		case *data != "":
+			return usageError(bodyFlag + " cannot be combined with -d")
+		case len(targets) > 0 || len(apiRequests) > 0 || len(burpItems) > 0:
+			return usageError(bodyFlag + " cannot be combined with --targets, --openapi, or --import-burp")
+		case mode == scanModeBucket:
+			return usageError(bodyFlag + " cannot be combined with --mode bucket")
+		case *recursive:
+			return usageError(bodyFlag + " cannot be combined with --recursive")
+		case method == http.MethodHead:
+			return usageError(bodyFlag + " requires --method GET or POST")
+		}
+	}
+
+	engineTargets, err := resolveTargets(targets, *wordlist)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if *jsonBody != "" {
+		engineTargets, err = jsonBodyTargets(strings.TrimSpace(*targetURL), *jsonBody, strings.TrimSpace(*fuzzField))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+	if len(formSpecs) > 0 {
+		engineTargets, err = formTargets(strings.TrimSpace(*targetURL), formSpecs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+	if len(apiRequests) > 0 {
+		engineTargets = openapiTargets(binaryName, apiRequests, strings.TrimSpace(*wordlist), *aggressive)
+		if len(engineTargets) == 0 {
+			return usageError("every operation of the --openapi spec changes server state; pass --aggressive to scan them")
+		}
+	}
+	if len(burpItems) > 0 {
+		engineTargets = burpTargets(burpItems, strings.TrimSpace(*wordlist))
 	}
 
 	statuses, err := matcher.ParseStatusList(*matchStatus)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(2)
+		return exitUsage
 	}
 
 	sizeRange, err := matcher.ParseSizeRange(*filterSize)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(2)
+		return exitUsage
+	}
+
+	if strings.TrimSpace(*resumePath) == "default" {
+		defaultDB, err := paths.DefaultResumeDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: locate default resume database: %v\n", binaryName, err)
+			return exitUsage
+		}
+		*resumePath = defaultDB
+	}
+
+	dedupScope, err := store.ParseDedupScope(*dedupScopeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+
+	order, err := engine.ParseOrder(*orderFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+
+	resumeMode, err := engine.ParseProgressMode(*progressMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
 	}
 
 	if *similarityThreshold < 0 || *similarityThreshold > 1 {
 		fmt.Fprintf(os.Stderr, "%s: --similarity-threshold must be between 0 and 1\n", binaryName)
-		os.Exit(2)
+		return exitUsage
 	}
 
 	ctx := context.Background()
 
-	var baselineBody []byte
-	if !*noBaseline && !*dryRun {
-		capturedBaseline, err := captureBaseline(ctx, *targetURL, *timeout, *followRedirects)
+	var dnsCache *httpclient.DNSCache
+	if *dnsCacheTTL > 0 {
+		dnsCache = httpclient.NewDNSCache(*dnsCacheTTL, min(*dnsCacheTTL, httpclient.DefaultNegativeDNSTTL))
+	}
+
+	scanStats := &engine.Stats{}
+
+	var bandwidth *httpclient.BandwidthLimiter
+	if trimmed := strings.TrimSpace(*maxBandwidth); trimmed != "" {
+		rate, err := httpclient.ParseBandwidth(trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: --max-bandwidth: %v\n", binaryName, err)
+			return exitUsage
+		}
+		bandwidth = httpclient.NewBandwidthLimiter(rate)
+	}
+
+	var (
+		baselineBody []byte
+		baselineType string
+		baselineTech []string
+		detectedWAF  []string
+		headerBase   headerBaseline
+	)
+	baselineClient := httpclient.Options{
+		Timeout:         *timeout,
+		FollowRedirects: *followRedirects,
+		Proxy:           strings.TrimSpace(*proxyFlag),
+		DNSCache:        dnsCache,
+		Network:         network,
+		SNI:             strings.TrimSpace(*sniFlag),
+		TLSFingerprint:  tlsFingerprint,
+	}
+	baselineOpts := &httpclient.RequestOptions{Headers: make(http.Header)}
+	if trimmed := strings.TrimSpace(*hostHeader); trimmed != "" {
+		baselineOpts.Headers.Set("Host", trimmed)
+	}
+	if !cookieFuzz {
+		baselineOpts.Cookie = strings.TrimSpace(*cookie)
+	}
+	// A single baseline cannot describe several hosts, so similarity filtering
+	// is only available when scanning one target.
+	if !*noBaseline && !*dryRun && len(engineTargets) == 0 && mode == scanModeDir && !cookieFuzz {
+		url := templater.New().Expand(*targetURL, randomToken())
+		_, baselineHeader, capturedBaseline, err := captureBaseline(ctx, http.MethodGet, url, baselineOpts, baselineClient, *maxBodySize)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: baseline request failed: %v\n", binaryName, err)
 		} else {
 			baselineBody = capturedBaseline
+			baselineType = baselineHeader.Get("Content-Type")
+			detectedWAF = warnWAF(binaryName, waf.Detect(baselineHeader, capturedBaseline), *autoWAFSafe)
+			baselineTech = fingerprint.Names(baselineHeader, capturedBaseline)
+		}
+	}
+	// Header probes are compared with the target answering the same request
+	// without them. A second request tells whether its size varies.
+	if !*dryRun && mode == scanModeHeader {
+		baselineOpts.Body, baselineOpts.BodyFile = body, bodyFile
+		var sizes [2]int
+		for i := range sizes {
+			status, baselineHeader, capturedBaseline, err := captureBaseline(ctx, method, *targetURL, baselineOpts, baselineClient, *maxBodySize)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: baseline request failed: %v\n", binaryName, err)
+				return exitRuntime
+			}
+			sizes[i] = len(capturedBaseline)
+			if i == 0 {
+				headerBase.status = status
+				baselineBody = capturedBaseline
+				baselineType = baselineHeader.Get("Content-Type")
+				detectedWAF = warnWAF(binaryName, waf.Detect(baselineHeader, capturedBaseline), *autoWAFSafe)
+				baselineTech = fingerprint.Names(baselineHeader, capturedBaseline)
+			}
 		}
+		headerBase.size = sizes[0]
+		headerBase.stableSize = sizes[0] == sizes[1]
 	}
 
-	selectedProfile := *profile
-	if *beginner {
-		selectedProfile = "beginner"
+	if name := strings.TrimSpace(*saveProfile); name != "" {
+		saved := config.Profile{
+			Method:          method,
+			Concurrency:     *concurrency,
+			Recursive:       *recursive,
+			Timeout:         *timeout,
+			FollowRedirects: *followRedirects,
+			MatchStatus:     strings.TrimSpace(*matchStatus),
+			FilterSize:      strings.TrimSpace(*filterSize),
+			Outputs:         []string{"pretty"},
+		}
+		if *outputPath != "" {
+			saved.Outputs = append(saved.Outputs, strings.ToLower(*outputFormat))
+		}
+		if *throttle > 0 {
+			saved.Throttle = *throttle
+		}
+		if *jitter > 0 {
+			saved.Jitter = *jitter
+		}
+
+		profileDir, err := config.DefaultProfileDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+
+		path, err := config.SaveProfile(profileDir, name, saved)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		fmt.Fprintf(os.Stderr, "%s: saved profile %q to %s\n", binaryName, strings.ToLower(name), path)
 	}
 
 	binaryBase := filepath.Base(os.Args[0])
@@ -198,11 +790,108 @@ Only continue if you are operating within the law and the documented scope of yo
 		fmt.Sprintf("binary=%s", binaryBase),
 	}
 
+	if trimmed := strings.TrimSpace(*targetsFile); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("targets=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*importBurpFile); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("import_burp=%s", trimmed))
+	}
+	if *burpBaselines {
+		runConfigEntries = append(runConfigEntries, "import_burp_baselines=true")
+	}
+	if trimmed := strings.TrimSpace(*openapiSpec); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("openapi=%s", trimmed))
+	}
+	if *noMethodFallback {
+		runConfigEntries = append(runConfigEntries, "no_method_fallback=true")
+	}
+	if *smartMethod {
+		runConfigEntries = append(runConfigEntries, "smart_method=true")
+	}
+	if *scopeInclude != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("scope_include=%s", *scopeInclude))
+	}
+	if *scopeExclude != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("scope_exclude=%s", *scopeExclude))
+	}
+	if trimmed := strings.TrimSpace(*blacklistFile); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("blacklist=%s", trimmed))
+	}
+	if *allowDangerous {
+		runConfigEntries = append(runConfigEntries, "allow_dangerous=true")
+	}
+	if *respectRobots {
+		runConfigEntries = append(runConfigEntries, "respect_robots=true")
+	}
+	if *normalizeURLs {
+		runConfigEntries = append(runConfigEntries, "normalize_urls=true")
+	}
+	if mode != scanModeDir {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("mode=%s", mode))
+	}
+	if order != engine.OrderAsIs {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("order=%s", order))
+	}
+	if trimmed := strings.TrimSpace(*acceptEncoding); trimmed != engine.DefaultAcceptEncoding {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("accept_encoding=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*hostHeader); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("host_header=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*cookie); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("cookie=%s", hashSecret(trimmed)))
+	}
+	if trimmed := strings.TrimSpace(*sniFlag); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("sni=%s", trimmed))
+	}
+	if tlsFingerprint != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("tls_fingerprint=%s", tlsFingerprint))
+	}
+	if *maxBodySize != engine.DefaultMaxBodySize {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("max_body_size=%d", *maxBodySize))
+	}
+	if network != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("network=%s", network))
+	}
+	if *noKeepAlive {
+		runConfigEntries = append(runConfigEntries, "no_keepalive=true")
+	}
+	if *noCharsetDecode {
+		runConfigEntries = append(runConfigEntries, "no_charset_decode=true")
+	}
+	if *data != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("data=%s", hashSecret(*data)))
+	}
+	if *jsonBody != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("json_body=%s", *jsonBody))
+	}
+	if *fuzzField != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("fuzz_field=%s", strings.TrimSpace(*fuzzField)))
+	}
+	for _, spec := range formSpecs {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("form=%s", spec))
+	}
+	if *throttle > 0 {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("throttle=%s", throttle.String()))
+	}
+	if *jitter > 0 {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("jitter=%s", jitter.String()))
+	}
 	if *aggressive {
 		runConfigEntries = append(runConfigEntries, "aggressive=true")
 	}
 	if *recursive {
 		runConfigEntries = append(runConfigEntries, "recursive=true")
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("recursion_depth=%d", *recursionDepth))
+		if *recursionPayloads > 0 {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("recursion_payloads=%d", *recursionPayloads))
+		}
+	}
+	if *bypassDenied {
+		runConfigEntries = append(runConfigEntries, "bypass=true")
+	}
+	if *methodOverride {
+		runConfigEntries = append(runConfigEntries, "method_override=true")
 	}
 	if *confirmLegal {
 		runConfigEntries = append(runConfigEntries, "confirm_legal=true")
@@ -229,11 +918,65 @@ Only continue if you are operating within the law and the documented scope of yo
 	if *resumePath != "" {
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("resume_db=%s", *resumePath))
 	}
+	if backendValue := strings.ToLower(strings.TrimSpace(*resumeBackend)); backendValue != "" && backendValue != store.BackendSQLite {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("resume_backend=%s", backendValue))
+	}
+	if dedupScope != store.DedupScopeRun {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("dedup_scope=%s", dedupScope))
+	}
+	if trimmed := strings.TrimSpace(*resumeFrom); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("resume_from=%s", trimmed))
+	}
 	if trimmed := strings.TrimSpace(*progressFile); trimmed != "" {
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("progress_file=%s", trimmed))
 	}
+	if trimmed := strings.TrimSpace(*proxyFlag); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("proxy=%s", trimmed))
+	}
+	if len(pluginSpecs) > 0 {
+		for _, spec := range pluginSpecs {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin=%s", spec))
+		}
+		if len(pluginSpecs) > 1 || policy != plugin.PolicyAll {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin_policy=%s", policy))
+		}
+		if policy == plugin.PolicyWeighted {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin_threshold=%f", *pluginThreshold))
+		}
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin_workers=%d", *pluginWorkers))
+		if !orderedPlugins {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin_order=%s", pluginOrderUnordered))
+		}
+		if transport := strings.ToLower(strings.TrimSpace(*pluginTransport)); transport != plugin.TransportExec {
+			runConfigEntries = append(runConfigEntries, fmt.Sprintf("plugin_transport=%s", transport))
+		}
+	}
+	if trimmed := strings.TrimSpace(*transformPlugin); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("transform_plugin=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*outputPlugin); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("output_plugin=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*matchPlugin); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("match_plugin=%s", trimmed))
+	}
+	if *includeHeaders {
+		runConfigEntries = append(runConfigEntries, "output_include_headers=true")
+	}
+	if trimmed := strings.TrimSpace(*outputHeaders); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("output_headers=%s", trimmed))
+	}
+	if trimmed := strings.TrimSpace(*streamAddr); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("stream_addr=%s", trimmed))
+	}
+	if *streamToken != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("stream_token=%s", hashSecret(*streamToken)))
+	}
+	if trimmed := strings.TrimSpace(*outputRotateSize); trimmed != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("output_rotate_size=%s", trimmed))
+	}
 	if strings.TrimSpace(*preHook) != "" {
-		runConfigEntries = append(runConfigEntries, fmt.Sprintf("pre_hook=%s", strings.TrimSpace(*preHook)))
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("pre_hook=%s", hashSecret(strings.TrimSpace(*preHook))))
 	}
 	if selectedProfile != "" {
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("profile=%s", selectedProfile))
@@ -241,6 +984,12 @@ Only continue if you are operating within the law and the documented scope of yo
 	if viewValue := strings.ToLower(strings.TrimSpace(*viewModeFlag)); viewValue != "" && viewValue != "table" {
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("view=%s", viewValue))
 	}
+	if sortOrder == output.SortSeverity {
+		runConfigEntries = append(runConfigEntries, "sort=severity")
+	}
+	if minSeverity != "" {
+		runConfigEntries = append(runConfigEntries, fmt.Sprintf("min_severity=%s", minSeverity))
+	}
 	if modeValue := strings.ToLower(strings.TrimSpace(*colorModeFlag)); modeValue != "" && modeValue != "auto" {
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("color_mode=%s", modeValue))
 	}
@@ -248,11 +997,17 @@ Only continue if you are operating within the law and the documented scope of yo
 		runConfigEntries = append(runConfigEntries, fmt.Sprintf("color_preset=%s", presetValue))
 	}
 
-	if prof, ok := config.LookupProfile(selectedProfile); ok {
-		runConfigEntries = append(runConfigEntries, prof.RunHashConfig()...)
+	if hasProfile {
+		runConfigEntries = append(runConfigEntries, resolvedProfile.RunHashConfig()...)
 	}
 
 	payloadEntries := []string{strings.TrimSpace(*wordlist)}
+	if trimmed := strings.TrimSpace(*targetsFile); trimmed != "" {
+		payloadEntries = append(payloadEntries, trimmed)
+		for _, target := range engineTargets {
+			payloadEntries = append(payloadEntries, target.Wordlist)
+		}
+	}
 
 	runMeta := store.RunMetadata{
 		TargetURL:   strings.TrimSpace(*targetURL),
@@ -266,6 +1021,7 @@ Only continue if you are operating within the law and the documented scope of yo
 		RunID:       strings.TrimSpace(*runID),
 		ConfigList:  runConfigEntries,
 		PayloadList: payloadEntries,
+		DedupScope:  dedupScope,
 	}
 
 	if runMeta.RunID == "" {
@@ -277,31 +1033,142 @@ Only continue if you are operating within the law and the documented scope of yo
 	normalizedPayloads := runMeta.PayloadEntries()
 
 	var (
-		resumeDB    *store.SQLite
-		runRecorder *store.Run
+		resumeDB    store.Store
+		runRecorder store.Recorder
 	)
 
 	cfg := engine.Config{
-		URL:             *targetURL,
-		Wordlist:        *wordlist,
-		Concurrency:     *concurrency,
-		Timeout:         *timeout,
-		OutputPath:      *outputPath,
-		Profile:         selectedProfile,
-		Beginner:        *beginner,
-		BinaryName:      binaryBase,
-		RunRecorder:     runRecorder,
-		Method:          method,
-		FollowRedirects: *followRedirects,
-		PreHook:         strings.TrimSpace(*preHook),
-		ProgressFile:    strings.TrimSpace(*progressFile),
+		URL:               *targetURL,
+		Wordlist:          *wordlist,
+		Concurrency:       *concurrency,
+		Timeout:           *timeout,
+		OutputPath:        *outputPath,
+		Profile:           selectedProfile,
+		Beginner:          *beginner,
+		BinaryName:        binaryBase,
+		RunRecorder:       runRecorder,
+		Method:            method,
+		FollowRedirects:   *followRedirects,
+		PreHook:           strings.TrimSpace(*preHook),
+		ProgressFile:      strings.TrimSpace(*progressFile),
+		ProgressMode:      resumeMode,
+		Proxy:             strings.TrimSpace(*proxyFlag),
+		Throttle:          *throttle,
+		Jitter:            *jitter,
+		SharedPool:        *parallelTargets,
+		SmartMethod:       *smartMethod,
+		Order:             order,
+		NormalizeURLs:     *normalizeURLs,
+		Scope:             scope,
+		Recursive:         *recursive,
+		RecursionDepth:    *recursionDepth,
+		RecursionPayloads: *recursionPayloads,
+		MaxWordlistLine:   *maxWordlistLine,
+		DNSCache:          dnsCache,
+		Stats:             scanStats,
+		DisableKeepAlives: *noKeepAlive,
+		Network:           network,
+		SNI:               strings.TrimSpace(*sniFlag),
+		TLSFingerprint:    tlsFingerprint,
+		Bandwidth:         bandwidth,
+		MaxBodySize:       *maxBodySize,
+		DiscardBodies:     *discardBodies,
+		HostHeader:        strings.TrimSpace(*hostHeader),
+		AcceptEncoding:    strings.TrimSpace(*acceptEncoding),
+		Body:              body,
+		BodyFile:          bodyFile,
+		BreakerThreshold:  *breakerThreshold,
+		BreakerCooldown:   *breakerCooldown,
+		Targets:           engineTargets,
+		Logger:            slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	cfg.DisableMethodFallback = *noMethodFallback
+
+	if trimmed := strings.TrimSpace(*transformPlugin); trimmed != "" {
+		transformer, err := plugin.Open(ctx, persistentTransport(*pluginTransport), trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		defer func() {
+			if err := transformer.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: close transform plugin: %v\n", binaryName, err)
+			}
+		}()
+
+		cfg.TransformPayload = func(payload string) ([]string, error) {
+			resp, err := transformer.Transform(ctx, plugin.TransformEvent{Payload: payload})
+			return resp.Payloads, err
+		}
+	}
+
+	if mode == scanModeHeader {
+		cfg.BuildRequest = headerRequest
+	}
+	if cookieFuzz {
+		cfg.BuildRequest = cookieRequest(strings.TrimSpace(*cookie))
+	} else {
+		cfg.Cookie = strings.TrimSpace(*cookie)
+	}
+	if mode == scanModeBucket {
+		// Every word becomes the full URLs of its buckets, which the
+		// placeholder alone is replaced with.
+		cfg.URL = templater.DefaultPlaceholder
+		toURLs := bucketPayloads(ctx, binaryName, *targetURL)
+		cfg.TransformPayload = chainTransforms(cfg.TransformPayload, toURLs)
+	}
+
+	var robotsPolicy *robots.Policy
+	if *respectRobots {
+		if mode == scanModeBucket {
+			return usageError("--respect-robots cannot be combined with --mode bucket")
+		}
+		if *dryRun {
+			fmt.Fprintf(os.Stderr, "%s: --dry-run does not fetch robots.txt, so disallowed paths are still listed\n", binaryName)
+		} else {
+			robotsPolicy, err = loadRobots(ctx, binaryName, cfg, httpclient.Options{
+				Timeout:         *timeout,
+				FollowRedirects: true,
+				Proxy:           strings.TrimSpace(*proxyFlag),
+				DNSCache:        dnsCache,
+				Network:         network,
+				SNI:             strings.TrimSpace(*sniFlag),
+				TLSFingerprint:  tlsFingerprint,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+				return exitRuntime
+			}
+			if cfg.Scope == nil {
+				cfg.Scope = &engine.Scope{}
+			}
+			cfg.Scope.Robots = robotsPolicy
+		}
 	}
 
 	if *dryRun {
 		plan, err := engine.Plan(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: dry run failed: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
+		}
+
+		if jsonPlan {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(dryRunPlan{
+				Method:              method,
+				TotalPermutations:   plan.TotalPermutations,
+				QuickPermutations:   plan.QuickPermutations,
+				PrimaryPermutations: plan.PrimaryPermutations,
+				Stages:              plan.Stages,
+				Config:              normalizedConfig,
+				Payloads:            normalizedPayloads,
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: dry run failed: %v\n", binaryName, err)
+				return exitRuntime
+			}
+			return exitOK
 		}
 
 		fmt.Fprintf(os.Stdout, "Dry run: %d permutations", plan.TotalPermutations)
@@ -319,22 +1186,43 @@ Only continue if you are operating within the law and the documented scope of yo
 			fmt.Fprintln(os.Stdout, "(no permutations generated)")
 		}
 
-		return
+		return exitOK
+	}
+
+	var hostBaselines map[string][]byte
+	if *burpBaselines {
+		hostBaselines = burpxml.Baselines(burpItems)
+		if len(hostBaselines) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s recorded no 404 responses to use as baselines\n", binaryName, strings.TrimSpace(*importBurpFile))
+		}
 	}
 
+	if mode == scanModeHeader {
+		// Probes are judged against the baseline alone; the matcher only
+		// measures how similar their bodies are to it.
+		statuses, sizeRange = nil, matcher.SizeRange{}
+	}
 	resultMatcher := matcher.New(matcher.Options{
 		Statuses:            statuses,
 		Size:                sizeRange,
 		BaselineBody:        baselineBody,
 		SimilarityThreshold: *similarityThreshold,
+		HostBaselines:       hostBaselines,
+		DecodeCharset:       !*noCharsetDecode,
+		BaselineContentType: baselineType,
 	})
 
 	if *resumePath != "" {
+		if err := os.MkdirAll(filepath.Dir(*resumePath), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: create resume directory: %v\n", binaryName, err)
+			return exitRuntime
+		}
+
 		var err error
-		resumeDB, err = store.OpenSQLite(*resumePath)
+		resumeDB, err = store.Open(*resumeBackend, *resumePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
 		}
 
 		defer func() {
@@ -346,7 +1234,7 @@ Only continue if you are operating within the law and the documented scope of yo
 		runRecorder, err = resumeDB.StartRun(ctx, runMeta)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
 		}
 
 		if stored := strings.TrimSpace(runRecorder.RunID()); stored != "" {
@@ -356,10 +1244,85 @@ Only continue if you are operating within the law and the documented scope of yo
 
 	cfg.RunRecorder = runRecorder
 
+	if trimmed := strings.TrimSpace(*resumeFrom); trimmed != "" {
+		attempted, err := output.LoadJSONLAttempts(trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		cfg.Attempted = attempted
+		fmt.Fprintf(os.Stderr, "%s: skipping %d URLs recorded in %s\n", binaryName, len(attempted), trimmed)
+	}
+
+	if trimmed := strings.TrimSpace(*matchPlugin); trimmed != "" {
+		classifier, err := plugin.Open(ctx, persistentTransport(*pluginTransport), trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		defer func() {
+			if err := classifier.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: close match plugin: %v\n", binaryName, err)
+			}
+		}()
+
+		cfg.ClassifyResult = func(ctx context.Context, res engine.Result) (bool, error) {
+			verdict, err := classifier.Classify(ctx, responseEvent(res))
+			return verdict.Match, err
+		}
+	}
+
+	followUps, err := engine.NewFollowUpExecutor(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+
+	var hitPlugins []verifier
+	if len(pluginSpecs) > 0 {
+		for _, spec := range pluginSpecs {
+			path, weight := parsePluginSpec(spec)
+			client, err := plugin.Open(ctx, *pluginTransport, path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+				return exitRuntime
+			}
+			defer func() {
+				if err := client.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: close plugin %s: %v\n", binaryName, path, err)
+				}
+			}()
+			hitPlugins = append(hitPlugins, verifier{path: path, weight: weight, client: client})
+		}
+
+		notifyPlugins(ctx, hitPlugins, plugin.LifecycleEvent{
+			Type:   plugin.EventRunStart,
+			RunID:  runIdentifier,
+			Target: strings.TrimSpace(*targetURL),
+			Method: method,
+		})
+
+		cfg.OnStage = func(ev engine.StageEvent) {
+			eventType := plugin.EventStageStart
+			if ev.Done {
+				eventType = plugin.EventStageEnd
+			}
+			notifyPlugins(ctx, hitPlugins, plugin.LifecycleEvent{
+				Type:   eventType,
+				RunID:  runIdentifier,
+				Target: ev.Target,
+				Method: ev.Method,
+				Stage:  ev.Stage,
+			})
+		}
+	}
+
+	runStarted := time.Now()
+
 	results, err := engine.Run(ctx, cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-		os.Exit(1)
+		return exitRuntime
 	}
 
 	prettyWriter := output.NewPrettyWriter(os.Stdout, output.PrettyOptions{
@@ -368,23 +1331,38 @@ Only continue if you are operating within the law and the documented scope of yo
 		ColorMode:      colorMode,
 		ColorPreset:    colorPreset,
 		TargetURL:      strings.TrimSpace(*targetURL),
+		Sort:           sortOrder,
 	})
 
 	var (
 		jsonlWriter *output.JSONLWriter
 		burpWriter  *output.BurpWriter
 		burpPoster  *output.BurpPoster
+		sinkPlugin  *output.PluginSink
+		stream      *output.Stream
 		writerErr   error
 	)
 
+	// A run continued from the resume database skips the paths it attempted
+	// before, so its outputs are appended to rather than replaced.
+	continuing := runRecorder != nil && runRecorder.Continued()
+
 	if *outputPath != "" {
 		format := strings.ToLower(*outputFormat)
 		switch format {
 		case "jsonl", "":
-			jsonlWriter, err = output.NewJSONLFile(*outputPath, *showSimilarity)
+			appending := continuing || sameFile(*outputPath, *resumeFrom)
+			switch {
+			case rotateSize > 0:
+				jsonlWriter, err = output.RotatingJSONLFile(*outputPath, *showSimilarity, rotateSize, appending)
+			case appending:
+				jsonlWriter, err = output.AppendJSONLFile(*outputPath, *showSimilarity)
+			default:
+				jsonlWriter, err = output.NewJSONLFile(*outputPath, *showSimilarity)
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-				os.Exit(1)
+				return exitRuntime
 			}
 			defer func() {
 				if closeErr := jsonlWriter.Close(); closeErr != nil && writerErr == nil {
@@ -393,15 +1371,19 @@ Only continue if you are operating within the law and the documented scope of yo
 			}()
 		default:
 			fmt.Fprintf(os.Stderr, "%s: unsupported output format %q\n", binaryName, format)
-			os.Exit(2)
+			return exitUsage
 		}
 	}
 
 	if *burpExport != "" {
-		burpWriter, err = output.NewBurpFile(*burpExport, method)
+		if continuing {
+			burpWriter, err = output.AppendBurpFile(*burpExport, method)
+		} else {
+			burpWriter, err = output.NewBurpFile(*burpExport, method)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
 		}
 		defer func() {
 			if closeErr := burpWriter.Close(); closeErr != nil && writerErr == nil {
@@ -411,70 +1393,242 @@ Only continue if you are operating within the law and the documented scope of yo
 	}
 
 	if trimmed := strings.TrimSpace(*burpHost); trimmed != "" {
-		burpPoster, err = output.NewBurpPoster(trimmed, method)
+		burpPoster, err = output.NewBurpPoster(trimmed, method, os.Stderr)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
 		}
 	}
 
+	runHeader := output.RunHeader{
+		RunID:     runIdentifier,
+		TargetURL: runMeta.TargetURL,
+		Wordlist:  runMeta.Wordlist,
+		StartedAt: runMeta.StartedAt.Format(time.RFC3339Nano),
+		Config:    normalizedConfig,
+		Payloads:  normalizedPayloads,
+		WAF:       detectedWAF,
+		Stack:     baselineTech,
+	}
+
+	headerNames := strings.Split(*outputHeaders, ",")
+	if *includeHeaders {
+		headerNames = append(headerNames, output.DefaultHeaders...)
+	}
+
 	if jsonlWriter != nil {
-		header := output.RunHeader{
-			RunID:     runIdentifier,
-			TargetURL: runMeta.TargetURL,
-			Wordlist:  runMeta.Wordlist,
-			StartedAt: runMeta.StartedAt.Format(time.RFC3339Nano),
-			Config:    normalizedConfig,
-			Payloads:  normalizedPayloads,
+		jsonlWriter.IncludeHeaders(headerNames)
+		if err := jsonlWriter.WriteHeader(runHeader); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+	}
+
+	if trimmed := strings.TrimSpace(*outputPlugin); trimmed != "" {
+		sinkPlugin, err = output.StartPluginSink(ctx, trimmed, *showSimilarity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		sinkPlugin.IncludeHeaders(headerNames)
+		if err := sinkPlugin.WriteHeader(runHeader); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
 		}
+	}
 
-		if err := jsonlWriter.WriteHeader(header); err != nil {
+	if trimmed := strings.TrimSpace(*streamAddr); trimmed != "" {
+		stream, err = output.StartStream(trimmed, *streamToken, *showSimilarity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		stream.IncludeHeaders(headerNames)
+		if err := stream.WriteHeader(runHeader); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
-			os.Exit(1)
+			return exitRuntime
+		}
+		fmt.Fprintf(os.Stderr, "%s: streaming hits at http://%s/events\n", binaryName, stream.Addr())
+	}
+
+	var (
+		summary plugin.RunSummary
+		tally   scanTally
+		// graphQLNames collects the operations of GraphQL schemas for
+		// --graphql-wordlist.
+		graphQLNames []string
+	)
+
+	var shots *screenshotter
+	if command := strings.TrimSpace(*screenshotCmd); command != "" {
+		if err := os.MkdirAll(*screenshotDir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: create screenshot directory: %v\n", binaryName, err)
+			return exitRuntime
 		}
+		shots = &screenshotter{command: command, dir: *screenshotDir}
 	}
 
-	var runErr error
+	// Without verification plugins or screenshots checking a result is
+	// cheap, so a single worker keeps the results in engine order.
+	checkWorkers := 1
+	if len(hitPlugins) > 0 || shots != nil {
+		checkWorkers = *pluginWorkers
+	}
 
-	for res := range results {
+	checked := checkResults(results, checkWorkers, orderedPlugins, func(res engine.Result) checkedResult {
 		outcome := resultMatcher.Evaluate(res)
 		if outcome.HasSimilarity {
 			res.HasSimilarity = true
 			res.Similarity = outcome.Similarity
 		}
 
-		matches := outcome.Matched
-		if matches {
-			if jsonlWriter != nil {
-				if err := jsonlWriter.Write(res); err != nil && writerErr == nil {
-					writerErr = err
+		item := checkedResult{res: res, matches: outcome.Matched}
+		item.res.MatchedRules = outcome.Rules
+		if mode == scanModeBucket {
+			item.matches = checkBucket(&item.res)
+			item.res.MatchedRules = []string{"bucket"}
+		}
+		if mode == scanModeHeader {
+			item.matches = checkHeaderChange(&item.res, headerBase, outcome)
+			item.res.MatchedRules = []string{"header"}
+		}
+		if item.matches && len(hitPlugins) > 0 && res.Err == nil {
+			// A plugin that fails does not vote, so a hit is kept when
+			// every plugin fails.
+			votes := make([]plugin.Vote, 0, len(hitPlugins))
+			for _, hp := range hitPlugins {
+				resp, requests, err := verifyHit(ctx, hp.client, followUps, res)
+				item.verification = append(item.verification, requests...)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: plugin %s: %s: %v\n", binaryName, hp.path, res.URL, err)
+					continue
 				}
-			}
-			if burpWriter != nil && res.Err == nil {
-				if err := burpWriter.Write(res); err != nil && writerErr == nil {
-					writerErr = err
+				votes = append(votes, plugin.Vote{Response: resp, Weight: hp.weight})
+				item.res.Tags = appendTags(item.res.Tags, resp.Tags)
+				if note := strings.TrimSpace(resp.Note); note != "" {
+					item.res.Notes = append(item.res.Notes, note)
 				}
 			}
-			if burpPoster != nil && res.Err == nil {
-				if err := burpPoster.Write(res); err != nil && writerErr == nil {
-					writerErr = err
-				}
+			item.matches, item.res.Confidence = plugin.Aggregate(policy, *pluginThreshold, votes)
+			item.res.HasConfidence = len(votes) > 0
+			if len(votes) > 0 {
+				item.res.MatchedRules = append(item.res.MatchedRules, "verify-plugins")
 			}
-			if runRecorder != nil {
-				if err := runRecorder.RecordHit(ctx, store.HitRecord{
-					Path:          res.URL,
-					StatusCode:    res.StatusCode,
-					ContentLength: res.ContentLength,
-					Duration:      res.Duration,
-				}); err != nil && writerErr == nil {
-					writerErr = err
-				}
+		}
+		if item.matches && res.Err == nil {
+			item.res.Technologies = fingerprint.Names(res.ResponseHeader, res.Body)
+		}
+		if item.matches && engine.LooksLikeGraphQL(item.res) {
+			item.verification = append(item.verification, probeGraphQL(ctx, followUps, &item.res))
+		}
+		if item.matches {
+			item.res.Severity = engine.ScoreSeverity(item.res)
+			if minSeverity != "" && engine.SeverityRank(item.res.Severity) < engine.SeverityRank(minSeverity) {
+				item.matches = false
+				item.res.Severity = ""
+			} else if minSeverity != "" {
+				item.res.MatchedRules = append(item.res.MatchedRules, "min-severity")
+			}
+		}
+		if !item.matches {
+			item.res.MatchedRules = nil
+		}
+		if shots != nil && item.matches && res.Err == nil {
+			path, err := shots.capture(ctx, res.URL)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
 			}
+			item.res.Screenshot = path
+		}
+		if *bypassDenied && item.matches && engine.Bypassable(item.res) {
+			item.bypasses = tryBypasses(ctx, followUps, item.res, engine.Bypasses(item.res.URL), "bypass")
+		}
+		if *methodOverride && item.matches && engine.MethodRestricted(item.res) {
+			overrides := tryBypasses(ctx, followUps, item.res, engine.MethodOverrides(item.res), "method-override")
+			item.bypasses = append(item.bypasses, overrides...)
+		}
+		if *discardBodies && !item.matches {
+			item.res.Body = nil
+		}
+		return item
+	})
 
-			if err := prettyWriter.Write(res); err != nil && writerErr == nil {
+	// writeHit delivers a hit to every output.
+	writeHit := func(res engine.Result) {
+		if jsonlWriter != nil {
+			if err := jsonlWriter.WriteMatch(res); err != nil && writerErr == nil {
+				writerErr = err
+			}
+		}
+		if burpWriter != nil && res.Err == nil {
+			if err := burpWriter.Write(res); err != nil && writerErr == nil {
+				writerErr = err
+			}
+		}
+		if burpPoster != nil && res.Err == nil {
+			if err := burpPoster.Write(res); err != nil && writerErr == nil {
+				writerErr = err
+			}
+		}
+		if sinkPlugin != nil && res.Err == nil {
+			if err := sinkPlugin.Write(res); err != nil && writerErr == nil {
+				writerErr = err
+			}
+		}
+		if stream != nil && res.Err == nil {
+			if err := stream.Write(res); err != nil && writerErr == nil {
 				writerErr = err
 			}
 		}
+		if runRecorder != nil {
+			if err := runRecorder.RecordHit(ctx, store.HitRecord{
+				Path:          res.URL,
+				StatusCode:    res.StatusCode,
+				ContentLength: res.ContentLength,
+				Duration:      res.Duration,
+				Confidence:    res.Confidence,
+				HasConfidence: res.HasConfidence,
+				Tags:          res.Tags,
+				Notes:         res.Notes,
+				Screenshot:    res.Screenshot,
+			}); err != nil && writerErr == nil {
+				writerErr = err
+			}
+		}
+
+		if res.Err != nil && tally.repeatedError(res) {
+			return
+		}
+		if err := prettyWriter.Write(res); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	for item := range checked {
+		res, matches, verification := item.res, item.matches, item.verification
+
+		summary.Requests += 1 + len(verification)
+		tally.add(res, matches)
+		if res.Err != nil {
+			summary.Errors++
+			if len(hitPlugins) > 0 {
+				notifyPlugins(ctx, hitPlugins, plugin.LifecycleEvent{
+					Type:  plugin.EventError,
+					RunID: runIdentifier,
+					URL:   res.URL,
+					Error: res.Err.Error(),
+				})
+			}
+		}
+
+		if matches {
+			summary.Hits++
+			writeHit(res)
+		}
+		if res.GraphQL != nil {
+			graphQLNames = append(graphQLNames, res.GraphQL.Queries...)
+			graphQLNames = append(graphQLNames, res.GraphQL.Mutations...)
+		}
 
 		if !matches && jsonlWriter != nil {
 			if err := jsonlWriter.Write(res); err != nil && writerErr == nil {
@@ -482,58 +1636,393 @@ Only continue if you are operating within the law and the documented scope of yo
 			}
 		}
 
-		if res.Err != nil && runErr == nil {
-			runErr = res.Err
+		if jsonlWriter != nil {
+			for _, followUp := range verification {
+				if err := jsonlWriter.Write(followUp); err != nil && writerErr == nil {
+					writerErr = err
+				}
+			}
+		}
+
+		for _, attempt := range item.bypasses {
+			summary.Requests++
+			if bypassed(attempt) {
+				attempt.MatchedRules = []string{"bypass"}
+				summary.Hits++
+				tally.add(attempt, true)
+				writeHit(attempt)
+			} else if jsonlWriter != nil {
+				if err := jsonlWriter.Write(attempt); err != nil && writerErr == nil {
+					writerErr = err
+				}
+			}
+		}
+	}
+
+	if len(hitPlugins) > 0 {
+		summary.DurationMS = time.Since(runStarted).Milliseconds()
+		if dnsCache != nil {
+			stats := dnsCache.Stats()
+			summary.DNS = &stats
+		}
+		stats := scanStats.Snapshot()
+		summary.Stats = &stats
+		notifyPlugins(ctx, hitPlugins, plugin.LifecycleEvent{
+			Type:    plugin.EventRunComplete,
+			RunID:   runIdentifier,
+			Summary: &summary,
+		})
+	}
+
+	if trimmed := strings.TrimSpace(*graphqlWordlist); trimmed != "" {
+		if len(graphQLNames) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: no GraphQL endpoint allowed introspection; %s was not written\n", binaryName, trimmed)
+		} else if err := writeGraphQLWordlist(trimmed, graphQLNames); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	if runRecorder != nil {
+		if err := runRecorder.Finish(ctx); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	footer := tally.footer(runIdentifier, scanStats.Snapshot(), time.Since(runStarted))
+	footer.RobotsSkipped = robotsPolicy.Skipped()
+	if jsonlWriter != nil {
+		if err := jsonlWriter.WriteFooter(footer); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	if burpPoster != nil {
+		if err := burpPoster.Close(); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	if sinkPlugin != nil {
+		if err := sinkPlugin.Close(); err != nil && writerErr == nil {
+			writerErr = err
+		}
+	}
+
+	if stream != nil {
+		if err := stream.WriteFooter(footer); err != nil && writerErr == nil {
+			writerErr = err
+		}
+		if err := stream.Close(); err != nil && writerErr == nil {
+			writerErr = err
 		}
 	}
 
 	if err := prettyWriter.Flush(); err != nil && writerErr == nil {
 		writerErr = err
 	}
+	printScanSummary(os.Stderr, footer)
 
 	if writerErr != nil {
 		fmt.Fprintf(os.Stderr, "%s: output error: %v\n", binaryName, writerErr)
-		os.Exit(1)
+		return exitRuntime
+	}
+
+	if tally.failed() {
+		fmt.Fprintf(os.Stderr, "%s: all %d requests failed: %s\n", binaryName, tally.errors, footer.ErrorGroups[0].Message)
+		return exitRuntime
 	}
 
-	if runErr != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, runErr)
-		os.Exit(1)
+	if *failOnHits && summary.Hits > 0 {
+		return exitHits
 	}
+	return exitOK
 }
 
-func exitWithUsage(message string) {
+// usageError prints message and the usage and returns the usage exit status.
+func usageError(message string) int {
 	fmt.Fprintf(os.Stderr, "Error: %s\n\n", message)
 	flag.Usage()
-	os.Exit(2)
+	return exitUsage
 }
 
-func captureBaseline(ctx context.Context, target string, timeout time.Duration, followRedirects bool) ([]byte, error) {
-	client := httpclient.New(timeout, followRedirects)
-	tpl := templater.New()
-	url := tpl.Expand(target, randomToken())
+// captureBaseline requests url with method and opts using a client built
+// from clientOpts and returns the status, the header, and up to maxBody bytes
+// of the body of the response, the same amount the engine captures for the
+// responses compared with it.
+func captureBaseline(ctx context.Context, method, url string, opts *httpclient.RequestOptions, clientOpts httpclient.Options, maxBody int64) (int, http.Header, []byte, error) {
+	client, err := httpclient.NewWithOptions(clientOpts)
+	if err != nil {
+		return 0, nil, nil, err
+	}
 
 	reqCtx := ctx
-	if timeout > 0 {
+	if clientOpts.Timeout > 0 {
 		var cancel context.CancelFunc
-		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+		reqCtx, cancel = context.WithTimeout(ctx, clientOpts.Timeout)
 		defer cancel()
 	}
 
-	resp, err := client.Request(reqCtx, http.MethodGet, url, nil)
+	resp, err := client.Request(reqCtx, method, url, opts)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 	defer resp.Body.Close()
 
-	const maxBaselineBytes = 1024 * 1024
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBaselineBytes))
+	if maxBody <= 0 {
+		maxBody = engine.DefaultMaxBodySize
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 	_, _ = io.Copy(io.Discard, resp.Body)
 
-	return body, nil
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// resolveTargets converts targets file entries into engine targets, expanding
+// per-target profiles. Values set directly on a line win over its profile.
+func resolveTargets(targets []config.Target, defaultWordlist string) ([]engine.Target, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	resolved := make([]engine.Target, 0, len(targets))
+	for _, target := range targets {
+		entry := engine.Target{
+			URL:      target.URL,
+			Method:   target.Method,
+			Wordlist: target.Wordlist,
+			Headers:  target.Headers,
+			Throttle: target.Throttle,
+		}
+
+		if target.Profile != "" {
+			p, err := config.ResolveProfile(target.Profile)
+			if err != nil {
+				return nil, fmt.Errorf("target %s: %w", target.URL, err)
+			}
+			if entry.Method == "" {
+				entry.Method = strings.ToUpper(strings.TrimSpace(p.Method))
+			}
+			entry.Concurrency = p.Concurrency
+			if entry.Throttle == 0 {
+				entry.Throttle = p.Throttle
+			}
+			entry.Jitter = p.Jitter
+		}
+
+		switch entry.Method {
+		case "", http.MethodGet, http.MethodHead, http.MethodPost:
+		default:
+			return nil, fmt.Errorf("target %s: unsupported HTTP method %q", target.URL, entry.Method)
+		}
+
+		if entry.Wordlist == "" {
+			entry.Wordlist = strings.TrimSpace(defaultWordlist)
+		}
+		if entry.Wordlist == "" {
+			return nil, fmt.Errorf("target %s: no wordlist given on the line or with -w", target.URL)
+		}
+
+		resolved = append(resolved, entry)
+	}
+
+	return resolved, nil
+}
+
+// dryRunPlan is the plan printed by --dry-run --output-format json. Config
+// and Payloads are the normalized entries of the JSONL run header.
+type dryRunPlan struct {
+	Method              string             `json:"method"`
+	TotalPermutations   int                `json:"total_permutations"`
+	QuickPermutations   int                `json:"quick_permutations"`
+	PrimaryPermutations int                `json:"primary_permutations"`
+	Stages              []engine.StagePlan `json:"stages"`
+	Config              []string           `json:"config"`
+	Payloads            []string           `json:"payloads"`
+}
+
+// pluginList collects the values of a repeated flag.
+type pluginList []string
+
+func (p *pluginList) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, ",")
+}
+
+func (p *pluginList) Set(value string) error {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return errors.New("plugin path is empty")
+	}
+	*p = append(*p, trimmed)
+	return nil
+}
+
+// verifier is a running --plugin and its weight in the aggregated verdict.
+type verifier struct {
+	path   string
+	weight float64
+	client plugin.Client
+}
+
+// parsePluginSpec splits a --plugin value of the form path[=weight]. A
+// suffix that is not a positive number is kept as part of the path.
+func parsePluginSpec(spec string) (string, float64) {
+	if i := strings.LastIndex(spec, "="); i > 0 {
+		if weight, err := strconv.ParseFloat(spec[i+1:], 64); err == nil && weight > 0 {
+			return spec[:i], weight
+		}
+	}
+	return spec, 1
+}
+
+// notifyPlugins sends a lifecycle event to every plugin, reporting failures
+// without stopping the run.
+func notifyPlugins(ctx context.Context, plugins []verifier, event plugin.LifecycleEvent) {
+	event.Timestamp = time.Now().UTC()
+	for _, p := range plugins {
+		if err := p.client.Notify(ctx, event); err != nil {
+			fmt.Fprintf(os.Stderr, "hydro: plugin %s: %s event: %v\n", p.path, event.Type, err)
+		}
+	}
+}
+
+func matchEvent(res engine.Result) plugin.MatchEvent {
+	event := plugin.MatchEvent{
+		URL:           res.URL,
+		Method:        res.RequestMethod,
+		StatusCode:    res.StatusCode,
+		ContentLength: res.ContentLength,
+		DurationMS:    res.Duration.Milliseconds(),
+		Word:          res.Word,
+		Payload:       res.Payload,
+		Headers:       res.ResponseHeader,
+		Body:          res.Body,
+		FollowUpOf:    res.FollowUpOf,
+	}
+	if res.Err != nil {
+		event.Error = res.Err.Error()
+	}
+	return event
+}
+
+// maxFollowUps bounds the chain of follow-up requests a plugin can ask for
+// while verifying a single hit.
+const maxFollowUps = 3
+
+// verifyHit asks the plugin about res, performs the follow-up requests it asks
+// for, and feeds each outcome back to it. It returns the plugin's verdict, made
+// of the last verify, confidence, and note values it answered with and every
+// tag, and the follow-up results.
+func verifyHit(ctx context.Context, client plugin.Client, executor *engine.FollowUpExecutor, res engine.Result) (plugin.Response, []engine.Result, error) {
+	var verdict plugin.Response
+	event := matchEvent(res)
+
+	var followUps []engine.Result
+	for {
+		resp, err := client.Call(ctx, event)
+		if err != nil {
+			return verdict, followUps, err
+		}
+		if resp.Verify != nil {
+			verdict.Verify = resp.Verify
+		}
+		if resp.Confidence != nil {
+			verdict.Confidence = resp.Confidence
+		}
+		if resp.Note != "" {
+			verdict.Note = resp.Note
+		}
+		verdict.Tags = appendTags(verdict.Tags, resp.Tags)
+		if resp.Request == nil {
+			return verdict, followUps, nil
+		}
+		if len(followUps) == maxFollowUps {
+			return verdict, followUps, fmt.Errorf("plugin asked for more than %d follow-up requests", maxFollowUps)
+		}
+
+		followUp := executor.Execute(ctx, res, followUpRequest(*resp.Request))
+		followUps = append(followUps, followUp)
+		event = matchEvent(followUp)
+	}
+}
+
+// appendTags adds the non-empty tags that are not in tags yet.
+func appendTags(tags, add []string) []string {
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func followUpRequest(spec plugin.RequestSpec) engine.FollowUp {
+	req := engine.FollowUp{
+		URL:             spec.URL,
+		Method:          spec.Method,
+		Body:            spec.Body,
+		Timeout:         time.Duration(spec.TimeoutMS) * time.Millisecond,
+		FollowRedirects: spec.FollowRedirects,
+	}
+	if len(spec.Headers) > 0 {
+		req.Headers = make(http.Header, len(spec.Headers))
+		for name, value := range spec.Headers {
+			req.Headers.Set(name, value)
+		}
+	}
+	return req
+}
+
+// persistentTransport returns the transport for plugins that are called for
+// every payload or response and so must stay running for the whole scan.
+func persistentTransport(transport string) string {
+	if strings.EqualFold(strings.TrimSpace(transport), plugin.TransportGRPC) {
+		return plugin.TransportGRPC
+	}
+	return plugin.TransportJSONRPC
+}
+
+func responseEvent(res engine.Result) plugin.ResponseEvent {
+	digest := res.BodySHA256
+	if digest == "" {
+		sum := sha256.Sum256(res.Body)
+		digest = hex.EncodeToString(sum[:])
+	}
+	return plugin.ResponseEvent{
+		URL:           res.URL,
+		Method:        res.RequestMethod,
+		StatusCode:    res.StatusCode,
+		ContentLength: res.ContentLength,
+		DurationMS:    res.Duration.Milliseconds(),
+		Word:          res.Word,
+		Payload:       res.Payload,
+		Headers:       res.ResponseHeader,
+		BodySHA256:    digest,
+		BodySize:      len(res.Body),
+		BodyWords:     res.BodyWords,
+		BodyLines:     res.BodyLines,
+	}
+}
+
+func sameFile(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return false
+	}
+
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	return absA == absB
 }
 
 func randomToken() string {
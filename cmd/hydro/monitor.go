@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"hydr0g3n/pkg/config"
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/output"
+)
+
+// webhookTimeout bounds how long delivering one change to --webhook may take.
+const webhookTimeout = 10 * time.Second
+
+// Kinds of change reported by "hydro monitor".
+const (
+	changeStatus      = "status"
+	changeContent     = "content"
+	changeUnavailable = "unavailable"
+	changeAvailable   = "available"
+)
+
+// monitorState is what a monitored URL answered last.
+type monitorState struct {
+	Status     int    `json:"status"`
+	Size       int64  `json:"size"`
+	BodySHA256 string `json:"body_sha256,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// monitorChange is the JSON body posted to --webhook for every change.
+type monitorChange struct {
+	Type   string       `json:"type"`
+	Time   time.Time    `json:"time"`
+	URL    string       `json:"url"`
+	Change string       `json:"change"`
+	Was    monitorState `json:"was"`
+	Now    monitorState `json:"now"`
+}
+
+// runMonitorCommand implements "hydro monitor". It requests the findings of
+// the JSONL results files given as arguments every --interval and reports
+// those whose status, body, or availability changed since the previous
+// round, starting from the values recorded in the files. It returns the
+// process exit code.
+func runMonitorCommand(binaryName string, args []string) int {
+	interval := flag.Duration("interval", 5*time.Minute, "Time between rounds of requests")
+	rounds := flag.Int("rounds", 0, "Stop after this many rounds (0 runs until interrupted)")
+	webhook := flag.String("webhook", "", "URL that receives every change as a JSON POST")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s monitor [options] results.jsonl ...\n", binaryName)
+		fmt.Fprintln(flag.CommandLine.Output(), "\nRequests the findings of previous runs periodically and reports changes.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Accepts the same flags as a scan, and:")
+		for _, name := range []string{"interval", "rounds", "webhook"} {
+			f := flag.Lookup(name)
+			fmt.Fprintf(flag.CommandLine.Output(), "  -%s\n    \t%s (default %q)\n", f.Name, f.Usage, f.DefValue)
+		}
+	}
+
+	// Flags may follow the files as well as precede them.
+	var paths []string
+	for rest := args; ; {
+		if err := flag.CommandLine.Parse(rest); err != nil {
+			return exitUsage
+		}
+		if flag.NArg() == 0 {
+			break
+		}
+		paths = append(paths, flag.Arg(0))
+		rest = flag.Args()[1:]
+	}
+	if len(paths) == 0 {
+		flag.Usage()
+		return exitUsage
+	}
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "%s: --interval must be positive\n", binaryName)
+		return exitUsage
+	}
+
+	if _, err := config.ApplyEnvironment(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if profileDir, err := config.DefaultProfileDir(); err == nil {
+		if _, err := config.LoadUserProfiles(profileDir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+	if selected := strings.TrimSpace(lookupString("profile")); selected != "" {
+		profile, err := config.ResolveProfile(selected)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		if _, err := config.ApplyProfile(flag.CommandLine, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+
+	statuses, err := matcher.ParseStatusList(lookupString("match-status"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	sizeRange, err := matcher.ParseSizeRange(lookupString("filter-size"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	resultMatcher := matcher.New(matcher.Options{Statuses: statuses, Size: sizeRange})
+
+	var findings []output.JSONLResult
+	for _, path := range paths {
+		results, err := output.LoadJSONLResults(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		findings = append(findings, replayFindings(results, resultMatcher)...)
+	}
+	if len(findings) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: the results files hold no findings to monitor\n", binaryName)
+		return exitUsage
+	}
+
+	executor, err := engine.NewFollowUpExecutor(engine.Config{
+		Timeout:         lookupDuration("timeout"),
+		FollowRedirects: lookupBool("follow-redirects"),
+		Proxy:           strings.TrimSpace(lookupString("proxy")),
+		MaxBodySize:     lookupInt64("max-body-size"),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+
+	states := make([]monitorState, len(findings))
+	for i, finding := range findings {
+		states[i] = monitorState{Status: finding.Status, Size: finding.Size, BodySHA256: finding.BodySHA256}
+		// Bodies are needed to tell when content changes. A HEAD response
+		// has none, so its recorded hash is that of an empty body and the
+		// first GET sets the one to compare with.
+		if finding.Method == "" || finding.Method == http.MethodHead {
+			findings[i].Method = http.MethodGet
+			states[i].BodySHA256 = ""
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	changed := 0
+	for round := 1; ; round++ {
+		replayed := replay(ctx, executor, findings, lookupInt("concurrency"))
+		if ctx.Err() != nil {
+			break
+		}
+
+		roundChanges := 0
+		for i, res := range replayed {
+			now := monitorState{Status: res.StatusCode, Size: res.ContentLength, BodySHA256: res.BodySHA256}
+			if res.Err != nil {
+				now = monitorState{Error: res.Err.Error()}
+			}
+			kind := compareStates(states[i], now)
+			if kind == "" {
+				if now.BodySHA256 == "" {
+					now.BodySHA256 = states[i].BodySHA256
+				}
+				states[i] = now
+				continue
+			}
+
+			roundChanges++
+			change := monitorChange{Type: "change", Time: time.Now().UTC(), URL: findings[i].URL, Change: kind, Was: states[i], Now: now}
+			fmt.Fprintln(os.Stdout, describeChange(change))
+			if target := strings.TrimSpace(*webhook); target != "" {
+				if err := postChange(ctx, target, change); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: webhook: %v\n", binaryName, err)
+				}
+			}
+			states[i] = now
+		}
+		changed += roundChanges
+		fmt.Fprintf(os.Stderr, "%s: round %d: %d URLs, %d changed\n", binaryName, round, len(findings), roundChanges)
+
+		if *rounds > 0 && round >= *rounds {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(*interval):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if lookupBool("fail-on-hits") && changed > 0 {
+		return exitHits
+	}
+	return exitOK
+}
+
+// compareStates returns the kind of change between the previous and current
+// answers of a URL, or "" when it did not change. Bodies are only compared
+// when both hashes are known.
+func compareStates(was, now monitorState) string {
+	switch {
+	case was.Error == "" && now.Error != "":
+		return changeUnavailable
+	case was.Error != "" && now.Error == "":
+		return changeAvailable
+	case now.Error != "":
+		return ""
+	case was.Status != now.Status:
+		return changeStatus
+	case was.BodySHA256 != "" && now.BodySHA256 != "" && was.BodySHA256 != now.BodySHA256:
+		return changeContent
+	}
+	return ""
+}
+
+// describeChange renders change as a line of monitor output.
+func describeChange(change monitorChange) string {
+	detail := ""
+	switch change.Change {
+	case changeStatus:
+		detail = fmt.Sprintf("%d -> %d", change.Was.Status, change.Now.Status)
+	case changeContent:
+		detail = fmt.Sprintf("%d -> %d bytes", change.Was.Size, change.Now.Size)
+	case changeUnavailable:
+		detail = change.Now.Error
+	case changeAvailable:
+		detail = fmt.Sprintf("answering %d", change.Now.Status)
+	}
+	return fmt.Sprintf("%s  %-11s  %s  %s", change.Time.Format(time.RFC3339), change.Change, change.URL, detail)
+}
+
+// postChange delivers change to the webhook at target.
+func postChange(ctx context.Context, target string, change monitorChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", target, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareStates(t *testing.T) {
+	tests := []struct {
+		name string
+		was  monitorState
+		now  monitorState
+		want string
+	}{
+		{name: "unchanged", was: monitorState{Status: 200, BodySHA256: "a"}, now: monitorState{Status: 200, BodySHA256: "a"}},
+		{name: "status", was: monitorState{Status: 200}, now: monitorState{Status: 404}, want: changeStatus},
+		{name: "content", was: monitorState{Status: 200, BodySHA256: "a"}, now: monitorState{Status: 200, BodySHA256: "b"}, want: changeContent},
+		{name: "status wins over content", was: monitorState{Status: 200, BodySHA256: "a"}, now: monitorState{Status: 500, BodySHA256: "b"}, want: changeStatus},
+		{name: "body hash unknown before", was: monitorState{Status: 200}, now: monitorState{Status: 200, BodySHA256: "b"}},
+		{name: "body hash unknown now", was: monitorState{Status: 200, BodySHA256: "a"}, now: monitorState{Status: 200}},
+		{name: "size alone", was: monitorState{Status: 200, Size: 10}, now: monitorState{Status: 200, Size: 20}},
+		{name: "unavailable", was: monitorState{Status: 200}, now: monitorState{Error: "timeout"}, want: changeUnavailable},
+		{name: "available again", was: monitorState{Error: "timeout"}, now: monitorState{Status: 200}, want: changeAvailable},
+		{name: "still unavailable", was: monitorState{Error: "timeout"}, now: monitorState{Error: "connection refused"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareStates(tt.was, tt.now); got != tt.want {
+				t.Fatalf("compareStates(%+v, %+v) = %q, want %q", tt.was, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeChange(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		change monitorChange
+		want   string
+	}{
+		{
+			change: monitorChange{Change: changeStatus, Was: monitorState{Status: 200}, Now: monitorState{Status: 403}},
+			want:   "2026-01-02T03:04:05Z  status       http://target/admin  200 -> 403",
+		},
+		{
+			change: monitorChange{Change: changeContent, Was: monitorState{Size: 10}, Now: monitorState{Size: 12}},
+			want:   "2026-01-02T03:04:05Z  content      http://target/admin  10 -> 12 bytes",
+		},
+		{
+			change: monitorChange{Change: changeUnavailable, Now: monitorState{Error: "timeout"}},
+			want:   "2026-01-02T03:04:05Z  unavailable  http://target/admin  timeout",
+		},
+		{
+			change: monitorChange{Change: changeAvailable, Now: monitorState{Status: 200}},
+			want:   "2026-01-02T03:04:05Z  available    http://target/admin  answering 200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.change.Change, func(t *testing.T) {
+			tt.change.Time = at
+			tt.change.URL = "http://target/admin"
+			if got := describeChange(tt.change); got != tt.want {
+				t.Fatalf("describeChange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusNoContent},
+		{name: "rejected", status: http.StatusBadGateway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got monitorChange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("expected a JSON body, got %q", ct)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode change: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			change := monitorChange{Type: "change", URL: "http://target/admin", Change: changeStatus, Was: monitorState{Status: 200}, Now: monitorState{Status: 404}}
+			err := postChange(context.Background(), server.URL, change)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("postChange() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "502") {
+				t.Fatalf("expected the status in the error, got %v", err)
+			}
+			if got.URL != change.URL || got.Change != change.Change || got.Now.Status != 404 {
+				t.Fatalf("expected the change to be posted, got %+v", got)
+			}
+		})
+	}
+}
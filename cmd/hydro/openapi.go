@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/openapi"
+)
+
+// openapiTargets turns the request templates of a spec into scan targets
+// using wordlist. Operations that change the state of the server are left
+// out unless aggressive is set.
+func openapiTargets(binaryName string, requests []openapi.Request, wordlist string, aggressive bool) []engine.Target {
+	targets := make([]engine.Target, 0, len(requests))
+	skipped := 0
+	for _, r := range requests {
+		if !r.Safe() && !aggressive {
+			skipped++
+			continue
+		}
+		targets = append(targets, engine.Target{
+			URL:      r.URL,
+			Method:   r.Method,
+			Wordlist: wordlist,
+			Headers:  r.Headers,
+			Body:     r.Body,
+		})
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%s: skipping operations that change server state (%d request templates); pass --aggressive to include them\n", binaryName, skipped)
+	}
+	return targets
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// Orders in which --plugin-order delivers checked results.
+const (
+	pluginOrderOrdered   = "ordered"
+	pluginOrderUnordered = "unordered"
+)
+
+func parsePluginOrder(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", pluginOrderOrdered:
+		return true, nil
+	case pluginOrderUnordered:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported plugin order %q (want %s or %s)", value, pluginOrderOrdered, pluginOrderUnordered)
+	}
+}
+
+// checkedResult is an engine result after the matchers and verification
+// plugins have looked at it.
+type checkedResult struct {
+	res     engine.Result
+	matches bool
+	// verification lists the follow-up requests plugins asked for.
+	verification []engine.Result
+	// bypasses lists the variants of a denied hit tried with --bypass and
+	// --method-override.
+	bypasses []engine.Result
+}
+
+// checkResults runs check on up to workers results at a time so slow plugins
+// do not hold up the results loop. With ordered set, results are delivered in
+// the order they arrived; otherwise as soon as they are checked.
+func checkResults(in <-chan engine.Result, workers int, ordered bool, check func(engine.Result) checkedResult) <-chan checkedResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan checkedResult)
+
+	type job struct {
+		res  engine.Result
+		slot chan checkedResult
+	}
+	jobs := make(chan job)
+
+	// In ordered mode every result gets a slot, and slots are queued in
+	// arrival order. The queue bounds how far delivery can lag behind.
+	var slots chan chan checkedResult
+	if ordered {
+		slots = make(chan chan checkedResult, workers)
+	}
+
+	go func() {
+		defer close(jobs)
+		if slots != nil {
+			defer close(slots)
+		}
+		for res := range in {
+			j := job{res: res}
+			if slots != nil {
+				j.slot = make(chan checkedResult, 1)
+				slots <- j.slot
+			}
+			jobs <- j
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				checked := check(j.res)
+				if j.slot != nil {
+					j.slot <- checked
+				} else {
+					out <- checked
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		if slots != nil {
+			for slot := range slots {
+				out <- <-slot
+			}
+		}
+		wg.Wait()
+	}()
+
+	return out
+}
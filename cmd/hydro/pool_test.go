@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+)
+
+func TestParsePluginOrder(t *testing.T) {
+	tests := []struct {
+		value   string
+		ordered bool
+		wantErr bool
+	}{
+		{value: "", ordered: true},
+		{value: "ordered", ordered: true},
+		{value: " Unordered ", ordered: false},
+		{value: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		ordered, err := parsePluginOrder(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parsePluginOrder(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+		}
+		if err == nil && ordered != tt.ordered {
+			t.Fatalf("parsePluginOrder(%q) = %t, want %t", tt.value, ordered, tt.ordered)
+		}
+	}
+}
+
+func TestCheckResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		ordered bool
+		results int
+		// maxInFlight is the most checks expected to run at once.
+		maxInFlight int32
+	}{
+		{name: "ordered pool", workers: 4, ordered: true, results: 40, maxInFlight: 4},
+		{name: "unordered pool", workers: 4, ordered: false, results: 40, maxInFlight: 4},
+		{name: "single worker", workers: 1, ordered: true, results: 10, maxInFlight: 1},
+		{name: "non-positive workers use one", workers: 0, ordered: false, results: 10, maxInFlight: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan engine.Result)
+			go func() {
+				defer close(in)
+				for i := 0; i < tt.results; i++ {
+					in <- engine.Result{URL: strconv.Itoa(i)}
+				}
+			}()
+
+			var (
+				inFlight atomic.Int32
+				mu       sync.Mutex
+				peak     int32
+			)
+			check := func(res engine.Result) checkedResult {
+				n := inFlight.Add(1)
+				mu.Lock()
+				if n > peak {
+					peak = n
+				}
+				mu.Unlock()
+
+				// Later results finish first so ordering has to be restored.
+				i, _ := strconv.Atoi(res.URL)
+				time.Sleep(time.Duration(tt.results-i) * 100 * time.Microsecond)
+
+				inFlight.Add(-1)
+				return checkedResult{res: res, matches: i%2 == 0}
+			}
+
+			var got []int
+			for checked := range checkResults(in, tt.workers, tt.ordered, check) {
+				i, _ := strconv.Atoi(checked.res.URL)
+				if checked.matches != (i%2 == 0) {
+					t.Fatalf("result %d delivered with the verdict of another result", i)
+				}
+				got = append(got, i)
+			}
+
+			if len(got) != tt.results {
+				t.Fatalf("expected %d results, got %d", tt.results, len(got))
+			}
+			if peak > tt.maxInFlight {
+				t.Fatalf("expected at most %d concurrent checks, saw %d", tt.maxInFlight, peak)
+			}
+
+			if tt.ordered {
+				if !sort.IntsAreSorted(got) {
+					t.Fatalf("expected results in arrival order, got %v", got)
+				}
+				return
+			}
+			sort.Ints(got)
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("expected every result exactly once, got %v", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"hydr0g3n/pkg/config"
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/output"
+)
+
+// runReplayCommand implements "hydro replay". It requests every finding of
+// the JSONL results files given as arguments again, with the transport and
+// matcher flags of the current invocation, and reports which still match. It
+// returns the process exit code.
+func runReplayCommand(binaryName string, args []string) int {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s replay [options] results.jsonl ...\n", binaryName)
+		fmt.Fprintln(flag.CommandLine.Output(), "\nRequests the findings of previous runs again and reports which still match.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Accepts the same flags as a scan.")
+	}
+
+	// Flags may follow the files as well as precede them.
+	var paths []string
+	for rest := args; ; {
+		if err := flag.CommandLine.Parse(rest); err != nil {
+			return exitUsage
+		}
+		if flag.NArg() == 0 {
+			break
+		}
+		paths = append(paths, flag.Arg(0))
+		rest = flag.Args()[1:]
+	}
+	if len(paths) == 0 {
+		flag.Usage()
+		return exitUsage
+	}
+
+	if _, err := config.ApplyEnvironment(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	if profileDir, err := config.DefaultProfileDir(); err == nil {
+		if _, err := config.LoadUserProfiles(profileDir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+	if selected := strings.TrimSpace(lookupString("profile")); selected != "" {
+		profile, err := config.ResolveProfile(selected)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+		if _, err := config.ApplyProfile(flag.CommandLine, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitUsage
+		}
+	}
+
+	statuses, err := matcher.ParseStatusList(lookupString("match-status"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	sizeRange, err := matcher.ParseSizeRange(lookupString("filter-size"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitUsage
+	}
+	resultMatcher := matcher.New(matcher.Options{Statuses: statuses, Size: sizeRange})
+
+	var findings []output.JSONLResult
+	for _, path := range paths {
+		results, err := output.LoadJSONLResults(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		findings = append(findings, replayFindings(results, resultMatcher)...)
+	}
+
+	executor, err := engine.NewFollowUpExecutor(engine.Config{
+		Timeout:         lookupDuration("timeout"),
+		FollowRedirects: lookupBool("follow-redirects"),
+		Proxy:           strings.TrimSpace(lookupString("proxy")),
+		MaxBodySize:     lookupInt64("max-body-size"),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+
+	replayed := replay(context.Background(), executor, findings, lookupInt("concurrency"))
+
+	reproduced := 0
+	for _, res := range replayed {
+		if res.Err == nil && resultMatcher.Matches(res) {
+			reproduced++
+		}
+	}
+	if err := printReplay(os.Stdout, findings, replayed, resultMatcher); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d of %d findings reproduced\n", binaryName, reproduced, len(findings))
+
+	if lookupBool("fail-on-hits") && reproduced > 0 {
+		return exitHits
+	}
+	return exitOK
+}
+
+// replayFindings returns the entries of results that were findings. Files
+// written before entries were marked as matches have no marked entries; their
+// findings are the entries whose recorded status and size pass m.
+func replayFindings(results []output.JSONLResult, m matcher.Matcher) []output.JSONLResult {
+	var marked, matching []output.JSONLResult
+	for _, res := range results {
+		if res.Match {
+			marked = append(marked, res)
+		}
+		if m.Matches(engine.Result{URL: res.URL, StatusCode: res.Status, ContentLength: res.Size}) {
+			matching = append(matching, res)
+		}
+	}
+
+	if len(marked) > 0 {
+		return marked
+	}
+	return matching
+}
+
+// replay requests every finding again with up to concurrency requests at
+// once and returns the results in the order of findings.
+func replay(ctx context.Context, executor *engine.FollowUpExecutor, findings []output.JSONLResult, concurrency int) []engine.Result {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]engine.Result, len(findings))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, finding := range findings {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = executor.Execute(ctx, engine.Result{URL: finding.URL, RequestMethod: finding.Method}, engine.FollowUp{})
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// printReplay writes a line per finding telling whether its replayed result
+// still matches.
+func printReplay(w io.Writer, findings []output.JSONLResult, replayed []engine.Result, m matcher.Matcher) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tWAS\tNOW\tURL")
+	for i, finding := range findings {
+		res := replayed[i]
+
+		outcome, now := "gone", strconv.Itoa(res.StatusCode)
+		switch {
+		case res.Err != nil:
+			outcome, now = "error", "-"
+		case m.Matches(res):
+			outcome = "reproduced"
+		}
+
+		line := fmt.Sprintf("%s\t%d\t%s\t%s", outcome, finding.Status, now, finding.URL)
+		if res.Err != nil {
+			line += " (" + res.Err.Error() + ")"
+		}
+		fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
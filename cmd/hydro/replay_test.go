@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/matcher"
+	"hydr0g3n/pkg/output"
+)
+
+func TestReplayFindings(t *testing.T) {
+	m := matcher.New(matcher.Options{Statuses: []int{200}})
+
+	tests := []struct {
+		name    string
+		results []output.JSONLResult
+		want    []string
+	}{
+		{
+			name: "marked entries",
+			results: []output.JSONLResult{
+				{URL: "http://target/a", Status: 200, Match: true},
+				// A hit of matchers other than --match-status.
+				{URL: "http://target/b", Status: 403, Match: true},
+				{URL: "http://target/c", Status: 200},
+			},
+			want: []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name: "unmarked file falls back to the matcher",
+			results: []output.JSONLResult{
+				{URL: "http://target/a", Status: 200},
+				{URL: "http://target/b", Status: 404},
+				{URL: "http://target/c", Status: 200},
+			},
+			want: []string{"http://target/a", "http://target/c"},
+		},
+		{
+			name:    "no findings",
+			results: []output.JSONLResult{{URL: "http://target/a", Status: 404}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range replayFindings(tt.results, m) {
+				got = append(got, finding.URL)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected findings %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrintReplay(t *testing.T) {
+	m := matcher.New(matcher.Options{Statuses: []int{200}})
+	finding := output.JSONLResult{URL: "http://target/admin", Status: 200}
+
+	tests := []struct {
+		name     string
+		replayed engine.Result
+		want     string
+	}{
+		{name: "reproduced", replayed: engine.Result{StatusCode: 200}, want: "reproduced 200 200 http://target/admin"},
+		{name: "gone", replayed: engine.Result{StatusCode: 404}, want: "gone 200 404 http://target/admin"},
+		{name: "error", replayed: engine.Result{Err: errors.New("connection refused")}, want: "error 200 - http://target/admin (connection refused)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printReplay(&buf, []output.JSONLResult{finding}, []engine.Result{tt.replayed}, m); err != nil {
+				t.Fatalf("print replay: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 || !strings.HasPrefix(lines[0], "RESULT") {
+				t.Fatalf("expected a header and one line, got %q", buf.String())
+			}
+			if got := strings.Join(strings.Fields(lines[1]), " "); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"hydr0g3n/pkg/store"
+)
+
+// runReportCommand implements "hydro report html". It renders the runs a
+// SQLite resume database recorded against one target as an HTML timeline of
+// the hits that appeared, disappeared, or changed status between runs. It
+// returns the process exit code.
+func runReportCommand(binaryName string, args []string) int {
+	usage := func(w io.Writer) {
+		fmt.Fprintf(w, "Usage: %s report html --db resume.db --target <url> [--out report.html]\n", binaryName)
+		fmt.Fprintln(w, "\nRenders the hits recorded for a target across runs as an HTML timeline.")
+	}
+	if len(args) == 0 || args[0] != "html" {
+		usage(os.Stderr)
+		return exitUsage
+	}
+
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.Usage = func() {
+		usage(flags.Output())
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", "", "SQLite resume database written with --resume")
+	target := flags.String("target", "", "Target URL of the runs to report, as passed to -u")
+	outPath := flags.String("out", "", "Path to write the report to (default: stdout)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if strings.TrimSpace(*dbPath) == "" || strings.TrimSpace(*target) == "" {
+		flags.Usage()
+		return exitUsage
+	}
+
+	db, err := store.OpenSQLiteReadOnly(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	defer db.Close()
+
+	runs, err := db.TargetHistory(context.Background(), strings.TrimSpace(*target))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s has no runs against %s\n", binaryName, *dbPath, *target)
+		return exitRuntime
+	}
+
+	out := io.Writer(os.Stdout)
+	if trimmed := strings.TrimSpace(*outPath); trimmed != "" {
+		file, err := os.Create(trimmed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: create report: %v\n", binaryName, err)
+			return exitRuntime
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := reportTemplate.Execute(out, buildTimeline(strings.TrimSpace(*target), runs)); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: write report: %v\n", binaryName, err)
+		return exitRuntime
+	}
+	return exitOK
+}
+
+// timeline is the data of an HTML report.
+type timeline struct {
+	Target    string
+	Generated string
+	Runs      []timelineRun
+	Paths     []timelinePath
+}
+
+// timelineRun is one run of a timeline and how its hits differ from the
+// previous run.
+type timelineRun struct {
+	RunID       string
+	StartedAt   string
+	Hits        int
+	Appeared    []string
+	Disappeared []string
+	Changed     []statusChange
+}
+
+type statusChange struct {
+	Path     string
+	From, To int
+}
+
+// timelinePath is the status of a hit in every run, in run order.
+type timelinePath struct {
+	Path  string
+	Cells []timelineCell
+}
+
+// timelineCell is the status of a path in one run. State is "new", "gone",
+// "changed", "same", or empty when the path was not a hit of the run or of
+// the run before it.
+type timelineCell struct {
+	Status int
+	State  string
+}
+
+// buildTimeline compares every run with the one before it. A path hit more
+// than once in a run keeps the status of its last hit.
+func buildTimeline(target string, runs []store.RunHistory) timeline {
+	report := timeline{Target: target, Generated: time.Now().UTC().Format(time.RFC3339)}
+
+	statuses := make([]map[string]int, len(runs))
+	seen := make(map[string]struct{})
+	for i, run := range runs {
+		statuses[i] = make(map[string]int, len(run.Hits))
+		for _, hit := range run.Hits {
+			statuses[i][hit.Path] = hit.StatusCode
+			seen[hit.Path] = struct{}{}
+		}
+	}
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for i, run := range runs {
+		entry := timelineRun{RunID: run.RunID, Hits: len(statuses[i])}
+		if !run.StartedAt.IsZero() {
+			entry.StartedAt = run.StartedAt.UTC().Format("2006-01-02 15:04:05 MST")
+		}
+		if i > 0 {
+			for _, path := range paths {
+				before, had := statuses[i-1][path]
+				after, has := statuses[i][path]
+				switch {
+				case has && !had:
+					entry.Appeared = append(entry.Appeared, path)
+				case had && !has:
+					entry.Disappeared = append(entry.Disappeared, path)
+				case has && before != after:
+					entry.Changed = append(entry.Changed, statusChange{Path: path, From: before, To: after})
+				}
+			}
+		}
+		report.Runs = append(report.Runs, entry)
+	}
+
+	for _, path := range paths {
+		row := timelinePath{Path: path, Cells: make([]timelineCell, len(runs))}
+		for i := range runs {
+			status, has := statuses[i][path]
+			var before int
+			had := false
+			if i > 0 {
+				before, had = statuses[i-1][path]
+			}
+			cell := timelineCell{Status: status}
+			switch {
+			case has && i > 0 && !had:
+				cell.State = "new"
+			case has && had && before != status:
+				cell.State = "changed"
+			case has:
+				cell.State = "same"
+			case had:
+				cell.State = "gone"
+			}
+			row.Cells[i] = cell
+		}
+		report.Paths = append(report.Paths, row)
+	}
+
+	return report
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>hydro timeline: {{.Target}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; font-size: 0.9rem; }
+th, td { border: 1px solid #d0d7de; padding: 0.25rem 0.5rem; text-align: left; }
+th { background: #f6f8fa; }
+td.new { background: #dafbe1; }
+td.gone { background: #ffebe9; }
+td.changed { background: #fff8c5; }
+.path { font-family: ui-monospace, monospace; }
+.run { margin-bottom: 1.5rem; }
+</style>
+</head>
+<body>
+<h1>Timeline of {{.Target}}</h1>
+<p>{{len .Runs}} runs, {{len .Paths}} paths found. Generated {{.Generated}}.</p>
+
+<h2>Hits by run</h2>
+<table>
+<tr><th class="path">Path</th>{{range .Runs}}<th>{{.StartedAt}}<br>{{.RunID}}</th>{{end}}</tr>
+{{range .Paths}}<tr><td class="path">{{.Path}}</td>{{range .Cells}}<td class="{{.State}}">{{if eq .State "gone"}}gone{{else if .State}}{{.Status}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Changes</h2>
+{{range .Runs}}<div class="run">
+<h3>{{.StartedAt}} {{.RunID}}: {{.Hits}} hits</h3>
+{{if .Appeared}}<p>Appeared:</p><ul>{{range .Appeared}}<li class="path">{{.}}</li>{{end}}</ul>{{end}}
+{{if .Disappeared}}<p>Disappeared:</p><ul>{{range .Disappeared}}<li class="path">{{.}}</li>{{end}}</ul>{{end}}
+{{if .Changed}}<p>Status changed:</p><ul>{{range .Changed}}<li><span class="path">{{.Path}}</span>: {{.From}} → {{.To}}</li>{{end}}</ul>{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/store"
+)
+
+// resumeFlagAliases maps the run configuration keys whose flag is not the key
+// with underscores replaced by dashes.
+var resumeFlagAliases = map[string]string{
+	"target_url":  "u",
+	"wordlist":    "w",
+	"output_path": "output",
+	"resume_db":   "resume",
+	"data":        "d",
+}
+
+// runResumeCommand implements "hydro resume". With --list it prints the runs
+// of a SQLite resume database and returns a nil argument list. Otherwise it
+// rebuilds the scan flags of the named run from its stored configuration and
+// returns them, followed by any flags given after the run identifier, so the
+// scan continues where it stopped. The exit code is meaningful only when the
+// returned arguments are nil.
+func runResumeCommand(binaryName string, args []string) ([]string, int) {
+	flags := flag.NewFlagSet("resume", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s resume --list --db resume.db\n", binaryName)
+		fmt.Fprintf(flags.Output(), "       %s resume --db resume.db <run-id> [scan flags]\n", binaryName)
+		fmt.Fprintln(flags.Output(), "\nLists the runs of a resume database, or continues one with its original flags.")
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		flags.PrintDefaults()
+	}
+	list := flags.Bool("list", false, "List the runs recorded in the database")
+	dbPath := flags.String("db", "", "SQLite resume database written with --resume")
+	if err := flags.Parse(args); err != nil {
+		return nil, exitUsage
+	}
+	if strings.TrimSpace(*dbPath) == "" || (!*list && flags.NArg() == 0) {
+		flags.Usage()
+		return nil, exitUsage
+	}
+
+	db, err := store.OpenSQLiteReadOnly(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return nil, exitRuntime
+	}
+	defer db.Close()
+
+	if *list {
+		runs, err := db.ListRuns(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+			return nil, exitRuntime
+		}
+		printRunList(runs)
+		return nil, exitOK
+	}
+
+	runID := strings.TrimSpace(flags.Arg(0))
+	run, err := db.LoadRun(context.Background(), runID)
+	if errors.Is(err, store.ErrRunNotFound) {
+		fmt.Fprintf(os.Stderr, "%s: %s has no run %s\n", binaryName, *dbPath, runID)
+		return nil, exitRuntime
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return nil, exitRuntime
+	}
+	if !run.FinishedAt.IsZero() {
+		fmt.Fprintf(os.Stderr, "%s: run %s already finished; paths it attempted are skipped\n", binaryName, runID)
+	}
+
+	scanArgs, skipped, secrets := resumeArgs(run, *dbPath)
+	for _, key := range skipped {
+		fmt.Fprintf(os.Stderr, "%s: run %s: setting %s is not restored\n", binaryName, runID, key)
+	}
+	for _, name := range secrets {
+		fmt.Fprintf(os.Stderr, "%s: run %s: --%s is only stored as a hash; give it again after the run identifier\n", binaryName, runID, name)
+	}
+	return append(scanArgs, flags.Args()[1:]...), exitOK
+}
+
+// printRunList writes a table of runs to stdout. The total is the number of
+// requests the wordlist expands to, "?" when the wordlist can no longer be
+// read.
+func printRunList(runs []store.RunHistory) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tTARGET\tSTARTED\tATTEMPTED\tSTATE")
+	for _, run := range runs {
+		total := "?"
+		wordlist, _ := run.ConfigValue("wordlist")
+		if plan, err := engine.Plan(engine.Config{URL: run.TargetURL, Wordlist: wordlist}); err == nil {
+			total = strconv.Itoa(plan.TotalPermutations)
+		}
+
+		started := "-"
+		if !run.StartedAt.IsZero() {
+			started = run.StartedAt.Local().Format("2006-01-02 15:04:05")
+		}
+
+		state := "interrupted"
+		if !run.FinishedAt.IsZero() {
+			state = "finished"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%s\t%s\n", run.RunID, run.TargetURL, started, run.Attempted, total, state)
+	}
+	tw.Flush()
+}
+
+// resumeArgs rebuilds the scan flags of run from its stored configuration,
+// writing to the resume database at dbPath under the same run identifier. It
+// also returns the configuration keys that have no matching flag and the
+// secret flags, whose values are only stored as hashes. Profile entries are
+// left out because --profile restores them.
+func resumeArgs(run store.RunHistory, dbPath string) ([]string, []string, []string) {
+	var (
+		args    []string
+		skipped []string
+		secrets []string
+	)
+	for _, entry := range run.Config {
+		key, value := entry.Key, entry.Value
+		switch {
+		case key == "binary" || key == "resume_db" || strings.HasPrefix(key, "profile."):
+			continue
+		case key == "network":
+			switch value {
+			case "tcp4":
+				args = append(args, "-4")
+			case "tcp6":
+				args = append(args, "-6")
+			}
+			continue
+		}
+
+		name, ok := resumeFlagAliases[key]
+		if !ok {
+			name = strings.ReplaceAll(key, "_", "-")
+		}
+		if flag.Lookup(name) == nil {
+			skipped = append(skipped, key)
+			continue
+		}
+		if _, ok := secretFlags[name]; ok {
+			secrets = append(secrets, name)
+			continue
+		}
+		args = append(args, fmt.Sprintf("-%s=%s", name, value))
+	}
+
+	return append(args, "-resume="+dbPath, "-run-id="+run.RunID), skipped, secrets
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/httpclient"
+	"hydr0g3n/pkg/robots"
+	"hydr0g3n/pkg/templater"
+)
+
+// loadRobots fetches the robots.txt of every host scanned by cfg. Targets
+// whose host is fuzzed have no single robots.txt and are only warned about.
+func loadRobots(ctx context.Context, binaryName string, cfg engine.Config, opts httpclient.Options) (*robots.Policy, error) {
+	client, err := httpclient.NewWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{cfg.URL}
+	if len(cfg.Targets) > 0 {
+		urls = urls[:0]
+		for _, target := range cfg.Targets {
+			urls = append(urls, target.URL)
+		}
+	}
+
+	policy := robots.NewPolicy(robots.Agent)
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || strings.Contains(u.Host, templater.DefaultPlaceholder) {
+			fmt.Fprintf(os.Stderr, "%s: --respect-robots cannot apply to %s, whose host is not fixed\n", binaryName, rawURL)
+			continue
+		}
+		if err := policy.Load(ctx, client, rawURL); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
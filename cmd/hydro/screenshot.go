@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// screenshotTimeout bounds how long --screenshot-cmd may take for one hit.
+const screenshotTimeout = time.Minute
+
+// screenshotter runs the --screenshot-cmd hook for hits, writing images to
+// dir.
+type screenshotter struct {
+	command string
+	dir     string
+}
+
+// capture runs the hook for url and returns the path of the image it
+// captured: the last line the command printed, or the {output} path when it
+// printed nothing.
+func (s screenshotter) capture(ctx context.Context, url string) (string, error) {
+	sum := sha256.Sum256([]byte(url))
+	output := filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".png")
+	command := strings.NewReplacer("{url}", shellQuote(url), "{output}", shellQuote(output)).Replace(s.command)
+
+	ctx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Env = append(os.Environ(), "HYDRO_URL="+url, "HYDRO_OUTPUT="+output)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			err = fmt.Errorf("%w: %s", err, lastLine(detail))
+		}
+		return "", fmt.Errorf("screenshot %s: %w", url, err)
+	}
+
+	if printed := lastLine(strings.TrimSpace(stdout.String())); printed != "" {
+		return printed, nil
+	}
+	if _, err := os.Stat(output); err != nil {
+		return "", fmt.Errorf("screenshot %s: %w", url, errors.New("the command neither printed an image path nor wrote {output}"))
+	}
+	return output, nil
+}
+
+// lastLine returns the last line of s.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
+
+// shellQuote quotes s as a single word for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
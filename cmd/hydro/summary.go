@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/output"
+)
+
+// scanTally accumulates the results of a scan for the summary printed when
+// it ends.
+type scanTally struct {
+	hits         int
+	hitsByStatus map[int]int
+	errors       int
+	responses    int
+	latency      time.Duration
+	// truncated counts the responses whose body was cut at --max-body-size.
+	truncated int
+	// errorGroups collects identical errors by message, in the order they
+	// were first seen.
+	errorGroups []*output.ErrorGroup
+	errorIndex  map[string]*output.ErrorGroup
+}
+
+const (
+	// errorExamples is the number of URLs kept as examples of an error.
+	errorExamples = 3
+	// printedErrorGroups is the number of distinct errors listed by the
+	// summary printed at the end of a scan.
+	printedErrorGroups = 5
+)
+
+// add counts res, a hit of the run's matchers when hit is set.
+func (t *scanTally) add(res engine.Result, hit bool) {
+	if res.Err != nil {
+		t.errors++
+		t.addError(res)
+		return
+	}
+
+	t.responses++
+	t.latency += res.Duration
+	if res.BodyTruncated {
+		t.truncated++
+	}
+	if hit {
+		if t.hitsByStatus == nil {
+			t.hitsByStatus = make(map[int]int)
+		}
+		t.hits++
+		t.hitsByStatus[res.StatusCode]++
+	}
+}
+
+func (t *scanTally) addError(res engine.Result) {
+	message := errorMessage(res.Err)
+	group, ok := t.errorIndex[message]
+	if !ok {
+		if t.errorIndex == nil {
+			t.errorIndex = make(map[string]*output.ErrorGroup)
+		}
+		group = &output.ErrorGroup{Message: message}
+		t.errorIndex[message] = group
+		t.errorGroups = append(t.errorGroups, group)
+	}
+	group.Count++
+	if len(group.Examples) < errorExamples {
+		group.Examples = append(group.Examples, res.URL)
+	}
+}
+
+// repeatedError reports whether the error of res was already seen, so that
+// pretty output shows each distinct error once. It must be called after add.
+func (t *scanTally) repeatedError(res engine.Result) bool {
+	group, ok := t.errorIndex[errorMessage(res.Err)]
+	return ok && group.Count > 1
+}
+
+// failed reports whether every request of the scan failed, which is the
+// only way request errors fail the scan.
+func (t *scanTally) failed() bool {
+	return t.errors > 0 && t.responses == 0
+}
+
+// errorMessage returns the message of err without the request it was
+// returned for, so the same failure of different URLs reads the same.
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// footer returns the summary of a scan that sent the requests counted by
+// stats and ran for elapsed.
+func (t *scanTally) footer(runID string, stats engine.StatsSnapshot, elapsed time.Duration) output.RunFooter {
+	footer := output.RunFooter{
+		RunID:        runID,
+		Requests:     stats.Requests,
+		Hits:         t.hits,
+		HitsByStatus: t.hitsByStatus,
+		Errors:       t.errors,
+		ErrorTypes:   stats.ErrorTypes,
+		DurationMS:   elapsed.Milliseconds(),
+		RateLimit:    stats.RateLimit,
+
+		TruncatedBodies: t.truncated,
+	}
+	for _, group := range t.errorGroups {
+		footer.ErrorGroups = append(footer.ErrorGroups, *group)
+	}
+	sort.SliceStable(footer.ErrorGroups, func(i, j int) bool {
+		return footer.ErrorGroups[i].Count > footer.ErrorGroups[j].Count
+	})
+	if t.responses > 0 {
+		footer.AvgLatencyMS = float64(t.latency.Microseconds()) / float64(t.responses) / 1000
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		footer.RequestsPerSecond = float64(stats.Requests) / seconds
+	}
+	return footer
+}
+
+// printScanSummary writes footer in the form shown at the end of a scan.
+func printScanSummary(w io.Writer, footer output.RunFooter) {
+	duration := time.Duration(footer.DurationMS) * time.Millisecond
+	latency := time.Duration(footer.AvgLatencyMS * float64(time.Millisecond)).Round(time.Microsecond)
+
+	fmt.Fprintf(w, "Summary: %d requests in %s (%.1f req/s), average latency %s\n",
+		footer.Requests, duration, footer.RequestsPerSecond, latency)
+
+	hits := make([]string, 0, len(footer.HitsByStatus))
+	for status, count := range footer.HitsByStatus {
+		hits = append(hits, fmt.Sprintf("%d: %d", status, count))
+	}
+	sort.Strings(hits)
+	fmt.Fprintf(w, "  hits: %d%s\n", footer.Hits, breakdown(hits))
+
+	errorTypes := make([]string, 0, len(footer.ErrorTypes))
+	for kind, count := range footer.ErrorTypes {
+		errorTypes = append(errorTypes, fmt.Sprintf("%s: %d", kind, count))
+	}
+	sort.Strings(errorTypes)
+	fmt.Fprintf(w, "  errors: %d%s\n", footer.Errors, breakdown(errorTypes))
+	for i, group := range footer.ErrorGroups {
+		if i == printedErrorGroups {
+			fmt.Fprintf(w, "    and %d other errors\n", len(footer.ErrorGroups)-i)
+			break
+		}
+		fmt.Fprintf(w, "    %d× %s (e.g. %s)\n", group.Count, group.Message, strings.Join(group.Examples, ", "))
+	}
+	if footer.TruncatedBodies > 0 {
+		fmt.Fprintf(w, "  warning: %d response bodies were longer than --max-body-size and were only partly checked\n", footer.TruncatedBodies)
+	}
+	if footer.RobotsSkipped > 0 {
+		fmt.Fprintf(w, "  robots.txt: %d disallowed URLs skipped\n", footer.RobotsSkipped)
+	}
+
+	if limit := footer.RateLimit; limit != nil {
+		after := time.Duration(limit.StartedAfterMS) * time.Millisecond
+		fmt.Fprintf(w, "  rate limited: %s after %s at %.1f req/s, settled at %.1f req/s (%d throttled, %d blocked)\n",
+			rateLimitReasons[limit.Reason], after, limit.TriggerRate, limit.SettledRate, limit.Throttled, limit.Blocked)
+		if limit.Reason == engine.RateLimitLatency {
+			fmt.Fprintf(w, "  latency rose from %.0fms to %.0fms\n", limit.BaselineLatencyMS, limit.PeakLatencyMS)
+		}
+	}
+}
+
+// rateLimitReasons describes the reasons of engine.RateLimitReport.
+var rateLimitReasons = map[string]string{
+	engine.RateLimitStatus:  "429 responses",
+	engine.RateLimitBlocked: "WAF block pages",
+	engine.RateLimitLatency: "latency spike",
+}
+
+func breakdown(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
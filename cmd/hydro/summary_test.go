@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/output"
+)
+
+// requestError is the error the HTTP client returns for a failed request
+// to target.
+func requestError(target, message string) error {
+	return &url.Error{Op: "Get", URL: target, Err: errors.New(message)}
+}
+
+func TestScanTallyGroupsErrors(t *testing.T) {
+	refused := "connect: connection refused"
+	timeout := "context deadline exceeded"
+
+	tests := []struct {
+		name    string
+		results []engine.Result
+		groups  []output.ErrorGroup
+		// repeated lists, for each result in order, whether its error was
+		// seen before.
+		repeated []bool
+		failed   bool
+	}{
+		{
+			name:     "no errors",
+			results:  []engine.Result{{URL: "http://target/a", StatusCode: 200}},
+			repeated: []bool{false},
+		},
+		{
+			name: "same error of different URLs",
+			results: []engine.Result{
+				{URL: "http://target/a", Err: requestError("http://target/a", refused)},
+				{URL: "http://target/b", Err: requestError("http://target/b", refused)},
+			},
+			groups:   []output.ErrorGroup{{Message: refused, Count: 2, Examples: []string{"http://target/a", "http://target/b"}}},
+			repeated: []bool{false, true},
+			failed:   true,
+		},
+		{
+			name: "most frequent first",
+			results: []engine.Result{
+				{URL: "http://target/a", Err: requestError("http://target/a", timeout)},
+				{URL: "http://target/b", Err: requestError("http://target/b", refused)},
+				{URL: "http://target/c", Err: requestError("http://target/c", refused)},
+				{URL: "http://target/d", StatusCode: 404},
+			},
+			groups: []output.ErrorGroup{
+				{Message: refused, Count: 2, Examples: []string{"http://target/b", "http://target/c"}},
+				{Message: timeout, Count: 1, Examples: []string{"http://target/a"}},
+			},
+			repeated: []bool{false, false, true, false},
+		},
+		{
+			name: "examples capped",
+			results: []engine.Result{
+				{URL: "http://target/a", Err: errors.New(timeout)},
+				{URL: "http://target/b", Err: errors.New(timeout)},
+				{URL: "http://target/c", Err: errors.New(timeout)},
+				{URL: "http://target/d", Err: errors.New(timeout)},
+			},
+			groups:   []output.ErrorGroup{{Message: timeout, Count: 4, Examples: []string{"http://target/a", "http://target/b", "http://target/c"}}},
+			repeated: []bool{false, true, true, true},
+			failed:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tally scanTally
+			for i, res := range tt.results {
+				tally.add(res, false)
+				if res.Err != nil && tally.repeatedError(res) != tt.repeated[i] {
+					t.Fatalf("result %d: expected repeated %t", i, tt.repeated[i])
+				}
+			}
+
+			footer := tally.footer("run", engine.StatsSnapshot{}, time.Second)
+			if !slices.EqualFunc(footer.ErrorGroups, tt.groups, func(a, b output.ErrorGroup) bool {
+				return a.Message == b.Message && a.Count == b.Count && slices.Equal(a.Examples, b.Examples)
+			}) {
+				t.Fatalf("expected error groups %+v, got %+v", tt.groups, footer.ErrorGroups)
+			}
+			if tally.failed() != tt.failed {
+				t.Fatalf("expected failed %t, got %t", tt.failed, tally.failed())
+			}
+		})
+	}
+}
+
+func TestPrintScanSummaryErrorGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups int
+		want   []string
+		absent []string
+	}{
+		{name: "none", groups: 0, absent: []string{"×"}},
+		{
+			name:   "all listed",
+			groups: printedErrorGroups,
+			want:   []string{"3× error 0 (e.g. http://target/0)", "3× error 4 (e.g. http://target/4)"},
+			absent: []string{"other errors"},
+		},
+		{
+			name:   "rest counted",
+			groups: printedErrorGroups + 2,
+			want:   []string{"3× error 4 (e.g. http://target/4)", "and 2 other errors"},
+			absent: []string{"error 5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			footer := output.RunFooter{Errors: 3 * tt.groups}
+			for i := 0; i < tt.groups; i++ {
+				footer.ErrorGroups = append(footer.ErrorGroups, output.ErrorGroup{
+					Message:  fmt.Sprintf("error %d", i),
+					Count:    3,
+					Examples: []string{fmt.Sprintf("http://target/%d", i)},
+				})
+			}
+
+			var buf bytes.Buffer
+			printScanSummary(&buf, footer)
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Fatalf("expected %q in the summary:\n%s", want, buf.String())
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(buf.String(), absent) {
+					t.Fatalf("expected no %q in the summary:\n%s", absent, buf.String())
+				}
+			}
+		})
+	}
+}
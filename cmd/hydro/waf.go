@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"hydr0g3n/pkg/config"
+	"hydr0g3n/pkg/waf"
+)
+
+// warnWAF warns about the firewalls and CDNs detected in the baseline
+// response and returns their names for the run metadata. With autoSafe it
+// also applies the pacing of the waf-safe profile to the flags the user left
+// unset.
+func warnWAF(binaryName string, detections []waf.Detection, autoSafe bool) []string {
+	if len(detections) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(detections))
+	for _, d := range detections {
+		names = append(names, d.Name)
+		fmt.Fprintf(os.Stderr, "%s: warning: target appears to be behind %s (%s)\n", binaryName, d.Name, d.Evidence)
+	}
+
+	if !autoSafe {
+		fmt.Fprintf(os.Stderr, "%s: warning: consider --profile waf-safe or --auto-waf-safe to avoid being blocked\n", binaryName)
+		return names
+	}
+
+	safe, err := config.ResolveProfile("waf-safe")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return names
+	}
+	// Only pacing is adjusted: the method and matchers are already in use.
+	applied, err := config.ApplyProfile(flag.CommandLine, config.Profile{
+		Concurrency: safe.Concurrency,
+		Throttle:    safe.Throttle,
+		Timeout:     safe.Timeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", binaryName, err)
+		return names
+	}
+	if len(applied) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: applied waf-safe pacing to %s\n", binaryName, strings.Join(applied, ", "))
+	}
+	return names
+}
@@ -1,10 +1,77 @@
 # bash completion for hydro
 _hydro_completions()
 {
-    local cur opts
+    local cur prev opts
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
-    opts="--beginner --burp-export --burp-host --concurrency --filter-size --follow-redirects --help --match-status --method --no-baseline --output --output-format --pre-hook --profile --resume --run-id --show-similarity --similarity-threshold --timeout -h -u -w"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    opts="--accept-encoding --aggressive --allow-dangerous --auto-waf-safe --beginner --blacklist --breaker-cooldown --breaker-threshold --burp-export --burp-host --bypass --color-mode --color-preset --concurrency --confirm-legal --cookie --dedup-scope --discard-bodies --dns-cache-ttl --dry-run --fail-on-hits --filter-size --follow-redirects --form --fuzz-field --graphql-wordlist --help --host-header --import-burp --import-burp-baselines --jitter --json-body --match-plugin --match-status --max-bandwidth --max-body-size --max-wordlist-line --method --method-override --min-severity --mode --no-baseline --no-charset-decode --no-keepalive --no-method-fallback --normalize-urls --openapi --order --output --output-format --output-headers --output-include-headers --output-plugin --output-rotate-size --parallel-targets --plugin --plugin-order --plugin-policy --plugin-threshold --plugin-transport --plugin-workers --pre-hook --print-config --profile --progress-file --progress-mode --proxy --recursion-depth --recursion-payloads --recursive --respect-robots --resume --resume-backend --resume-from --run-id --save-profile --scope-exclude --scope-include --screenshot-cmd --screenshot-dir --show-similarity --similarity-threshold --smart-method --sni --sort --stream-addr --stream-token --targets --throttle --timeout --tls-fingerprint --transform-plugin --view -4 -6 -d -h -u -w"
+
+    # bash splits "--flag=value" into "--flag", "=" and "value".
+    if [[ ${cur} == "=" ]]; then
+        cur=""
+    elif [[ ${prev} == "=" ]]; then
+        prev="${COMP_WORDS[COMP_CWORD-2]}"
+    fi
+
+    case "${prev}" in
+        -blacklist|--blacklist)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+        -color-mode|--color-mode)
+            COMPREPLY=( $(compgen -W "auto always never" -- "${cur}") )
+            return 0
+            ;;
+        -import-burp|--import-burp)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+        -json-body|--json-body)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+        -min-severity|--min-severity)
+            COMPREPLY=( $(compgen -W "info low medium high critical" -- "${cur}") )
+            return 0
+            ;;
+        -mode|--mode)
+            COMPREPLY=( $(compgen -W "dir bucket header" -- "${cur}") )
+            return 0
+            ;;
+        -openapi|--openapi)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+        -output|--output)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+        -output-format|--output-format)
+            COMPREPLY=( $(compgen -W "jsonl json" -- "${cur}") )
+            return 0
+            ;;
+        -profile|--profile)
+            COMPREPLY=( $(compgen -W "aggressive api beginner stealth waf-safe" -- "${cur}") )
+            return 0
+            ;;
+        -sort|--sort)
+            COMPREPLY=( $(compgen -W "found severity" -- "${cur}") )
+            return 0
+            ;;
+        -tls-fingerprint|--tls-fingerprint)
+            COMPREPLY=( $(compgen -W "chrome firefox random" -- "${cur}") )
+            return 0
+            ;;
+        -view|--view)
+            COMPREPLY=( $(compgen -W "table tree" -- "${cur}") )
+            return 0
+            ;;
+        -w|--w)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
+    esac
 
     if [[ ${cur} == -* ]]; then
         COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
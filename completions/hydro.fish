@@ -1,23 +1,100 @@
 # fish completion for hydro
+complete -c hydro -s 4 -d 'Connect to targets over IPv4 only'
+complete -c hydro -s 6 -d 'Connect to targets over IPv6 only'
+complete -c hydro -l accept-encoding -r -d 'Accept-Encoding to request; gzip and deflate bodies are decoded before matching (identity disables compression)'
+complete -c hydro -l aggressive -d 'Enable aggressive permutations that may disrupt targets'
+complete -c hydro -l allow-dangerous -d 'Request dangerous paths such as logout, delete, and shutdown, which are skipped by default'
+complete -c hydro -l auto-waf-safe -d 'Slow down to the waf-safe profile\'s pacing when the baseline response shows a WAF or CDN'
 complete -c hydro -l beginner -d 'Enable beginner-friendly defaults'
+complete -c hydro -l blacklist -r -F -d 'File of further dangerous path segments to skip, one per line, on top of the built-in list'
+complete -c hydro -l breaker-cooldown -r -d 'How long requests to a failing host are paused'
+complete -c hydro -l breaker-threshold -r -d 'Consecutive connection errors or timeouts that pause requests to a host (0 disables)'
 complete -c hydro -l burp-export -r -d 'Write matched requests and responses to a Burp-compatible XML file'
-complete -c hydro -l burp-host -r -d 'POST matched findings to a Burp Collaborator endpoint'
+complete -c hydro -l burp-host -r -d 'Stream matched findings as they are found to a Burp extension endpoint (e.g. http://127.0.0.1:1337), retrying while it is unavailable'
+complete -c hydro -l bypass -d 'Retry 401 and 403 hits with path and header tricks, reporting variants answering 200 as bypass findings'
+complete -c hydro -l color-mode -x -a 'auto always never' -d 'Color output mode (auto, always, never)'
+complete -c hydro -l color-preset -r -d 'Color palette for pretty output (default, protanopia, tritanopia, blue-light)'
 complete -c hydro -l concurrency -r -d 'Number of concurrent workers'
+complete -c hydro -l confirm-legal -d 'Acknowledge that you are authorized for aggressive, recursive, bypass, method override, or --allow-dangerous scans'
+complete -c hydro -l cookie -r -d 'Cookie header to send with every request, such as \'session=FUZZ\'; FUZZ is replaced by each payload'
+complete -c hydro -s d -r -d 'Request body to send with every request; @path streams the file from disk'
+complete -c hydro -l dedup-scope -r -d 'Scope used to skip previously attempted paths with --resume (run, target, global)'
+complete -c hydro -l discard-bodies -d 'Drop the bodies of non-matching responses as soon as they are checked, keeping their hash'
+complete -c hydro -l dns-cache-ttl -r -d 'How long resolved hostnames are cached (0 disables the cache)'
+complete -c hydro -l dry-run -d 'Display planned permutations without sending any requests'
+complete -c hydro -l fail-on-hits -d 'Exit with status 1 when the scan completes with hits (--fail-on-hits=false exits 0)'
 complete -c hydro -l filter-size -r -d 'Filter visible hits by response size range (min-max bytes)'
 complete -c hydro -l follow-redirects -d 'Follow HTTP redirects (up to 5 hops)'
+complete -c hydro -l form -r -d 'Multipart form field to send, name=value or name=@file[;filename=name][;type=mime], with FUZZ in values and filenames (repeatable)'
+complete -c hydro -l fuzz-field -r -d 'Dot-separated path of the --json-body value to fuzz, such as user.name (default: every string value in turn)'
+complete -c hydro -l graphql-wordlist -r -d 'Write the query and mutation names of GraphQL endpoints that allow introspection to this file, as a wordlist'
 complete -c hydro -s h -d 'Show usage information'
 complete -c hydro -l help -d 'Show usage information'
+complete -c hydro -l host-header -r -d 'Host header to send while connecting to the host in the URL'
+complete -c hydro -l import-burp -r -F -d 'Burp Suite XML export whose in-scope hosts and directories are scanned as targets'
+complete -c hydro -l import-burp-baselines -d 'Use the 404 responses recorded in --import-burp as the similarity baseline of their hosts'
+complete -c hydro -l jitter -r -d 'Upper bound of a random delay added before each dispatched request (e.g. 500ms)'
+complete -c hydro -l json-body -r -F -d 'JSON document to send as the body, with the value at --fuzz-field replaced by each payload'
+complete -c hydro -l match-plugin -r -d 'Plugin that decides whether each response is a hit, alongside the built-in matchers'
 complete -c hydro -l match-status -r -d 'Comma-separated list of HTTP status codes to include in hits'
+complete -c hydro -l max-bandwidth -r -d 'Cap the rate at which all workers read responses (e.g. 5MB/s)'
+complete -c hydro -l max-body-size -r -d 'Bytes of each response body kept for matching, similarity, and plugins'
+complete -c hydro -l max-wordlist-line -r -d 'Longest wordlist line to read, in bytes'
 complete -c hydro -l method -r -d 'HTTP method to use for requests (GET, HEAD, POST)'
+complete -c hydro -l method-override -d 'Retry 403, 405, and 501 hits with other methods and method override headers, reporting variants answering 200'
+complete -c hydro -l min-severity -x -a 'info low medium high critical' -d 'Only report hits of at least this severity (info, low, medium, high, critical)'
+complete -c hydro -l mode -x -a 'dir bucket header' -d 'What to fuzz (dir, bucket, header); bucket probes S3, GCS, and Azure buckets named after -u and each word, header sends each word as a header name to -u'
 complete -c hydro -l no-baseline -d 'Disable the automatic baseline request used for similarity filtering'
-complete -c hydro -l output -r -d 'Path to write output results'
-complete -c hydro -l output-format -r -d 'Format for --output (jsonl)'
+complete -c hydro -l no-charset-decode -d 'Compare response bodies as raw bytes instead of transcoding their declared charset to UTF-8'
+complete -c hydro -l no-keepalive -d 'Close the connection after every request instead of reusing it'
+complete -c hydro -l no-method-fallback -d 'Keep HEAD responses with 405 Method Not Allowed instead of repeating them with GET'
+complete -c hydro -l normalize-urls -d 'Lowercase the scheme and host of request URLs and remove //, ./ and ../ from their paths'
+complete -c hydro -l openapi -r -F -d 'OpenAPI or Swagger spec whose documented routes are scanned, with FUZZ at every path and query parameter'
+complete -c hydro -l order -r -d 'Order in which words are sent (as-is, alpha, frequency); frequency sends likely hits first'
+complete -c hydro -l output -r -F -d 'Path to write output results (gzip-compressed when it ends in .gz)'
+complete -c hydro -l output-format -x -a 'jsonl json' -d 'Format for --output (jsonl), or json to print the --dry-run plan as JSON'
+complete -c hydro -l output-headers -r -d 'Comma-separated response headers to include in JSONL output (e.g. Server,Content-Type)'
+complete -c hydro -l output-include-headers -d 'Include the Content-Type, Location, and Server response headers in JSONL output'
+complete -c hydro -l output-plugin -r -d 'Program that receives every hit as JSONL on stdin and delivers it'
+complete -c hydro -l output-rotate-size -r -d 'Split --output into numbered files of this size (e.g. 500MB)'
+complete -c hydro -l parallel-targets -d 'Scan all --targets at once, sharing --concurrency workers among them'
+complete -c hydro -l plugin -r -d 'Plugin executable that verifies each hit and receives run lifecycle events (repeatable, path[=weight])'
+complete -c hydro -l plugin-order -r -d 'Deliver verified hits in arrival order or as soon as they are verified (ordered, unordered)'
+complete -c hydro -l plugin-policy -r -d 'How the verdicts of several --plugin are combined (all, any, weighted)'
+complete -c hydro -l plugin-threshold -r -d 'Confidence a hit needs to be kept with --plugin-policy=weighted (0-1)'
+complete -c hydro -l plugin-transport -r -d 'How to talk to --plugin (exec, jsonrpc, grpc)'
+complete -c hydro -l plugin-workers -r -d 'Number of hits verified by --plugin or captured by --screenshot-cmd at the same time'
 complete -c hydro -l pre-hook -r -d 'Shell command to run once before requests to fetch auth headers (stdout JSON)'
-complete -c hydro -l profile -r -d 'Named execution profile to load'
-complete -c hydro -l resume -r -d 'Path to a SQLite database for resuming and recording runs'
+complete -c hydro -l print-config -d 'Print every setting after applying the environment and profile, with its source, and exit'
+complete -c hydro -l profile -x -a 'aggressive api beginner stealth waf-safe' -d 'Named execution profile to load'
+complete -c hydro -l progress-file -r -d 'Path to store progress checkpoints for resuming runs'
+complete -c hydro -l progress-mode -r -d 'How --progress-file resumes (index, hash); hash detects an edited wordlist'
+complete -c hydro -l proxy -r -d 'Send requests through an HTTP, HTTPS, or SOCKS5 proxy URL'
+complete -c hydro -l recursion-depth -r -d 'How many directories below the target --recursive scans'
+complete -c hydro -l recursion-payloads -r -d 'Maximum requests sent inside each directory found by --recursive (0 = no limit)'
+complete -c hydro -l recursive -d 'Enable recursive discovery that can rapidly expand scope'
+complete -c hydro -l respect-robots -d 'Fetch the robots.txt of every target and skip the paths it disallows'
+complete -c hydro -l resume -r -d 'Path to a SQLite database for resuming and recording runs ("default" uses the data directory)'
+complete -c hydro -l resume-backend -r -d 'Storage backend for --resume (sqlite, bolt)'
+complete -c hydro -l resume-from -r -d 'Skip URLs already present in a previous JSONL output file'
 complete -c hydro -l run-id -r -d 'Override the deterministic run identifier used for persistence'
+complete -c hydro -l save-profile -r -d 'Save the effective configuration of this run as a named user profile'
+complete -c hydro -l scope-exclude -r -d 'Never request URLs matching this regular expression, including redirects and follow-up requests'
+complete -c hydro -l scope-include -r -d 'Only request URLs matching this regular expression, including redirects and follow-up requests'
+complete -c hydro -l screenshot-cmd -r -d 'Shell command run for every hit to capture a screenshot; {url} and {output} are replaced with the hit URL and an image path'
+complete -c hydro -l screenshot-dir -r -d 'Directory for the image paths given to --screenshot-cmd'
 complete -c hydro -l show-similarity -d 'Include similarity scores in output (debug)'
 complete -c hydro -l similarity-threshold -r -d 'Hide hits whose bodies are this similar to the baseline (0-1)'
+complete -c hydro -l smart-method -d 'Send HEAD requests and repeat with GET only those answering 2xx, 3xx, 401, or 403'
+complete -c hydro -l sni -r -d 'TLS server name to send instead of the URL\'s host'
+complete -c hydro -l sort -x -a 'found severity' -d 'Order of table rows (found, severity); severity prints the most severe hits first once the scan ends'
+complete -c hydro -l stream-addr -r -d 'Serve hits live as server-sent events at http://<addr>/events (e.g. 127.0.0.1:8787)'
+complete -c hydro -l stream-token -r -d 'Token --stream-addr clients must send as a Bearer token or ?token=; also lets browser pages on other origins subscribe'
+complete -c hydro -l targets -r -d 'File of target URLs with optional per-target overrides, scanned instead of -u'
+complete -c hydro -l throttle -r -d 'Minimum delay between dispatched requests (e.g. 100ms)'
 complete -c hydro -l timeout -r -d 'Request timeout duration'
+complete -c hydro -l tls-fingerprint -x -a 'chrome firefox random' -d 'Send the TLS ClientHello of a browser (chrome, firefox, random) instead of Go\'s own'
+complete -c hydro -l transform-plugin -r -d 'Plugin that rewrites each payload into zero or more payloads before requests are built'
 complete -c hydro -s u -r -d 'Target URL or template (required)'
-complete -c hydro -s w -r -d 'Path to the wordlist file (required)'
+complete -c hydro -l view -x -a 'table tree' -d 'Pretty output layout (table, tree)'
+complete -c hydro -s w -r -F -d 'Path to the wordlist file (required)'
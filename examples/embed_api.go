@@ -27,12 +27,15 @@ func main() {
 	}))
 	defer server.Close()
 
-	cfg := hydroapi.Config{
-		URL:         server.URL + "/FUZZ",
-		Wordlist:    filepath.Join("wordlists", "sample_small.txt"),
-		Method:      http.MethodGet,
-		Concurrency: 5,
-		Timeout:     5 * time.Second,
+	cfg, err := hydroapi.NewConfig(
+		hydroapi.WithTarget(server.URL+"/FUZZ"),
+		hydroapi.WithWordlist(filepath.Join("wordlists", "sample_small.txt")),
+		hydroapi.WithMethod(http.MethodGet),
+		hydroapi.WithConcurrency(5),
+		hydroapi.WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		log.Fatalf("build config: %v", err)
 	}
 
 	api := hydroapi.New()
@@ -0,0 +1,213 @@
+// Package bucket derives cloud storage bucket names from a wordlist and the
+// name of a target, builds the URLs that probe them on Amazon S3, Google
+// Cloud Storage, and Azure Blob Storage, and reads the answers of each
+// provider.
+package bucket
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Providers reported in Finding.Provider.
+const (
+	ProviderS3    = "s3"
+	ProviderGCS   = "gcs"
+	ProviderAzure = "azure"
+)
+
+// States reported in Finding.State.
+const (
+	// StatePublic is a bucket whose contents anyone can list.
+	StatePublic = "public"
+	// StateExists is a bucket that exists but refuses anonymous listing.
+	StateExists = "exists"
+	// StateMissing is a bucket name nobody has claimed.
+	StateMissing = "missing"
+	// StateUnknown is an answer that does not tell whether the bucket exists.
+	StateUnknown = "unknown"
+)
+
+// Finding is what a provider's answer to a probe reveals about a bucket.
+type Finding struct {
+	Provider string
+	State    string
+	// Code is the error code the provider answered with, such as
+	// AccessDenied or NoSuchBucket, if any.
+	Code string
+}
+
+// Found reports whether f is a bucket that exists.
+func (f Finding) Found() bool {
+	return f.State == StatePublic || f.State == StateExists
+}
+
+// separators join the base name of the target and a word into a bucket name.
+var separators = []string{"", "-", "."}
+
+// BaseName returns the name buckets of target are likely named after: target
+// itself when it is a plain name, or the first label of its host, less any
+// "www", when it is a URL.
+func BaseName(target string) string {
+	target = strings.TrimSpace(target)
+	host := target
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return ""
+		}
+		host = u.Hostname()
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if label, _, ok := strings.Cut(host, "."); ok && strings.Contains(target, "://") {
+		host = label
+	}
+	return sanitize(host)
+}
+
+// Names returns the candidate bucket names for word: the word alone and
+// joined to base in either order. Names are lowercased and stripped of
+// characters no provider allows; duplicates are left out.
+func Names(word, base string) []string {
+	word = sanitize(word)
+	if word == "" {
+		return nil
+	}
+
+	names := []string{word}
+	if base != "" && base != word {
+		for _, sep := range separators {
+			names = append(names, base+sep+word, word+sep+base)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	unique := names[:0]
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+var (
+	s3Name        = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	gcsName       = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,61}[a-z0-9]$`)
+	azureAccount  = regexp.MustCompile(`^[a-z0-9]{3,24}$`)
+	azureBucket   = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9]|-[a-z0-9]){2,62}$`)
+	invalidChars  = regexp.MustCompile(`[^a-z0-9._-]+`)
+	nonAlnum      = regexp.MustCompile(`[^a-z0-9]+`)
+	codeElement   = regexp.MustCompile(`<Code>([^<]+)</Code>`)
+	listingResult = regexp.MustCompile(`<(?:ListBucketResult|EnumerationResults)\b`)
+)
+
+// URLs returns the URLs probing name on every provider whose naming rules
+// allow it. Azure containers live in a storage account, so they are probed
+// in account, which is left out when empty or not a valid account name.
+func URLs(name, account string) []string {
+	var urls []string
+	if s3Name.MatchString(name) && !strings.Contains(name, "..") && net.ParseIP(name) == nil {
+		if strings.Contains(name, ".") {
+			// Dotted names do not match the wildcard certificate of the
+			// virtual-hosted endpoint.
+			urls = append(urls, "https://s3.amazonaws.com/"+name+"/")
+		} else {
+			urls = append(urls, "https://"+name+".s3.amazonaws.com/")
+		}
+	}
+	if gcsName.MatchString(name) && !strings.Contains(name, "..") {
+		urls = append(urls, "https://storage.googleapis.com/"+name+"/")
+	}
+	if azureAccount.MatchString(account) && azureBucket.MatchString(name) {
+		urls = append(urls, "https://"+account+".blob.core.windows.net/"+name+"?restype=container&comp=list")
+	}
+	return urls
+}
+
+// Account returns the Azure storage account name derived from base, which
+// only allows lowercase letters and digits.
+func Account(base string) string {
+	return nonAlnum.ReplaceAllString(base, "")
+}
+
+// AzureHost returns the host of the Azure storage account named account.
+func AzureHost(account string) string {
+	return account + ".blob.core.windows.net"
+}
+
+// Interpret reads the answer to a probe of rawURL, with the given status
+// code and body. It returns false when rawURL is not a probe of any
+// provider.
+func Interpret(rawURL string, status int, body []byte) (Finding, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Finding{}, false
+	}
+
+	var f Finding
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "s3.amazonaws.com" || strings.HasSuffix(host, ".s3.amazonaws.com"):
+		f.Provider = ProviderS3
+	case host == "storage.googleapis.com":
+		f.Provider = ProviderGCS
+	case strings.HasSuffix(host, ".blob.core.windows.net"):
+		f.Provider = ProviderAzure
+	default:
+		return Finding{}, false
+	}
+
+	if m := codeElement.FindSubmatch(body); m != nil {
+		f.Code = string(m[1])
+	}
+	f.State = state(f.Provider, status, f.Code, body)
+	return f, true
+}
+
+// state decides whether a bucket exists from the status code and error code
+// its provider answered with. Azure answers anonymous requests for private
+// containers as if they were missing, so only its public containers show.
+func state(provider string, status int, code string, body []byte) string {
+	switch code {
+	case "NoSuchBucket", "ContainerNotFound", "InvalidBucketName", "ResourceNotFound":
+		return StateMissing
+	case "AccessDenied", "AllAccessDisabled", "AuthorizationFailure", "PublicAccessNotPermitted",
+		"NoAuthenticationInformation", "UserProjectAccountProblem", "PermanentRedirect":
+		return StateExists
+	}
+
+	switch status {
+	case http.StatusOK:
+		if listingResult.Match(body) {
+			return StatePublic
+		}
+		return StateUnknown
+	case http.StatusForbidden:
+		return StateExists
+	case http.StatusMovedPermanently, http.StatusTemporaryRedirect:
+		// S3 redirects to the region a bucket lives in.
+		if provider == ProviderS3 {
+			return StateExists
+		}
+	case http.StatusNotFound:
+		return StateMissing
+	}
+	return StateUnknown
+}
+
+// sanitize lowercases name and removes the characters no provider allows,
+// along with separators at either end.
+func sanitize(name string) string {
+	name = invalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "")
+	return strings.Trim(name, ".-_")
+}
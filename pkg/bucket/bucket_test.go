@@ -0,0 +1,137 @@
+package bucket
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBaseName(t *testing.T) {
+	tests := map[string]string{
+		"acme":                          "acme",
+		"Acme Corp":                     "acmecorp",
+		"https://www.acme-corp.com/app": "acme-corp",
+		"http://shop.acme.io:8080":      "shop",
+		"https://10.0.0.1/":             "",
+	}
+	for target, want := range tests {
+		if got := BaseName(target); got != want {
+			t.Errorf("BaseName(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	got := Names("Backup", "acme")
+	want := []string{"backup", "acmebackup", "backupacme", "acme-backup", "backup-acme", "acme.backup", "backup.acme"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names = %q, want %q", got, want)
+	}
+
+	if got := Names("acme", "acme"); !reflect.DeepEqual(got, []string{"acme"}) {
+		t.Fatalf("Names of the base name = %q", got)
+	}
+	if got := Names("!!", "acme"); got != nil {
+		t.Fatalf("Names of an invalid word = %q, want none", got)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	got := URLs("acme-backup", "acme")
+	want := []string{
+		"https://acme-backup.s3.amazonaws.com/",
+		"https://storage.googleapis.com/acme-backup/",
+		"https://acme.blob.core.windows.net/acme-backup?restype=container&comp=list",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("URLs = %q, want %q", got, want)
+	}
+
+	got = URLs("acme.backup", "acme-corp")
+	want = []string{
+		"https://s3.amazonaws.com/acme.backup/",
+		"https://storage.googleapis.com/acme.backup/",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("URLs of a dotted name = %q, want %q", got, want)
+	}
+
+	if got := URLs("ab", "acme"); got != nil {
+		t.Fatalf("URLs of a short name = %q, want none", got)
+	}
+}
+
+func TestInterpret(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		status int
+		body   string
+		want   Finding
+	}{
+		{
+			name:   "s3 missing",
+			url:    "https://acme.s3.amazonaws.com/",
+			status: http.StatusNotFound,
+			body:   "<Error><Code>NoSuchBucket</Code></Error>",
+			want:   Finding{Provider: ProviderS3, State: StateMissing, Code: "NoSuchBucket"},
+		},
+		{
+			name:   "s3 private",
+			url:    "https://acme.s3.amazonaws.com/",
+			status: http.StatusForbidden,
+			body:   "<Error><Code>AccessDenied</Code></Error>",
+			want:   Finding{Provider: ProviderS3, State: StateExists, Code: "AccessDenied"},
+		},
+		{
+			name:   "s3 other region",
+			url:    "https://s3.amazonaws.com/acme.backup/",
+			status: http.StatusMovedPermanently,
+			body:   "<Error><Code>PermanentRedirect</Code></Error>",
+			want:   Finding{Provider: ProviderS3, State: StateExists, Code: "PermanentRedirect"},
+		},
+		{
+			name:   "gcs public",
+			url:    "https://storage.googleapis.com/acme/",
+			status: http.StatusOK,
+			body:   `<ListBucketResult xmlns="http://doc.s3.amazonaws.com/2006-03-01"><Name>acme</Name></ListBucketResult>`,
+			want:   Finding{Provider: ProviderGCS, State: StatePublic},
+		},
+		{
+			name:   "azure missing container",
+			url:    "https://acme.blob.core.windows.net/logs?restype=container&comp=list",
+			status: http.StatusNotFound,
+			body:   "<Error><Code>ContainerNotFound</Code></Error>",
+			want:   Finding{Provider: ProviderAzure, State: StateMissing, Code: "ContainerNotFound"},
+		},
+		{
+			name:   "azure container hidden from anonymous requests",
+			url:    "https://acme.blob.core.windows.net/logs?restype=container&comp=list",
+			status: http.StatusNotFound,
+			body:   "<Error><Code>ResourceNotFound</Code></Error>",
+			want:   Finding{Provider: ProviderAzure, State: StateMissing, Code: "ResourceNotFound"},
+		},
+		{
+			name:   "unexpected answer",
+			url:    "https://storage.googleapis.com/acme/",
+			status: http.StatusBadGateway,
+			want:   Finding{Provider: ProviderGCS, State: StateUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Interpret(tt.url, tt.status, []byte(tt.body))
+			if !ok {
+				t.Fatalf("Interpret(%q) did not recognize the provider", tt.url)
+			}
+			if got != tt.want {
+				t.Fatalf("Interpret = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := Interpret("https://example.com/acme", http.StatusOK, nil); ok {
+		t.Fatal("Interpret recognized a URL of no provider")
+	}
+}
@@ -0,0 +1,154 @@
+// Package burpxml reads the XML files Burp Suite exports items to, such as
+// the proxy history or site map, to seed scans with the hosts and paths an
+// engagement already covers.
+package burpxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Item is one request of an export, with its response when one was
+// recorded.
+type Item struct {
+	URL      string
+	Method   string
+	Status   int
+	Request  []byte
+	Response []byte
+}
+
+// message is a request or response, base64-encoded when the attribute says
+// so.
+type message struct {
+	Base64 string `xml:"base64,attr"`
+	Value  string `xml:",chardata"`
+}
+
+func (m message) decode() ([]byte, error) {
+	if !strings.EqualFold(m.Base64, "true") {
+		return []byte(m.Value), nil
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(m.Value))
+}
+
+// Load reads the export at path.
+func Load(path string) ([]Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open burp export: %w", err)
+	}
+	defer file.Close()
+
+	items, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return items, nil
+}
+
+// Parse reads an export from r.
+func Parse(r io.Reader) ([]Item, error) {
+	var export struct {
+		XMLName xml.Name `xml:"items"`
+		Items   []struct {
+			URL      string  `xml:"url"`
+			Method   string  `xml:"method"`
+			Status   int     `xml:"status"`
+			Request  message `xml:"request"`
+			Response message `xml:"response"`
+		} `xml:"item"`
+	}
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("parse burp export: %w", err)
+	}
+
+	items := make([]Item, 0, len(export.Items))
+	for i, raw := range export.Items {
+		item := Item{
+			URL:    strings.TrimSpace(raw.URL),
+			Method: strings.ToUpper(strings.TrimSpace(raw.Method)),
+			Status: raw.Status,
+		}
+		var err error
+		if item.Request, err = raw.Request.decode(); err != nil {
+			return nil, fmt.Errorf("item %d: decode request: %w", i+1, err)
+		}
+		if item.Response, err = raw.Response.decode(); err != nil {
+			return nil, fmt.Errorf("item %d: decode response: %w", i+1, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ResponseBody returns the body of the recorded response, or nil when none
+// was recorded or it does not parse.
+func (it Item) ResponseBody() []byte {
+	if len(it.Response) == 0 {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(it.Response)), nil)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return body
+}
+
+// Seeds returns a template for every directory the items reach, with
+// placeholder as its last segment, in the order the items list them. An
+// item for /app/login.php seeds https://host/app/placeholder.
+func Seeds(items []Item, placeholder string) []string {
+	var seeds []string
+	seen := make(map[string]struct{})
+	for _, item := range items {
+		u, err := url.Parse(item.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		dir := path.Dir(u.Path)
+		if strings.HasSuffix(u.Path, "/") {
+			dir = path.Clean(u.Path)
+		}
+		seed := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: path.Join(dir, placeholder)}).String()
+		if _, ok := seen[seed]; ok {
+			continue
+		}
+		seen[seed] = struct{}{}
+		seeds = append(seeds, seed)
+	}
+	return seeds
+}
+
+// Baselines returns, for every host with a recorded 404 response, the body
+// of its first one, keyed by the host and port of the item URL.
+func Baselines(items []Item) map[string][]byte {
+	baselines := make(map[string][]byte)
+	for _, item := range items {
+		if item.Status != http.StatusNotFound {
+			continue
+		}
+		u, err := url.Parse(item.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if _, ok := baselines[u.Host]; ok {
+			continue
+		}
+		if body := item.ResponseBody(); len(body) > 0 {
+			baselines[u.Host] = body
+		}
+	}
+	return baselines
+}
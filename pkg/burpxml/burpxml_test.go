@@ -0,0 +1,58 @@
+package burpxml
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	response := "HTTP/1.1 404 Not Found\r\nContent-Type: text/html\r\nContent-Length: 9\r\n\r\nnot found"
+	export := `<?xml version="1.0"?>
+<items burpVersion="2024.1">
+  <item>
+    <url><![CDATA[https://app.example.com/shop/cart.php?id=1]]></url>
+    <method><![CDATA[GET]]></method>
+    <status>200</status>
+    <request base64="false"><![CDATA[GET /shop/cart.php?id=1 HTTP/1.1]]></request>
+  </item>
+  <item>
+    <url><![CDATA[https://app.example.com/missing]]></url>
+    <method>get</method>
+    <status>404</status>
+    <response base64="true">` + base64.StdEncoding.EncodeToString([]byte(response)) + `</response>
+  </item>
+  <item>
+    <url><![CDATA[https://app.example.com/shop/]]></url>
+    <status>200</status>
+  </item>
+</items>`
+
+	items, err := Parse(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(items) != 3 || items[1].Method != "GET" || string(items[0].Request) != "GET /shop/cart.php?id=1 HTTP/1.1" {
+		t.Fatalf("items = %+v", items)
+	}
+	if body := items[1].ResponseBody(); string(body) != "not found" {
+		t.Fatalf("ResponseBody = %q", body)
+	}
+
+	want := []string{"https://app.example.com/shop/FUZZ", "https://app.example.com/FUZZ"}
+	if seeds := Seeds(items, "FUZZ"); !reflect.DeepEqual(seeds, want) {
+		t.Fatalf("Seeds = %q, want %q", seeds, want)
+	}
+
+	baselines := Baselines(items)
+	if len(baselines) != 1 || string(baselines["app.example.com"]) != "not found" {
+		t.Fatalf("Baselines = %q", baselines)
+	}
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<html></html>")); err == nil {
+		t.Fatal("Parse accepted a document that is not a Burp export")
+	}
+}
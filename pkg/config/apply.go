@@ -0,0 +1,69 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyProfile copies the values of p onto the flags in fs that were not set
+// on the command line or through the environment, so that explicit settings
+// always take precedence over a profile. It must be called after
+// ApplyEnvironment and returns the names of the flags the profile set. The
+// flags it sets are marked as visited, so callers that need to know what was
+// given on the command line should take SetFlags before applying a profile.
+func ApplyProfile(fs *flag.FlagSet, p Profile) ([]string, error) {
+	explicit := make(map[string]struct{})
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	values := []struct {
+		flag  string
+		value string
+		set   bool
+	}{
+		{flag: "method", value: strings.ToUpper(strings.TrimSpace(p.Method)), set: strings.TrimSpace(p.Method) != ""},
+		{flag: "concurrency", value: strconv.Itoa(p.Concurrency), set: p.Concurrency > 0},
+		{flag: "throttle", value: p.Throttle.String(), set: p.Throttle > 0},
+		{flag: "jitter", value: p.Jitter.String(), set: p.Jitter > 0},
+		{flag: "recursive", value: "true", set: p.Recursive},
+		{flag: "timeout", value: p.Timeout.String(), set: p.Timeout > 0},
+		{flag: "follow-redirects", value: "true", set: p.FollowRedirects},
+		{flag: "match-status", value: strings.TrimSpace(p.MatchStatus), set: strings.TrimSpace(p.MatchStatus) != ""},
+		{flag: "filter-size", value: strings.TrimSpace(p.FilterSize), set: strings.TrimSpace(p.FilterSize) != ""},
+		{flag: "output-format", value: p.fileOutputFormat(), set: p.fileOutputFormat() != ""},
+	}
+
+	var applied []string
+	for _, v := range values {
+		if !v.set {
+			continue
+		}
+		if _, ok := explicit[v.flag]; ok {
+			continue
+		}
+		if fs.Lookup(v.flag) == nil {
+			continue
+		}
+		if err := fs.Set(v.flag, v.value); err != nil {
+			return applied, fmt.Errorf("apply profile %s: %w", v.flag, err)
+		}
+		applied = append(applied, v.flag)
+	}
+
+	return applied, nil
+}
+
+// fileOutputFormat returns the first non-terminal output listed by the
+// profile, which selects the format used for --output.
+func (p Profile) fileOutputFormat() string {
+	for _, out := range p.Outputs {
+		out = strings.ToLower(strings.TrimSpace(out))
+		if out != "" && out != "pretty" {
+			return out
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestApplyProfileRespectsExplicitFlags(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	method := fs.String("method", "HEAD", "")
+	concurrency := fs.Int("concurrency", 10, "")
+	throttle := fs.Duration("throttle", 0, "")
+	timeout := fs.Duration("timeout", 10*time.Second, "")
+	follow := fs.Bool("follow-redirects", false, "")
+	matchStatus := fs.String("match-status", "", "")
+
+	if err := fs.Parse([]string{"--concurrency", "7"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	profile, err := ResolveProfile("api")
+	if err != nil {
+		t.Fatalf("resolve api profile: %v", err)
+	}
+
+	if _, err := ApplyProfile(fs, profile); err != nil {
+		t.Fatalf("apply profile: %v", err)
+	}
+
+	if *concurrency != 7 {
+		t.Fatalf("explicit concurrency should win, got %d", *concurrency)
+	}
+	if *method != "GET" {
+		t.Fatalf("expected method from profile, got %q", *method)
+	}
+	if *throttle != 50*time.Millisecond {
+		t.Fatalf("expected throttle from profile, got %v", *throttle)
+	}
+	if *timeout != 10*time.Second {
+		t.Fatalf("expected timeout from profile, got %v", *timeout)
+	}
+	if *follow {
+		t.Fatal("follow-redirects should remain disabled")
+	}
+	if *matchStatus != profile.MatchStatus {
+		t.Fatalf("expected match status from profile, got %q", *matchStatus)
+	}
+}
+
+func TestApplyProfileDoesNotChangeCommandLineFlags(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	fs.String("method", "HEAD", "")
+	fs.Int("concurrency", 10, "")
+
+	if err := fs.Parse([]string{"--concurrency", "7"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	commandLine := SetFlags(fs)
+
+	applied, err := ApplyProfile(fs, Profile{Method: "GET", Concurrency: 20})
+	if err != nil {
+		t.Fatalf("apply profile: %v", err)
+	}
+
+	if len(applied) != 1 || applied[0] != "method" {
+		t.Fatalf("expected the profile to set only method, got %v", applied)
+	}
+	if commandLine["method"] {
+		t.Fatal("method set by the profile must not count as given on the command line")
+	}
+	if !commandLine["concurrency"] {
+		t.Fatal("expected --concurrency to be reported as set on the command line")
+	}
+}
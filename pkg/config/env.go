@@ -0,0 +1,97 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix is prepended to flag names to form environment variable names.
+const EnvPrefix = "HYDRO_"
+
+// envAliases gives short flags a readable environment variable name.
+var envAliases = map[string]string{
+	"u": "URL",
+	"w": "WORDLIST",
+}
+
+// commandLineOnly lists the flags never read from the environment: the
+// confirmations that guard a scan and the flags that run an action instead of
+// configuring one, which a variable left in a shell must not trigger.
+var commandLineOnly = map[string]struct{}{
+	"confirm-legal":   {},
+	"allow-dangerous": {},
+	"save-profile":    {},
+	"print-config":    {},
+	"resume":          {},
+}
+
+// EnvName returns the environment variable consulted for the named flag, for
+// example HYDRO_CONCURRENCY for --concurrency.
+func EnvName(flagName string) string {
+	name, ok := envAliases[flagName]
+	if !ok {
+		name = strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+	}
+	return EnvPrefix + name
+}
+
+// SetFlags returns the names of the flags set on fs so far. Called right after
+// fs.Parse it identifies the flags given on the command line; ApplyEnvironment
+// and ApplyProfile set flags through fs.Set, which marks them as visited too.
+func SetFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// ApplyEnvironment resolves configuration precedence for fs: flags given on the
+// command line win, followed by HYDRO_* environment variables, followed by the
+// flag defaults. It must be called after fs.Parse and returns the names of the
+// flags that were set from the environment. The flags in commandLineOnly are
+// left alone.
+func ApplyEnvironment(fs *flag.FlagSet) ([]string, error) {
+	return applyEnvironment(fs, os.LookupEnv)
+}
+
+func applyEnvironment(fs *flag.FlagSet, lookup func(string) (string, bool)) ([]string, error) {
+	explicit := make(map[string]struct{})
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+
+	var (
+		applied []string
+		errs    []string
+	)
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := explicit[f.Name]; ok {
+			return
+		}
+		if _, ok := commandLineOnly[f.Name]; ok {
+			return
+		}
+
+		envName := EnvName(f.Name)
+		value, ok := lookup(envName)
+		if !ok {
+			return
+		}
+
+		if err := fs.Set(f.Name, strings.TrimSpace(value)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", envName, err))
+			return
+		}
+		applied = append(applied, f.Name)
+	})
+
+	if len(errs) > 0 {
+		return applied, fmt.Errorf("invalid environment configuration: %s", strings.Join(errs, "; "))
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"flag"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvironmentPrecedence(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 10, "")
+	timeout := fs.Duration("timeout", 10*time.Second, "")
+	target := fs.String("u", "", "")
+	proxy := fs.String("proxy", "", "")
+
+	if err := fs.Parse([]string{"--concurrency", "3"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	env := map[string]string{
+		"HYDRO_CONCURRENCY": "50",
+		"HYDRO_TIMEOUT":     "2s",
+		"HYDRO_URL":         "https://example.com/FUZZ",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	applied, err := applyEnvironment(fs, lookup)
+	if err != nil {
+		t.Fatalf("apply environment: %v", err)
+	}
+
+	if *concurrency != 3 {
+		t.Fatalf("command line flag should win over environment, got %d", *concurrency)
+	}
+	if *timeout != 2*time.Second {
+		t.Fatalf("expected timeout from environment, got %v", *timeout)
+	}
+	if *target != "https://example.com/FUZZ" {
+		t.Fatalf("expected target from HYDRO_URL, got %q", *target)
+	}
+	if *proxy != "" {
+		t.Fatalf("expected proxy to keep its default, got %q", *proxy)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("expected 2 flags applied from environment, got %v", applied)
+	}
+}
+
+func TestApplyEnvironmentRejectsInvalidValues(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	fs.Int("concurrency", 10, "")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	lookup := func(name string) (string, bool) {
+		if name == "HYDRO_CONCURRENCY" {
+			return "lots", true
+		}
+		return "", false
+	}
+
+	if _, err := applyEnvironment(fs, lookup); err == nil {
+		t.Fatal("expected invalid environment value to be rejected")
+	}
+}
+
+func TestSetFlagsExcludesEnvironmentValues(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	fs.String("method", "", "")
+	fs.Int("concurrency", 10, "")
+
+	if err := fs.Parse([]string{"--concurrency", "3"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	commandLine := SetFlags(fs)
+
+	lookup := func(name string) (string, bool) {
+		if name == "HYDRO_METHOD" {
+			return "GET", true
+		}
+		return "", false
+	}
+	if _, err := applyEnvironment(fs, lookup); err != nil {
+		t.Fatalf("apply environment: %v", err)
+	}
+
+	if !commandLine["concurrency"] {
+		t.Fatal("expected --concurrency to be reported as set on the command line")
+	}
+	if commandLine["method"] {
+		t.Fatal("method set through HYDRO_METHOD must not count as given on the command line")
+	}
+	if !SetFlags(fs)["method"] {
+		t.Fatal("expected ApplyEnvironment to mark method as set")
+	}
+}
+
+func TestApplyEnvironmentIgnoresCommandLineOnlyFlags(t *testing.T) {
+	fs := flag.NewFlagSet("hydro", flag.ContinueOnError)
+	fs.Bool("confirm-legal", false, "")
+	fs.Bool("allow-dangerous", false, "")
+	fs.String("save-profile", "", "")
+	fs.Bool("print-config", false, "")
+	fs.String("resume", "", "")
+	fs.String("method", "", "")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	env := map[string]string{
+		"HYDRO_CONFIRM_LEGAL":   "true",
+		"HYDRO_ALLOW_DANGEROUS": "true",
+		"HYDRO_SAVE_PROFILE":    "leaked",
+		"HYDRO_PRINT_CONFIG":    "true",
+		"HYDRO_RESUME":          "default",
+		"HYDRO_METHOD":          "GET",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	applied, err := applyEnvironment(fs, lookup)
+	if err != nil {
+		t.Fatalf("apply environment: %v", err)
+	}
+	if !slices.Equal(applied, []string{"method"}) {
+		t.Fatalf("expected only method from the environment, got %v", applied)
+	}
+	for name := range commandLineOnly {
+		f := fs.Lookup(name)
+		if f.Value.String() != f.DefValue {
+			t.Fatalf("expected --%s to keep its default, got %q", name, f.Value.String())
+		}
+	}
+}
@@ -2,20 +2,54 @@ package config
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Profile describes a named set of runtime defaults for the engine.
 type Profile struct {
-	Method      string
-	Concurrency int
-	Throttle    time.Duration
-	Recursive   bool
-	Timeout     time.Duration
-	Outputs     []string
+	Method          string        `yaml:"method,omitempty"`
+	Concurrency     int           `yaml:"concurrency,omitempty"`
+	Throttle        time.Duration `yaml:"throttle,omitempty"`
+	Jitter          time.Duration `yaml:"jitter,omitempty"`
+	Recursive       bool          `yaml:"recursive,omitempty"`
+	Timeout         time.Duration `yaml:"timeout,omitempty"`
+	Outputs         []string      `yaml:"outputs,omitempty"`
+	FollowRedirects bool          `yaml:"follow_redirects,omitempty"`
+	MatchStatus     string        `yaml:"match_status,omitempty"`
+	FilterSize      string        `yaml:"filter_size,omitempty"`
+	// Extends names parent profiles whose values are applied before this
+	// profile's own. It accepts a single name or a list.
+	Extends ProfileList `yaml:"extends,omitempty"`
+}
+
+// ProfileList is a list of profile names that decodes from either a YAML
+// scalar or a sequence.
+type ProfileList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *ProfileList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = splitProfileNames(value.Value)
+		return nil
+	}
+
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*l = names
+	return nil
 }
 
+// profilesMu guards profiles and profileAliases, which grow when user profiles
+// are registered.
+var profilesMu sync.RWMutex
+
 var profiles = map[string]Profile{
 	"beginner": {
 		Method:      "HEAD",
@@ -25,27 +59,217 @@ var profiles = map[string]Profile{
 		Timeout:     10 * time.Second,
 		Outputs:     []string{"pretty", "jsonl"},
 	},
+	// stealth keeps a low request rate that blends in with normal browsing
+	// traffic; jitter keeps it from arriving at a fixed interval.
+	"stealth": {
+		Method:      "GET",
+		Concurrency: 2,
+		Throttle:    time.Second,
+		Jitter:      time.Second,
+		Recursive:   false,
+		Timeout:     15 * time.Second,
+		Outputs:     []string{"pretty", "jsonl"},
+		MatchStatus: "200,204,301,302,307,401,403",
+	},
+	// aggressive trades politeness for throughput on targets that can take it,
+	// so it adds no jitter.
+	"aggressive": {
+		Method:      "HEAD",
+		Concurrency: 100,
+		Recursive:   false,
+		Timeout:     5 * time.Second,
+		Outputs:     []string{"pretty", "jsonl"},
+		MatchStatus: "200,204,301,302,307,401,403,405",
+	},
+	// api targets JSON/REST endpoints where auth and method errors are
+	// interesting findings in their own right.
+	"api": {
+		Method:      "GET",
+		Concurrency: 20,
+		Throttle:    50 * time.Millisecond,
+		Jitter:      25 * time.Millisecond,
+		Recursive:   false,
+		Timeout:     10 * time.Second,
+		Outputs:     []string{"pretty", "jsonl"},
+		MatchStatus: "200,201,204,301,302,307,401,403,405,500",
+	},
+	// waf-safe avoids tripping rate-based web application firewall rules.
+	// 403 is left out of the matched statuses because firewalls answer
+	// blocked requests with it.
+	"waf-safe": {
+		Method:      "GET",
+		Concurrency: 3,
+		Throttle:    500 * time.Millisecond,
+		Jitter:      500 * time.Millisecond,
+		Recursive:   false,
+		Timeout:     20 * time.Second,
+		Outputs:     []string{"pretty", "jsonl"},
+		MatchStatus: "200,204,301,302,307,401,405",
+	},
 }
 
 // profileAliases maps aliases to their canonical profile names.
 var profileAliases = map[string]string{
 	"beginner": "beginner",
+	"quiet":    "stealth",
+	"fast":     "aggressive",
+	"rest":     "api",
+	"waf":      "waf-safe",
+	"wafsafe":  "waf-safe",
 }
 
-// LookupProfile returns the configuration for a named profile.
-// The lookup is case-insensitive and follows profile aliases.
+// LookupProfile returns the configuration for a named profile with any
+// inherited values applied. The lookup is case-insensitive and follows profile
+// aliases that no registered profile shadows.
 func LookupProfile(name string) (Profile, bool) {
 	if name == "" {
 		return Profile{}, false
 	}
 
-	canonical, ok := profileAliases[strings.ToLower(name)]
-	if !ok {
-		canonical = strings.ToLower(name)
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	profile, err := resolveLocked(name, nil)
+	return profile, err == nil
+}
+
+// ResolveProfile resolves a profile specification. The specification may
+// compose several comma-separated profiles ("stealth,api"); later profiles
+// override earlier ones. Each profile's extends chain is applied first.
+func ResolveProfile(spec string) (Profile, error) {
+	names := splitProfileNames(spec)
+	if len(names) == 0 {
+		return Profile{}, fmt.Errorf("profile name must not be empty")
+	}
+
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	var resolved Profile
+	for _, name := range names {
+		profile, err := resolveLocked(name, nil)
+		if err != nil {
+			return Profile{}, err
+		}
+		resolved = mergeProfiles(resolved, profile)
+	}
+
+	return resolved, nil
+}
+
+func resolveLocked(name string, chain []string) (Profile, error) {
+	canonical := strings.ToLower(strings.TrimSpace(name))
+	// A profile registered under an alias, such as a user profile named fast,
+	// wins over the built-in profile the alias stands for.
+	if _, ok := profiles[canonical]; !ok {
+		if alias, ok := profileAliases[canonical]; ok {
+			canonical = alias
+		}
+	}
+
+	for _, seen := range chain {
+		if seen == canonical {
+			return Profile{}, fmt.Errorf("profile inheritance cycle: %s -> %s", strings.Join(chain, " -> "), canonical)
+		}
 	}
 
 	profile, ok := profiles[canonical]
-	return profile, ok
+	if !ok {
+		if len(chain) > 0 {
+			return Profile{}, fmt.Errorf("profile %q extends unknown profile %q", chain[len(chain)-1], name)
+		}
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+
+	chain = append(chain, canonical)
+
+	var resolved Profile
+	for _, parent := range profile.Extends {
+		parentProfile, err := resolveLocked(parent, chain)
+		if err != nil {
+			return Profile{}, err
+		}
+		resolved = mergeProfiles(resolved, parentProfile)
+	}
+
+	return mergeProfiles(resolved, profile), nil
+}
+
+// mergeProfiles overlays the non-zero fields of overlay onto base. Boolean
+// fields can only be switched on by an overlay.
+func mergeProfiles(base, overlay Profile) Profile {
+	merged := base
+	merged.Extends = nil
+
+	if overlay.Method != "" {
+		merged.Method = overlay.Method
+	}
+	if overlay.Concurrency > 0 {
+		merged.Concurrency = overlay.Concurrency
+	}
+	if overlay.Throttle > 0 {
+		merged.Throttle = overlay.Throttle
+	}
+	if overlay.Jitter > 0 {
+		merged.Jitter = overlay.Jitter
+	}
+	if overlay.Recursive {
+		merged.Recursive = true
+	}
+	if overlay.Timeout > 0 {
+		merged.Timeout = overlay.Timeout
+	}
+	if len(overlay.Outputs) > 0 {
+		merged.Outputs = append([]string(nil), overlay.Outputs...)
+	}
+	if overlay.FollowRedirects {
+		merged.FollowRedirects = true
+	}
+	if overlay.MatchStatus != "" {
+		merged.MatchStatus = overlay.MatchStatus
+	}
+	if overlay.FilterSize != "" {
+		merged.FilterSize = overlay.FilterSize
+	}
+
+	return merged
+}
+
+func splitProfileNames(spec string) []string {
+	var names []string
+	for _, part := range strings.Split(spec, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			names = append(names, trimmed)
+		}
+	}
+	return names
+}
+
+// RegisterProfile adds or replaces a named profile. Names are case-insensitive.
+func RegisterProfile(name string, profile Profile) error {
+	canonical := strings.ToLower(strings.TrimSpace(name))
+	if canonical == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+
+	profiles[canonical] = profile
+	return nil
+}
+
+// ProfileNames returns the names of all known profiles in sorted order.
+func ProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // RunHashConfig returns stable key/value entries that describe the profile for
@@ -62,6 +286,9 @@ func (p Profile) RunHashConfig() []string {
 	if p.Throttle > 0 {
 		entries = append(entries, fmt.Sprintf("profile.throttle=%s", p.Throttle))
 	}
+	if p.Jitter > 0 {
+		entries = append(entries, fmt.Sprintf("profile.jitter=%s", p.Jitter))
+	}
 	entries = append(entries, fmt.Sprintf("profile.recursive=%t", p.Recursive))
 	if p.Timeout > 0 {
 		entries = append(entries, fmt.Sprintf("profile.timeout=%s", p.Timeout))
@@ -79,6 +306,15 @@ func (p Profile) RunHashConfig() []string {
 			entries = append(entries, fmt.Sprintf("profile.outputs=%s", strings.Join(outputs, ",")))
 		}
 	}
+	if p.FollowRedirects {
+		entries = append(entries, "profile.follow_redirects=true")
+	}
+	if status := strings.TrimSpace(p.MatchStatus); status != "" {
+		entries = append(entries, fmt.Sprintf("profile.match_status=%s", status))
+	}
+	if size := strings.TrimSpace(p.FilterSize); size != "" {
+		entries = append(entries, fmt.Sprintf("profile.filter_size=%s", size))
+	}
 
 	return entries
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -56,3 +57,55 @@ func TestLookupProfileUnknown(t *testing.T) {
 		t.Fatal("expected lookup to fail for unknown profile")
 	}
 }
+
+func TestBuiltinProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		concurrency int
+		throttle    time.Duration
+		jitter      time.Duration
+		matchStatus string
+	}{
+		{name: "stealth", method: "GET", concurrency: 2, throttle: time.Second, jitter: time.Second, matchStatus: "200,204,301,302,307,401,403"},
+		{name: "aggressive", method: "HEAD", concurrency: 100, matchStatus: "200,204,301,302,307,401,403,405"},
+		{name: "api", method: "GET", concurrency: 20, throttle: 50 * time.Millisecond, jitter: 25 * time.Millisecond, matchStatus: "200,201,204,301,302,307,401,403,405,500"},
+		{name: "waf-safe", method: "GET", concurrency: 3, throttle: 500 * time.Millisecond, jitter: 500 * time.Millisecond, matchStatus: "200,204,301,302,307,401,405"},
+	}
+
+	for _, tt := range tests {
+		profile, ok := LookupProfile(tt.name)
+		if !ok {
+			t.Fatalf("expected %s profile to be available", tt.name)
+		}
+		if profile.Method != tt.method || profile.Concurrency != tt.concurrency || profile.Throttle != tt.throttle {
+			t.Fatalf("unexpected %s profile: %+v", tt.name, profile)
+		}
+		if profile.Jitter != tt.jitter || profile.MatchStatus != tt.matchStatus {
+			t.Fatalf("unexpected %s jitter or matcher defaults: %+v", tt.name, profile)
+		}
+		wantJitter := tt.jitter > 0
+		hasJitter := false
+		for _, entry := range profile.RunHashConfig() {
+			hasJitter = hasJitter || strings.HasPrefix(entry, "profile.jitter=")
+		}
+		if hasJitter != wantJitter {
+			t.Fatalf("expected %s run hash config to include jitter=%t, got %v", tt.name, wantJitter, profile.RunHashConfig())
+		}
+		if profile.Recursive {
+			t.Fatalf("built-in profile %s must not enable recursion", tt.name)
+		}
+	}
+}
+
+func TestLookupProfileAliases(t *testing.T) {
+	for alias, want := range map[string]int{"waf": 3, "quiet": 2, "REST": 20} {
+		profile, ok := LookupProfile(alias)
+		if !ok {
+			t.Fatalf("expected alias %q to resolve", alias)
+		}
+		if profile.Concurrency != want {
+			t.Fatalf("alias %q resolved to unexpected profile %+v", alias, profile)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Target is a single entry of a targets file. Empty fields fall back to the
+// settings of the invocation.
+type Target struct {
+	URL      string
+	Method   string
+	Wordlist string
+	Profile  string
+	Headers  http.Header
+	Throttle time.Duration
+}
+
+// LoadTargets reads a targets file from path. See ParseTargets for the format.
+func LoadTargets(path string) ([]Target, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open targets file: %w", err)
+	}
+	defer file.Close()
+
+	targets, err := ParseTargets(file)
+	if err != nil {
+		return nil, fmt.Errorf("targets file %s: %w", path, err)
+	}
+	return targets, nil
+}
+
+// ParseTargets parses a targets file. Each non-empty line starts with a target
+// URL followed by optional key=value overrides:
+//
+//	https://a.example/FUZZ method=GET wordlist=api.txt
+//	https://b.example/FUZZ profile=stealth header="Authorization: Bearer x"
+//	https://c.example/FUZZ throttle=250ms
+//
+// Supported keys are method, wordlist, profile, throttle, and header, which
+// may repeat.
+// Values containing spaces are wrapped in double quotes. Lines starting with
+// # are comments.
+func ParseTargets(r io.Reader) ([]Target, error) {
+	var targets []Target
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitTargetLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		target := Target{URL: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: override %q must be key=value", lineNo, field)
+			}
+
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "method":
+				target.Method = strings.ToUpper(strings.TrimSpace(value))
+			case "wordlist":
+				target.Wordlist = strings.TrimSpace(value)
+			case "profile":
+				target.Profile = strings.TrimSpace(value)
+			case "throttle":
+				throttle, err := time.ParseDuration(strings.TrimSpace(value))
+				if err != nil || throttle < 0 {
+					return nil, fmt.Errorf("line %d: invalid throttle %q", lineNo, value)
+				}
+				target.Throttle = throttle
+			case "header":
+				name, headerValue, ok := strings.Cut(value, ":")
+				if !ok || strings.TrimSpace(name) == "" {
+					return nil, fmt.Errorf("line %d: header %q must be \"Name: value\"", lineNo, value)
+				}
+				if target.Headers == nil {
+					target.Headers = make(http.Header)
+				}
+				target.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+			default:
+				return nil, fmt.Errorf("line %d: unknown override %q", lineNo, key)
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// splitTargetLine splits line on whitespace, keeping double-quoted sections
+// together and removing the quotes.
+func splitTargetLine(line string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case !quoted && (r == ' ' || r == '\t'):
+			if started {
+				fields = append(fields, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if started {
+		fields = append(fields, current.String())
+	}
+
+	return fields, nil
+}
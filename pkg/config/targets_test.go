@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTargetsOverrides(t *testing.T) {
+	input := `
+# staging hosts
+https://a.example/FUZZ
+https://b.example/FUZZ method=get wordlist=api.txt profile=stealth throttle=250ms header="Authorization: Bearer abc" header="X-Env: staging"
+`
+
+	targets, err := ParseTargets(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse targets: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+
+	if targets[0].URL != "https://a.example/FUZZ" || targets[0].Method != "" || targets[0].Headers != nil {
+		t.Fatalf("unexpected plain target: %+v", targets[0])
+	}
+
+	b := targets[1]
+	if b.Method != "GET" || b.Wordlist != "api.txt" || b.Profile != "stealth" || b.Throttle != 250*time.Millisecond {
+		t.Fatalf("unexpected overrides: %+v", b)
+	}
+	if got := b.Headers.Get("Authorization"); got != "Bearer abc" {
+		t.Fatalf("unexpected authorization header %q", got)
+	}
+	if got := b.Headers.Get("X-Env"); got != "staging" {
+		t.Fatalf("unexpected X-Env header %q", got)
+	}
+}
+
+func TestParseTargetsRejectsInvalidLines(t *testing.T) {
+	cases := []string{
+		"https://a.example/FUZZ colour=blue",
+		"https://a.example/FUZZ method",
+		"https://a.example/FUZZ header=NoColon",
+		"https://a.example/FUZZ throttle=soon",
+		`https://a.example/FUZZ header="X-Open: 1`,
+	}
+
+	for _, input := range cases {
+		if _, err := ParseTargets(strings.NewReader(input)); err == nil {
+			t.Fatalf("expected %q to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"hydr0g3n/pkg/paths"
+)
+
+// DefaultProfileDir returns the directory that holds user-defined profiles,
+// typically ~/.config/hydro/profiles. See paths.ConfigDir.
+func DefaultProfileDir() (string, error) {
+	dir, err := paths.ProfileDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config directory: %w", err)
+	}
+	return dir, nil
+}
+
+// LoadUserProfiles reads every *.yaml and *.yml file in dir and registers it
+// as a profile named after the file. User profiles replace built-in profiles
+// with the same name. A missing directory is not an error. The names of the
+// registered profiles are returned.
+func LoadUserProfiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read profile directory: %w", err)
+	}
+
+	var loaded []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		profile, err := LoadProfileFile(path)
+		if err != nil {
+			return loaded, err
+		}
+
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if err := RegisterProfile(name, profile); err != nil {
+			return loaded, fmt.Errorf("register profile %s: %w", path, err)
+		}
+		loaded = append(loaded, strings.ToLower(name))
+	}
+
+	return loaded, nil
+}
+
+// LoadProfileFile decodes a single YAML profile. Unknown keys are rejected so
+// that typos are reported instead of silently ignored.
+func LoadProfileFile(path string) (Profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("open profile: %w", err)
+	}
+	defer file.Close()
+
+	var profile Profile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&profile); err != nil && !errors.Is(err, io.EOF) {
+		return Profile{}, fmt.Errorf("decode profile %s: %w", path, err)
+	}
+
+	return profile, nil
+}
+
+// SaveProfile writes profile to dir as name.yaml, replacing any existing file,
+// and returns the path that was written.
+func SaveProfile(dir, name string, profile Profile) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("profile name must not be empty")
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create profile directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return "", fmt.Errorf("encode profile: %w", err)
+	}
+
+	path := filepath.Join(dir, strings.ToLower(name)+".yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write profile: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadUserProfilesRegistersYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	stealth := []byte("method: GET\nconcurrency: 2\nthrottle: 750ms\ntimeout: 20s\noutputs: [jsonl]\n")
+	if err := os.WriteFile(filepath.Join(dir, "Stealth-Test.yaml"), stealth, 0o600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+
+	loaded, err := LoadUserProfiles(dir)
+	if err != nil {
+		t.Fatalf("load profiles: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != "stealth-test" {
+		t.Fatalf("unexpected loaded profiles: %v", loaded)
+	}
+
+	profile, ok := LookupProfile("stealth-test")
+	if !ok {
+		t.Fatal("expected user profile to be registered")
+	}
+	if profile.Method != "GET" || profile.Concurrency != 2 {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if profile.Throttle != 750*time.Millisecond || profile.Timeout != 20*time.Second {
+		t.Fatalf("unexpected durations: throttle=%v timeout=%v", profile.Throttle, profile.Timeout)
+	}
+}
+
+func TestLoadUserProfilesMissingDirectory(t *testing.T) {
+	loaded, err := LoadUserProfiles(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("expected missing directory to be ignored, got %v", err)
+	}
+	if len(loaded) != 0 {
+		t.Fatalf("expected no profiles, got %v", loaded)
+	}
+}
+
+func TestLoadProfileFileRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.yaml")
+	if err := os.WriteFile(path, []byte("concurency: 5\n"), 0o600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	if _, err := LoadProfileFile(path); err == nil {
+		t.Fatal("expected unknown key to be rejected")
+	}
+}
+
+func TestSaveProfileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	want := Profile{
+		Method:          "GET",
+		Concurrency:     25,
+		Throttle:        50 * time.Millisecond,
+		Timeout:         5 * time.Second,
+		Outputs:         []string{"pretty", "jsonl"},
+		FollowRedirects: true,
+		MatchStatus:     "200,403",
+		FilterSize:      "100-",
+	}
+
+	path, err := SaveProfile(dir, "Tuned", want)
+	if err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+	if filepath.Base(path) != "tuned.yaml" {
+		t.Fatalf("unexpected profile path %q", path)
+	}
+
+	got, err := LoadProfileFile(path)
+	if err != nil {
+		t.Fatalf("load saved profile: %v", err)
+	}
+
+	if got.Method != want.Method || got.Concurrency != want.Concurrency || got.Throttle != want.Throttle ||
+		got.Timeout != want.Timeout || got.FollowRedirects != want.FollowRedirects ||
+		got.MatchStatus != want.MatchStatus || got.FilterSize != want.FilterSize || len(got.Outputs) != 2 {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveProfileRejectsPathNames(t *testing.T) {
+	if _, err := SaveProfile(t.TempDir(), "../escape", Profile{}); err == nil {
+		t.Fatal("expected profile names containing separators to be rejected")
+	}
+}
+
+func TestUserProfileExtendsParents(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"internal-base.yaml": "method: GET\ntimeout: 30s\nmatch_status: \"200,403\"\n",
+		"internal-api.yaml":  "extends: [stealth, internal-base]\nconcurrency: 4\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if _, err := LoadUserProfiles(dir); err != nil {
+		t.Fatalf("load profiles: %v", err)
+	}
+
+	profile, err := ResolveProfile("internal-api")
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+
+	if profile.Concurrency != 4 {
+		t.Fatalf("expected own concurrency to win, got %d", profile.Concurrency)
+	}
+	if profile.Throttle != time.Second {
+		t.Fatalf("expected throttle inherited from stealth, got %v", profile.Throttle)
+	}
+	if profile.Timeout != 30*time.Second || profile.MatchStatus != "200,403" {
+		t.Fatalf("expected later parent to override earlier parent, got %+v", profile)
+	}
+	if len(profile.Extends) != 0 {
+		t.Fatalf("resolved profile should not carry extends, got %v", profile.Extends)
+	}
+}
+
+func TestResolveProfileComposesAndDetectsCycles(t *testing.T) {
+	composed, err := ResolveProfile("stealth, api")
+	if err != nil {
+		t.Fatalf("resolve composed profile: %v", err)
+	}
+	if composed.Concurrency != 20 || composed.Throttle != 50*time.Millisecond || composed.Timeout != 10*time.Second {
+		t.Fatalf("expected api values to override stealth, got %+v", composed)
+	}
+
+	if err := RegisterProfile("loop-a", Profile{Extends: ProfileList{"loop-b"}}); err != nil {
+		t.Fatalf("register loop-a: %v", err)
+	}
+	if err := RegisterProfile("loop-b", Profile{Extends: ProfileList{"loop-a"}}); err != nil {
+		t.Fatalf("register loop-b: %v", err)
+	}
+
+	if _, err := ResolveProfile("loop-a"); err == nil {
+		t.Fatal("expected inheritance cycle to be reported")
+	}
+	if _, ok := LookupProfile("loop-a"); ok {
+		t.Fatal("expected lookup of cyclic profile to fail")
+	}
+}
+
+func TestUserProfileShadowsAlias(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := SaveProfile(dir, "fast", Profile{Method: "GET", Concurrency: 7}); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+	if _, err := LoadUserProfiles(dir); err != nil {
+		t.Fatalf("load profiles: %v", err)
+	}
+	t.Cleanup(func() {
+		profilesMu.Lock()
+		delete(profiles, "fast")
+		profilesMu.Unlock()
+	})
+
+	profile, err := ResolveProfile("Fast")
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+	if profile.Method != "GET" || profile.Concurrency != 7 {
+		t.Fatalf("expected the user profile fast, got %+v", profile)
+	}
+
+	// The other aliases still resolve to their built-in profiles.
+	if waf, ok := LookupProfile("waf"); !ok || waf.Concurrency != 3 {
+		t.Fatalf("expected waf to resolve to waf-safe, got %+v", waf)
+	}
+}
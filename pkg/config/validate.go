@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats lists the values accepted in a profile's outputs.
+var outputFormats = map[string]struct{}{
+	"pretty": {},
+	"jsonl":  {},
+}
+
+// Validate reports settings in p that are invalid or contradict each other.
+// The syntax of match_status and filter_size is checked by the matcher and is
+// not covered here.
+func (p Profile) Validate() []error {
+	var problems []error
+
+	switch method := strings.ToUpper(strings.TrimSpace(p.Method)); method {
+	case "", "GET", "HEAD", "POST":
+	default:
+		problems = append(problems, fmt.Errorf("unsupported method %q", p.Method))
+	}
+
+	if p.Concurrency < 0 {
+		problems = append(problems, fmt.Errorf("concurrency must not be negative, got %d", p.Concurrency))
+	}
+	if p.Throttle < 0 {
+		problems = append(problems, fmt.Errorf("throttle must not be negative, got %s", p.Throttle))
+	}
+	if p.Jitter < 0 {
+		problems = append(problems, fmt.Errorf("jitter must not be negative, got %s", p.Jitter))
+	}
+	if p.Timeout < 0 {
+		problems = append(problems, fmt.Errorf("timeout must not be negative, got %s", p.Timeout))
+	}
+
+	var fileFormats []string
+	for _, output := range p.Outputs {
+		format := strings.ToLower(strings.TrimSpace(output))
+		if _, ok := outputFormats[format]; !ok {
+			problems = append(problems, fmt.Errorf("unknown output format %q", output))
+			continue
+		}
+		if format != "pretty" {
+			fileFormats = append(fileFormats, format)
+		}
+	}
+	if len(fileFormats) > 1 {
+		problems = append(problems, fmt.Errorf("outputs list several file formats (%s) but only one file is written", strings.Join(fileFormats, ", ")))
+	}
+
+	return problems
+}
+
+// CheckProfileFile decodes the profile at path and reports every unknown key
+// and badly typed value instead of stopping at the first, unlike
+// LoadProfileFile. The returned profile holds the fields that could be decoded;
+// its settings are not checked, see Validate.
+func CheckProfileFile(path string) (Profile, []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, []error{fmt.Errorf("read profile: %w", err)}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Profile{}, []error{fmt.Errorf("decode profile: %w", err)}
+	}
+	if len(root.Content) == 0 {
+		return Profile{}, nil
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return Profile{}, []error{fmt.Errorf("line %d: profile must be a mapping of settings", doc.Line)}
+	}
+
+	var problems []error
+	known := profileKeys()
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key := doc.Content[i]
+		if _, ok := known[key.Value]; !ok {
+			problems = append(problems, fmt.Errorf("line %d: unknown key %q", key.Line, key.Value))
+		}
+	}
+
+	var profile Profile
+	if err := doc.Decode(&profile); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return profile, append(problems, fmt.Errorf("decode profile: %w", err))
+		}
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, errors.New(msg))
+		}
+	}
+
+	return profile, problems
+}
+
+// profileKeys returns the YAML keys accepted in a profile file.
+func profileKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	t := reflect.TypeOf(Profile{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = struct{}{}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckProfileFileReportsEveryProblem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typos.yaml")
+	data := []byte("concurency: 5\nmethod: GET\ntimeout: soon\nfollow_redirect: true\nthrottle: 250ms\n")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	profile, problems := CheckProfileFile(path)
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems, got %d: %v", len(problems), problems)
+	}
+	for i, want := range []string{`line 1: unknown key "concurency"`, `line 4: unknown key "follow_redirect"`, "line 3:"} {
+		if !strings.Contains(problems[i].Error(), want) {
+			t.Fatalf("problem %d = %q, want it to contain %q", i, problems[i], want)
+		}
+	}
+
+	if profile.Method != "GET" || profile.Throttle != 250*time.Millisecond {
+		t.Fatalf("expected valid fields to be decoded, got %+v", profile)
+	}
+}
+
+func TestCheckProfileFileAcceptsValidProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ok.yaml")
+	if err := os.WriteFile(path, []byte("extends: stealth\nconcurrency: 4\noutputs: [pretty, jsonl]\n"), 0o600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	profile, problems := CheckProfileFile(path)
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if profile.Concurrency != 4 || len(profile.Extends) != 1 {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	valid := Profile{Method: "head", Concurrency: 5, Outputs: []string{"pretty", "jsonl"}}
+	if problems := valid.Validate(); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	invalid := Profile{
+		Method:      "PUT",
+		Concurrency: -1,
+		Timeout:     -time.Second,
+		Outputs:     []string{"jsonl", "xml", "JSONL"},
+	}
+	problems := invalid.Validate()
+	if len(problems) != 5 {
+		t.Fatalf("expected 5 problems, got %d: %v", len(problems), problems)
+	}
+	if !strings.Contains(problems[4].Error(), "several file formats") {
+		t.Fatalf("expected conflicting outputs to be reported, got %v", problems[4])
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"hydr0g3n/bench"
 	"hydr0g3n/pkg/httpclient"
+	"hydr0g3n/pkg/store"
 	"hydr0g3n/pkg/templater"
 )
 
@@ -35,7 +37,7 @@ func BenchmarkStageRunner(b *testing.B) {
 		{name: "notfound", word: "ghost"},
 	}
 
-	concLevels := []int{1, 4, 16}
+	concLevels := []int{1, 4, 16, 64}
 
 	for _, bc := range cases {
 		bc := bc
@@ -48,17 +50,75 @@ func BenchmarkStageRunner(b *testing.B) {
 					client := httpclient.New(5*time.Second, false)
 					tpl := templater.New()
 
-					benchmarkStageRunner(b, target, wordlist, conc, client, tpl)
+					benchmarkStageRunner(b, target, wordlist, conc, client, tpl, nil)
 				})
 			}
 		})
 	}
 }
 
-func benchmarkStageRunner(b *testing.B, target, wordlist string, concurrency int, client *httpclient.Client, tpl *templater.Templater) {
+// BenchmarkStageRunnerOptions measures scans that record progress and
+// attempts, which keep the job queue like any other scan.
+func BenchmarkStageRunnerOptions(b *testing.B) {
+	srv := bench.NewServer()
+	b.Cleanup(func() {
+		srv.Close()
+	})
+
+	target := srv.URL() + "/FUZZ"
+	dir := b.TempDir()
+	wordlist := buildWordlist(b, dir, "options", "fast", requestsPerIteration)
+
+	cases := []struct {
+		name      string
+		configure func(b *testing.B, runner *stageRunner)
+	}{
+		{
+			name: "progress",
+			configure: func(b *testing.B, runner *stageRunner) {
+				tracker, err := newProgressTracker(filepath.Join(b.TempDir(), "progress.json"), false)
+				if err != nil {
+					b.Fatalf("new tracker: %v", err)
+				}
+				runner.progress = tracker
+			},
+		},
+		{
+			name: "store",
+			configure: func(b *testing.B, runner *stageRunner) {
+				db, err := store.OpenBolt(filepath.Join(b.TempDir(), "resume.db"))
+				if err != nil {
+					b.Fatalf("open store: %v", err)
+				}
+				b.Cleanup(func() { db.Close() })
+				recorder, err := db.StartRun(context.Background(), store.RunMetadata{TargetURL: target})
+				if err != nil {
+					b.Fatalf("start run: %v", err)
+				}
+				runner.runRecorder = recorder
+			},
+		},
+	}
+
+	for _, bc := range cases {
+		b.Run(bc.name, func(b *testing.B) {
+			for _, conc := range []int{16, 64} {
+				b.Run(fmt.Sprintf("c%d", conc), func(b *testing.B) {
+					client := httpclient.New(5*time.Second, false)
+					benchmarkStageRunner(b, target, wordlist, conc, client, templater.New(), bc.configure)
+				})
+			}
+		})
+	}
+}
+
+// benchmarkStageRunner scans wordlist b.N times. configure, when set, is
+// called on the runner of every scan outside the timed section.
+func benchmarkStageRunner(b *testing.B, target, wordlist string, concurrency int, client *httpclient.Client, tpl *templater.Templater, configure func(b *testing.B, runner *stageRunner)) {
 	b.Helper()
 
 	ctx := context.Background()
+	var latencies bench.Histogram
 
 	runOnce := func() {
 		resultsCh := make(chan Result, requestsPerIteration)
@@ -72,15 +132,21 @@ func benchmarkStageRunner(b *testing.B, target, wordlist string, concurrency int
 			tpl:         tpl,
 			results:     resultsCh,
 		}
+		if configure != nil {
+			b.StopTimer()
+			configure(b, &runner)
+			b.StartTimer()
+		}
 
 		drained := make(chan struct{})
 		go func() {
-			for range resultsCh {
+			for res := range resultsCh {
+				latencies.Add(res.Duration)
 			}
 			close(drained)
 		}()
 
-		if _, err := runner.run("bench", wordlist, "", ""); err != nil {
+		if _, err := runner.run(stage{name: "bench", wordlist: wordlist}); err != nil {
 			b.Fatalf("run: %v", err)
 		}
 
@@ -88,13 +154,13 @@ func benchmarkStageRunner(b *testing.B, target, wordlist string, concurrency int
 		<-drained
 	}
 
+	b.ReportAllocs()
 	b.ResetTimer()
-	start := time.Now()
 	for i := 0; i < b.N; i++ {
 		runOnce()
 	}
-	elapsed := time.Since(start)
 	b.StopTimer()
+	elapsed := b.Elapsed()
 
 	totalRequests := float64(b.N * requestsPerIteration)
 	if elapsed > 0 {
@@ -102,6 +168,45 @@ func benchmarkStageRunner(b *testing.B, target, wordlist string, concurrency int
 		b.ReportMetric(totalRequests/elapsed.Seconds(), "req/s")
 	}
 	b.ReportMetric(float64(requestsPerIteration), "requests/op")
+	b.ReportMetric(float64(latencies.Percentile(50).Microseconds()), "p50-µs")
+	b.ReportMetric(float64(latencies.Percentile(99).Microseconds()), "p99-µs")
+}
+
+// BenchmarkJobDispatch measures handing jobs from the wordlist reader to
+// workers that do no work, with and without the job queue shared by the
+// workers. queued=false is the unbuffered hand-off the queue replaced.
+func BenchmarkJobDispatch(b *testing.B) {
+	for _, conc := range []int{16, 64, 256} {
+		for _, queued := range []bool{false, true} {
+			b.Run(fmt.Sprintf("c%d/queued=%t", conc, queued), func(b *testing.B) {
+				runner := stageRunner{ctx: context.Background(), concurrency: conc}
+				size := 0
+				if queued {
+					size = runner.queueSize()
+				}
+				jobs := make(chan job, size)
+
+				var wg sync.WaitGroup
+				wg.Add(conc)
+				for i := 0; i < conc; i++ {
+					go func() {
+						defer wg.Done()
+						for range jobs {
+						}
+					}()
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if !runner.enqueue(jobs, job{url: "http://example.com/bench"}) {
+						b.Fatal("enqueue failed")
+					}
+				}
+				close(jobs)
+				wg.Wait()
+			})
+		}
+	}
 }
 
 func buildWordlist(tb testing.TB, dir, name, word string, count int) string {
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultBlacklist lists the path segments of endpoints that change state
+// when merely requested, such as ending the session the scan is
+// authenticated with or deleting data.
+var DefaultBlacklist = []string{
+	"logout",
+	"logoff",
+	"log-out",
+	"signout",
+	"sign-out",
+	"delete",
+	"remove",
+	"destroy",
+	"purge",
+	"wipe",
+	"truncate",
+	"drop",
+	"shutdown",
+	"reboot",
+	"restart",
+	"kill",
+	"uninstall",
+	"deactivate",
+	"unsubscribe",
+	"reset",
+	"password-reset",
+	"reset-password",
+	"resetpassword",
+	"forgot-password",
+}
+
+// Blacklist matches URLs whose path contains a dangerous segment. Segments
+// are compared case-insensitively, with and without their extension, so
+// "logout" also matches /Logout and /logout.php.
+type Blacklist struct {
+	segments map[string]struct{}
+}
+
+// NewBlacklist returns a Blacklist of the given segments. Leading and
+// trailing slashes are ignored.
+func NewBlacklist(segments []string) *Blacklist {
+	b := &Blacklist{segments: make(map[string]struct{}, len(segments))}
+	for _, segment := range segments {
+		segment = strings.ToLower(strings.Trim(strings.TrimSpace(segment), "/"))
+		if segment != "" {
+			b.segments[segment] = struct{}{}
+		}
+	}
+	return b
+}
+
+// LoadBlacklist reads the segments listed one per line in the file at path.
+// Blank lines and lines starting with # are ignored.
+func LoadBlacklist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open blacklist: %w", err)
+	}
+	defer file.Close()
+
+	var segments []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		segments = append(segments, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read blacklist: %w", err)
+	}
+	return segments, nil
+}
+
+// Blocks reports whether a segment of the path of rawURL is on the list. A
+// nil Blacklist blocks nothing.
+func (b *Blacklist) Blocks(rawURL string) bool {
+	if b == nil || len(b.segments) == 0 {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, segment := range strings.Split(strings.ToLower(u.Path), "/") {
+		if segment == "" {
+			continue
+		}
+		if _, ok := b.segments[segment]; ok {
+			return true
+		}
+		if ext := path.Ext(segment); ext != "" {
+			if _, ok := b.segments[strings.TrimSuffix(segment, ext)]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBlacklistBlocks(t *testing.T) {
+	blacklist := NewBlacklist(DefaultBlacklist)
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/logout", true},
+		{"https://example.com/account/Logout.php?next=/", true},
+		{"https://example.com/api/users/1/delete/", true},
+		{"https://example.com/password-reset", true},
+		{"https://example.com/logouts", false},
+		{"https://example.com/admin?action=delete", false},
+		{"https://example.com/", false},
+	}
+	for _, tt := range tests {
+		if got := blacklist.Blocks(tt.url); got != tt.want {
+			t.Errorf("Blocks(%q) = %t, want %t", tt.url, got, tt.want)
+		}
+	}
+
+	var none *Blacklist
+	if none.Blocks("https://example.com/logout") {
+		t.Fatal("nil Blacklist blocked a URL")
+	}
+}
+
+func TestLoadBlacklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.txt")
+	if err := os.WriteFile(path, []byte("# session\n/terminate-session/\n\n  archive\n"), 0o600); err != nil {
+		t.Fatalf("write blacklist: %v", err)
+	}
+	segments, err := LoadBlacklist(path)
+	if err != nil {
+		t.Fatalf("LoadBlacklist: %v", err)
+	}
+	if want := []string{"/terminate-session/", "archive"}; !reflect.DeepEqual(segments, want) {
+		t.Fatalf("LoadBlacklist = %q, want %q", segments, want)
+	}
+
+	scope := &Scope{Blacklist: NewBlacklist(segments)}
+	if scope.Allows("https://example.com/terminate-session") || !scope.Allows("https://example.com/logout") {
+		t.Fatal("Scope did not apply its Blacklist")
+	}
+}
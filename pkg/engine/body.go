@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// bodyDigest computes the hash, word count, and line count of a body as it is
+// written, so the body is scanned once while it is read.
+type bodyDigest struct {
+	sum    hash.Hash
+	words  int
+	lines  int
+	inWord bool
+	size   int64
+	last   byte
+}
+
+func newBodyDigest() *bodyDigest {
+	return &bodyDigest{sum: sha256.New()}
+}
+
+func (d *bodyDigest) Write(p []byte) (int, error) {
+	d.sum.Write(p)
+	for _, b := range p {
+		switch b {
+		case '\n':
+			d.lines++
+			d.inWord = false
+		case ' ', '\t', '\r', '\v', '\f':
+			d.inWord = false
+		default:
+			if !d.inWord {
+				d.words++
+				d.inWord = true
+			}
+		}
+	}
+	if len(p) > 0 {
+		d.size += int64(len(p))
+		d.last = p[len(p)-1]
+	}
+	return len(p), nil
+}
+
+// SHA256 returns the hex SHA-256 of the bytes written.
+func (d *bodyDigest) SHA256() string {
+	return hex.EncodeToString(d.sum.Sum(nil))
+}
+
+// Words returns the number of whitespace-separated words written.
+func (d *bodyDigest) Words() int {
+	return d.words
+}
+
+// Lines returns the number of lines written, counting a last line without a
+// trailing newline.
+func (d *bodyDigest) Lines() int {
+	if d.size > 0 && d.last != '\n' {
+		return d.lines + 1
+	}
+	return d.lines
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestBodyDigestCountsAcrossWrites(t *testing.T) {
+	tests := []struct {
+		body  string
+		words int
+		lines int
+	}{
+		{body: "", words: 0, lines: 0},
+		{body: "one", words: 1, lines: 1},
+		{body: "one two\n", words: 2, lines: 1},
+		{body: "  <h1>Not Found</h1>\r\n\r\n<p>gone</p>", words: 3, lines: 3},
+		{body: "a\n\nb\n", words: 2, lines: 3},
+	}
+
+	for _, tt := range tests {
+		// Split the body into single bytes so words and lines span writes.
+		digest := newBodyDigest()
+		for i := 0; i < len(tt.body); i++ {
+			digest.Write([]byte{tt.body[i]})
+		}
+
+		sum := sha256.Sum256([]byte(tt.body))
+		if digest.SHA256() != hex.EncodeToString(sum[:]) {
+			t.Fatalf("%q: unexpected hash %s", tt.body, digest.SHA256())
+		}
+		if digest.Words() != tt.words || digest.Words() != len(strings.Fields(tt.body)) {
+			t.Fatalf("%q: expected %d words, got %d", tt.body, tt.words, digest.Words())
+		}
+		if digest.Lines() != tt.lines {
+			t.Fatalf("%q: expected %d lines, got %d", tt.body, tt.lines, digest.Lines())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// breakerPoll is how often workers check whether a half-open circuit's probe
+// request has finished.
+const breakerPoll = 50 * time.Millisecond
+
+// breaker pauses requests to hosts that keep failing at the connection level.
+// After threshold consecutive failures the circuit of a host opens and its
+// requests wait for the cooldown. Then a single probe request is let through:
+// its success closes the circuit, its failure opens it again.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+type hostCircuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// newBreaker returns a breaker, or nil when threshold disables it.
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown, hosts: make(map[string]*hostCircuit)}
+}
+
+func (b *breaker) circuit(host string) *hostCircuit {
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+	return c
+}
+
+// wait blocks until a request to host may be sent. It returns false when ctx
+// ends first. A nil breaker never blocks.
+func (b *breaker) wait(ctx context.Context, host string) bool {
+	if b == nil {
+		return ctx.Err() == nil
+	}
+
+	for {
+		b.mu.Lock()
+		c := b.circuit(host)
+		delay := time.Duration(0)
+		switch {
+		case c.openUntil.IsZero():
+			b.mu.Unlock()
+			return ctx.Err() == nil
+		case time.Now().Before(c.openUntil):
+			delay = time.Until(c.openUntil)
+		case !c.probing:
+			c.probing = true
+			b.mu.Unlock()
+			return ctx.Err() == nil
+		default:
+			delay = breakerPoll
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// record notes the outcome of a request to host. When the failure opened the
+// circuit it returns an error describing the pause.
+func (b *breaker) record(host string, err error) error {
+	if b == nil {
+		return nil
+	}
+
+	failed := isConnectionFailure(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host)
+	if !failed {
+		// The host answered, even if the response was unusable.
+		*c = hostCircuit{}
+		return nil
+	}
+
+	c.failures++
+	open := false
+	switch {
+	case c.probing:
+		c.probing = false
+		open = true
+	case c.openUntil.IsZero() && c.failures >= b.threshold:
+		open = true
+	}
+	if !open {
+		return nil
+	}
+
+	c.openUntil = time.Now().Add(b.cooldown)
+	return fmt.Errorf("circuit breaker: %s failed %d times in a row, pausing requests to it for %s", host, c.failures, b.cooldown)
+}
+
+// hostOf returns the host and port of rawURL, which keys its circuit.
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Host
+}
+
+// isConnectionFailure reports whether err means the host could not be reached
+// or did not answer in time, as opposed to an error about the response.
+func isConnectionFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		os.IsTimeout(err) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerPausesFailingHost(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	results, err := Run(ctx, Config{
+		URL:              "http://" + listener.Addr().String() + "/FUZZ",
+		Words:            SliceWordlist([]string{"a", "b", "c", "d", "e", "f"}),
+		Method:           http.MethodGet,
+		Concurrency:      1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var requestErrs, breakerErrs int
+	for res := range results {
+		switch {
+		case res.Err == nil:
+			t.Fatalf("unexpected success: %+v", res)
+		case strings.Contains(res.Err.Error(), "circuit breaker"):
+			breakerErrs++
+		default:
+			requestErrs++
+		}
+	}
+
+	if requestErrs != 2 || breakerErrs != 1 {
+		t.Fatalf("expected 2 request errors and 1 breaker error, got %d and %d", requestErrs, breakerErrs)
+	}
+	if got := accepted.Load(); got > 2 {
+		t.Fatalf("expected no requests while the circuit is open, server saw %d connections", got)
+	}
+}
+
+func TestBreakerClosesAfterSuccessfulProbe(t *testing.T) {
+	b := newBreaker(1, 10*time.Millisecond)
+	failure := &net.OpError{Op: "dial", Err: context.DeadlineExceeded}
+
+	if err := b.record("host", failure); err == nil {
+		t.Fatal("expected the first failure to open the circuit")
+	}
+	if !b.wait(context.Background(), "host") {
+		t.Fatal("expected the probe to be let through after the cooldown")
+	}
+	if err := b.record("host", nil); err != nil {
+		t.Fatalf("unexpected error for a successful probe: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if !b.wait(ctx, "host") {
+		t.Fatal("expected the circuit to be closed after a successful probe")
+	}
+}
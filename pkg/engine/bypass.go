@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Bypass techniques reported in Result.Bypass.
+const (
+	BypassEncodedDot   = "encoded-dot"
+	BypassDoubleSlash  = "double-slash"
+	BypassTrailingDot  = "trailing-dot"
+	BypassCaseSwap     = "case-swap"
+	BypassOriginalURL  = "x-original-url"
+	BypassForwardedFor = "x-forwarded-for"
+)
+
+// bypassForwardedFrom is the client address claimed by BypassForwardedFor.
+const bypassForwardedFrom = "127.0.0.1"
+
+// Bypass is a variant of a denied request shaped to slip past an access
+// control, such as one that only recognizes the path as written, trusts
+// headers set by a front-end proxy, or only restricts some methods.
+type Bypass struct {
+	Technique string
+	Request   FollowUp
+}
+
+// Bypassable reports whether res was denied in a way the bypass techniques
+// are tried for.
+func Bypassable(res Result) bool {
+	return res.Err == nil && (res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden)
+}
+
+// Bypasses returns the variants of a request for rawURL worth trying when it
+// was denied. Path variants that would not change the URL are left out.
+func Bypasses(rawURL string) []Bypass {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	p := u.EscapedPath()
+	dir, name := path.Split(strings.TrimSuffix(p, "/"))
+	trailing := ""
+	if strings.HasSuffix(p, "/") {
+		trailing = "/"
+	}
+
+	var bypasses []Bypass
+	withPath := func(technique, escaped string) {
+		if escaped == p {
+			return
+		}
+		bypasses = append(bypasses, Bypass{Technique: technique, Request: FollowUp{URL: withEscapedPath(u, escaped)}})
+	}
+
+	if name != "" {
+		withPath(BypassEncodedDot, dir+"%2e/"+name+trailing)
+		withPath(BypassDoubleSlash, "/"+strings.TrimPrefix(dir, "/")+"/"+name+trailing)
+		withPath(BypassTrailingDot, dir+name+"."+trailing)
+		withPath(BypassCaseSwap, dir+swapCase(name)+trailing)
+	}
+
+	if requestURI := u.RequestURI(); requestURI != "/" {
+		root := *u
+		root.Path, root.RawPath, root.RawQuery = "/", "", ""
+		bypasses = append(bypasses, Bypass{Technique: BypassOriginalURL, Request: FollowUp{
+			URL:     root.String(),
+			Headers: http.Header{"X-Original-Url": {requestURI}},
+		}})
+	}
+	bypasses = append(bypasses, Bypass{Technique: BypassForwardedFor, Request: FollowUp{
+		URL:     rawURL,
+		Headers: http.Header{"X-Forwarded-For": {bypassForwardedFrom}},
+	}})
+	return bypasses
+}
+
+// withEscapedPath returns u with its path replaced by escaped, kept exactly
+// as written rather than re-encoded.
+func withEscapedPath(u *url.URL, escaped string) string {
+	var b strings.Builder
+	b.WriteString(u.Scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(u.Host)
+	b.WriteString(escaped)
+	if u.RawQuery != "" {
+		b.WriteByte('?')
+		b.WriteString(u.RawQuery)
+	}
+	return b.String()
+}
+
+// swapCase uppercases a lowercase name and lowercases the rest, so the
+// result differs from name whenever it has letters.
+func swapCase(name string) string {
+	if strings.ToLower(name) == name {
+		return strings.ToUpper(name)
+	}
+	return strings.ToLower(name)
+}
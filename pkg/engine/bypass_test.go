@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBypasses(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.RequestURI+" "+r.Header.Get("X-Original-Url")+r.Header.Get("X-Forwarded-For"))
+	}))
+	defer server.Close()
+
+	bypasses := Bypasses(server.URL + "/api/admin?x=1")
+	want := []struct{ technique, sent string }{
+		{BypassEncodedDot, "/api/%2e/admin?x=1 "},
+		{BypassDoubleSlash, "/api//admin?x=1 "},
+		{BypassTrailingDot, "/api/admin.?x=1 "},
+		{BypassCaseSwap, "/api/ADMIN?x=1 "},
+		{BypassOriginalURL, "/ /api/admin?x=1"},
+		{BypassForwardedFor, "/api/admin?x=1 127.0.0.1"},
+	}
+	if len(bypasses) != len(want) {
+		t.Fatalf("expected %d bypasses, got %+v", len(want), bypasses)
+	}
+
+	executor, err := NewFollowUpExecutor(Config{})
+	if err != nil {
+		t.Fatalf("executor: %v", err)
+	}
+	origin := Result{URL: server.URL + "/api/admin?x=1", RequestMethod: http.MethodGet, StatusCode: http.StatusForbidden}
+	for i, b := range bypasses {
+		if b.Technique != want[i].technique {
+			t.Fatalf("bypass %d: expected %s, got %s", i, want[i].technique, b.Technique)
+		}
+		if res := executor.Execute(context.Background(), origin, b.Request); res.Err != nil {
+			t.Fatalf("%s: %v", b.Technique, res.Err)
+		}
+		if seen[i] != want[i].sent {
+			t.Fatalf("%s: expected %q to reach the server, got %q", b.Technique, want[i].sent, seen[i])
+		}
+	}
+}
+
+func TestBypassesSkipUnchangedRequests(t *testing.T) {
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{target: "http://example.com/", want: []string{BypassForwardedFor}},
+		{target: "http://example.com/123", want: []string{BypassEncodedDot, BypassDoubleSlash, BypassTrailingDot, BypassOriginalURL, BypassForwardedFor}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, b := range Bypasses(tt.target) {
+			got = append(got, b.Technique)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.target, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultAcceptEncoding is sent when Config.AcceptEncoding is empty.
+const DefaultAcceptEncoding = "gzip"
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodeBody returns a reader of the decoded body for the given
+// Content-Encoding and whether the encoding was decoded. Empty bodies, such
+// as those of HEAD responses, and encodings other than gzip and deflate, such
+// as br, are returned unchanged.
+func decodeBody(body io.Reader, encoding string) (io.Reader, bool, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if len(header) == 0 {
+		return buffered, false, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, false, fmt.Errorf("decode gzip body: %w", err)
+		}
+		return zr, true, nil
+	case "deflate":
+		// Servers send either zlib-wrapped or raw deflate data.
+		if err == nil && isZlibHeader(header) {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, false, fmt.Errorf("decode deflate body: %w", err)
+			}
+			return zr, true, nil
+		}
+		return flate.NewReader(buffered), true, nil
+	default:
+		return buffered, false, nil
+	}
+}
+
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/httpclient"
+)
+
+func TestExecuteRequestDecodesCompressedBodies(t *testing.T) {
+	plain := strings.Repeat("hello hydro ", 100)
+
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, _ = io.WriteString(w, plain)
+		w.Close()
+		return buf.Bytes()
+	}
+	bodies := map[string][]byte{
+		"gzip":    compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }),
+		"deflate": compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }),
+		"raw": compress(func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}),
+		"br": []byte("not really brotli"),
+	}
+	encodings := map[string]string{"gzip": "gzip", "deflate": "deflate", "raw": "deflate", "br": "br"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Encoding", encodings[name])
+		_, _ = w.Write(bodies[name])
+	}))
+	defer server.Close()
+
+	client := httpclient.New(2*time.Second, false)
+	opts := &httpclient.RequestOptions{Headers: http.Header{"Accept-Encoding": {"gzip, deflate, br"}}}
+
+	for _, name := range []string{"gzip", "deflate", "raw"} {
+		res := executeRequest(context.Background(), client, server.URL+"/"+name, time.Second, http.MethodGet, opts, nil, 0)
+		if res.Err != nil {
+			t.Fatalf("%s: %v", name, res.Err)
+		}
+		if string(res.Body) != plain {
+			t.Fatalf("%s: body was not decoded", name)
+		}
+		if res.CompressedSize != int64(len(bodies[name])) || res.DecompressedSize != int64(len(plain)) || res.ContentLength != int64(len(plain)) {
+			t.Fatalf("%s: unexpected sizes: compressed=%d decompressed=%d length=%d", name, res.CompressedSize, res.DecompressedSize, res.ContentLength)
+		}
+	}
+
+	res := executeRequest(context.Background(), client, server.URL+"/br", time.Second, http.MethodGet, opts, nil, 0)
+	if res.Err != nil || string(res.Body) != "not really brotli" || res.ContentEncoding != "br" || res.DecompressedSize != 0 {
+		t.Fatalf("expected the br body to be kept as is, got %+v", res)
+	}
+
+	res = executeRequest(context.Background(), client, server.URL+"/gzip", time.Second, http.MethodHead, opts, nil, 0)
+	if res.Err != nil {
+		t.Fatalf("expected a HEAD response with an encoding to succeed, got %v", res.Err)
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"hydr0g3n/pkg/httpclient"
+)
+
+// FollowUp describes an extra request issued to verify a result, such as the
+// verification request a plugin asks for. Empty fields fall back to the
+// request that produced the original result.
+type FollowUp struct {
+	URL    string
+	Method string
+	Body   []byte
+	// Headers are added on top of the original request headers, replacing
+	// headers with the same name.
+	Headers         http.Header
+	Timeout         time.Duration
+	FollowRedirects *bool
+}
+
+// FollowUpExecutor performs follow-up requests with the transport settings
+// of a run. It is safe for concurrent use.
+type FollowUpExecutor struct {
+	timeout         time.Duration
+	followRedirects bool
+	maxBody         int64
+	scope           *Scope
+	// clients holds the client that does not follow redirects at index 0
+	// and the one that does at index 1.
+	clients [2]*httpclient.Client
+}
+
+// NewFollowUpExecutor returns an executor using the timeout, redirect policy,
+// and proxy of cfg.
+func NewFollowUpExecutor(cfg Config) (*FollowUpExecutor, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	e := &FollowUpExecutor{timeout: timeout, followRedirects: cfg.FollowRedirects, maxBody: cfg.MaxBodySize, scope: cfg.Scope}
+	for i, follow := range []bool{false, true} {
+		client, err := httpclient.NewWithOptions(httpclient.Options{
+			Timeout:           timeout,
+			FollowRedirects:   follow,
+			Proxy:             cfg.Proxy,
+			DNSCache:          cfg.DNSCache,
+			DisableKeepAlives: cfg.DisableKeepAlives,
+			Network:           cfg.Network,
+			SNI:               cfg.SNI,
+			TLSFingerprint:    cfg.TLSFingerprint,
+			Bandwidth:         cfg.Bandwidth,
+			AllowRedirect:     cfg.Scope.allowRedirect(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		e.clients[i] = client
+	}
+	return e, nil
+}
+
+// Execute performs req on behalf of origin and returns its outcome. The
+// returned Result has FollowUpOf set to origin.URL. A request to a URL out of
+// the scope of the run is not sent and fails with ErrOutOfScope.
+func (e *FollowUpExecutor) Execute(ctx context.Context, origin Result, req FollowUp) Result {
+	url := strings.TrimSpace(req.URL)
+	if url == "" {
+		url = origin.URL
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(req.Method))
+	if method == "" {
+		method = origin.RequestMethod
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = e.timeout
+	}
+
+	follow := e.followRedirects
+	if req.FollowRedirects != nil {
+		follow = *req.FollowRedirects
+	}
+	client := e.clients[0]
+	if follow {
+		client = e.clients[1]
+	}
+
+	headers := origin.RequestHeader.Clone()
+	if strings.TrimSpace(req.URL) == "" && origin.RequestHost != "" {
+		// The Host of the original request is not part of its headers.
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set("Host", origin.RequestHost)
+	}
+	opts := withHeaders(&httpclient.RequestOptions{Headers: headers}, req.Headers)
+	opts.Body = req.Body
+
+	var res Result
+	if e.scope.Allows(url) {
+		res = executeRequest(ctx, client, url, timeout, method, opts, nil, e.maxBody)
+	} else {
+		res = Result{URL: url, RequestMethod: method, Err: ErrOutOfScope}
+	}
+	res.FollowUpOf = origin.URL
+	res.Word = origin.Word
+	res.Payload = origin.Payload
+	return res
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// IntrospectionSeverity is the severity of a GraphQL endpoint answering
+// introspection queries: it hands out the whole schema of the API,
+// including operations no client uses.
+const IntrospectionSeverity = "medium"
+
+// graphQLSegments are the last path segments GraphQL endpoints are commonly
+// served at.
+var graphQLSegments = map[string]struct{}{
+	"graphql":     {},
+	"graphql.php": {},
+	"graphiql":    {},
+	"gql":         {},
+	"graphql-api": {},
+	"playground":  {},
+	"altair":      {},
+}
+
+// graphQLMarkers are bodies only GraphQL servers and their consoles answer
+// with.
+var graphQLMarkers = [][]byte{
+	[]byte("Must provide query string"),
+	[]byte("GET query missing"),
+	[]byte("POST body missing"),
+	[]byte("graphql-playground"),
+	[]byte("GraphiQL"),
+	[]byte(`"errors":[{"message":"Syntax Error`),
+}
+
+// introspectionQuery asks for the root types of a schema and the names of
+// their fields.
+const introspectionQuery = `query IntrospectionQuery { __schema { queryType { name fields { name } } mutationType { name fields { name } } } }`
+
+// GraphQLReport describes the GraphQL endpoint a hit was probed as.
+type GraphQLReport struct {
+	// Introspection is set when the endpoint answered the introspection
+	// query with its schema.
+	Introspection bool `json:"introspection"`
+	// Queries and Mutations are the names of the top-level fields of the
+	// schema, in schema order.
+	Queries   []string `json:"queries,omitempty"`
+	Mutations []string `json:"mutations,omitempty"`
+}
+
+// LooksLikeGraphQL reports whether the successful response res comes from
+// what appears to be a GraphQL endpoint, by its path or body.
+func LooksLikeGraphQL(res Result) bool {
+	if res.Err != nil {
+		return false
+	}
+	if u, err := url.Parse(res.URL); err == nil {
+		if _, ok := graphQLSegments[strings.ToLower(path.Base(u.Path))]; ok {
+			return true
+		}
+	}
+	for _, marker := range graphQLMarkers {
+		if bytes.Contains(res.Body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// IntrospectionProbe returns the request that sends the introspection query
+// to the endpoint of a result.
+func IntrospectionProbe() FollowUp {
+	body, _ := json.Marshal(map[string]string{"query": introspectionQuery})
+	return FollowUp{
+		Method:  http.MethodPost,
+		Body:    body,
+		Headers: http.Header{"Content-Type": {"application/json"}},
+	}
+}
+
+// ParseIntrospection reads the answer to IntrospectionProbe. It reports
+// false when the body is not a GraphQL response, and a report without
+// Introspection when the endpoint refused the query.
+func ParseIntrospection(body []byte) (GraphQLReport, bool) {
+	type rootType struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	var response struct {
+		Data *struct {
+			Schema *struct {
+				QueryType    *rootType `json:"queryType"`
+				MutationType *rootType `json:"mutationType"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return GraphQLReport{}, false
+	}
+	if response.Data == nil || response.Data.Schema == nil {
+		return GraphQLReport{}, response.Data != nil || len(response.Errors) > 0
+	}
+
+	names := func(t *rootType) []string {
+		if t == nil {
+			return nil
+		}
+		var names []string
+		for _, field := range t.Fields {
+			if field.Name != "" {
+				names = append(names, field.Name)
+			}
+		}
+		return names
+	}
+	return GraphQLReport{
+		Introspection: true,
+		Queries:       names(response.Data.Schema.QueryType),
+		Mutations:     names(response.Data.Schema.MutationType),
+	}, true
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestLooksLikeGraphQL(t *testing.T) {
+	tests := []struct {
+		name string
+		res  Result
+		want bool
+	}{
+		{name: "path", res: Result{URL: "https://example.com/api/graphql"}, want: true},
+		{name: "console path", res: Result{URL: "https://example.com/GraphiQL/"}, want: true},
+		{name: "body", res: Result{URL: "https://example.com/api", Body: []byte(`{"errors":[{"message":"Must provide query string."}]}`)}, want: true},
+		{name: "other path", res: Result{URL: "https://example.com/graphs"}},
+	}
+	for _, tt := range tests {
+		if got := LooksLikeGraphQL(tt.res); got != tt.want {
+			t.Errorf("%s: LooksLikeGraphQL = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIntrospectionProbe(t *testing.T) {
+	probe := IntrospectionProbe()
+	if probe.Method != http.MethodPost || probe.Headers.Get("Content-Type") != "application/json" {
+		t.Fatalf("probe = %s with %v", probe.Method, probe.Headers)
+	}
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(probe.Body, &body); err != nil || body.Query != introspectionQuery {
+		t.Fatalf("probe body = %s (%v)", probe.Body, err)
+	}
+}
+
+func TestParseIntrospection(t *testing.T) {
+	report, ok := ParseIntrospection([]byte(`{"data":{"__schema":{
+		"queryType":{"name":"Query","fields":[{"name":"users"},{"name":"me"}]},
+		"mutationType":{"name":"Mutation","fields":[{"name":"login"}]}}}}`))
+	want := GraphQLReport{Introspection: true, Queries: []string{"users", "me"}, Mutations: []string{"login"}}
+	if !ok || !reflect.DeepEqual(report, want) {
+		t.Fatalf("ParseIntrospection = %+v, %t, want %+v", report, ok, want)
+	}
+
+	report, ok = ParseIntrospection([]byte(`{"errors":[{"message":"GraphQL introspection is not allowed"}]}`))
+	if !ok || report.Introspection {
+		t.Fatalf("refused introspection = %+v, %t", report, ok)
+	}
+
+	if _, ok := ParseIntrospection([]byte("<html>not found</html>")); ok {
+		t.Fatal("ParseIntrospection accepted a body that is not GraphQL")
+	}
+}
@@ -0,0 +1,43 @@
+package engine
+
+import "bytes"
+
+// ListingSeverity is the severity of an open directory listing: it exposes
+// every file of a directory, including ones no page links to.
+const ListingSeverity = "medium"
+
+// listingMarkers recognize the directory indexes of common servers, checked
+// in order. A marker matches when the body contains all its substrings.
+var listingMarkers = []struct {
+	server string
+	all    []string
+}{
+	{server: "iis", all: []string{"[To Parent Directory]"}},
+	{server: "tomcat", all: []string{"<title>Directory Listing For /"}},
+	{server: "python", all: []string{"<title>Directory listing for /"}},
+	{server: "apache", all: []string{"<title>Index of /", "?C=N;O=D"}},
+	{server: "apache", all: []string{"<title>Index of /", "<address>Apache"}},
+	{server: "lighttpd", all: []string{"<title>Index of /", "lighttpd"}},
+	{server: "nginx", all: []string{"<title>Index of /", `<hr><pre><a href="../">../</a>`}},
+	{server: "nginx", all: []string{"<title>Index of /", "nginx"}},
+	{server: "generic", all: []string{"<title>Index of /"}},
+}
+
+// DirectoryListing returns the server whose directory index body is, such as
+// "apache", "nginx", or "iis", "generic" for an index of another server, or
+// "" when body is not a directory listing.
+func DirectoryListing(body []byte) string {
+	for _, marker := range listingMarkers {
+		matched := true
+		for _, s := range marker.all {
+			if !bytes.Contains(body, []byte(s)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return marker.server
+		}
+	}
+	return ""
+}
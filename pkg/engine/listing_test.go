@@ -0,0 +1,39 @@
+package engine
+
+import "testing"
+
+func TestDirectoryListing(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "apache",
+			body: `<html><head><title>Index of /files</title></head><body><h1>Index of /files</h1><table><tr><th><a href="?C=N;O=D">Name</a></th></tr></table></body></html>`,
+			want: "apache",
+		},
+		{
+			name: "nginx",
+			body: "<html>\r\n<head><title>Index of /files/</title></head>\r\n<body>\r\n<h1>Index of /files/</h1><hr><pre><a href=\"../\">../</a>\r\n</pre><hr></body>\r\n</html>",
+			want: "nginx",
+		},
+		{
+			name: "iis",
+			body: `<html><head><title>example.com - /files/</title></head><body><H1>example.com - /files/</H1><hr><pre><A HREF="/">[To Parent Directory]</A><br></pre></body></html>`,
+			want: "iis",
+		},
+		{name: "python", body: "<title>Directory listing for /</title>", want: "python"},
+		{name: "tomcat", body: "<title>Directory Listing For /docs/</title>", want: "tomcat"},
+		{name: "other index", body: "<title>Index of /pub</title>", want: "generic"},
+		{name: "page mentioning indexes", body: "<title>Home</title><p>Index of /files is disabled</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DirectoryListing([]byte(tt.body)); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// normalizeURL returns rawURL with its scheme and host lowercased and its path
+// cleaned of empty, "." and ".." segments, so URLs that reach the same
+// resource compare equal. Percent-encoded segments such as %2e are left
+// alone, and a trailing slash is kept. URLs that do not parse are returned
+// unchanged.
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if escaped := u.EscapedPath(); escaped != "" {
+		cleaned := path.Clean("/" + escaped)
+		if strings.HasSuffix(escaped, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		if unescaped, err := url.PathUnescape(cleaned); err == nil {
+			u.Path, u.RawPath = unescaped, cleaned
+		}
+	}
+
+	return u.String()
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTP://Example.COM//admin":        "http://example.com/admin",
+		"http://example.com/./a/../admin/": "http://example.com/admin/",
+		"http://example.com/Admin":         "http://example.com/Admin",
+		"http://example.com/%2e%2e/etc":    "http://example.com/%2e%2e/etc",
+		"http://example.com/a//b?q=//x":    "http://example.com/a/b?q=//x",
+		"http://example.com":               "http://example.com",
+	}
+
+	for raw, want := range tests {
+		if got := normalizeURL(raw); got != want {
+			t.Fatalf("normalizeURL(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestRunSkipsDuplicateURLs(t *testing.T) {
+	for _, tt := range []struct {
+		normalize bool
+		want      int64
+	}{{false, 3}, {true, 1}} {
+		var requests atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+		results, err := Run(context.Background(), Config{
+			URL:           server.URL + "/FUZZ",
+			Words:         SliceWordlist([]string{"admin", "admin", "/admin", "./admin"}),
+			Method:        http.MethodGet,
+			Concurrency:   1,
+			NormalizeURLs: tt.normalize,
+		})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		emitted := int64(0)
+		for range results {
+			emitted++
+		}
+		server.Close()
+
+		if requests.Load() != tt.want || emitted != tt.want {
+			t.Fatalf("normalize=%t: expected %d requests and results, got %d and %d", tt.normalize, tt.want, requests.Load(), emitted)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Payload orders accepted by Config.Order.
+const (
+	// OrderAsIs sends the words in wordlist order.
+	OrderAsIs = "as-is"
+	// OrderAlpha sorts the words alphabetically.
+	OrderAlpha = "alpha"
+	// OrderFrequency sends the words most likely to be hits first, by the
+	// counts embedded in the wordlist and the built-in popularity index.
+	OrderFrequency = "frequency"
+)
+
+// ParseOrder validates a payload order, defaulting to OrderAsIs.
+func ParseOrder(v string) (string, error) {
+	switch order := strings.ToLower(strings.TrimSpace(v)); order {
+	case "":
+		return OrderAsIs, nil
+	case OrderAsIs, OrderAlpha, OrderFrequency:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown order %q (choose from frequency, alpha, as-is)", v)
+	}
+}
+
+// popularWords ranks path segments by how often they exist on web servers,
+// most common first. OrderFrequency sends the words listed here before the
+// others when the wordlist has no counts of its own.
+var popularWords = []string{
+	"admin", "login", "index.php", "index.html", "robots.txt", "api",
+	"wp-admin", "wp-login.php", "wp-content", "images", "img", "css", "js",
+	"static", "assets", "uploads", "includes", "test", "backup", "config",
+	"dashboard", "user", "users", "account", "search", "register", "logout",
+	"administrator", "phpmyadmin", "server-status", ".git", ".env",
+	"sitemap.xml", "favicon.ico", "cgi-bin", "docs", "download", "files",
+	"media", "tmp", "old", "dev", "private", "portal", "data", "v1", "v2",
+	"graphql", "swagger", "health", "status", "console", "manager", "panel",
+	"upload", "backups", "db", "logs", "phpinfo.php", ".htaccess",
+}
+
+var popularRank = func() map[string]int {
+	ranks := make(map[string]int, len(popularWords))
+	for i, word := range popularWords {
+		ranks[word] = i
+	}
+	return ranks
+}()
+
+// orderWordlist reads every line of r and returns them as a wordlist in
+// order. Blank lines are dropped.
+//
+// With OrderFrequency a line may carry a count, either before the word as
+// printed by "uniq -c" ("1234 admin") or after it separated by a tab
+// ("admin\t1234"). Counted words come first, highest count first, followed by
+// the words of the popularity index and then the rest in wordlist order.
+func orderWordlist(r io.Reader, order string, maxLine int) (io.Reader, error) {
+	type entry struct {
+		word  string
+		count int
+		rank  int
+	}
+
+	var entries []entry
+	scanner := newWordlistScanner(r, maxLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		e := entry{word: line, count: -1, rank: len(popularWords)}
+		if order == OrderFrequency {
+			e.word, e.count = splitCount(line)
+			if rank, ok := popularRank[strings.ToLower(e.word)]; ok {
+				e.rank = rank
+			}
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read wordlist: %w", err)
+	}
+
+	switch order {
+	case OrderAlpha:
+		slices.SortStableFunc(entries, func(a, b entry) int {
+			return strings.Compare(a.word, b.word)
+		})
+	case OrderFrequency:
+		slices.SortStableFunc(entries, func(a, b entry) int {
+			if a.count != b.count {
+				return b.count - a.count
+			}
+			return a.rank - b.rank
+		})
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.word)
+		b.WriteByte('\n')
+	}
+	return strings.NewReader(b.String()), nil
+}
+
+// splitCount separates the count of a frequency wordlist line from its word.
+// Lines without a count return -1.
+func splitCount(line string) (string, int) {
+	if word, count, ok := strings.Cut(line, "\t"); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(count)); err == nil && n >= 0 {
+			return strings.TrimSpace(word), n
+		}
+	}
+
+	if count, word, ok := strings.Cut(line, " "); ok {
+		if n, err := strconv.Atoi(count); err == nil && n >= 0 {
+			if word = strings.TrimSpace(word); word != "" {
+				return word, n
+			}
+		}
+	}
+
+	return line, -1
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderWordlist(t *testing.T) {
+	const wordlist = "zeta\n\n  12 backup\nlogin\nalpha\t40\nadmin\n7 \n"
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{OrderAlpha, []string{"12 backup", "7", "admin", "alpha\t40", "login", "zeta"}},
+		// Counted words first, then the popularity index, then the rest in
+		// wordlist order. A lone number is a word, not a count.
+		{OrderFrequency, []string{"alpha", "backup", "admin", "login", "zeta", "7"}},
+	}
+
+	for _, tt := range tests {
+		r, err := orderWordlist(strings.NewReader(wordlist), tt.order, 0)
+		if err != nil {
+			t.Fatalf("%s: order wordlist: %v", tt.order, err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: read: %v", tt.order, err)
+		}
+		if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: expected %q, got %q", tt.order, tt.want, got)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	if order, err := ParseOrder(""); err != nil || order != OrderAsIs {
+		t.Fatalf("expected the default order, got %q, %v", order, err)
+	}
+	if order, err := ParseOrder(" Frequency "); err != nil || order != OrderFrequency {
+		t.Fatalf("expected frequency, got %q, %v", order, err)
+	}
+	if _, err := ParseOrder("random"); err == nil {
+		t.Fatal("expected an unknown order to be rejected")
+	}
+}
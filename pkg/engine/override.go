@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Method override techniques reported in Result.Bypass. MethodVerb sends
+// another method directly; the others send POST naming the method in a
+// header honored by many frameworks.
+const (
+	MethodVerb           = "verb"
+	MethodOverrideHeader = "x-http-method-override"
+	MethodOverrideShort  = "x-method-override"
+	MethodOverrideHTTP   = "x-http-method"
+)
+
+// overrideVerbs are the methods tried on a restricted endpoint.
+var overrideVerbs = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// overrideHeaders name the header of each header technique.
+var overrideHeaders = []struct{ technique, header string }{
+	{MethodOverrideHeader, "X-HTTP-Method-Override"},
+	{MethodOverrideShort, "X-Method-Override"},
+	{MethodOverrideHTTP, "X-HTTP-Method"},
+}
+
+// MethodRestricted reports whether res was refused in a way that may depend
+// on its method, so method overrides are tried for it.
+func MethodRestricted(res Result) bool {
+	if res.Err != nil {
+		return false
+	}
+	switch res.StatusCode {
+	case http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// MethodOverrides returns the variants of the refused request res that send
+// it with another method, or with POST and a method override header naming
+// the original method or another one.
+func MethodOverrides(res Result) []Bypass {
+	original := res.RequestMethod
+	if original == "" {
+		original = http.MethodGet
+	}
+
+	var overrides []Bypass
+	for _, verb := range overrideVerbs {
+		if verb != original {
+			overrides = append(overrides, Bypass{Technique: MethodVerb, Request: FollowUp{Method: verb}})
+		}
+	}
+
+	// HEAD is named as GET, which a framework answers the same way.
+	named := []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	if !slices.Contains(named, original) && original != http.MethodHead && original != http.MethodPost {
+		named = append([]string{original}, named...)
+	}
+	for _, h := range overrideHeaders {
+		for _, verb := range named {
+			headers := make(http.Header)
+			headers.Set(h.header, verb)
+			overrides = append(overrides, Bypass{Technique: h.technique, Request: FollowUp{Method: http.MethodPost, Headers: headers}})
+		}
+	}
+	return overrides
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverrides(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		override := r.Header.Get("X-HTTP-Method-Override") + r.Header.Get("X-Method-Override") + r.Header.Get("X-HTTP-Method")
+		seen = append(seen, strings.TrimSpace(r.Method+" "+override))
+		if override == http.MethodDelete {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	origin := Result{URL: server.URL + "/users/1", RequestMethod: http.MethodGet, StatusCode: http.StatusMethodNotAllowed}
+	if !MethodRestricted(origin) {
+		t.Fatal("expected a 405 response to be method restricted")
+	}
+
+	executor, err := NewFollowUpExecutor(Config{})
+	if err != nil {
+		t.Fatalf("executor: %v", err)
+	}
+	var allowed []string
+	for _, o := range MethodOverrides(origin) {
+		res := executor.Execute(context.Background(), origin, o.Request)
+		if res.Err != nil {
+			t.Fatalf("%s: %v", o.Technique, res.Err)
+		}
+		if res.StatusCode == http.StatusOK {
+			allowed = append(allowed, o.Technique)
+		}
+	}
+
+	want := "POST DELETE"
+	if got := strings.Join(seen, ","); !strings.HasPrefix(got, "POST,PUT,PATCH,DELETE,POST GET,") || strings.Count(got, want) != 3 {
+		t.Fatalf("unexpected requests %s", got)
+	}
+	if got := strings.Join(allowed, ","); got != "x-http-method-override,x-method-override,x-http-method" {
+		t.Fatalf("expected every override header to get through, got %s", got)
+	}
+}
+
+func TestMethodOverridesNameOriginalMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		first  string
+	}{
+		{method: http.MethodHead, first: http.MethodGet},
+		{method: http.MethodPut, first: http.MethodGet},
+		{method: http.MethodOptions, first: http.MethodOptions},
+	}
+
+	for _, tt := range tests {
+		overrides := MethodOverrides(Result{RequestMethod: tt.method})
+		for _, o := range overrides {
+			if o.Request.Method == tt.method {
+				t.Fatalf("%s: must not be sent again directly", tt.method)
+			}
+		}
+		var first Bypass
+		for _, o := range overrides {
+			if o.Technique == MethodOverrideHeader {
+				first = o
+				break
+			}
+		}
+		if got := first.Request.Headers.Get("X-HTTP-Method-Override"); got != tt.first {
+			t.Fatalf("%s: expected the first override to name %s, got %s", tt.method, tt.first, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// Pauser holds back the requests of a running scan without stopping it.
+// While paused, no new requests are dispatched; requests already in flight
+// complete normally. The zero value is ready to use and not paused.
+type Pauser struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// Pause stops new requests from being dispatched. Pausing a paused scan has
+// no effect.
+func (p *Pauser) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.paused {
+		p.paused = true
+		p.resume = make(chan struct{})
+	}
+}
+
+// Resume lets a paused scan continue. Resuming a running scan has no effect.
+func (p *Pauser) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		p.paused = false
+		close(p.resume)
+	}
+}
+
+// Paused reports whether the scan is paused.
+func (p *Pauser) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// wait blocks while the scan is paused. It returns false when ctx is done
+// first. A nil Pauser never blocks.
+func (p *Pauser) wait(ctx context.Context) bool {
+	if p == nil {
+		return ctx.Err() == nil
+	}
+
+	for {
+		p.mu.Lock()
+		paused, resume := p.paused, p.resume
+		p.mu.Unlock()
+
+		if !paused {
+			return ctx.Err() == nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-resume:
+		}
+	}
+}
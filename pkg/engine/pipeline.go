@@ -0,0 +1,97 @@
+package engine
+
+// stage is one step of the scan of a target. A stage reads its own wordlist;
+// once it is done the scan moves to onSuccess when any of its responses
+// satisfied success, and to onFailure otherwise. Routing to
+// progressStageComplete ends the scan of the target.
+type stage struct {
+	name string
+	// wordlist is the path of the wordlist the stage reads; empty reads
+	// Config.Words.
+	wordlist string
+	// success reports whether a response makes the stage successful. Nil
+	// means no response does.
+	success   func(Result) bool
+	onSuccess string
+	onFailure string
+	// branches scans the wordlist inside each directory queued by the
+	// stages before it instead of at the target.
+	branches bool
+}
+
+// pipeline lists the stages of a target, starting with the first to run. Its
+// order also ranks the stages recorded in the progress file: the stages
+// before the recorded one have completed.
+type pipeline []stage
+
+// pipeline returns the stages scanned for target. With Quick or Beginner set
+// and a small wordlist next to the target's, a quick probe runs first and the
+// primary stage only follows when the probe finds something. With Recursive
+// set, the directories found are scanned after the primary stage.
+func (cfg Config) pipeline(target Target) pipeline {
+	primary := stage{
+		name:      progressStagePrimary,
+		wordlist:  target.Wordlist,
+		success:   quickPositive,
+		onSuccess: progressStageComplete,
+		onFailure: progressStageComplete,
+	}
+	stages := pipeline{primary}
+
+	if cfg.Recursive {
+		stages[0].onSuccess = progressStageRecursive
+		stages[0].onFailure = progressStageRecursive
+		stages = append(stages, stage{
+			name:      progressStageRecursive,
+			wordlist:  target.Wordlist,
+			onSuccess: progressStageComplete,
+			onFailure: progressStageComplete,
+			branches:  true,
+		})
+	}
+
+	if (cfg.Quick || cfg.Beginner) && target.Wordlist != "" {
+		if quickWordlist := locateQuickWordlist(target.Wordlist); quickWordlist != "" {
+			quick := stage{
+				name:      progressStageQuick,
+				wordlist:  quickWordlist,
+				success:   quickPositive,
+				onSuccess: progressStagePrimary,
+				onFailure: progressStageComplete,
+			}
+			return append(pipeline{quick}, stages...)
+		}
+	}
+
+	return stages
+}
+
+// stage returns the stage called name.
+func (p pipeline) stage(name string) (stage, bool) {
+	for _, s := range p {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return stage{}, false
+}
+
+// rank returns the position of the stage called name, len(p) for
+// progressStageComplete, and -1 for stages not in p.
+func (p pipeline) rank(name string) int {
+	if name == progressStageComplete {
+		return len(p)
+	}
+	for i, s := range p {
+		if s.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// quickPositive reports whether res answered with a status suggesting the
+// path exists.
+func quickPositive(res Result) bool {
+	return res.Err == nil && isQuickPositive(res.StatusCode)
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunRoutesQuickStageToPrimary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/found" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		quick  string
+		stages []string
+	}{
+		{name: "quick hit", quick: "found\n", stages: []string{"quick", "primary"}},
+		{name: "quick miss", quick: "missing\n", stages: []string{"quick"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			wordlist := filepath.Join(dir, "words.txt")
+			writeFile(t, wordlist, "a\nb\n")
+			writeFile(t, filepath.Join(dir, "sample_small.txt"), tt.quick)
+
+			stages := scanStages(t, Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Quick: true})
+			if strings.Join(stages, ",") != strings.Join(tt.stages, ",") {
+				t.Fatalf("expected stages %v, got %v", tt.stages, stages)
+			}
+		})
+	}
+}
+
+func TestRunResumesAtRecordedStage(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	writeFile(t, wordlist, "a\nb\nc\n")
+	writeFile(t, filepath.Join(dir, "sample_small.txt"), "quick\n")
+	progress := filepath.Join(dir, "progress.json")
+	writeFile(t, progress, `{"stage": "primary", "word_index": 1, "variant_index": 0}`)
+
+	scanStages(t, Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Quick: true, ProgressFile: progress})
+
+	sort.Strings(paths)
+	if strings.Join(paths, ",") != "/b,/c" {
+		t.Fatalf("expected the quick stage to be skipped and primary to resume at b, got %v", paths)
+	}
+}
+
+func TestPlanListsStages(t *testing.T) {
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	writeFile(t, wordlist, "a\nb\nc\n")
+	writeFile(t, filepath.Join(dir, "sample_small.txt"), "quick\n")
+
+	plan, err := Plan(Config{URL: "https://example.com/FUZZ", Wordlist: wordlist, Quick: true})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	want := []StagePlan{
+		{Target: "https://example.com/FUZZ", Stage: "quick", Permutations: 1, Samples: []string{"https://example.com/quick"}},
+		{Target: "https://example.com/FUZZ", Stage: "primary", Permutations: 3, Samples: []string{
+			"https://example.com/a", "https://example.com/b", "https://example.com/c",
+		}},
+	}
+	if !reflect.DeepEqual(plan.Stages, want) {
+		t.Fatalf("expected stages %+v, got %+v", want, plan.Stages)
+	}
+}
+
+// scanStages runs cfg to completion and returns the stages that ran.
+func scanStages(t *testing.T, cfg Config) []string {
+	t.Helper()
+
+	var stages []string
+	cfg.OnStage = func(event StageEvent) {
+		if !event.Done {
+			stages = append(stages, event.Stage)
+		}
+	}
+
+	results, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+	}
+	return stages
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pacer spaces the requests of a stage by the throttle interval plus a random
+// jitter delay. Workers take turns, so with several workers the gaps still
+// add up between consecutive requests.
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	jitter   time.Duration
+	// last is when the previous turn ended.
+	last time.Time
+}
+
+// newPacer returns a pacer for interval and jitter, or nil when neither is
+// set.
+func newPacer(interval, jitter time.Duration) *pacer {
+	if interval <= 0 && jitter <= 0 {
+		return nil
+	}
+	return &pacer{interval: interval, jitter: jitter}
+}
+
+// lock starts the turn of a worker; unlock ends it.
+func (p *pacer) lock() {
+	if p != nil {
+		p.mu.Lock()
+	}
+}
+
+func (p *pacer) unlock() {
+	if p != nil {
+		p.mu.Unlock()
+	}
+}
+
+// wait sleeps until the interval since the previous turn has passed, then
+// for the jitter delay. The caller must hold the turn. It reports false when
+// ctx is done first.
+func (p *pacer) wait(ctx context.Context) bool {
+	if p == nil {
+		return true
+	}
+
+	var delay time.Duration
+	if !p.last.IsZero() {
+		delay = p.interval - time.Since(p.last)
+	}
+	if p.jitter > 0 {
+		delay = max(delay, 0) + jitterDelay(p.jitter)
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+
+	p.last = time.Now()
+	return true
+}
+
+// progressWindow moves the progress checkpoint past a position only once the
+// job at it and the jobs at every earlier position have finished, so a
+// resumed scan repeats the jobs that were still queued or in flight when the
+// previous one stopped.
+type progressWindow struct {
+	mu sync.Mutex
+	// next is the first position that has not finished.
+	next     int
+	finished map[int]position
+	update   func(state progressState, url string) bool
+	// failed is set once update fails; the checkpoint is then left alone.
+	failed atomic.Bool
+}
+
+// position is the progress state reached by finishing a job at a position
+// of the wordlist.
+type position struct {
+	state progressState
+	url   string
+}
+
+// newProgressWindow returns a window checkpointing with update, or nil when
+// update is nil.
+func newProgressWindow(update func(state progressState, url string) bool) *progressWindow {
+	if update == nil {
+		return nil
+	}
+	return &progressWindow{finished: make(map[int]position), update: update}
+}
+
+// finish records that the job at position seq finished, leaving progress at
+// state.
+func (w *progressWindow) finish(seq int, state progressState, url string) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.finished[seq] = position{state: state, url: url}
+
+	last, advanced := position{}, false
+	for {
+		p, ok := w.finished[w.next]
+		if !ok {
+			break
+		}
+		delete(w.finished, w.next)
+		w.next++
+		last, advanced = p, true
+	}
+
+	if advanced && !w.failed.Load() && !w.update(last.state, last.url) {
+		w.failed.Store(true)
+	}
+}
+
+// stopped reports whether writing the checkpoint failed.
+func (w *progressWindow) stopped() bool {
+	return w != nil && w.failed.Load()
+}
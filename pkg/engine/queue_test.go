@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/httpclient"
+	"hydr0g3n/pkg/store"
+	"hydr0g3n/pkg/templater"
+)
+
+func TestProgressWindowWaitsForEarlierJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   []int
+		updates []int
+	}{
+		{name: "in order", order: []int{0, 1, 2}, updates: []int{0, 1, 2}},
+		{name: "later job first", order: []int{1, 0, 2}, updates: []int{1, 2}},
+		{name: "first job last", order: []int{2, 1, 0}, updates: []int{2}},
+		{name: "gap left open", order: []int{0, 2, 3}, updates: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []int
+			window := newProgressWindow(func(state progressState, url string) bool {
+				updates = append(updates, state.WordIndex)
+				return true
+			})
+
+			for _, seq := range tt.order {
+				window.finish(seq, progressState{Stage: progressStagePrimary, WordIndex: seq}, "")
+			}
+
+			if !slices.Equal(updates, tt.updates) {
+				t.Fatalf("expected checkpoints %v, got %v", tt.updates, updates)
+			}
+		})
+	}
+}
+
+func TestProgressWindowStopsAfterFailedUpdate(t *testing.T) {
+	calls := 0
+	window := newProgressWindow(func(state progressState, url string) bool {
+		calls++
+		return false
+	})
+
+	window.finish(0, progressState{}, "")
+	window.finish(1, progressState{}, "")
+
+	if !window.stopped() {
+		t.Fatal("expected the window to stop after the update failed")
+	}
+	if calls != 1 {
+		t.Fatalf("expected no update after the failure, got %d", calls)
+	}
+}
+
+// fakeRecorder records attempts in memory.
+type fakeRecorder struct {
+	mu     sync.Mutex
+	marked []string
+}
+
+func (f *fakeRecorder) RunID() string { return "fake" }
+
+func (f *fakeRecorder) Continued() bool { return false }
+
+func (f *fakeRecorder) MarkAttempt(ctx context.Context, path string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if slices.Contains(f.marked, path) {
+		return false, nil
+	}
+	f.marked = append(f.marked, path)
+	return true, nil
+}
+
+func (f *fakeRecorder) RecordOutcome(ctx context.Context, path string, outcome store.AttemptOutcome) error {
+	return nil
+}
+
+func (f *fakeRecorder) RecordHit(ctx context.Context, hit store.HitRecord) error { return nil }
+
+func (f *fakeRecorder) Finish(ctx context.Context) error { return nil }
+
+func TestStageRunnerLeavesQueuedJobsForResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	writeFile(t, wordlist, "a\nb\nc\n")
+	tracker, err := newProgressTracker(filepath.Join(dir, "progress.json"), false)
+	if err != nil {
+		t.Fatalf("new tracker: %v", err)
+	}
+
+	recorder := &fakeRecorder{}
+	runner := stageRunner{
+		ctx:         ctx,
+		target:      server.URL + "/FUZZ",
+		concurrency: 1,
+		timeout:     5 * time.Second,
+		method:      http.MethodGet,
+		client:      httpclient.New(5*time.Second, false),
+		tpl:         templater.New(),
+		results:     make(chan Result, 8),
+		progress:    tracker,
+		runRecorder: recorder,
+	}
+
+	go func() {
+		<-started
+		// Let the reader queue b behind the request for a.
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := runner.run(stage{name: progressStagePrimary, wordlist: wordlist}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	recorder.mu.Lock()
+	marked := slices.Clone(recorder.marked)
+	recorder.mu.Unlock()
+	if want := []string{server.URL + "/a"}; !slices.Equal(marked, want) {
+		t.Fatalf("expected only the sent request to be marked attempted, got %v", marked)
+	}
+	// The request for a may count as finished when it failed as the scan
+	// stopped, but the queued b never does.
+	if state := tracker.State(); state.WordIndex > 1 {
+		t.Fatalf("expected the checkpoint to stay before the queued request, got %+v", state)
+	}
+}
+
+func TestStageRunnerThrottleSpacesQueuedRequests(t *testing.T) {
+	const throttle = 40 * time.Millisecond
+
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	writeFile(t, wordlist, "a\nb\nc\nd\n")
+
+	runner := stageRunner{
+		ctx:         context.Background(),
+		target:      server.URL + "/FUZZ",
+		concurrency: 4,
+		timeout:     time.Second,
+		method:      http.MethodGet,
+		client:      httpclient.New(2*time.Second, false),
+		tpl:         templater.New(),
+		results:     make(chan Result, 8),
+		throttle:    throttle,
+	}
+
+	if _, err := runner.run(stage{name: progressStagePrimary, wordlist: wordlist}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(times))
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	for i := 1; i < len(times); i++ {
+		// Allow for the request reaching the handler after the turn ended.
+		if gap := times[i].Sub(times[i-1]); gap < throttle-10*time.Millisecond {
+			t.Fatalf("expected requests at least %v apart, got %v between request %d and %d", throttle, gap, i, i+1)
+		}
+	}
+}
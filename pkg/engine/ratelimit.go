@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"net/http"
+	"time"
+
+	"hydr0g3n/pkg/waf"
+)
+
+const (
+	// rateWindow is the span of time the responses of a scan are grouped by
+	// to tell when throttling started.
+	rateWindow = time.Second
+	// rateSpan is the number of windows at the end of a scan averaged for
+	// the rate it settled on.
+	rateSpan = 5
+	// latencyBaselineWindows is the number of windows that establish the
+	// normal latency of the target.
+	latencyBaselineWindows = 5
+	// minWindowResponses is the number of responses a window needs for its
+	// latency to count.
+	minWindowResponses = 5
+	// A window is slowed down when its average latency is latencySpikeFactor
+	// times the baseline and at least minLatencySpike above it.
+	latencySpikeFactor = 3
+	minLatencySpike    = 200 * time.Millisecond
+)
+
+// Reasons reported in RateLimitReport.Reason.
+const (
+	RateLimitStatus  = "status_429"
+	RateLimitBlocked = "block_page"
+	RateLimitLatency = "latency"
+)
+
+// RateLimitReport describes the throttling a target applied during a scan:
+// when it started, the request rate that set it off, and the rate the scan
+// went on at. Rates are observed, in requests per second: TriggerRate is the
+// average from the start of the scan until throttling started and
+// SettledRate the average of its last seconds.
+type RateLimitReport struct {
+	// Reason is what revealed the throttling first: 429 responses, block
+	// pages of a web application firewall, or a sudden rise in latency.
+	Reason         string    `json:"reason"`
+	StartedAt      time.Time `json:"started_at"`
+	StartedAfterMS int64     `json:"started_after_ms"`
+	TriggerRate    float64   `json:"trigger_rate"`
+	SettledRate    float64   `json:"settled_rate"`
+	// Throttled counts the 429 responses and Blocked the block pages of the
+	// whole scan.
+	Throttled int64 `json:"throttled"`
+	Blocked   int64 `json:"blocked"`
+	// BaselineLatencyMS is the average latency before throttling started,
+	// and PeakLatencyMS the highest average latency of a window after.
+	BaselineLatencyMS float64 `json:"baseline_latency_ms,omitempty"`
+	PeakLatencyMS     float64 `json:"peak_latency_ms,omitempty"`
+}
+
+type rateWindowCounts struct {
+	requests  int64
+	responses int64
+	latency   time.Duration
+}
+
+// rateWatch groups the responses of a scan into windows of rateWindow and
+// notes the first 429 response or block page for RateLimitReport. It is
+// guarded by the mutex of its Stats.
+type rateWatch struct {
+	start     time.Time
+	last      time.Time
+	requests  int64
+	throttled int64
+	blocked   int64
+	windows   []rateWindowCounts
+	// signal is when the first 429 response or block page arrived, after
+	// signalBefore other requests.
+	signal       time.Time
+	signalReason string
+	signalBefore int64
+}
+
+// observe counts res, received at now.
+func (w *rateWatch) observe(now time.Time, res Result) {
+	if w.start.IsZero() {
+		w.start = now
+	}
+	if now.After(w.last) {
+		w.last = now
+	}
+
+	i := w.window(now)
+	for len(w.windows) <= i {
+		w.windows = append(w.windows, rateWindowCounts{})
+	}
+	c := &w.windows[i]
+	c.requests++
+	w.requests++
+	if res.Err != nil {
+		return
+	}
+
+	c.responses++
+	c.latency += res.Duration
+
+	var reason string
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		w.throttled++
+		reason = RateLimitStatus
+	case res.StatusCode >= 400:
+		if _, ok := waf.BlockPage(res.Body); ok {
+			w.blocked++
+			reason = RateLimitBlocked
+		}
+	}
+	if reason != "" && w.signal.IsZero() {
+		w.signal, w.signalReason, w.signalBefore = now, reason, w.requests-1
+	}
+}
+
+// window returns the index of the window t falls in.
+func (w *rateWatch) window(t time.Time) int {
+	return max(int(t.Sub(w.start)/rateWindow), 0)
+}
+
+// report returns the throttling seen so far, or nil when there was none.
+func (w *rateWatch) report() *RateLimitReport {
+	onset, before, reason := w.signal, w.signalBefore, w.signalReason
+
+	// A rise in latency counts when it comes before any 429 response or
+	// block page.
+	last := len(w.windows) - 1
+	if !onset.IsZero() {
+		last = w.window(onset) - 1
+	}
+	var (
+		baseline      time.Duration
+		baselineCount int
+		requests      int64
+	)
+	for i := 0; i <= last; i++ {
+		c := w.windows[i]
+		if c.responses >= minWindowResponses {
+			latency := c.average()
+			if baselineCount < latencyBaselineWindows {
+				baseline += latency
+				baselineCount++
+			} else if spike(latency, baseline/time.Duration(baselineCount)) {
+				onset, before, reason = w.start.Add(time.Duration(i)*rateWindow), requests, RateLimitLatency
+				break
+			}
+		}
+		requests += c.requests
+	}
+	if onset.IsZero() {
+		return nil
+	}
+
+	report := &RateLimitReport{
+		Reason:         reason,
+		StartedAt:      onset,
+		StartedAfterMS: onset.Sub(w.start).Milliseconds(),
+		Throttled:      w.throttled,
+		Blocked:        w.blocked,
+	}
+	if elapsed := onset.Sub(w.start); elapsed > 0 {
+		report.TriggerRate = float64(before) / elapsed.Seconds()
+	}
+	first, end := w.window(onset), len(w.windows)-1
+	if first < end {
+		first++
+	}
+	report.SettledRate = w.rate(max(first, end-rateSpan+1), end)
+	if baselineCount > 0 {
+		report.BaselineLatencyMS = milliseconds(baseline / time.Duration(baselineCount))
+	}
+	for _, c := range w.windows[w.window(onset):] {
+		if c.responses > 0 {
+			report.PeakLatencyMS = max(report.PeakLatencyMS, milliseconds(c.average()))
+		}
+	}
+	return report
+}
+
+// rate returns the requests per second of windows from through to.
+func (w *rateWatch) rate(from, to int) float64 {
+	var requests int64
+	var elapsed time.Duration
+	for i := from; i <= to; i++ {
+		requests += w.windows[i].requests
+		if i == len(w.windows)-1 {
+			// The last window ends with the last response.
+			elapsed += w.last.Sub(w.start.Add(time.Duration(i) * rateWindow))
+		} else {
+			elapsed += rateWindow
+		}
+	}
+	if elapsed <= 0 {
+		elapsed = rateWindow
+	}
+	return float64(requests) / elapsed.Seconds()
+}
+
+// spike reports whether latency is far enough above the normal latency to
+// count as throttling.
+func spike(latency, normal time.Duration) bool {
+	return latency >= latencySpikeFactor*normal && latency-normal >= minLatencySpike
+}
+
+func (c rateWindowCounts) average() time.Duration {
+	if c.responses == 0 {
+		return 0
+	}
+	return c.latency / time.Duration(c.responses)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
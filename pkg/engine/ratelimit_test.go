@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// observeWindow records n responses evenly spread over window i of w.
+func observeWindow(w *rateWatch, start time.Time, i, n int, res Result) {
+	for j := 0; j < n; j++ {
+		offset := time.Duration(i)*rateWindow + time.Duration(j)*rateWindow/time.Duration(n)
+		w.observe(start.Add(offset), res)
+	}
+}
+
+func TestRateWatchReport(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ok := Result{StatusCode: http.StatusNotFound, Duration: 20 * time.Millisecond}
+	slow := Result{StatusCode: http.StatusNotFound, Duration: time.Second}
+	limited := Result{StatusCode: http.StatusTooManyRequests, Duration: 20 * time.Millisecond}
+	blocked := Result{StatusCode: http.StatusForbidden, Body: []byte("<title>Attention Required! | Cloudflare</title>")}
+
+	t.Run("none", func(t *testing.T) {
+		var w rateWatch
+		for i := 0; i < 10; i++ {
+			observeWindow(&w, start, i, 20, ok)
+		}
+		observeWindow(&w, start, 10, 5, Result{Err: errors.New("timeout")})
+		if report := w.report(); report != nil {
+			t.Fatalf("expected no throttling, got %+v", report)
+		}
+	})
+
+	t.Run("429", func(t *testing.T) {
+		var w rateWatch
+		for i := 0; i < 3; i++ {
+			observeWindow(&w, start, i, 40, ok)
+		}
+		for i := 3; i < 8; i++ {
+			observeWindow(&w, start, i, 10, limited)
+		}
+		observeWindow(&w, start, 8, 1, ok)
+
+		report := w.report()
+		if report == nil {
+			t.Fatal("expected throttling to be reported")
+		}
+		if report.Reason != RateLimitStatus || report.StartedAfterMS != 3000 || !report.StartedAt.Equal(start.Add(3*time.Second)) {
+			t.Fatalf("unexpected onset %+v", report)
+		}
+		if report.Throttled != 50 || report.Blocked != 0 {
+			t.Fatalf("expected 50 throttled responses, got %+v", report)
+		}
+		// 120 requests precede the first 429 after 3s; windows 4-8 carry 41
+		// requests over the 4s up to the last response.
+		if report.TriggerRate != 40 || report.SettledRate != 10.25 {
+			t.Fatalf("expected rates 40 and 10.25, got %v and %v", report.TriggerRate, report.SettledRate)
+		}
+	})
+
+	t.Run("block page", func(t *testing.T) {
+		var w rateWatch
+		observeWindow(&w, start, 0, 10, ok)
+		observeWindow(&w, start, 1, 10, blocked)
+		if report := w.report(); report == nil || report.Reason != RateLimitBlocked || report.Blocked != 10 {
+			t.Fatalf("expected block pages to be reported, got %+v", report)
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		var w rateWatch
+		for i := 0; i < latencyBaselineWindows; i++ {
+			observeWindow(&w, start, i, 10, ok)
+		}
+		observeWindow(&w, start, latencyBaselineWindows, 10, slow)
+
+		report := w.report()
+		if report == nil || report.Reason != RateLimitLatency || report.StartedAfterMS != 5000 {
+			t.Fatalf("expected a latency rise after 5s, got %+v", report)
+		}
+		if report.BaselineLatencyMS != 20 || report.PeakLatencyMS != 1000 {
+			t.Fatalf("expected latency from 20ms to 1000ms, got %+v", report)
+		}
+	})
+}
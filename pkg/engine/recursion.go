@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"hydr0g3n/pkg/templater"
+)
+
+// progressStageRecursive is the stage scanning the directories found by a
+// recursive scan.
+const progressStageRecursive = "recursive"
+
+// DefaultRecursionDepth is how many directories deep a recursive scan goes
+// when Config.RecursionDepth is zero.
+const DefaultRecursionDepth = 2
+
+// recursionQueueLimit bounds the directories waiting to be scanned, so a
+// server answering every path like a directory cannot grow the queue without
+// end.
+const recursionQueueLimit = 1000
+
+// branch is a directory found by a recursive scan. Template is the target
+// URL for the words inside it and Depth how many directories deep it is.
+type branch struct {
+	Template string `json:"template"`
+	Depth    int    `json:"depth"`
+}
+
+// branchQueue holds the directories still to be scanned, oldest first. It is
+// safe for concurrent use.
+type branchQueue struct {
+	mu      sync.Mutex
+	pending []branch
+	queued  map[string]struct{}
+}
+
+// newBranchQueue returns a queue holding pending, as restored from a progress
+// checkpoint.
+func newBranchQueue(pending []branch) *branchQueue {
+	q := &branchQueue{queued: make(map[string]struct{})}
+	for _, b := range pending {
+		q.push(b)
+	}
+	return q
+}
+
+// push queues b unless it was queued before. It reports false when the queue
+// is full.
+func (q *branchQueue) push(b branch) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.queued[b.Template]; ok {
+		return true
+	}
+	if len(q.pending) >= recursionQueueLimit {
+		return false
+	}
+	q.queued[b.Template] = struct{}{}
+	q.pending = append(q.pending, b)
+	return true
+}
+
+// peek returns the oldest queued directory.
+func (q *branchQueue) peek() (branch, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return branch{}, false
+	}
+	return q.pending[0], true
+}
+
+// pop removes the oldest queued directory once it has been scanned.
+func (q *branchQueue) pop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) > 0 {
+		q.pending = q.pending[1:]
+	}
+}
+
+// snapshot returns a copy of the queued directories.
+func (q *branchQueue) snapshot() []branch {
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]branch(nil), q.pending...)
+}
+
+// recurse scans the wordlist of st inside every queued directory, queueing
+// the directories found there in turn until the queue is empty.
+func (r *stageRunner) recurse(st stage) (bool, error) {
+	inner := stage{name: st.name, wordlist: st.wordlist}
+
+	for r.ctx.Err() == nil {
+		next, ok := r.branches.peek()
+		if !ok {
+			break
+		}
+
+		sub := *r
+		sub.target = next.Template
+		sub.depth = next.Depth
+		sub.limit = r.branchLimit
+		if _, err := sub.run(inner); err != nil {
+			return false, err
+		}
+		if r.ctx.Err() != nil {
+			// The directory stays queued so a resumed scan finishes it.
+			break
+		}
+
+		r.branches.pop()
+		if err := r.progress.Set(progressState{Stage: st.name}); err != nil {
+			return false, err
+		}
+	}
+
+	if r.ctx.Err() == nil {
+		if err := r.progress.Set(progressState{Stage: st.onFailure}); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// queueDirectory queues the directory res points at, reached with payload,
+// when res is a directory-like hit within the depth limit.
+func (r *stageRunner) queueDirectory(res Result, payload string) {
+	if r.branches == nil || r.depth >= r.maxDepth || !isHit(res) || !isDirectory(res) {
+		return
+	}
+
+	child := branch{
+		Template: r.tpl.Expand(r.target, strings.TrimSuffix(payload, "/")+"/"+templater.DefaultPlaceholder),
+		Depth:    r.depth + 1,
+	}
+	if !r.branches.push(child) {
+		r.log().Warn("recursion queue is full; directory not scanned", "url", res.URL)
+	}
+}
+
+// isHit reports whether res found something: the verdict of the matcher or
+// classifier when there is one, otherwise a status suggesting the path
+// exists.
+func isHit(res Result) bool {
+	if res.Err != nil {
+		return false
+	}
+	if res.HasVerdict {
+		return res.Verdict
+	}
+	return quickPositive(res)
+}
+
+// isDirectory reports whether res looks like a directory: a redirect to the
+// same path with a trailing slash, an index listing, or a path ending in a
+// slash that exists or is forbidden.
+func isDirectory(res Result) bool {
+	requested, err := url.Parse(res.URL)
+	if err != nil {
+		return false
+	}
+
+	switch res.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		if location := res.ResponseHeader.Get("Location"); location != "" {
+			if target, err := requested.Parse(location); err == nil && addsSlash(requested, target) {
+				return true
+			}
+		}
+	}
+
+	// With redirects followed, the chain starts at the requested URL and the
+	// response is the one of the final URL.
+	if len(res.RedirectChain) > 0 {
+		if final, err := url.Parse(res.RequestURL); err == nil && addsSlash(requested, final) {
+			return true
+		}
+	}
+
+	if res.Listing != "" {
+		return true
+	}
+
+	if strings.HasSuffix(requested.Path, "/") && requested.Path != "/" {
+		return res.StatusCode >= 200 && res.StatusCode < 300 || res.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// addsSlash reports whether to is from with a slash added to its path.
+func addsSlash(from, to *url.URL) bool {
+	return to.Host == from.Host && !strings.HasSuffix(from.Path, "/") && to.Path == from.Path+"/"
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// directoryServer answers paths ending in "a" with a redirect to the same path
+// with a trailing slash, paths ending in a slash with 200, and the rest with
+// 404. It returns the paths requested so far.
+func directoryServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "a"):
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		case strings.HasSuffix(r.URL.Path, "/"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sorted := append([]string(nil), paths...)
+		sort.Strings(sorted)
+		return sorted
+	}
+}
+
+func TestRunRecursesIntoDirectories(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		limit int
+		want  []string
+	}{
+		{name: "depth 1", depth: 1, want: []string{"/a", "/a/a", "/a/z", "/z"}},
+		{name: "default depth", want: []string{"/a", "/a/a", "/a/a/a", "/a/a/z", "/a/z", "/z"}},
+		{name: "payload cap", limit: 1, want: []string{"/a", "/a/a", "/a/a/a", "/z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requested := directoryServer(t)
+			wordlist := filepath.Join(t.TempDir(), "words.txt")
+			writeFile(t, wordlist, "a\nz\n")
+
+			stages := scanStages(t, Config{
+				URL:               server.URL + "/FUZZ",
+				Wordlist:          wordlist,
+				Recursive:         true,
+				RecursionDepth:    tt.depth,
+				RecursionPayloads: tt.limit,
+			})
+			if strings.Join(stages, ",") != "primary,recursive" {
+				t.Fatalf("expected the primary and recursive stages, got %v", stages)
+			}
+			if got := requested(); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunResumesQueuedDirectories(t *testing.T) {
+	server, requested := directoryServer(t)
+
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	writeFile(t, wordlist, "a\nz\n")
+	progress := filepath.Join(dir, "progress.json")
+	writeFile(t, progress, `{"stage": "recursive", "word_index": 1, "variant_index": 0,
+		"branches": [{"template": "`+server.URL+`/a/a/FUZZ", "depth": 2}]}`)
+
+	scanStages(t, Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Recursive: true, ProgressFile: progress})
+
+	if got := requested(); strings.Join(got, ",") != "/a/a/z" {
+		t.Fatalf("expected only the rest of the queued directory to be scanned, got %v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Progress modes accepted by Config.ProgressMode.
+const (
+	// ProgressByIndex resumes at the word and variant positions of the
+	// checkpoint.
+	ProgressByIndex = "index"
+	// ProgressByHash also records a hash of the words before the checkpoint
+	// and of the last completed word. When the wordlist changed before the
+	// checkpoint, the scan resumes after the last completed word, or fails
+	// when that word is gone.
+	ProgressByHash = "hash"
+)
+
+// ParseProgressMode validates a progress mode, defaulting to ProgressByIndex.
+func ParseProgressMode(v string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(v)); mode {
+	case "":
+		return ProgressByIndex, nil
+	case ProgressByIndex, ProgressByHash:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown progress mode %q (choose from index, hash)", v)
+	}
+}
+
+// wordlistHashSeed starts the rolling FNV-1a hash of a wordlist.
+const wordlistHashSeed = 14695981039346656037
+
+// rollWordlistHash extends the rolling hash h of the words read so far with
+// word.
+func rollWordlistHash(h uint64, word string) uint64 {
+	const prime = 1099511628211
+	for i := 0; i < len(word); i++ {
+		h ^= uint64(word[i])
+		h *= prime
+	}
+	h ^= '\n'
+	h *= prime
+	return h
+}
+
+func formatWordlistHash(h uint64) string {
+	return strconv.FormatUint(h, 16)
+}
+
+// verifyProgress checks, with ProgressByHash, that the words before the
+// checkpoint of st are unchanged, and moves the checkpoint after the last
+// completed word when they are not.
+func (r *stageRunner) verifyProgress(st stage) error {
+	state := r.progress.State()
+	if !r.progress.byHash || state.Stage != st.name || state.WordlistHash == "" {
+		return nil
+	}
+
+	file, err := openWordlist(st.wordlist, r.words, r.order, r.maxLine)
+	if err != nil {
+		return fmt.Errorf("verify progress: %w", err)
+	}
+	defer file.Close()
+
+	moved, err := r.progress.relocate(file, r.maxLine)
+	if err != nil {
+		return err
+	}
+	if moved {
+		r.log().Warn("wordlist changed since the progress checkpoint; resuming after the last completed word",
+			"stage", st.name, "word_index", state.WordIndex, "resumed_at", r.progress.State().WordIndex)
+	}
+	return nil
+}
+
+// relocate reads the wordlist of the checkpointed stage and reports whether
+// the checkpoint had to move because the words before it changed. It fails
+// when the last completed word is no longer in the wordlist.
+func (p *progressTracker) relocate(words io.Reader, maxLine int) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.state
+	// The last completed word is the one before the checkpoint, unless the
+	// checkpoint is between two payloads of the same word.
+	lastIndex := state.WordIndex
+	if state.VariantIndex == 0 {
+		lastIndex--
+	}
+
+	scanner := newWordlistScanner(words, maxLine)
+	prefix := uint64(wordlistHashSeed)
+	index := 0
+	found := -1
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" {
+			continue
+		}
+
+		if index == state.WordIndex && formatWordlistHash(prefix) == state.WordlistHash {
+			return false, nil
+		}
+		// Of several copies of the word, prefer the one nearest to where
+		// it was.
+		if formatWordlistHash(rollWordlistHash(wordlistHashSeed, word)) == state.LastWordHash &&
+			(found < 0 || abs(index-lastIndex) < abs(found-lastIndex)) {
+			found = index
+		}
+
+		prefix = rollWordlistHash(prefix, word)
+		index++
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("verify progress: read wordlist: %w", err)
+	}
+	if index == state.WordIndex && formatWordlistHash(prefix) == state.WordlistHash {
+		return false, nil
+	}
+
+	if found < 0 {
+		return false, fmt.Errorf("wordlist changed since the progress checkpoint and its last completed word is gone; delete %s to start the stage over", p.path)
+	}
+
+	// The word may have moved, but not its position among its payloads.
+	p.state.WordIndex = found + state.WordIndex - lastIndex
+	return true, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
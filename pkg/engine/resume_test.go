@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunResumesByHashAfterWordlistChange(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// The checkpoint of a scan of a, b, c, d that completed b.
+	prefix := rollWordlistHash(rollWordlistHash(wordlistHashSeed, "a"), "b")
+	checkpoint := fmt.Sprintf(`{"stage": "primary", "word_index": 2, "variant_index": 0, "wordlist_hash": %q, "last_word_hash": %q}`,
+		formatWordlistHash(prefix), formatWordlistHash(rollWordlistHash(wordlistHashSeed, "b")))
+
+	tests := []struct {
+		name     string
+		wordlist string
+		paths    string
+		err      string
+	}{
+		{name: "unchanged", wordlist: "a\nb\nc\nd\n", paths: "/c,/d"},
+		{name: "word inserted", wordlist: "new\na\nb\nc\nd\n", paths: "/c,/d"},
+		{name: "word removed", wordlist: "b\nc\nd\n", paths: "/c,/d"},
+		{name: "last word gone", wordlist: "a\nc\nd\n", err: "last completed word is gone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			paths = nil
+			mu.Unlock()
+			dir := t.TempDir()
+			wordlist := filepath.Join(dir, "words.txt")
+			writeFile(t, wordlist, tt.wordlist)
+			progress := filepath.Join(dir, "progress.json")
+			writeFile(t, progress, checkpoint)
+
+			results, err := Run(context.Background(), Config{
+				URL:          server.URL + "/FUZZ",
+				Wordlist:     wordlist,
+				Method:       http.MethodGet,
+				ProgressFile: progress,
+				ProgressMode: ProgressByHash,
+			})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			var runErr error
+			for res := range results {
+				if res.Err != nil {
+					runErr = res.Err
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.err != "" {
+				if runErr == nil || !strings.Contains(runErr.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, runErr)
+				}
+				if len(paths) != 0 {
+					t.Fatalf("expected no requests, got %v", paths)
+				}
+				return
+			}
+			if runErr != nil {
+				t.Fatalf("unexpected error: %v", runErr)
+			}
+			sort.Strings(paths)
+			if got := strings.Join(paths, ","); got != tt.paths {
+				t.Fatalf("expected requests %s, got %s", tt.paths, got)
+			}
+		})
+	}
+}
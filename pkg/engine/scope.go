@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"hydr0g3n/pkg/robots"
+)
+
+// ErrOutOfScope is reported for follow-up requests whose URL the Scope of
+// the run does not allow.
+var ErrOutOfScope = errors.New("URL is out of scope")
+
+// Scope limits the URLs a run may request. A URL is in scope when it
+// matches Include, if set, and does not match Exclude, if set. The
+// expressions are matched against the whole URL, after template expansion
+// and normalization. A nil Scope allows every URL.
+type Scope struct {
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+	// Blacklist, when set, takes URLs with a dangerous path segment out of
+	// scope as well.
+	Blacklist *Blacklist
+	// Robots, when set, takes URLs the robots.txt of their host disallows
+	// out of scope as well.
+	Robots *robots.Policy
+}
+
+// NewScope compiles the include and exclude expressions into a Scope. It
+// returns nil when both are empty.
+func NewScope(include, exclude string) (*Scope, error) {
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+
+	s := &Scope{}
+	var err error
+	if include != "" {
+		if s.Include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("scope include: %w", err)
+		}
+	}
+	if exclude != "" {
+		if s.Exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("scope exclude: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Allows reports whether rawURL is in scope.
+func (s *Scope) Allows(rawURL string) bool {
+	if s == nil {
+		return true
+	}
+	if s.Include != nil && !s.Include.MatchString(rawURL) {
+		return false
+	}
+	if s.Exclude != nil && s.Exclude.MatchString(rawURL) {
+		return false
+	}
+	if s.Blacklist.Blocks(rawURL) {
+		return false
+	}
+	return s.Robots.Allowed(rawURL)
+}
+
+// allowRedirect adapts Allows to httpclient.Options.AllowRedirect. It
+// returns nil for a nil Scope so redirects are not checked at all.
+func (s *Scope) allowRedirect() func(*url.URL) bool {
+	if s == nil {
+		return nil
+	}
+	return func(u *url.URL) bool {
+		return s.Allows(u.String())
+	}
+}
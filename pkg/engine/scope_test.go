@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScopeAllows(t *testing.T) {
+	scope, err := NewScope(`^https://app\.example\.com/`, `/logout`)
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://app.example.com/admin", true},
+		{"https://app.example.com/logout", false},
+		{"https://evil.example.com/admin", false},
+		{"http://app.example.com/admin", false},
+	}
+	for _, tt := range tests {
+		if got := scope.Allows(tt.url); got != tt.want {
+			t.Errorf("Allows(%q) = %t, want %t", tt.url, got, tt.want)
+		}
+	}
+
+	if scope, err := NewScope("", ""); err != nil || scope != nil || !scope.Allows("https://anything/") {
+		t.Fatalf("empty scope = %v, %v", scope, err)
+	}
+	if _, err := NewScope("(", ""); err == nil {
+		t.Fatal("NewScope accepted an invalid expression")
+	}
+}
+
+func TestRunKeepsRequestsInScope(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	outside := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, "outside "+r.URL.Path)
+		mu.Unlock()
+	}))
+	defer outside.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/app/jump" {
+			http.Redirect(w, r, outside.URL+"/landing", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\n../../other\nlogout\njump\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	scope, err := NewScope("^"+server.URL+"/app/", "logout")
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	cfg := Config{
+		URL:             server.URL + "/app/FUZZ",
+		Wordlist:        wordlistPath,
+		Concurrency:     1,
+		Timeout:         time.Second,
+		Method:          http.MethodGet,
+		FollowRedirects: true,
+		NormalizeURLs:   true,
+		Scope:           scope,
+	}
+	results, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var jump Result
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		if res.URL == server.URL+"/app/jump" {
+			jump = res
+		}
+	}
+	if jump.StatusCode != http.StatusFound {
+		t.Fatalf("redirect out of scope was followed: status %d", jump.StatusCode)
+	}
+
+	executor, err := NewFollowUpExecutor(cfg)
+	if err != nil {
+		t.Fatalf("NewFollowUpExecutor: %v", err)
+	}
+	res := executor.Execute(ctx, jump, FollowUp{URL: outside.URL + "/app/admin"})
+	if !errors.Is(res.Err, ErrOutOfScope) {
+		t.Fatalf("follow-up out of scope = %v, want ErrOutOfScope", res.Err)
+	}
+
+	sort.Strings(requested)
+	want := []string{"/app/admin", "/app/jump"}
+	if !reflect.DeepEqual(requested, want) {
+		t.Fatalf("requested = %q, want %q", requested, want)
+	}
+}
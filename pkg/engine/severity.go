@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Severities rate how interesting a hit is, from least to most.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// severities lists the severities in increasing order.
+var severities = []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity validates a severity name, ignoring case.
+func ParseSeverity(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if SeverityRank(value) < 0 {
+		return "", fmt.Errorf("unknown severity %q (choose from %s)", value, strings.Join(severities, ", "))
+	}
+	return value, nil
+}
+
+// SeverityRank returns the position of severity in increasing order, or -1
+// for an unknown severity.
+func SeverityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// pathKeywords rate paths by the words they contain. The first keyword
+// found in the lowercased path decides, so more severe keywords come first.
+var pathKeywords = []struct {
+	keyword  string
+	severity string
+}{
+	{".git", SeverityCritical},
+	{".env", SeverityCritical},
+	{".svn", SeverityCritical},
+	{".hg", SeverityCritical},
+	{".htpasswd", SeverityCritical},
+	{"id_rsa", SeverityCritical},
+	{".aws", SeverityCritical},
+	{"wp-config", SeverityCritical},
+	{"credentials", SeverityCritical},
+	{"backup", SeverityHigh},
+	{".bak", SeverityHigh},
+	{".old", SeverityHigh},
+	{".sql", SeverityHigh},
+	{"dump", SeverityHigh},
+	{".zip", SeverityHigh},
+	{".tar", SeverityHigh},
+	{".gz", SeverityHigh},
+	{"phpinfo", SeverityHigh},
+	{"actuator", SeverityHigh},
+	{"server-status", SeverityHigh},
+	{"debug", SeverityHigh},
+	{"config", SeverityMedium},
+	{"admin", SeverityMedium},
+	{"console", SeverityMedium},
+	{"dashboard", SeverityMedium},
+	{"manager", SeverityMedium},
+	{"internal", SeverityMedium},
+	{"private", SeverityMedium},
+	{"swagger", SeverityMedium},
+	{"api-docs", SeverityMedium},
+}
+
+// bodyIndicators rate bodies that leak secrets or internals.
+var bodyIndicators = []struct {
+	pattern  *regexp.Regexp
+	severity string
+}{
+	{regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`), SeverityCritical},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), SeverityCritical},
+	{regexp.MustCompile(`(?i)aws_secret_access_key|db_password\s*=`), SeverityCritical},
+	{regexp.MustCompile(`(?i)\bpass(?:word|wd)\s*[=:]\s*['"]?[^\s'"<]{3,}`), SeverityHigh},
+	{regexp.MustCompile(`Traceback \(most recent call last\)|Exception in thread "|\tat [a-z]+(?:\.[A-Za-z_$]+)+\(|Fatal error: |Stack trace:|goroutine \d+ \[running\]`), SeverityMedium},
+}
+
+// ScoreSeverity rates res by its status class, the keywords of its path,
+// what its body leaks, and the directory listing or GraphQL introspection
+// found on it. Keywords only count in full for successful responses; a
+// path that answered 401 or 403 is rated low at most.
+func ScoreSeverity(res Result) string {
+	if res.Err != nil {
+		return ""
+	}
+
+	severity := SeverityInfo
+	raise := func(s string) {
+		if SeverityRank(s) > SeverityRank(severity) {
+			severity = s
+		}
+	}
+
+	success := res.StatusCode >= 200 && res.StatusCode < 300
+	if success || res.StatusCode >= 500 || res.StatusCode == 401 || res.StatusCode == 403 {
+		raise(SeverityLow)
+	}
+
+	if success {
+		path := strings.ToLower(res.URL)
+		if u, err := url.Parse(res.URL); err == nil {
+			path = strings.ToLower(u.Path)
+		}
+		for _, k := range pathKeywords {
+			if strings.Contains(path, k.keyword) {
+				raise(k.severity)
+				break
+			}
+		}
+	}
+
+	if len(res.Body) > 0 && (success || res.StatusCode >= 500) {
+		for _, indicator := range bodyIndicators {
+			if indicator.pattern.Match(res.Body) {
+				raise(indicator.severity)
+			}
+		}
+	}
+
+	if res.Listing != "" {
+		raise(ListingSeverity)
+	}
+	if res.GraphQL != nil && res.GraphQL.Introspection {
+		raise(IntrospectionSeverity)
+	}
+	return severity
+}
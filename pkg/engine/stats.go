@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Stats counts the responses of a scan by status code, its failed requests
+// by error type, and the requests of each worker, so a caller can tell
+// whether errors or slow responses limited throughput, and notes when the
+// target started throttling the scan. The zero value is ready to use; pass it
+// as Config.Stats and read it with Snapshot while the scan runs or after it
+// ends.
+type Stats struct {
+	mu       sync.Mutex
+	first    time.Time
+	last     time.Time
+	requests int64
+	statuses map[int]int64
+	errors   map[string]int64
+	workers  []workerCounts
+	limits   rateWatch
+}
+
+type workerCounts struct {
+	requests int64
+	errors   int64
+	busy     time.Duration
+}
+
+// StatsSnapshot is a copy of the counters of a Stats.
+type StatsSnapshot struct {
+	Requests   int64            `json:"requests"`
+	Statuses   map[int]int64    `json:"statuses,omitempty"`
+	ErrorTypes map[string]int64 `json:"error_types,omitempty"`
+	Workers    []WorkerStats    `json:"workers,omitempty"`
+	RateLimit  *RateLimitReport `json:"rate_limit,omitempty"`
+}
+
+// WorkerStats describes the requests sent by one worker. Workers are numbered
+// the same in every stage, so the counts of a worker span all stages.
+type WorkerStats struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+	// BusyMS is the time the worker spent on requests and
+	// RequestsPerSecond its throughput over the whole scan.
+	BusyMS            float64 `json:"busy_ms"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+}
+
+// record counts res, sent by worker.
+func (s *Stats) record(worker int, res Result) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if start := now.Add(-res.Duration); s.first.IsZero() || start.Before(s.first) {
+		s.first = start
+	}
+	s.last = now
+	s.requests++
+
+	for len(s.workers) <= worker {
+		s.workers = append(s.workers, workerCounts{})
+	}
+	w := &s.workers[worker]
+	w.requests++
+	w.busy += res.Duration
+	s.limits.observe(now, res)
+
+	if res.Err != nil {
+		w.errors++
+		if s.errors == nil {
+			s.errors = make(map[string]int64)
+		}
+		s.errors[errorType(res.Err)]++
+		return
+	}
+
+	if s.statuses == nil {
+		s.statuses = make(map[int]int64)
+	}
+	s.statuses[res.StatusCode]++
+}
+
+// Snapshot returns the counters recorded so far.
+func (s *Stats) Snapshot() StatsSnapshot {
+	if s == nil {
+		return StatsSnapshot{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := StatsSnapshot{Requests: s.requests, RateLimit: s.limits.report()}
+	if len(s.statuses) > 0 {
+		snapshot.Statuses = make(map[int]int64, len(s.statuses))
+		for code, n := range s.statuses {
+			snapshot.Statuses[code] = n
+		}
+	}
+	if len(s.errors) > 0 {
+		snapshot.ErrorTypes = make(map[string]int64, len(s.errors))
+		for kind, n := range s.errors {
+			snapshot.ErrorTypes[kind] = n
+		}
+	}
+
+	elapsed := s.last.Sub(s.first).Seconds()
+	for _, w := range s.workers {
+		stats := WorkerStats{
+			Requests: w.requests,
+			Errors:   w.errors,
+			BusyMS:   float64(w.busy) / float64(time.Millisecond),
+		}
+		if elapsed > 0 {
+			stats.RequestsPerSecond = float64(w.requests) / elapsed
+		}
+		snapshot.Workers = append(snapshot.Workers, stats)
+	}
+
+	return snapshot
+}
+
+// errorType names the kind of failure of a request for Stats.
+func errorType(err error) string {
+	var (
+		dnsErr      *net.DNSError
+		certErr     *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostErr     x509.HostnameError
+		recordErr   tls.RecordHeaderError
+		opErr       *net.OpError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err):
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection_reset"
+	case errors.As(err, &certErr) || errors.As(err, &unknownAuth) ||
+		errors.As(err, &hostErr) || errors.As(err, &recordErr):
+		return "tls"
+	case errors.As(err, &opErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestRunCollectsStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin":
+			w.WriteHeader(http.StatusOK)
+		case "/drop":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	stats := &Stats{}
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Words:       SliceWordlist([]string{"admin", "missing", "other", "drop"}),
+		Method:      http.MethodGet,
+		Concurrency: 2,
+		Stats:       stats,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for range results {
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Requests != 4 {
+		t.Fatalf("expected 4 requests, got %d", snapshot.Requests)
+	}
+	if want := map[int]int64{200: 1, 404: 2}; !reflect.DeepEqual(snapshot.Statuses, want) {
+		t.Fatalf("expected statuses %v, got %v", want, snapshot.Statuses)
+	}
+	if want := map[string]int64{"connection_reset": 1}; !reflect.DeepEqual(snapshot.ErrorTypes, want) {
+		t.Fatalf("expected error types %v, got %v", want, snapshot.ErrorTypes)
+	}
+
+	var requests, errs int64
+	for _, w := range snapshot.Workers {
+		requests += w.Requests
+		errs += w.Errors
+	}
+	if len(snapshot.Workers) == 0 || len(snapshot.Workers) > 2 || requests != 4 || errs != 1 {
+		t.Fatalf("expected the workers to account for 4 requests and 1 error, got %+v", snapshot.Workers)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{fmt.Errorf("get: %w", context.Canceled), "canceled"},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, "dns"},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, "connection_refused"},
+		{&net.OpError{Op: "read", Err: syscall.ECONNRESET}, "connection_reset"},
+		{x509.UnknownAuthorityError{}, "tls"},
+		{&net.OpError{Op: "dial", Err: errors.New("no route")}, "network"},
+		{errors.New("boom"), "other"},
+	}
+
+	for _, tt := range tests {
+		if got := errorType(tt.err); got != tt.want {
+			t.Fatalf("errorType(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
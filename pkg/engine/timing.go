@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing breaks the duration of a request down into its phases. Phases that
+// did not happen, such as DNS and connect on a reused connection, are zero.
+// When redirects are followed the DNS, connect, and TLS phases of every hop
+// are added up.
+type Timing struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// TTFB is the time from sending the request until the first byte of the
+	// final response arrived.
+	TTFB       time.Duration
+	ConnReused bool
+}
+
+// timingTrace records the phases of one request through httptrace. The hooks
+// may run on transport goroutines, so access is guarded by mu.
+type timingTrace struct {
+	mu         sync.Mutex
+	start      time.Time
+	timing     Timing
+	remoteAddr net.Addr
+
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+// traceTiming returns a context that records the phases of a request started
+// now, and the trace to read them from.
+func traceTiming(ctx context.Context) (context.Context, *timingTrace) {
+	t := &timingTrace{start: time.Now()}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timing.DNS += since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			t.connectStart = time.Now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			t.timing.Connect += since(t.connectStart)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timing.TLSHandshake += since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timing.ConnReused = info.Reused
+			if info.Conn != nil {
+				t.remoteAddr = info.Conn.RemoteAddr()
+			}
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timing.TTFB = time.Since(t.start)
+			t.mu.Unlock()
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// Timing returns the phases recorded so far.
+func (t *timingTrace) Timing() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timing
+}
+
+// RemoteIP returns the IP address of the last connection the request used, or
+// "" when none was made.
+func (t *timingTrace) RemoteIP() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.remoteAddr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(t.remoteAddr.String())
+	if err != nil {
+		return t.remoteAddr.String()
+	}
+	return host
+}
+
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
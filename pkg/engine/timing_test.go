@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/httpclient"
+)
+
+func TestExecuteRequestRecordsTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := httpclient.New(2*time.Second, false)
+
+	first := executeRequest(context.Background(), client, server.URL, time.Second, http.MethodGet, nil, nil, 0)
+	if first.Err != nil {
+		t.Fatalf("first request: %v", first.Err)
+	}
+	if first.Timing.Connect <= 0 || first.Timing.TLSHandshake != 0 || first.Timing.ConnReused {
+		t.Fatalf("expected a new plain connection, got %+v", first.Timing)
+	}
+	if first.Timing.TTFB < 20*time.Millisecond || first.Timing.TTFB > first.Duration {
+		t.Fatalf("expected TTFB between the handler delay and the duration, got %+v (duration %s)", first.Timing, first.Duration)
+	}
+
+	second := executeRequest(context.Background(), client, server.URL, time.Second, http.MethodGet, nil, nil, 0)
+	if second.Err != nil {
+		t.Fatalf("second request: %v", second.Err)
+	}
+	if !second.Timing.ConnReused || second.Timing.Connect != 0 {
+		t.Fatalf("expected the second request to reuse the connection, got %+v", second.Timing)
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMaxWordlistLine is the longest wordlist line, in bytes, read when
+// Config.MaxWordlistLine is zero.
+const DefaultMaxWordlistLine = 1024 * 1024
+
+// WordlistProvider supplies the words of a scan without a wordlist file.
+type WordlistProvider interface {
+	// OpenWordlist returns the words, one per line. It is called once per
+	// target scanned and once by Plan.
+	OpenWordlist() (io.ReadCloser, error)
+}
+
+// errWordlistConsumed is returned when a single-use provider is opened again.
+var errWordlistConsumed = errors.New("wordlist provider can only be read once")
+
+// ReaderWordlist returns a provider that streams the lines of r. r can be read
+// only once, so the provider serves a single target and cannot be combined
+// with Plan.
+func ReaderWordlist(r io.Reader) WordlistProvider {
+	return &readerWordlist{r: r}
+}
+
+type readerWordlist struct {
+	r    io.Reader
+	used atomic.Bool
+}
+
+func (p *readerWordlist) OpenWordlist() (io.ReadCloser, error) {
+	if p.used.Swap(true) {
+		return nil, errWordlistConsumed
+	}
+	return io.NopCloser(p.r), nil
+}
+
+// SliceWordlist returns a provider serving words. It can be opened any number
+// of times.
+func SliceWordlist(words []string) WordlistProvider {
+	return sliceWordlist(words)
+}
+
+type sliceWordlist []string
+
+func (p sliceWordlist) OpenWordlist() (io.ReadCloser, error) {
+	var b strings.Builder
+	for _, word := range p {
+		b.WriteString(word)
+		b.WriteByte('\n')
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+// ChannelWordlist returns a provider that reads words from ch until it is
+// closed, so payloads can be generated while the scan runs. Like
+// ReaderWordlist it can be read only once.
+func ChannelWordlist(ch <-chan string) WordlistProvider {
+	return &channelWordlist{ch: ch}
+}
+
+type channelWordlist struct {
+	ch   <-chan string
+	used atomic.Bool
+}
+
+func (p *channelWordlist) OpenWordlist() (io.ReadCloser, error) {
+	if p.used.Swap(true) {
+		return nil, errWordlistConsumed
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		for word := range p.ch {
+			if _, err := io.WriteString(pw, word+"\n"); err != nil {
+				// The reader was closed because the scan stopped.
+				return
+			}
+		}
+		pw.Close()
+	}()
+	return pr, nil
+}
+
+// openWordlist opens the wordlist file at path, or the provider when path is
+// empty. Orders other than OrderAsIs read the whole wordlist, with lines of up
+// to maxLine bytes, before the first word is returned.
+func openWordlist(path string, provider WordlistProvider, order string, maxLine int) (io.ReadCloser, error) {
+	var (
+		file io.ReadCloser
+		err  error
+	)
+	if path == "" {
+		if provider == nil {
+			return nil, errors.New("wordlist path is required")
+		}
+		file, err = provider.OpenWordlist()
+	} else {
+		file, err = os.Open(path)
+		if err != nil {
+			err = fmt.Errorf("open wordlist: %w", err)
+		}
+	}
+	if err != nil || order == "" || order == OrderAsIs {
+		return file, err
+	}
+
+	defer file.Close()
+	ordered, err := orderWordlist(file, order, maxLine)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(ordered), nil
+}
+
+// wordlistScanner reads the lines of a wordlist and numbers them, so a line
+// longer than the buffer can be reported by its number.
+type wordlistScanner struct {
+	*bufio.Scanner
+	line    int
+	maxLine int
+}
+
+// newWordlistScanner returns a scanner for lines of up to maxLine bytes, or
+// DefaultMaxWordlistLine when maxLine is zero.
+func newWordlistScanner(r io.Reader, maxLine int) *wordlistScanner {
+	if maxLine <= 0 {
+		maxLine = DefaultMaxWordlistLine
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, maxLine)), maxLine)
+	return &wordlistScanner{Scanner: scanner, maxLine: maxLine}
+}
+
+func (s *wordlistScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		return false
+	}
+	s.line++
+	return true
+}
+
+// Err returns the error that stopped the scanner, naming the line that did not
+// fit in the buffer.
+func (s *wordlistScanner) Err() error {
+	err := s.Scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("line %d is longer than %d bytes", s.line+1, s.maxLine)
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunReadsWordsFromChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	words := make(chan string)
+	go func() {
+		defer close(words)
+		for _, word := range []string{"a", "b", "c"} {
+			words <- word
+		}
+	}()
+
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Words:       ChannelWordlist(words),
+		Method:      http.MethodGet,
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		seen[res.Word] = true
+	}
+	if len(seen) != 3 || !seen["a"] || !seen["b"] || !seen["c"] {
+		t.Fatalf("expected a result per word, got %v", seen)
+	}
+}
+
+func TestSingleUseWordlistsRejectSecondOpen(t *testing.T) {
+	providers := map[string]WordlistProvider{
+		"reader":  ReaderWordlist(strings.NewReader("a\n")),
+		"channel": ChannelWordlist(make(chan string)),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			first, err := provider.OpenWordlist()
+			if err != nil {
+				t.Fatalf("first open: %v", err)
+			}
+			first.Close()
+
+			if _, err := provider.OpenWordlist(); !errors.Is(err, errWordlistConsumed) {
+				t.Fatalf("expected errWordlistConsumed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSliceWordlistCanBeReopened(t *testing.T) {
+	provider := SliceWordlist([]string{"admin", "login"})
+
+	for i := 0; i < 2; i++ {
+		r, err := provider.OpenWordlist()
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(data) != "admin\nlogin\n" {
+			t.Fatalf("unexpected words: %q", data)
+		}
+	}
+}
+
+func TestRunReadsLongWordlistLines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// Longer than the 64KiB default of bufio.Scanner.
+	long := strings.Repeat("x", 100*1024)
+	words := []string{"a", long, "b"}
+
+	for _, tt := range []struct {
+		maxLine int
+		words   int
+		err     string
+	}{
+		{0, 3, ""},
+		{1024, 1, "line 2 is longer than 1024 bytes"},
+	} {
+		results, err := Run(context.Background(), Config{
+			URL:             server.URL + "/FUZZ",
+			Words:           SliceWordlist(words),
+			Method:          http.MethodGet,
+			Concurrency:     1,
+			MaxWordlistLine: tt.maxLine,
+		})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+
+		sent := 0
+		var runErr error
+		for res := range results {
+			if res.Err != nil {
+				runErr = res.Err
+				continue
+			}
+			sent++
+		}
+
+		if sent != tt.words {
+			t.Fatalf("max line %d: expected %d requests, got %d", tt.maxLine, tt.words, sent)
+		}
+		if tt.err == "" && runErr != nil {
+			t.Fatalf("max line %d: unexpected error: %v", tt.maxLine, runErr)
+		}
+		if tt.err != "" && (runErr == nil || !strings.Contains(runErr.Error(), tt.err)) {
+			t.Fatalf("max line %d: expected an error containing %q, got %v", tt.maxLine, tt.err, runErr)
+		}
+	}
+}
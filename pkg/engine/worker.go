@@ -1,17 +1,20 @@
 package engine
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -40,6 +43,80 @@ type Result struct {
 	Err            error
 	Similarity     float64
 	HasSimilarity  bool
+	// Word is the wordlist entry the request was built from and Payload the
+	// expanded payload substituted into the target.
+	Word    string
+	Payload string
+	// Verdict is the decision of Config.Matcher or Config.ClassifyResult when
+	// HasVerdict is set.
+	Verdict    bool
+	HasVerdict bool
+	// Confidence is the aggregated confidence of the verification plugins
+	// between 0 and 1, when HasConfidence is set.
+	Confidence    float64
+	HasConfidence bool
+	// Tags and Notes are annotations attached by verification plugins.
+	Tags  []string
+	Notes []string
+	// FollowUpOf is the URL of the result a FollowUpExecutor request verified.
+	FollowUpOf string
+	// Bypass is the technique of a Bypasses variant that retried the denied
+	// request at FollowUpOf.
+	Bypass string
+	// Listing names the server whose open directory listing the body is, as
+	// returned by DirectoryListing.
+	Listing string
+	// Technologies lists the software fingerprinted in the response, such
+	// as "nginx 1.18.0" or "WordPress".
+	Technologies []string
+	// GraphQL is what an introspection query sent to the endpoint revealed,
+	// when it was probed as a GraphQL endpoint.
+	GraphQL *GraphQLReport
+	// Screenshot is the path of the image captured of the URL for visual
+	// triage, if any.
+	Screenshot string
+	// Severity rates a hit as returned by ScoreSeverity. It is empty for
+	// results that are not hits.
+	Severity string
+	// MatchedRules names the rules a hit satisfied, such as "status" or
+	// "similarity". It is empty for results that are not hits.
+	MatchedRules []string
+	// Timing breaks Duration down into the phases of the request.
+	Timing Timing
+	// ContentEncoding is the Content-Encoding of the response. gzip and
+	// deflate bodies are decoded before Body is set: CompressedSize is then
+	// the size received and DecompressedSize the decoded size, up to the
+	// body limit. Other encodings, such as br, leave Body encoded and
+	// DecompressedSize zero.
+	ContentEncoding  string
+	CompressedSize   int64
+	DecompressedSize int64
+	// RedirectChain lists the redirect responses followed before the final
+	// response, oldest first. The final response is the one at RequestURL.
+	RedirectChain []RedirectHop
+	// RemoteIP is the address of the server that sent the response, which
+	// tells apart the servers of a host with several addresses. Through a
+	// proxy it is the proxy's address.
+	RemoteIP string
+	// BodySHA256 is the hex SHA-256 of the captured body, and BodyWords and
+	// BodyLines its whitespace-separated words and lines. They are computed
+	// while the body is read and kept when the body itself is discarded.
+	BodySHA256 string
+	BodyWords  int
+	BodyLines  int
+	// BodyTruncated is set when the body was longer than the captured
+	// part, so matching, hashing, and plugins only saw its start.
+	BodyTruncated bool
+	// ProbeStatus is the status of the HEAD request that was answered by
+	// sending this request with GET, as with Config.SmartMethod or after a
+	// 405 Method Not Allowed.
+	ProbeStatus int
+}
+
+// RedirectHop is one redirect response followed by a request.
+type RedirectHop struct {
+	URL        string
+	StatusCode int
 }
 
 // Config represents the parameters required to execute a fuzzing run.
@@ -53,11 +130,260 @@ type Config struct {
 	Beginner        bool
 	Quick           bool
 	BinaryName      string
-	RunRecorder     *store.Run
+	RunRecorder     store.Recorder
 	Method          string
 	FollowRedirects bool
 	PreHook         string
 	ProgressFile    string
+	Proxy           string
+	// ProgressMode selects how ProgressFile checkpoints are matched to the
+	// wordlist on resume: ProgressByIndex (the default) trusts the recorded
+	// positions, ProgressByHash also detects a changed wordlist.
+	ProgressMode string
+	// Throttle is the minimum delay between dispatched requests across all
+	// workers. Zero disables pacing.
+	Throttle time.Duration
+	// Jitter is the upper bound of a random delay added before each
+	// dispatched request, on top of Throttle, so requests do not arrive at a
+	// fixed rate. Zero disables it.
+	Jitter time.Duration
+	// SmartMethod sends HEAD requests and repeats with GET only those whose
+	// status suggests the path exists (2xx, 3xx, 401, or 403), so bodies are
+	// only downloaded where they matter. It applies when the method is HEAD.
+	SmartMethod bool
+	// DisableMethodFallback keeps HEAD responses with 405 Method Not
+	// Allowed. By default the request is repeated with GET, since many
+	// frameworks reject HEAD for paths that exist.
+	DisableMethodFallback bool
+	// SharedPool scans all Targets at once instead of one after the other.
+	// At most Concurrency requests are in flight across the targets, while
+	// each target keeps to its own Concurrency and Throttle.
+	SharedPool bool
+	// BreakerThreshold is the number of consecutive connection errors or
+	// timeouts after which requests to a host are paused for BreakerCooldown
+	// (default 30s). Zero disables the circuit breaker.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// AcceptEncoding is sent as the Accept-Encoding header of every request;
+	// empty means DefaultAcceptEncoding and "identity" asks for uncompressed
+	// responses.
+	AcceptEncoding string
+	// HostHeader, when set, is sent as the Host header of every request while
+	// connections still go to the host in the URL. A Host header of a target
+	// takes precedence.
+	HostHeader string
+	// Body is sent as the body of every request. BodyFile, used when Body is
+	// empty, names a file streamed from disk for every request instead.
+	Body     []byte
+	BodyFile string
+	// BuildRequest, when set, builds the parts of every request that carry
+	// its payload, to fuzz request bodies or headers. The URL is then
+	// expanded only when it holds a placeholder, and is requested once per
+	// payload request rather than once.
+	BuildRequest func(payload string) (PayloadRequest, error)
+	// Cookie is sent as the Cookie header of every request, followed by any
+	// cookies set by the pre-hook.
+	Cookie string
+	// DNSCache, when set, resolves target hostnames once per TTL instead of
+	// for every new connection.
+	DNSCache *httpclient.DNSCache
+	// DisableKeepAlives opens a new connection for every request, for
+	// targets that behave differently on reused connections.
+	DisableKeepAlives bool
+	// Network is "tcp4" or "tcp6" to connect to targets over IPv4 or IPv6
+	// only. Empty allows both.
+	Network string
+	// SNI, when set, is sent as the TLS server name of every connection
+	// instead of the host in the URL. It is independent of HostHeader.
+	SNI string
+	// TLSFingerprint, when set, sends the TLS ClientHello of a browser
+	// instead of Go's own; see httpclient.TLSFingerprints.
+	TLSFingerprint string
+	// Bandwidth, when set, caps the rate at which all workers together read
+	// response bodies.
+	Bandwidth *httpclient.BandwidthLimiter
+	// MaxBodySize is how many bytes of each response body are captured;
+	// zero means DefaultMaxBodySize. The rest of the body is read and
+	// discarded.
+	MaxBodySize int64
+	// DiscardBodies drops the Body of results ClassifyResult rejects,
+	// keeping BodySHA256, so large scans do not hold bodies that will not be
+	// used.
+	DiscardBodies bool
+	// Words, when set, supplies the wordlist of targets that have no Wordlist
+	// file. The quick stage is skipped for these targets.
+	Words WordlistProvider
+	// Order is the order in which the words of each wordlist are sent, one
+	// of OrderAsIs (the default), OrderAlpha, or OrderFrequency.
+	Order string
+	// MaxWordlistLine is the longest wordlist line read, in bytes; zero means
+	// DefaultMaxWordlistLine. A longer line stops the stage with an error
+	// naming it.
+	MaxWordlistLine int
+	// Attempted lists URLs completed by a previous run that should be skipped.
+	Attempted map[string]struct{}
+	// NormalizeURLs lowercases the scheme and host of every request URL and
+	// removes empty, "." and ".." path segments before it is sent. Either
+	// way a URL already requested by the run with the same method is not
+	// requested again.
+	NormalizeURLs bool
+	// Scope, when set, limits the URLs the run requests. URLs built from the
+	// wordlist, including those of recursive stages, are skipped when out
+	// of scope, redirects leaving the scope are not followed, and follow-up
+	// requests to URLs out of scope fail with ErrOutOfScope.
+	Scope *Scope
+	// Recursive scans the wordlist again inside every directory the scan
+	// finds, such as a hit redirecting to the same path with a trailing
+	// slash or an index listing, once the other stages of the target are
+	// done.
+	Recursive bool
+	// RecursionDepth is how many directories below the target a recursive
+	// scan goes; zero means DefaultRecursionDepth.
+	RecursionDepth int
+	// RecursionPayloads caps the requests sent inside each directory found
+	// by a recursive scan. Zero means no cap.
+	RecursionPayloads int
+	// Targets runs the scan against each entry in turn instead of URL. Fields
+	// left empty on a target fall back to the values above.
+	Targets []Target
+	// TransformPayload, when set, rewrites every expanded payload into zero or
+	// more payloads before request URLs are built. A word whose payloads fail
+	// to transform is skipped and the error is reported as a Result.
+	TransformPayload func(payload string) ([]string, error)
+	// Matcher, when set, is consulted by the workers as soon as the status
+	// line and headers of a response arrive. A response it rejects is emitted
+	// with a false Verdict and without reading the body, and is not passed to
+	// ClassifyResult.
+	Matcher Matcher
+	// ClassifyResult, when set, is called by the workers for every successful
+	// response to decide whether it is a hit. The decision is stored in
+	// Result.Verdict; an error is reported in Result.Err.
+	ClassifyResult func(ctx context.Context, res Result) (bool, error)
+	// Pauser, when set, lets the caller pause and resume the scan while it
+	// runs.
+	Pauser *Pauser
+	// Stats, when set, counts the responses and failed requests of the scan
+	// and the requests of each worker.
+	Stats *Stats
+	// OnStage, when set, is called as each stage of each target starts and
+	// ends. Calls are never concurrent, even with SharedPool.
+	OnStage func(StageEvent)
+	// Logger receives internal warnings, such as pre-hook output that was
+	// ignored or failed progress and store writes. Nil discards them.
+	Logger *slog.Logger
+}
+
+// Matcher decides whether a response is a hit. Match sees the result before
+// the body is read, so Body is always empty. It is called by several workers at
+// once and must be safe for concurrent use.
+type Matcher interface {
+	Match(res Result) bool
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(res Result) bool
+
+// Match calls f(res).
+func (f MatcherFunc) Match(res Result) bool {
+	return f(res)
+}
+
+// StageEvent reports a scan stage starting or ending for one target.
+type StageEvent struct {
+	Target string
+	Method string
+	// Stage is "quick" for the probe run with the small wordlist,
+	// "primary", or "recursive" for the directories found by a recursive
+	// scan.
+	Stage string
+	Done  bool
+}
+
+// Target describes one entry of a multi-target scan and the settings that
+// override the run configuration for it.
+type Target struct {
+	URL         string
+	Method      string
+	Wordlist    string
+	Concurrency int
+	Headers     http.Header
+	// Throttle is the minimum delay between the requests of this target,
+	// replacing Config.Throttle.
+	Throttle time.Duration
+	// Jitter replaces Config.Jitter for this target.
+	Jitter time.Duration
+	// Body, when set, is sent as the body of every request of this target
+	// instead of Config.Body.
+	Body []byte
+	// BuildRequest, when set, replaces Config.BuildRequest for this target.
+	BuildRequest func(payload string) (PayloadRequest, error)
+}
+
+// PayloadRequest is what BuildRequest adds to a request for its payload.
+type PayloadRequest struct {
+	// Body, when set, replaces the body of the run.
+	Body []byte
+	// Header is added to the headers of the run, replacing those of the same
+	// name.
+	Header http.Header
+	// Cookie is added to the cookies of the run.
+	Cookie string
+	// Key tells the request apart from other requests to the same URL, so
+	// that each is sent once. Empty uses Body, which suits bodies that are
+	// the same every time they are built for a payload.
+	Key string
+}
+
+// targets returns the targets to scan with the run defaults filled in. A
+// configuration without Targets yields a single target built from URL.
+func (cfg Config) targets() ([]Target, error) {
+	if len(cfg.Targets) == 0 {
+		if cfg.URL == "" {
+			return nil, errors.New("target URL is required")
+		}
+		if cfg.Wordlist == "" && cfg.Words == nil {
+			return nil, errors.New("wordlist path is required")
+		}
+		return []Target{{URL: cfg.URL, Wordlist: cfg.Wordlist, BuildRequest: cfg.BuildRequest}}, nil
+	}
+
+	resolved := make([]Target, 0, len(cfg.Targets))
+	for i, target := range cfg.Targets {
+		if strings.TrimSpace(target.URL) == "" {
+			return nil, fmt.Errorf("target %d: URL is required", i+1)
+		}
+		if target.Wordlist == "" {
+			target.Wordlist = cfg.Wordlist
+		}
+		if target.BuildRequest == nil {
+			target.BuildRequest = cfg.BuildRequest
+		}
+		if target.Wordlist == "" && cfg.Words == nil {
+			return nil, fmt.Errorf("target %s: wordlist path is required", target.URL)
+		}
+		resolved = append(resolved, target)
+	}
+
+	return resolved, nil
+}
+
+// expandPayloads returns the payloads generated for word, passed through
+// TransformPayload when it is set.
+func expandPayloads(tpl *templater.Templater, word string, transform func(string) ([]string, error)) ([]string, error) {
+	payloads := tpl.ExpandPayload(word)
+	if transform == nil {
+		return payloads, nil
+	}
+
+	transformed := make([]string, 0, len(payloads))
+	for _, payload := range payloads {
+		out, err := transform(payload)
+		if err != nil {
+			return nil, fmt.Errorf("transform payload %q: %w", payload, err)
+		}
+		transformed = append(transformed, out...)
+	}
+	return transformed, nil
 }
 
 // PlanSummary describes the permutations that would be executed for a given
@@ -67,19 +393,33 @@ type PlanSummary struct {
 	PrimaryPermutations int
 	TotalPermutations   int
 	Samples             []string
+	// Stages breaks the permutations down by target and stage, in scan
+	// order.
+	Stages []StagePlan
+}
+
+// StagePlan describes the permutations of one stage of one target. Samples
+// holds up to planSampleLimit of its URLs.
+type StagePlan struct {
+	Target       string   `json:"target"`
+	Stage        string   `json:"stage"`
+	Permutations int      `json:"permutations"`
+	Samples      []string `json:"samples,omitempty"`
 }
 
 const planSampleLimit = 10
 
 // Plan enumerates the permutations for the provided configuration and returns
-// a summary containing counts and representative samples.
+// a summary containing counts and representative samples. Permutations out of
+// the Scope of cfg are left out.
 func Plan(cfg Config) (*PlanSummary, error) {
-	if cfg.URL == "" {
-		return nil, errors.New("target URL is required")
+	targets, err := cfg.targets()
+	if err != nil {
+		return nil, err
 	}
 
-	if cfg.Wordlist == "" {
-		return nil, errors.New("wordlist path is required")
+	if cfg.Order, err = ParseOrder(cfg.Order); err != nil {
+		return nil, err
 	}
 
 	tpl := templater.New()
@@ -92,44 +432,48 @@ func Plan(cfg Config) (*PlanSummary, error) {
 
 	summary := &PlanSummary{}
 
-	quickEnabled := cfg.Quick || cfg.Beginner
-	quickWordlist := ""
-	if quickEnabled {
-		quickWordlist = locateQuickWordlist(cfg.Wordlist)
-		if quickWordlist == "" {
-			quickEnabled = false
-		}
-	}
-
-	if quickEnabled {
-		count, err := countWordlistPermutations(quickWordlist, cfg.URL, tpl, addSample)
-		if err != nil {
-			return nil, err
+	for _, target := range targets {
+		for _, st := range cfg.pipeline(target) {
+			// The directories a recursive scan finds are not known yet.
+			if st.branches {
+				continue
+			}
+			plan := StagePlan{Target: target.URL, Stage: st.name}
+			count, err := countWordlistPermutations(cfg, st.wordlist, target.URL, tpl, func(url string) {
+				addSample(url)
+				if len(plan.Samples) < planSampleLimit {
+					plan.Samples = append(plan.Samples, url)
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+			plan.Permutations = count
+			summary.Stages = append(summary.Stages, plan)
+
+			switch st.name {
+			case progressStageQuick:
+				summary.QuickPermutations += count
+			case progressStagePrimary:
+				summary.PrimaryPermutations += count
+			}
+			summary.TotalPermutations += count
 		}
-		summary.QuickPermutations = count
-		summary.TotalPermutations += count
 	}
 
-	primaryCount, err := countWordlistPermutations(cfg.Wordlist, cfg.URL, tpl, addSample)
-	if err != nil {
-		return nil, err
-	}
-
-	summary.PrimaryPermutations = primaryCount
-	summary.TotalPermutations += primaryCount
 	summary.Samples = samples
 
 	return summary, nil
 }
 
-func countWordlistPermutations(path, target string, tpl *templater.Templater, addSample func(string)) (int, error) {
-	file, err := os.Open(path)
+func countWordlistPermutations(cfg Config, path, target string, tpl *templater.Templater, addSample func(string)) (int, error) {
+	file, err := openWordlist(path, cfg.Words, cfg.Order, cfg.MaxWordlistLine)
 	if err != nil {
-		return 0, fmt.Errorf("open wordlist: %w", err)
+		return 0, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := newWordlistScanner(file, cfg.MaxWordlistLine)
 	total := 0
 
 	for scanner.Scan() {
@@ -138,11 +482,24 @@ func countWordlistPermutations(path, target string, tpl *templater.Templater, ad
 			continue
 		}
 
-		payloads := tpl.ExpandPayload(word)
+		payloads, err := expandPayloads(tpl, word, cfg.TransformPayload)
+		if err != nil {
+			return 0, err
+		}
 		for _, payload := range payloads {
+			url := tpl.Expand(target, payload)
+			if cfg.Scope != nil {
+				scoped := url
+				if cfg.NormalizeURLs {
+					scoped = normalizeURL(url)
+				}
+				if !cfg.Scope.Allows(scoped) {
+					continue
+				}
+			}
 			total++
 			if addSample != nil {
-				addSample(tpl.Expand(target, payload))
+				addSample(url)
 			}
 		}
 	}
@@ -164,12 +521,9 @@ const (
 // worker pool that performs concurrent HTTP requests using the configured method. The caller receives a
 // channel of Result entries and is responsible for consuming it until closed.
 func Run(ctx context.Context, cfg Config) (<-chan Result, error) {
-	if cfg.URL == "" {
-		return nil, errors.New("target URL is required")
-	}
-
-	if cfg.Wordlist == "" {
-		return nil, errors.New("wordlist path is required")
+	targets, err := cfg.targets()
+	if err != nil {
+		return nil, err
 	}
 
 	concurrency := cfg.Concurrency
@@ -182,10 +536,34 @@ func Run(ctx context.Context, cfg Config) (<-chan Result, error) {
 		timeout = 10 * time.Second
 	}
 
-	if file, err := os.Open(cfg.Wordlist); err != nil {
-		return nil, fmt.Errorf("open wordlist: %w", err)
-	} else {
-		file.Close()
+	order, err := ParseOrder(cfg.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range targets {
+		// Providers are opened only when their target is scanned, since
+		// some can be read just once.
+		if target.Wordlist == "" {
+			continue
+		}
+		if file, err := openWordlist(target.Wordlist, nil, OrderAsIs, 0); err != nil {
+			return nil, err
+		} else {
+			file.Close()
+		}
+	}
+
+	if cfg.BodyFile != "" && len(cfg.Body) == 0 {
+		if _, err := os.Stat(cfg.BodyFile); err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+	}
+
+	// The progress file records a single position in a single wordlist, so it
+	// cannot describe a scan that spans several targets.
+	if len(targets) > 1 && strings.TrimSpace(cfg.ProgressFile) != "" {
+		return nil, errors.New("progress file is not supported with multiple targets")
 	}
 
 	results := make(chan Result)
@@ -194,70 +572,318 @@ func Run(ctx context.Context, cfg Config) (<-chan Result, error) {
 		method = http.MethodHead
 	}
 
-	client := httpclient.New(timeout, cfg.FollowRedirects)
+	client, err := httpclient.NewWithOptions(httpclient.Options{
+		Timeout:           timeout,
+		FollowRedirects:   cfg.FollowRedirects,
+		Proxy:             cfg.Proxy,
+		DNSCache:          cfg.DNSCache,
+		DisableKeepAlives: cfg.DisableKeepAlives,
+		Network:           cfg.Network,
+		SNI:               cfg.SNI,
+		TLSFingerprint:    cfg.TLSFingerprint,
+		Bandwidth:         cfg.Bandwidth,
+		AllowRedirect:     cfg.Scope.allowRedirect(),
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	tpl := templater.New()
+	hostBreaker := newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
 
 	runRecorder := cfg.RunRecorder
 
-	progressTracker, err := newProgressTracker(strings.TrimSpace(cfg.ProgressFile))
+	progressMode, err := ParseProgressMode(cfg.ProgressMode)
 	if err != nil {
 		return nil, err
 	}
 
-	quickEnabled := cfg.Quick || cfg.Beginner
-	quickWordlist := ""
-	if quickEnabled {
-		quickWordlist = locateQuickWordlist(cfg.Wordlist)
-		if quickWordlist == "" {
-			quickEnabled = false
-		}
+	progressTracker, err := newProgressTracker(strings.TrimSpace(cfg.ProgressFile), progressMode == ProgressByHash)
+	if err != nil {
+		return nil, err
 	}
 
-	requestOpts, err := runPreHook(ctx, cfg.PreHook)
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	requestOpts, err := runPreHook(ctx, cfg.PreHook, logger)
 	if err != nil {
 		return nil, err
 	}
 
+	acceptEncoding := strings.TrimSpace(cfg.AcceptEncoding)
+	if acceptEncoding == "" {
+		acceptEncoding = DefaultAcceptEncoding
+	}
+	// Setting Accept-Encoding stops the transport from decoding gzip on its
+	// own, so executeRequest sees the encoded size.
+	runOpts := &httpclient.RequestOptions{
+		Headers:  http.Header{"Accept-Encoding": {acceptEncoding}},
+		Body:     cfg.Body,
+		BodyFile: cfg.BodyFile,
+	}
+	if host := strings.TrimSpace(cfg.HostHeader); host != "" {
+		runOpts.Headers.Set("Host", host)
+	}
+	runOpts.Cookie = strings.TrimSpace(cfg.Cookie)
+	// Headers from the pre-hook take precedence over the run defaults.
+	if requestOpts != nil {
+		runOpts = withCookie(runOpts, requestOpts.Cookie)
+		runOpts = withHeaders(runOpts, requestOpts.Headers)
+	}
+	requestOpts = runOpts
+
 	go func() {
 		defer close(results)
+		defer func() {
+			if err := progressTracker.Flush(); err != nil {
+				logger.Warn("write progress failed", "path", progressTracker.path, "error", err)
+			}
+		}()
+
+		// URLs requested by any stage of any target, so that words expanding
+		// to the same URL are only requested once.
+		seen := &urlSet{}
+
+		var (
+			pool    chan struct{}
+			stageMu sync.Mutex
+		)
+		if cfg.SharedPool {
+			pool = make(chan struct{}, concurrency)
+		}
 
-		runner := stageRunner{
-			ctx:         ctx,
-			target:      cfg.URL,
-			concurrency: concurrency,
-			timeout:     timeout,
-			method:      method,
-			client:      client,
-			tpl:         tpl,
-			runRecorder: runRecorder,
-			results:     results,
-			requestOpts: requestOpts,
-			progress:    progressTracker,
-		}
-
-		if quickEnabled {
-			positive, err := runner.run(progressStageQuick, quickWordlist, progressStagePrimary, progressStageComplete)
-			if err != nil {
-				runner.emit(Result{Err: err})
-				return
+		// scan runs the stages of target and reports whether the run should
+		// go on to the next target.
+		scan := func(target Target) bool {
+			runner := stageRunner{
+				ctx:         ctx,
+				target:      target.URL,
+				concurrency: concurrency,
+				timeout:     timeout,
+				method:      method,
+				client:      client,
+				tpl:         tpl,
+				runRecorder: runRecorder,
+				results:     results,
+				requestOpts: withBody(withHeaders(requestOpts, target.Headers), target.Body),
+				build:       target.BuildRequest,
+				progress:    progressTracker,
+				attempted:   cfg.Attempted,
+				normalize:   cfg.NormalizeURLs,
+				seen:        seen,
+				scope:       cfg.Scope,
+				throttle:    cfg.Throttle,
+				jitter:      cfg.Jitter,
+				pool:        pool,
+				smart:       cfg.SmartMethod,
+				fallback:    !cfg.DisableMethodFallback,
+				transform:   cfg.TransformPayload,
+				match:       cfg.Matcher,
+				classify:    cfg.ClassifyResult,
+				pauser:      cfg.Pauser,
+				stats:       cfg.Stats,
+				breaker:     hostBreaker,
+				words:       cfg.Words,
+				order:       order,
+				maxLine:     cfg.MaxWordlistLine,
+				logger:      logger,
+				maxBody:     cfg.MaxBodySize,
+				dropBodies:  cfg.DiscardBodies,
+			}
+			if target.Method != "" {
+				runner.method = strings.ToUpper(target.Method)
+			}
+			if target.Concurrency > 0 {
+				runner.concurrency = target.Concurrency
+			}
+			if target.Throttle > 0 {
+				runner.throttle = target.Throttle
+			}
+			if target.Jitter > 0 {
+				runner.jitter = target.Jitter
+			}
+			if cfg.Recursive {
+				runner.branches = newBranchQueue(progressTracker.State().Branches)
+				progressTracker.useBranches(runner.branches)
+				runner.maxDepth = cfg.RecursionDepth
+				if runner.maxDepth <= 0 {
+					runner.maxDepth = DefaultRecursionDepth
+				}
+				runner.branchLimit = cfg.RecursionPayloads
 			}
 
-			if !positive {
-				return
+			notify := func(name string, done bool) {
+				if cfg.OnStage != nil {
+					stageMu.Lock()
+					defer stageMu.Unlock()
+					cfg.OnStage(StageEvent{Target: target.URL, Method: runner.method, Stage: name, Done: done})
+				}
+			}
+
+			stages := cfg.pipeline(target)
+			progressTracker.usePipeline(stages)
+
+			for name := stages[0].name; name != progressStageComplete; {
+				st, ok := stages.stage(name)
+				if !ok {
+					runner.emit(Result{Err: fmt.Errorf("unknown scan stage %q", name)})
+					return false
+				}
+
+				notify(st.name, false)
+				positive, err := runner.run(st)
+				notify(st.name, true)
+				if err != nil {
+					runner.emit(Result{Err: err})
+					return false
+				}
+
+				name = st.onFailure
+				if positive {
+					name = st.onSuccess
+				}
 			}
+			return true
 		}
 
-		if _, err := runner.run(progressStagePrimary, cfg.Wordlist, progressStageComplete, progressStageComplete); err != nil {
-			runner.emit(Result{Err: err})
+		if cfg.SharedPool {
+			var wg sync.WaitGroup
+			for _, target := range targets {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					scan(target)
+				}()
+			}
+			wg.Wait()
+			return
+		}
+
+		for _, target := range targets {
+			if ctx.Err() != nil || !scan(target) {
+				return
+			}
 		}
 	}()
 
 	return results, nil
 }
 
-func executeRequest(ctx context.Context, client *httpclient.Client, url string, timeout time.Duration, method string, opts *httpclient.RequestOptions) Result {
+// withHeaders returns a copy of opts with headers added on top of any headers
+// opts already carries. opts is returned unchanged when headers is empty.
+func withHeaders(opts *httpclient.RequestOptions, headers http.Header) *httpclient.RequestOptions {
+	if len(headers) == 0 {
+		return opts
+	}
+
+	merged := &httpclient.RequestOptions{Headers: make(http.Header)}
+	if opts != nil {
+		merged.Cookie = opts.Cookie
+		merged.Body = opts.Body
+		merged.BodyFile = opts.BodyFile
+		for key, values := range opts.Headers {
+			merged.Headers[key] = append([]string(nil), values...)
+		}
+	}
+	for key, values := range headers {
+		merged.Headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	return merged
+}
+
+// withBody returns a copy of opts sending body instead of any body opts
+// carries. opts is returned unchanged when body is empty.
+func withBody(opts *httpclient.RequestOptions, body []byte) *httpclient.RequestOptions {
+	if len(body) == 0 {
+		return opts
+	}
+
+	merged := &httpclient.RequestOptions{Body: body}
+	if opts != nil {
+		merged.Cookie = opts.Cookie
+		merged.Headers = opts.Headers
+	}
+	return merged
+}
+
+// withCookie returns a copy of opts sending cookie after any cookies opts
+// carries. opts is returned unchanged when cookie is empty.
+func withCookie(opts *httpclient.RequestOptions, cookie string) *httpclient.RequestOptions {
+	if cookie == "" {
+		return opts
+	}
+
+	merged := &httpclient.RequestOptions{Cookie: cookie}
+	if opts != nil {
+		*merged = *opts
+		if opts.Cookie != "" {
+			merged.Cookie = opts.Cookie + "; " + cookie
+		} else {
+			merged.Cookie = cookie
+		}
+	}
+	return merged
+}
+
+// DefaultMaxBodySize is how many bytes of each response body are captured
+// when Config.MaxBodySize is zero.
+const DefaultMaxBodySize = 1024 * 1024
+
+// maxResponseHeaderBytes bounds the response headers kept on a Result.
+const maxResponseHeaderBytes = 64 * 1024
+
+// capHeader copies h, keeping values in order of their names until limit bytes
+// of names and values are used. The remaining values are dropped.
+func capHeader(h http.Header, limit int) http.Header {
+	if h == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	capped := make(http.Header, len(h))
+	used := 0
+	for _, name := range names {
+		for _, value := range h[name] {
+			used += len(name) + len(value)
+			if used > limit {
+				return capped
+			}
+			capped[name] = append(capped[name], value)
+		}
+	}
+	return capped
+}
+
+// redirectChain returns the redirect responses that led to req, oldest first.
+func redirectChain(req *http.Request) []RedirectHop {
+	var chain []RedirectHop
+	for r := req; r.Response != nil && r.Response.Request != nil; r = r.Response.Request {
+		hop := RedirectHop{StatusCode: r.Response.StatusCode}
+		if r.Response.Request.URL != nil {
+			hop.URL = r.Response.Request.URL.String()
+		}
+		chain = append(chain, hop)
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+func executeRequest(ctx context.Context, client *httpclient.Client, url string, timeout time.Duration, method string, opts *httpclient.RequestOptions, match Matcher, maxBody int64) Result {
 	result := Result{URL: url, RequestMethod: method, RequestURL: url}
+	if opts != nil {
+		// Reported even when the request fails; replaced by the headers
+		// actually sent once a response arrives.
+		result.RequestHeader = opts.Headers.Clone()
+	}
 
 	reqCtx := ctx
 	if timeout > 0 {
@@ -266,9 +892,12 @@ func executeRequest(ctx context.Context, client *httpclient.Client, url string,
 		defer cancel()
 	}
 
+	reqCtx, trace := traceTiming(reqCtx)
 	start := time.Now()
 	resp, err := client.Request(reqCtx, method, url, opts)
 	result.Duration = time.Since(start)
+	result.Timing = trace.Timing()
+	result.RemoteIP = trace.RemoteIP()
 	if err != nil {
 		result.Err = err
 		return result
@@ -279,7 +908,7 @@ func executeRequest(ctx context.Context, client *httpclient.Client, url string,
 	result.ContentLength = resp.ContentLength
 	result.ResponseProto = resp.Proto
 	result.ResponseStatus = resp.Status
-	result.ResponseHeader = resp.Header.Clone()
+	result.ResponseHeader = capHeader(resp.Header, maxResponseHeaderBytes)
 
 	if resp.Request != nil {
 		request := resp.Request
@@ -290,17 +919,57 @@ func executeRequest(ctx context.Context, client *httpclient.Client, url string,
 		result.RequestProto = request.Proto
 		result.RequestHost = request.Host
 		result.RequestHeader = request.Header.Clone()
+		result.RedirectChain = redirectChain(request)
 	}
 
-	const maxBodyBytes = 1024 * 1024
-	reader := io.LimitReader(resp.Body, maxBodyBytes)
+	if match != nil && !match.Match(result) {
+		result.Verdict = false
+		result.HasVerdict = true
+		// Drain a little so small bodies do not cost the connection.
+		_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+		return result
+	}
+
+	wire := &countingReader{r: resp.Body}
+	decoded, ok, err := decodeBody(wire, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodySize
+	}
+	digest := newBodyDigest()
+	reader := io.TeeReader(io.LimitReader(decoded, maxBody), digest)
 	body, err := io.ReadAll(reader)
 	if err != nil {
 		result.Err = err
 		return result
 	}
+	if int64(len(body)) == maxBody {
+		var next [1]byte
+		n, _ := io.ReadFull(decoded, next[:])
+		result.BodyTruncated = n > 0
+	}
 	_, _ = io.Copy(io.Discard, resp.Body)
 	result.Body = body
+	result.BodySHA256 = digest.SHA256()
+	result.BodyWords = digest.Words()
+	result.BodyLines = digest.Lines()
+	if result.StatusCode >= 200 && result.StatusCode < 300 {
+		result.Listing = DirectoryListing(body)
+	}
+
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+		result.ContentEncoding = encoding
+		result.CompressedSize = wire.n
+		if ok {
+			result.DecompressedSize = int64(len(body))
+			// Size matching applies to the decoded body.
+			result.ContentLength = result.DecompressedSize
+		}
+	}
 
 	return result
 }
@@ -313,75 +982,214 @@ type stageRunner struct {
 	method      string
 	client      *httpclient.Client
 	tpl         *templater.Templater
-	runRecorder *store.Run
+	runRecorder store.Recorder
 	results     chan<- Result
 	requestOpts *httpclient.RequestOptions
+	build       func(payload string) (PayloadRequest, error)
 	progress    *progressTracker
+	attempted   map[string]struct{}
+	normalize   bool
+	seen        *urlSet
+	scope       *Scope
+	throttle    time.Duration
+	jitter      time.Duration
+	pool        chan struct{}
+	smart       bool
+	fallback    bool
+	transform   func(string) ([]string, error)
+	match       Matcher
+	classify    func(context.Context, Result) (bool, error)
+	pauser      *Pauser
+	stats       *Stats
+	breaker     *breaker
+	branches    *branchQueue
+	depth       int
+	maxDepth    int
+	branchLimit int
+	limit       int
+	words       WordlistProvider
+	order       string
+	maxLine     int
+	logger      *slog.Logger
+	maxBody     int64
+	dropBodies  bool
+}
+
+// requested reports whether url was already requested with the method of
+// the stage by the run, marking it as requested otherwise.
+func (r *stageRunner) requested(url string) bool {
+	if r.seen == nil {
+		return false
+	}
+	return !r.seen.add(r.method + " " + url)
 }
 
-func (r *stageRunner) run(stage string, wordlistPath string, nextStageOnSuccess, nextStageOnFailure string) (bool, error) {
+// urlSet is a set of requests, as method and URL, shared by the targets of a
+// run.
+type urlSet struct {
+	mu   sync.Mutex
+	urls map[string]struct{}
+}
+
+// add adds url to the set and reports whether it was missing.
+func (s *urlSet) add(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.urls[url]; ok {
+		return false
+	}
+	if s.urls == nil {
+		s.urls = make(map[string]struct{})
+	}
+	s.urls[url] = struct{}{}
+	return true
+}
+
+// run scans the wordlist of st and reports whether any response satisfied its
+// success predicate. A stage the progress file records as completed is
+// skipped, reporting success when the previous run moved on to st.onSuccess.
+func (r *stageRunner) run(st stage) (bool, error) {
 	if r.progress != nil {
-		if err := r.progress.EnsureStage(stage); err != nil {
+		if err := r.progress.EnsureStage(st.name); err != nil {
 			return false, err
 		}
 
-		if r.progress.StageCompleted(stage) {
-			if stage == progressStageQuick {
-				state := r.progress.State()
-				if state.Stage == progressStagePrimary {
-					return true, nil
-				}
-			}
-			return false, nil
+		if r.progress.StageCompleted(st.name) {
+			return r.progress.Succeeded(st), nil
+		}
+
+		if err := r.verifyProgress(st); err != nil {
+			return false, err
 		}
 	}
 
-	file, err := os.Open(wordlistPath)
+	if st.branches {
+		return r.recurse(st)
+	}
+
+	file, err := openWordlist(st.wordlist, r.words, r.order, r.maxLine)
 	if err != nil {
-		return false, fmt.Errorf("open wordlist: %w", err)
+		return false, err
 	}
 	defer file.Close()
 
-	jobs := make(chan string)
+	jobs := make(chan job, r.queueSize())
+	pace := newPacer(r.throttle, r.jitter)
+	var window *progressWindow
+	if r.progress != nil {
+		window = newProgressWindow(r.updateProgress)
+	}
 	var wg sync.WaitGroup
 	var positive atomic.Bool
 
-	worker := func() {
+	worker := func(id int) {
 		defer wg.Done()
 
 		for {
 			select {
 			case <-r.ctx.Done():
 				return
-			case url, ok := <-jobs:
+			case j, ok := <-jobs:
 				if !ok {
 					return
 				}
+				url := j.url
 
-				res := executeRequest(r.ctx, r.client, url, r.timeout, r.method, r.requestOpts)
+				// A job queued before a pause must not be sent until the
+				// scan resumes.
+				if !r.pauser.wait(r.ctx) {
+					return
+				}
 
-				if res.Err == nil && isQuickPositive(res.StatusCode) {
+				send, ok := r.admit(j, pace)
+				if !ok {
+					return
+				}
+				if !send {
+					window.finish(j.seq, j.position, url)
+					continue
+				}
+
+				host := hostOf(url)
+				if !r.breaker.wait(r.ctx, host) {
+					return
+				}
+
+				res, ok := r.send(id, url, r.method, j.extra)
+				if !ok {
+					return
+				}
+				if r.method == http.MethodHead && (r.smart && escalates(res) || r.fallback && rejectsHead(res)) {
+					probe := res.StatusCode
+					if res, ok = r.send(id, url, http.MethodGet, j.extra); !ok {
+						return
+					}
+					res.ProbeStatus = probe
+				}
+				if err := r.breaker.record(host, res.Err); err != nil {
+					r.log().Warn("circuit breaker opened", "host", host, "error", res.Err)
+					if !r.emit(Result{URL: url, Err: err}) {
+						return
+					}
+				}
+				res.Word = j.word
+				res.Payload = j.payload
+				if r.classify != nil && res.Err == nil && !res.HasVerdict {
+					verdict, err := r.classify(r.ctx, res)
+					if err != nil {
+						r.log().Warn("classify response failed", "url", url, "error", err)
+						res.Err = fmt.Errorf("classify response: %w", err)
+					} else {
+						res.Verdict = verdict
+						res.HasVerdict = true
+					}
+				}
+				if r.dropBodies && res.HasVerdict && !res.Verdict {
+					res.Body = nil
+				}
+
+				if st.success != nil && st.success(res) {
 					positive.Store(true)
 				}
+				r.queueDirectory(res, j.payload)
+
+				if err := r.recordOutcome(j.key, res); err != nil {
+					r.log().Warn("store write failed", "url", url, "error", err)
+					if !r.emit(Result{URL: url, Err: err}) {
+						return
+					}
+				}
 
 				if !r.emit(res) {
 					return
 				}
+
+				window.finish(j.seq, j.position, url)
 			}
 		}
 	}
 
 	wg.Add(r.concurrency)
 	for i := 0; i < r.concurrency; i++ {
-		go worker()
+		go worker(i)
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := newWordlistScanner(file, r.maxLine)
 	stop := false
+	// capped is set once limit requests were sent; the stage then counts as
+	// completed.
+	capped := false
+	sent := 0
+	// seq numbers the positions of the stage for the progress window.
+	seq := 0
 	wordIndex := 0
+	// prefix hashes the words before wordIndex for hash checkpoints.
+	prefix := uint64(wordlistHashSeed)
+	hashes := r.progress != nil && r.progress.byHash
 
 	for scanner.Scan() {
-		if r.ctx.Err() != nil {
+		if r.ctx.Err() != nil || window.stopped() {
 			stop = true
 			break
 		}
@@ -391,76 +1199,115 @@ func (r *stageRunner) run(stage string, wordlistPath string, nextStageOnSuccess,
 			continue
 		}
 
-		payloads := r.tpl.ExpandPayload(word)
+		var prefixHash, nextPrefixHash, wordHash string
+		nextPrefix := rollWordlistHash(prefix, word)
+		if hashes {
+			prefixHash = formatWordlistHash(prefix)
+			nextPrefixHash = formatWordlistHash(nextPrefix)
+			wordHash = formatWordlistHash(rollWordlistHash(wordlistHashSeed, word))
+		}
+
+		payloads, err := expandPayloads(r.tpl, word, r.transform)
+		if err != nil {
+			if !r.emit(Result{Err: err}) {
+				stop = true
+				break
+			}
+			wordIndex++
+			prefix = nextPrefix
+			continue
+		}
+
 		for variantIndex, payload := range payloads {
-			if r.progress != nil && !r.progress.Allow(stage, wordIndex, variantIndex) {
+			if r.progress != nil && !r.progress.Allow(st.name, wordIndex, variantIndex) {
 				continue
 			}
+			if r.limit > 0 && sent >= r.limit {
+				capped = true
+				break
+			}
 
-			url := r.tpl.Expand(r.target, payload)
-
-			nextWord := wordIndex
-			nextVariant := variantIndex + 1
-			if nextVariant >= len(payloads) {
-				nextWord = wordIndex + 1
-				nextVariant = 0
+			url := r.target
+			if r.build == nil || r.tpl.HasPlaceholder(url) {
+				url = r.tpl.Expand(r.target, payload)
+			}
+			if r.normalize {
+				url = normalizeURL(url)
 			}
 
-			if r.runRecorder != nil {
-				inserted, err := r.runRecorder.MarkAttempt(r.ctx, url)
+			// Requests that differ only in what BuildRequest added are
+			// told apart by its key.
+			key := url
+			var extra *PayloadRequest
+			if r.build != nil {
+				built, err := r.build(payload)
 				if err != nil {
-					if !r.emit(Result{URL: url, Err: fmt.Errorf("record attempt: %w", err)}) {
+					if !r.emit(Result{URL: url, Word: word, Payload: payload, Err: fmt.Errorf("build request: %w", err)}) {
 						stop = true
 						break
 					}
 					continue
 				}
-
-				if !inserted {
-					if !r.updateProgress(stage, nextWord, nextVariant, url) {
-						stop = true
-						break
-					}
-					continue
+				extra = &built
+				if built.Key != "" {
+					key = url + " " + built.Key
+				} else {
+					key = url + " " + string(built.Body)
 				}
 			}
 
-			if !r.enqueue(jobs, url) {
-				stop = true
-				break
+			next := progressState{
+				Stage:        st.name,
+				WordIndex:    wordIndex,
+				VariantIndex: variantIndex + 1,
+				WordlistHash: prefixHash,
+				LastWordHash: wordHash,
+			}
+			if next.VariantIndex >= len(payloads) {
+				next.WordIndex++
+				next.VariantIndex = 0
+				next.WordlistHash = nextPrefixHash
+			}
+
+			seq++
+			if _, done := r.attempted[key]; done || !r.scope.Allows(url) || r.requested(key) {
+				window.finish(seq-1, next, url)
+				continue
 			}
 
-			if !r.updateProgress(stage, nextWord, nextVariant, url) {
+			if !r.enqueue(jobs, job{url: url, word: word, payload: payload, extra: extra, key: key, seq: seq - 1, position: next}) {
 				stop = true
 				break
 			}
+			sent++
 		}
 
-		if stop {
+		if stop || capped {
 			break
 		}
 
 		wordIndex++
+		prefix = nextPrefix
 	}
 
-	if err := scanner.Err(); err != nil && !stop {
+	if err := scanner.Err(); err != nil && !stop && !capped {
 		r.emit(Result{Err: fmt.Errorf("read wordlist: %w", err)})
 	}
 
 	close(jobs)
 	wg.Wait()
 
-	completed := !stop
+	completed := !stop && !window.stopped()
 	positiveResult := positive.Load()
 
 	if completed && r.progress != nil {
-		nextStage := nextStageOnFailure
+		nextStage := st.onFailure
 		if positiveResult {
-			nextStage = nextStageOnSuccess
+			nextStage = st.onSuccess
 		}
 
 		if nextStage != "" {
-			if err := r.progress.Set(nextStage, 0, 0); err != nil {
+			if err := r.progress.Set(progressState{Stage: nextStage}); err != nil {
 				return positiveResult, err
 			}
 		}
@@ -472,27 +1319,181 @@ func (r *stageRunner) run(stage string, wordlistPath string, nextStageOnSuccess,
 func (r *stageRunner) emit(res Result) bool {
 	select {
 	case <-r.ctx.Done():
+		if res.Err != nil {
+			r.log().Debug("dropped error after the scan stopped", "url", res.URL, "error", res.Err)
+		}
 		return false
 	case r.results <- res:
 		return true
 	}
 }
 
-func (r *stageRunner) enqueue(jobs chan<- string, url string) bool {
+// log returns the runner's logger, discarding output when none is set.
+func (r *stageRunner) log() *slog.Logger {
+	if r.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return r.logger
+}
+
+// job is a request handed from the wordlist reader to the workers.
+type job struct {
+	url     string
+	word    string
+	payload string
+	// extra is what the run's BuildRequest built for payload, nil when it
+	// has none.
+	extra *PayloadRequest
+	// key identifies the request among the attempts of the run: the URL,
+	// followed by the key of extra when there is one.
+	key string
+	// seq numbers the position of the job in the stage and position is the
+	// progress reached once it finished.
+	seq      int
+	position progressState
+}
+
+// queueSize returns how many jobs the wordlist reader may queue ahead of the
+// workers. A queue lets a worker that finishes a request take the next job
+// without waiting for the reader to be scheduled, which at high concurrency
+// otherwise serialises the workers on the hand-off. Queued jobs are paced and
+// recorded by the worker that sends them and only move the progress
+// checkpoint once finished, so the queue is kept with every option. The
+// workers share the one queue instead of each owning a shard of it, so a
+// worker that runs out of work never sits idle while jobs wait in the shard
+// of a worker stuck on a slow response; BenchmarkJobDispatch measures the
+// hand-off it saves.
+func (r *stageRunner) queueSize() int {
+	return r.concurrency
+}
+
+func (r *stageRunner) enqueue(jobs chan<- job, j job) bool {
+	if !r.pauser.wait(r.ctx) {
+		return false
+	}
+
 	select {
 	case <-r.ctx.Done():
 		return false
-	case jobs <- url:
+	case jobs <- j:
 		return true
 	}
 }
 
-func (r *stageRunner) updateProgress(stage string, wordIndex, variantIndex int, url string) bool {
+// jitterDelay returns a random delay in [0, max). It is a variable so tests
+// can make the delay predictable.
+var jitterDelay = func(max time.Duration) time.Duration {
+	return time.Duration(rand.Int64N(int64(max)))
+}
+
+// admit records the attempt of j and waits for its turn under pace. It
+// reports send false for a job a previous run already attempted, and ok
+// false when the scan stops first. The attempt is recorded within the turn so
+// that a job which was only queued when the scan stopped is not marked, and a
+// resumed scan sends it.
+func (r *stageRunner) admit(j job, pace *pacer) (send, ok bool) {
+	pace.lock()
+	defer pace.unlock()
+
+	if r.runRecorder != nil {
+		inserted, err := r.runRecorder.MarkAttempt(r.ctx, j.key)
+		if err != nil {
+			r.log().Warn("store write failed", "url", j.url, "error", err)
+			return false, r.emit(Result{URL: j.url, Err: fmt.Errorf("record attempt: %w", err)})
+		}
+		if !inserted {
+			return false, true
+		}
+	}
+
+	return true, pace.wait(r.ctx)
+}
+
+// send requests url with method and the payload request extra, if any,
+// counting it in the run statistics. It reports false when the scan stops
+// before the request is sent.
+func (r *stageRunner) send(worker int, url, method string, extra *PayloadRequest) (Result, bool) {
+	if !r.acquire() {
+		return Result{}, false
+	}
+	opts := r.requestOpts
+	if extra != nil {
+		opts = withCookie(withBody(withHeaders(opts, extra.Header), extra.Body), extra.Cookie)
+	}
+	res := executeRequest(r.ctx, r.client, url, r.timeout, method, opts, r.match, r.maxBody)
+	r.release()
+	r.stats.record(worker, res)
+	return res, true
+}
+
+// escalates reports whether the HEAD response res is worth repeating with GET
+// to read its body.
+func escalates(res Result) bool {
+	if res.Err != nil || (res.HasVerdict && !res.Verdict) {
+		return false
+	}
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 400:
+		return true
+	case res.StatusCode == http.StatusUnauthorized, res.StatusCode == http.StatusForbidden:
+		return true
+	default:
+		return false
+	}
+}
+
+// rejectsHead reports whether the HEAD response res refused the method rather
+// than answering for the path.
+func rejectsHead(res Result) bool {
+	return res.Err == nil && res.StatusCode == http.StatusMethodNotAllowed
+}
+
+// acquire takes a slot of the shared pool for a request, reporting false when
+// the scan stops first.
+func (r *stageRunner) acquire() bool {
+	if r.pool == nil {
+		return true
+	}
+	select {
+	case <-r.ctx.Done():
+		return false
+	case r.pool <- struct{}{}:
+		return true
+	}
+}
+
+// release returns the slot taken by acquire.
+func (r *stageRunner) release() {
+	if r.pool != nil {
+		<-r.pool
+	}
+}
+
+// recordOutcome stores the outcome of the attempt recorded under key.
+func (r *stageRunner) recordOutcome(key string, res Result) error {
+	if r.runRecorder == nil {
+		return nil
+	}
+
+	outcome := store.AttemptOutcome{StatusCode: res.StatusCode}
+	if res.Err != nil {
+		outcome.Error = res.Err.Error()
+	}
+
+	if err := r.runRecorder.RecordOutcome(r.ctx, key, outcome); err != nil {
+		return fmt.Errorf("record outcome: %w", err)
+	}
+
+	return nil
+}
+
+func (r *stageRunner) updateProgress(state progressState, url string) bool {
 	if r.progress == nil {
 		return true
 	}
 
-	if err := r.progress.Set(stage, wordIndex, variantIndex); err != nil {
+	if err := r.progress.Set(state); err != nil {
+		r.log().Warn("write progress failed", "path", r.progress.path, "stage", state.Stage, "error", err)
 		r.emit(Result{URL: url, Err: fmt.Errorf("write progress: %w", err)})
 		return false
 	}
@@ -504,22 +1505,49 @@ type progressState struct {
 	Stage        string `json:"stage"`
 	WordIndex    int    `json:"word_index"`
 	VariantIndex int    `json:"variant_index"`
+	// WordlistHash hashes the words before WordIndex and LastWordHash the
+	// word of the last completed payload. They are only written with
+	// ProgressByHash.
+	WordlistHash string `json:"wordlist_hash,omitempty"`
+	LastWordHash string `json:"last_word_hash,omitempty"`
+	// Branches lists the directories a recursive scan has yet to finish,
+	// the one in progress first.
+	Branches []branch `json:"branches,omitempty"`
 }
 
+// Progress checkpoints are written once this many positions have been set
+// or this much time has passed since the last write, whichever comes first,
+// so a scan that crashes replays at most progressCheckpointEvery requests.
+const (
+	progressCheckpointEvery    = 100
+	progressCheckpointInterval = time.Second
+)
+
 type progressTracker struct {
 	path     string
 	mu       sync.Mutex
 	state    progressState
 	hasState bool
+	// pending counts the positions set since the checkpoint was last
+	// written at lastWrite.
+	pending   int
+	lastWrite time.Time
+	// stages ranks the stage names of the checkpoint.
+	stages pipeline
+	// byHash keeps the hashes of checkpoints so a resumed stage can detect
+	// a changed wordlist.
+	byHash bool
+	// branches, when set, is written with every checkpoint.
+	branches *branchQueue
 }
 
-func newProgressTracker(path string) (*progressTracker, error) {
+func newProgressTracker(path string, byHash bool) (*progressTracker, error) {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return nil, nil
 	}
 
-	tracker := &progressTracker{path: path}
+	tracker := &progressTracker{path: path, lastWrite: time.Now(), byHash: byHash}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -542,6 +1570,17 @@ func newProgressTracker(path string) (*progressTracker, error) {
 	return tracker, nil
 }
 
+// usePipeline ranks the stages of later checkpoints by their order in stages.
+func (p *progressTracker) usePipeline(stages pipeline) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stages = stages
+}
+
 func (p *progressTracker) EnsureStage(stage string) error {
 	if p == nil {
 		return nil
@@ -550,7 +1589,7 @@ func (p *progressTracker) EnsureStage(stage string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.hasState && stageRank(stage) <= stageRank(p.state.Stage) {
+	if p.hasState && p.stages.rank(stage) <= p.stages.rank(p.state.Stage) {
 		return nil
 	}
 
@@ -560,6 +1599,18 @@ func (p *progressTracker) EnsureStage(stage string) error {
 	return p.writeLocked()
 }
 
+// useBranches writes the directories queued in branches with later
+// checkpoints.
+func (p *progressTracker) useBranches(branches *branchQueue) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.branches = branches
+}
+
 func (p *progressTracker) StageCompleted(stage string) bool {
 	if p == nil {
 		return false
@@ -572,7 +1623,21 @@ func (p *progressTracker) StageCompleted(stage string) bool {
 		return false
 	}
 
-	return stageRank(stage) < stageRank(p.state.Stage)
+	return p.stages.rank(stage) < p.stages.rank(p.state.Stage)
+}
+
+// Succeeded reports whether the checkpoint shows that the previous run moved
+// on from the completed stage st to st.onSuccess rather than st.onFailure.
+func (p *progressTracker) Succeeded(st stage) bool {
+	if p == nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stored := p.stages.rank(p.state.Stage)
+	return stored >= p.stages.rank(st.onSuccess) && stored < p.stages.rank(st.onFailure)
 }
 
 func (p *progressTracker) Allow(stage string, wordIndex, variantIndex int) bool {
@@ -587,8 +1652,8 @@ func (p *progressTracker) Allow(stage string, wordIndex, variantIndex int) bool
 		return true
 	}
 
-	currentStage := stageRank(stage)
-	storedStage := stageRank(p.state.Stage)
+	currentStage := p.stages.rank(stage)
+	storedStage := p.stages.rank(p.state.Stage)
 
 	if currentStage < storedStage {
 		return false
@@ -607,7 +1672,7 @@ func (p *progressTracker) Allow(stage string, wordIndex, variantIndex int) bool
 	return variantIndex >= p.state.VariantIndex
 }
 
-func (p *progressTracker) Set(stage string, wordIndex, variantIndex int) error {
+func (p *progressTracker) Set(state progressState) error {
 	if p == nil {
 		return nil
 	}
@@ -615,13 +1680,34 @@ func (p *progressTracker) Set(stage string, wordIndex, variantIndex int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.state = progressState{
-		Stage:        stage,
-		WordIndex:    wordIndex,
-		VariantIndex: variantIndex,
+	if !p.byHash {
+		state.WordlistHash, state.LastWordHash = "", ""
 	}
+
+	stageChanged := !p.hasState || p.state.Stage != state.Stage
+	p.state = state
 	p.hasState = true
+	p.pending++
 
+	if !stageChanged && p.pending < progressCheckpointEvery && time.Since(p.lastWrite) < progressCheckpointInterval {
+		return nil
+	}
+	return p.writeLocked()
+}
+
+// Flush writes the checkpoint if positions were set since it was last
+// written.
+func (p *progressTracker) Flush() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == 0 {
+		return nil
+	}
 	return p.writeLocked()
 }
 
@@ -651,9 +1737,12 @@ func (p *progressTracker) writeLocked() error {
 		return fmt.Errorf("create progress temp file: %w", err)
 	}
 
+	state := p.state
+	state.Branches = p.branches.snapshot()
+
 	encoder := json.NewEncoder(tmp)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(p.state); err != nil {
+	if err := encoder.Encode(state); err != nil {
 		tmp.Close()
 		os.Remove(tmp.Name())
 		return fmt.Errorf("encode progress checkpoint: %w", err)
@@ -669,6 +1758,8 @@ func (p *progressTracker) writeLocked() error {
 		return fmt.Errorf("replace progress file: %w", err)
 	}
 
+	p.pending = 0
+	p.lastWrite = time.Now()
 	return nil
 }
 
@@ -685,25 +1776,12 @@ func ensureProgressDir(path string) error {
 	return nil
 }
 
-func stageRank(stage string) int {
-	switch stage {
-	case progressStageQuick:
-		return 0
-	case progressStagePrimary:
-		return 1
-	case progressStageComplete:
-		return 2
-	default:
-		return -1
-	}
-}
-
 type preHookResponse struct {
 	Cookie  string            `json:"cookie"`
 	Headers map[string]string `json:"headers"`
 }
 
-func runPreHook(ctx context.Context, command string) (*httpclient.RequestOptions, error) {
+func runPreHook(ctx context.Context, command string, logger *slog.Logger) (*httpclient.RequestOptions, error) {
 	if strings.TrimSpace(command) == "" {
 		return nil, nil
 	}
@@ -737,6 +1815,7 @@ func runPreHook(ctx context.Context, command string) (*httpclient.RequestOptions
 		headers := make(http.Header, len(parsed.Headers))
 		for key, value := range parsed.Headers {
 			if strings.TrimSpace(key) == "" {
+				logger.Warn("pre-hook returned a header without a name", "value", value)
 				continue
 			}
 			headers.Set(key, value)
@@ -745,6 +1824,7 @@ func runPreHook(ctx context.Context, command string) (*httpclient.RequestOptions
 	}
 
 	if opts.Cookie == "" && len(opts.Headers) == 0 {
+		logger.Warn("pre-hook output sets no cookie or headers", "output", output)
 		return nil, nil
 	}
 
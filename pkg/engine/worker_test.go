@@ -1,11 +1,21 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -26,6 +36,64 @@ func TestRunValidatesConfig(t *testing.T) {
 	}
 }
 
+func TestStageRunnerJitterDelaysEachRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("a\nb\nc\nd\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	const jitter = 40 * time.Millisecond
+	var draws atomic.Int32
+	previous := jitterDelay
+	jitterDelay = func(max time.Duration) time.Duration {
+		if max != jitter {
+			t.Errorf("expected jitter bound %v, got %v", jitter, max)
+		}
+		draws.Add(1)
+		return max
+	}
+	defer func() { jitterDelay = previous }()
+
+	resultsCh := make(chan Result, 8)
+	runner := stageRunner{
+		ctx:         ctx,
+		target:      server.URL + "/FUZZ",
+		concurrency: 4,
+		timeout:     time.Second,
+		method:      http.MethodGet,
+		client:      httpclient.New(2*time.Second, false),
+		tpl:         templater.New(),
+		results:     resultsCh,
+		jitter:      jitter,
+	}
+
+	start := time.Now()
+	if _, err := runner.run(stage{
+		name:      progressStagePrimary,
+		wordlist:  wordlistPath,
+		onSuccess: progressStageComplete,
+		onFailure: progressStageComplete,
+	}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if got := draws.Load(); got != 4 {
+		t.Fatalf("expected a jitter delay per request, got %d", got)
+	}
+	if elapsed < 4*jitter {
+		t.Fatalf("expected jitter to spread 4 requests over at least %v, took %v", 4*jitter, elapsed)
+	}
+}
+
 func TestStageRunnerRunEmitsResults(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -33,6 +101,7 @@ func TestStageRunnerRunEmitsResults(t *testing.T) {
 	var hits int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-Test", "1")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	}))
@@ -57,7 +126,13 @@ func TestStageRunnerRunEmitsResults(t *testing.T) {
 		results:     resultsCh,
 	}
 
-	positive, err := runner.run(progressStagePrimary, wordlistPath, progressStageComplete, progressStageComplete)
+	positive, err := runner.run(stage{
+		name:      progressStagePrimary,
+		wordlist:  wordlistPath,
+		success:   quickPositive,
+		onSuccess: progressStageComplete,
+		onFailure: progressStageComplete,
+	})
 	if err != nil {
 		t.Fatalf("run: %v", err)
 	}
@@ -86,9 +161,879 @@ func TestStageRunnerRunEmitsResults(t *testing.T) {
 		if res.URL == runner.target {
 			t.Fatalf("placeholder was not expanded in URL %q", res.URL)
 		}
+		if res.Word == "" || res.Payload != res.Word || !strings.HasSuffix(res.URL, "/"+res.Payload) {
+			t.Fatalf("unexpected payload metadata: word=%q payload=%q url=%q", res.Word, res.Payload, res.URL)
+		}
+		if res.RequestMethod != http.MethodGet || res.ResponseHeader.Get("X-Test") != "1" {
+			t.Fatalf("unexpected request metadata: method=%q headers=%v", res.RequestMethod, res.ResponseHeader)
+		}
 	}
 
 	if got := atomic.LoadInt32(&hits); got != 2 {
 		t.Fatalf("expected 2 requests, got %d", got)
 	}
 }
+
+func TestRunAppliesPerTargetOverrides(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type seen struct {
+		method string
+		token  string
+	}
+	requests := make(chan seen, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- seen{method: r.Method, token: r.Header.Get("X-Token")}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	defaultList := filepath.Join(dir, "default.txt")
+	if err := os.WriteFile(defaultList, []byte("admin\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	apiList := filepath.Join(dir, "api.txt")
+	if err := os.WriteFile(apiList, []byte("v1\nv2\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	results, err := Run(ctx, Config{
+		Wordlist:    defaultList,
+		Concurrency: 1,
+		Timeout:     time.Second,
+		Targets: []Target{
+			{URL: server.URL + "/a/FUZZ"},
+			{URL: server.URL + "/b/FUZZ", Method: http.MethodGet, Wordlist: apiList, Headers: http.Header{"X-Token": {"secret"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var urls []string
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		urls = append(urls, res.URL)
+	}
+	close(requests)
+
+	if len(urls) != 3 {
+		t.Fatalf("expected 3 results, got %v", urls)
+	}
+
+	for req := range requests {
+		switch req.method {
+		case http.MethodHead:
+			if req.token != "" {
+				t.Fatalf("default target should not carry the override header")
+			}
+		case http.MethodGet:
+			if req.token != "secret" {
+				t.Fatalf("expected override header on GET target, got %q", req.token)
+			}
+		default:
+			t.Fatalf("unexpected method %s", req.method)
+		}
+	}
+}
+
+func TestRunSendsTargetBodiesAndMethodsToTheSameURL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("1\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	results, err := Run(ctx, Config{
+		Wordlist:    wordlistPath,
+		Concurrency: 1,
+		Timeout:     time.Second,
+		Body:        []byte("default"),
+		Targets: []Target{
+			{URL: server.URL + "/pets/FUZZ", Method: http.MethodGet},
+			{URL: server.URL + "/pets/FUZZ", Method: http.MethodPost, Body: []byte(`{"name":"rex"}`)},
+			{URL: server.URL + "/pets/FUZZ", Method: http.MethodPost},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+	}
+
+	want := []string{"GET /pets/1 default", `POST /pets/1 {"name":"rex"}`}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("requests = %q, want %q", requests, want)
+	}
+}
+
+func TestRunBuildsBodiesFromPayloads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("Content-Type")+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("alice\nbob\nalice\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	build := func(field string) func(string) (PayloadRequest, error) {
+		return func(payload string) (PayloadRequest, error) {
+			return PayloadRequest{Body: []byte(field + "=" + payload), Header: http.Header{"Content-Type": {"text/plain"}}}, nil
+		}
+	}
+	results, err := Run(ctx, Config{
+		Wordlist:     wordlistPath,
+		Concurrency:  1,
+		Timeout:      time.Second,
+		Method:       http.MethodPost,
+		BuildRequest: build("name"),
+		Targets: []Target{
+			{URL: server.URL + "/users"},
+			{URL: server.URL + "/users", BuildRequest: build("role")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+	}
+
+	want := []string{
+		"/users text/plain name=alice",
+		"/users text/plain name=bob",
+		"/users text/plain role=alice",
+		"/users text/plain role=bob",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("requests = %q, want %q", requests, want)
+	}
+}
+
+func TestRunSendsPayloadCookies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		cookies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cookies = append(cookies, r.URL.Path+" "+r.Header.Get("Cookie"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("abc\ndef\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	results, err := Run(ctx, Config{
+		URL:         server.URL + "/account",
+		Wordlist:    wordlistPath,
+		Concurrency: 1,
+		Timeout:     time.Second,
+		Cookie:      "lang=en",
+		BuildRequest: func(payload string) (PayloadRequest, error) {
+			return PayloadRequest{Cookie: "session=" + payload, Key: payload}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+	}
+
+	want := []string{"/account lang=en; session=abc", "/account lang=en; session=def"}
+	if !reflect.DeepEqual(cookies, want) {
+		t.Fatalf("cookies = %q, want %q", cookies, want)
+	}
+}
+
+func TestRunSharedPoolScansTargetsAtOnce(t *testing.T) {
+	var (
+		mu              sync.Mutex
+		inFlight        = make(map[string]int)
+		total, maxTotal int
+		maxTargets      int
+		overTargetLimit bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := strings.Split(r.URL.Path, "/")[1]
+
+		mu.Lock()
+		inFlight[target]++
+		total++
+		maxTotal = max(maxTotal, total)
+		maxTargets = max(maxTargets, len(inFlight))
+		overTargetLimit = overTargetLimit || inFlight[target] > 1
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		if inFlight[target]--; inFlight[target] == 0 {
+			delete(inFlight, target)
+		}
+		total--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	results, err := Run(context.Background(), Config{
+		Words:       SliceWordlist([]string{"a", "b", "c"}),
+		Method:      http.MethodGet,
+		Concurrency: 2,
+		SharedPool:  true,
+		Targets: []Target{
+			{URL: server.URL + "/x/FUZZ", Concurrency: 1},
+			{URL: server.URL + "/y/FUZZ", Concurrency: 1},
+			{URL: server.URL + "/z/FUZZ", Concurrency: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	count := 0
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		count++
+	}
+
+	if count != 9 {
+		t.Fatalf("expected 9 results, got %d", count)
+	}
+	if maxTotal > 2 || overTargetLimit {
+		t.Fatalf("expected at most 2 requests in flight and 1 per target, got %d in total (per target exceeded: %t)", maxTotal, overTargetLimit)
+	}
+	if maxTargets < 2 {
+		t.Fatal("expected the targets to be scanned at the same time")
+	}
+}
+
+func TestRunSmartMethodEscalatesInterestingResponses(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/admin":
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "welcome")
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Words:       SliceWordlist([]string{"admin", "private", "missing"}),
+		Method:      http.MethodHead,
+		Concurrency: 1,
+		SmartMethod: true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	got := make(map[string]Result)
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		got[res.Word] = res
+	}
+
+	if res := got["admin"]; res.RequestMethod != http.MethodGet || res.ProbeStatus != http.StatusOK || string(res.Body) != "welcome" {
+		t.Fatalf("expected admin to be fetched with GET after HEAD, got %s probe %d body %q", res.RequestMethod, res.ProbeStatus, res.Body)
+	}
+	if res := got["private"]; res.RequestMethod != http.MethodGet || res.ProbeStatus != http.StatusForbidden {
+		t.Fatalf("expected private to be fetched with GET after HEAD, got %s probe %d", res.RequestMethod, res.ProbeStatus)
+	}
+	if res := got["missing"]; res.RequestMethod != http.MethodHead || res.ProbeStatus != 0 {
+		t.Fatalf("expected missing to stay a HEAD request, got %s probe %d", res.RequestMethod, res.ProbeStatus)
+	}
+	if want := "HEAD /admin,GET /admin,HEAD /private,GET /private,HEAD /missing"; strings.Join(requests, ",") != want {
+		t.Fatalf("expected requests %s, got %s", want, strings.Join(requests, ","))
+	}
+}
+
+func TestRunRetriesHeadRejectedWithGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for _, disable := range []bool{false, true} {
+		results, err := Run(context.Background(), Config{
+			URL:                   server.URL + "/FUZZ",
+			Words:                 SliceWordlist([]string{"admin"}),
+			Method:                http.MethodHead,
+			DisableMethodFallback: disable,
+		})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		var res Result
+		for r := range results {
+			res = r
+		}
+
+		switch {
+		case res.Err != nil:
+			t.Fatalf("unexpected error: %v", res.Err)
+		case !disable && (res.RequestMethod != http.MethodGet || res.StatusCode != http.StatusOK || res.ProbeStatus != http.StatusMethodNotAllowed):
+			t.Fatalf("expected a GET retry recording the 405, got %s %d probe %d", res.RequestMethod, res.StatusCode, res.ProbeStatus)
+		case disable && (res.RequestMethod != http.MethodHead || res.StatusCode != http.StatusMethodNotAllowed):
+			t.Fatalf("expected the HEAD 405 to be kept, got %s %d", res.RequestMethod, res.StatusCode)
+		}
+	}
+}
+
+func TestPlanAppliesPayloadTransform(t *testing.T) {
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\nskip\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	plan, err := Plan(Config{
+		URL:      "https://example.com/FUZZ",
+		Wordlist: wordlistPath,
+		TransformPayload: func(payload string) ([]string, error) {
+			if payload == "skip" {
+				return nil, nil
+			}
+			return []string{payload, payload + "%00"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	if plan.TotalPermutations != 2 {
+		t.Fatalf("expected 2 permutations, got %d (%v)", plan.TotalPermutations, plan.Samples)
+	}
+	if plan.Samples[1] != "https://example.com/admin%00" {
+		t.Fatalf("unexpected samples: %v", plan.Samples)
+	}
+}
+
+func TestRunReportsPayloadTransformErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("bad\ngood\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	results, err := Run(context.Background(), Config{
+		URL:      server.URL + "/FUZZ",
+		Wordlist: wordlistPath,
+		Method:   http.MethodGet,
+		TransformPayload: func(payload string) ([]string, error) {
+			if payload == "bad" {
+				return nil, errors.New("encoder failed")
+			}
+			return []string{payload}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var urls, errs []string
+	for res := range results {
+		if res.Err != nil {
+			errs = append(errs, res.Err.Error())
+			continue
+		}
+		urls = append(urls, res.URL)
+	}
+
+	if len(errs) != 1 || !strings.Contains(errs[0], "encoder failed") {
+		t.Fatalf("expected one transform error, got %v", errs)
+	}
+	if len(urls) != 1 || urls[0] != server.URL+"/good" {
+		t.Fatalf("expected the remaining word to be requested, got %v", urls)
+	}
+}
+
+func TestRunClassifiesResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("hit\nmiss\nbroken\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Wordlist:    wordlistPath,
+		Method:      http.MethodGet,
+		Concurrency: 3,
+		ClassifyResult: func(_ context.Context, res Result) (bool, error) {
+			switch {
+			case strings.HasSuffix(res.URL, "/broken"):
+				return false, errors.New("plugin crashed")
+			default:
+				return strings.HasSuffix(res.URL, "/hit"), nil
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	verdicts := make(map[string]bool)
+	var errs []string
+	for res := range results {
+		if res.Err != nil {
+			errs = append(errs, res.Err.Error())
+			continue
+		}
+		if !res.HasVerdict {
+			t.Fatalf("expected a verdict for %s", res.URL)
+		}
+		verdicts[res.URL] = res.Verdict
+	}
+
+	if len(errs) != 1 || !strings.Contains(errs[0], "plugin crashed") {
+		t.Fatalf("expected one classify error, got %v", errs)
+	}
+	if !verdicts[server.URL+"/hit"] || verdicts[server.URL+"/miss"] || len(verdicts) != 2 {
+		t.Fatalf("unexpected verdicts: %v", verdicts)
+	}
+}
+
+func TestRunMatcherSkipsRejectedBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" {
+			_, _ = w.Write([]byte("welcome"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+	defer server.Close()
+
+	var classified atomic.Int32
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Words:       SliceWordlist([]string{"admin", "missing"}),
+		Method:      http.MethodGet,
+		Concurrency: 2,
+		Matcher: MatcherFunc(func(res Result) bool {
+			return res.StatusCode == http.StatusOK
+		}),
+		ClassifyResult: func(context.Context, Result) (bool, error) {
+			classified.Add(1)
+			return true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	byWord := make(map[string]Result)
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		byWord[res.Word] = res
+	}
+
+	if hit := byWord["admin"]; !hit.HasVerdict || !hit.Verdict || string(hit.Body) != "welcome" {
+		t.Fatalf("unexpected hit: %+v", hit)
+	}
+	if miss := byWord["missing"]; !miss.HasVerdict || miss.Verdict || miss.Body != nil {
+		t.Fatalf("expected the rejected result without a body, got %+v", miss)
+	}
+	if got := classified.Load(); got != 1 {
+		t.Fatalf("expected ClassifyResult only for the accepted response, got %d calls", got)
+	}
+}
+
+func TestRunSendsHostHeader(t *testing.T) {
+	var hosts sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts.Store(r.URL.Path, r.Host)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	results, err := Run(context.Background(), Config{
+		Words:      SliceWordlist([]string{"admin"}),
+		Method:     http.MethodGet,
+		HostHeader: "internal.app.local",
+		Targets: []Target{
+			{URL: server.URL + "/FUZZ"},
+			{URL: server.URL + "/other/FUZZ", Headers: http.Header{"Host": {"override.local"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		if res.RemoteIP != "127.0.0.1" {
+			t.Fatalf("expected the remote IP of the test server, got %q", res.RemoteIP)
+		}
+	}
+
+	if host, _ := hosts.Load("/admin"); host != "internal.app.local" {
+		t.Fatalf("expected the Host header override, got %v", host)
+	}
+	if host, _ := hosts.Load("/other/admin"); host != "override.local" {
+		t.Fatalf("expected the target Host header to win, got %v", host)
+	}
+}
+
+func TestRunLogsWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	results, err := Run(context.Background(), Config{
+		URL:         server.URL + "/FUZZ",
+		Words:       SliceWordlist([]string{"admin"}),
+		Method:      http.MethodGet,
+		Concurrency: 1,
+		PreHook:     `echo '{"headers":{" ":"x"}}'`,
+		ClassifyResult: func(context.Context, Result) (bool, error) {
+			return false, errors.New("plugin crashed")
+		},
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for range results {
+	}
+
+	for _, want := range []string{
+		"pre-hook returned a header without a name",
+		"pre-hook output sets no cookie or headers",
+		"classify response failed",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("expected log to contain %q, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestFollowUpExecutorAppliesOverrides(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s auth=%s probe=%s body=%s", r.Method, r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Probe"), body)
+	}))
+	defer server.Close()
+
+	executor, err := NewFollowUpExecutor(Config{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+
+	origin := Result{
+		URL:           server.URL + "/admin",
+		RequestMethod: http.MethodHead,
+		RequestHeader: http.Header{"Authorization": {"Bearer token"}, "X-Probe": {"original"}},
+	}
+
+	res := executor.Execute(context.Background(), origin, FollowUp{
+		Method:  "post",
+		Body:    []byte("check"),
+		Headers: http.Header{"X-Probe": {"override"}},
+	})
+	if res.Err != nil {
+		t.Fatalf("follow-up: %v", res.Err)
+	}
+	if res.FollowUpOf != origin.URL {
+		t.Fatalf("expected FollowUpOf %q, got %q", origin.URL, res.FollowUpOf)
+	}
+	if got, want := string(res.Body), "POST /admin auth=Bearer token probe=override body=check"; got != want {
+		t.Fatalf("unexpected request seen by server: %q, want %q", got, want)
+	}
+	if origin.RequestHeader.Get("X-Probe") != "original" {
+		t.Fatal("expected the original request headers to be left untouched")
+	}
+
+	res = executor.Execute(context.Background(), origin, FollowUp{URL: server.URL + "/other"})
+	if res.Err != nil || res.RequestMethod != http.MethodHead || res.URL != server.URL+"/other" {
+		t.Fatalf("expected the original method against the new URL, got %+v", res)
+	}
+}
+
+func TestPauserBlocksUntilResume(t *testing.T) {
+	var p Pauser
+	if !p.wait(context.Background()) {
+		t.Fatal("expected an unpaused Pauser not to block")
+	}
+
+	p.Pause()
+	released := make(chan bool)
+	go func() { released <- p.wait(context.Background()) }()
+
+	select {
+	case <-released:
+		t.Fatal("expected wait to block while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Resume()
+	if !<-released {
+		t.Fatal("expected wait to report success after Resume")
+	}
+
+	p.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if p.wait(ctx) {
+		t.Fatal("expected wait to give up when the context is done")
+	}
+}
+
+func TestCapHeaderDropsValuesPastLimit(t *testing.T) {
+	h := http.Header{
+		"A-Small": {"1"},
+		"B-Large": {strings.Repeat("x", 100)},
+		"C-Small": {"2"},
+	}
+
+	capped := capHeader(h, 50)
+	if capped.Get("A-Small") != "1" {
+		t.Fatalf("expected headers within the limit to be kept, got %v", capped)
+	}
+	if capped.Get("B-Large") != "" || capped.Get("C-Small") != "" {
+		t.Fatalf("expected headers past the limit to be dropped, got %v", capped)
+	}
+	if len(capHeader(h, 1024)) != 3 {
+		t.Fatalf("expected all headers under a large limit")
+	}
+}
+
+func TestRunDisableKeepAlivesOpensConnectionPerRequest(t *testing.T) {
+	for _, disable := range []bool{false, true} {
+		var mu sync.Mutex
+		conns := make(map[string]struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			conns[r.RemoteAddr] = struct{}{}
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		results, err := Run(context.Background(), Config{
+			URL:               server.URL + "/FUZZ",
+			Words:             SliceWordlist([]string{"a", "b", "c"}),
+			Method:            http.MethodGet,
+			Concurrency:       1,
+			DisableKeepAlives: disable,
+		})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		reused := 0
+		for res := range results {
+			if res.Err != nil {
+				t.Fatalf("unexpected error: %v", res.Err)
+			}
+			if res.Timing.ConnReused {
+				reused++
+			}
+		}
+		server.Close()
+
+		switch {
+		case disable && (len(conns) != 3 || reused != 0):
+			t.Fatalf("expected 3 fresh connections without keep-alive, got %d connections and %d reused", len(conns), reused)
+		case !disable && (len(conns) != 1 || reused != 2):
+			t.Fatalf("expected 1 connection reused twice, got %d connections and %d reused", len(conns), reused)
+		}
+	}
+}
+
+func TestRunRecordsRedirectChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin":
+			http.Redirect(w, r, "/admin/", http.StatusMovedPermanently)
+		case "/admin/":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	results, err := Run(context.Background(), Config{
+		URL:             server.URL + "/FUZZ",
+		Words:           SliceWordlist([]string{"admin", "login"}),
+		Method:          http.MethodGet,
+		FollowRedirects: true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	chains := make(map[string][]RedirectHop)
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		chains[res.URL] = res.RedirectChain
+	}
+
+	want := []RedirectHop{
+		{URL: server.URL + "/admin", StatusCode: http.StatusMovedPermanently},
+		{URL: server.URL + "/admin/", StatusCode: http.StatusFound},
+	}
+	if got := chains[server.URL+"/admin"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected chain %v, got %v", want, got)
+	}
+	if got := chains[server.URL+"/login"]; len(got) != 0 {
+		t.Fatalf("expected no redirects for /login, got %v", got)
+	}
+}
+
+func TestRunCapsAndDiscardsBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("a", 100))
+	}))
+	defer server.Close()
+
+	results, err := Run(context.Background(), Config{
+		URL:           server.URL + "/FUZZ",
+		Words:         SliceWordlist([]string{"keep", "drop"}),
+		Method:        http.MethodGet,
+		MaxBodySize:   10,
+		DiscardBodies: true,
+		ClassifyResult: func(ctx context.Context, res Result) (bool, error) {
+			return strings.HasSuffix(res.URL, "/keep"), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Repeat("a", 10)))
+	want := hex.EncodeToString(sum[:])
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		if res.BodySHA256 != want {
+			t.Fatalf("%s: expected the hash of the first 10 bytes, got %s", res.URL, res.BodySHA256)
+		}
+		if !res.BodyTruncated {
+			t.Fatalf("%s: expected the capped body to be marked truncated", res.URL)
+		}
+		switch {
+		case strings.HasSuffix(res.URL, "/keep") && string(res.Body) != strings.Repeat("a", 10):
+			t.Fatalf("expected the body capped at 10 bytes, got %q", res.Body)
+		case strings.HasSuffix(res.URL, "/drop") && res.Body != nil:
+			t.Fatalf("expected the rejected body to be dropped, got %q", res.Body)
+		}
+	}
+}
+
+func TestProgressTrackerBatchesCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	tracker, err := newProgressTracker(path, false)
+	if err != nil {
+		t.Fatalf("new tracker: %v", err)
+	}
+
+	read := func() progressState {
+		t.Helper()
+		reloaded, err := newProgressTracker(path, false)
+		if err != nil {
+			t.Fatalf("reload tracker: %v", err)
+		}
+		return reloaded.State()
+	}
+
+	// The first position of a stage is written straight away.
+	if err := tracker.Set(progressState{Stage: progressStagePrimary}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for i := 1; i <= progressCheckpointEvery+10; i++ {
+		if err := tracker.Set(progressState{Stage: progressStagePrimary, WordIndex: i}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	if state := read(); state.WordIndex != progressCheckpointEvery {
+		t.Fatalf("expected the checkpoint at word %d, got %+v", progressCheckpointEvery, state)
+	}
+
+	if err := tracker.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if state := read(); state.WordIndex != progressCheckpointEvery+10 {
+		t.Fatalf("expected the flushed position, got %+v", state)
+	}
+}
@@ -0,0 +1,174 @@
+// Package fingerprint recognizes the servers, languages, and frameworks
+// behind a response from its headers, cookies, and body, using a small
+// built-in database of signatures.
+package fingerprint
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Technology is a piece of software recognized in a response. Version is
+// empty when the response does not reveal it.
+type Technology struct {
+	Name    string
+	Version string
+}
+
+// String returns the name of t followed by its version, if known.
+func (t Technology) String() string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return t.Name + " " + t.Version
+}
+
+// signature recognizes one technology. A response matches when any of its
+// patterns does; the first submatch of a pattern, if any, is the version.
+type signature struct {
+	name string
+	// headers map header names to patterns of their value.
+	headers map[string]*regexp.Regexp
+	// cookies are prefixes of cookie names set by the technology.
+	cookies []string
+	// bodies are patterns of the body.
+	bodies []*regexp.Regexp
+}
+
+// version matches a dotted version number.
+const version = `(\d+(?:\.\d+)*)`
+
+var signatures = []signature{
+	{name: "nginx", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)\bnginx(?:/` + version + `)?`)}},
+	{name: "Apache", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`\bApache(?:/` + version + `)?`)}},
+	{name: "IIS", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`Microsoft-IIS(?:/` + version + `)?`)}},
+	{name: "LiteSpeed", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)litespeed`)}},
+	{name: "gunicorn", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`\bgunicorn(?:/` + version + `)?`)}},
+	{name: "Werkzeug", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`\bWerkzeug(?:/` + version + `)?`)}},
+	{name: "Python", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`\bPython/` + version)}},
+	{name: "Caddy", headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`\bCaddy\b`)}},
+	{name: "Envoy", headers: map[string]*regexp.Regexp{
+		"Server":                        regexp.MustCompile(`\benvoy\b`),
+		"X-Envoy-Upstream-Service-Time": regexp.MustCompile(`.`),
+	}},
+	{
+		name:    "PHP",
+		headers: map[string]*regexp.Regexp{"X-Powered-By": regexp.MustCompile(`\bPHP(?:/` + version + `)?`)},
+		cookies: []string{"PHPSESSID"},
+	},
+	{
+		name: "ASP.NET",
+		headers: map[string]*regexp.Regexp{
+			"X-Aspnet-Version": regexp.MustCompile(version),
+			"X-Powered-By":     regexp.MustCompile(`\bASP\.NET\b`),
+		},
+		cookies: []string{"ASP.NET_SessionId", ".AspNetCore."},
+	},
+	{name: "Express", headers: map[string]*regexp.Regexp{"X-Powered-By": regexp.MustCompile(`\bExpress\b`)}},
+	{
+		name:    "Next.js",
+		headers: map[string]*regexp.Regexp{"X-Powered-By": regexp.MustCompile(`\bNext\.js(?: ` + version + `)?`)},
+		bodies:  []*regexp.Regexp{regexp.MustCompile(`id="__NEXT_DATA__"`)},
+	},
+	{
+		name:    "WordPress",
+		headers: map[string]*regexp.Regexp{"Link": regexp.MustCompile(`api\.w\.org`)},
+		bodies: []*regexp.Regexp{
+			regexp.MustCompile(`<meta name="generator" content="WordPress(?: ` + version + `)?`),
+			regexp.MustCompile(`/wp-(?:content|includes)/`),
+		},
+	},
+	{
+		name: "Drupal",
+		headers: map[string]*regexp.Regexp{
+			"X-Generator":    regexp.MustCompile(`Drupal(?: ` + version + `)?`),
+			"X-Drupal-Cache": regexp.MustCompile(`.`),
+		},
+		bodies: []*regexp.Regexp{regexp.MustCompile(`Drupal\.settings|drupal-settings-json`)},
+	},
+	{name: "Joomla", bodies: []*regexp.Regexp{regexp.MustCompile(`<meta name="generator" content="Joomla!`)}},
+	{name: "Laravel", cookies: []string{"laravel_session"}},
+	{
+		name:    "Django",
+		cookies: []string{"django_language"},
+		bodies:  []*regexp.Regexp{regexp.MustCompile(`name="csrfmiddlewaretoken"`)},
+	},
+	{name: "Ruby on Rails", bodies: []*regexp.Regexp{regexp.MustCompile(`<meta name="csrf-param" content="authenticity_token"`)}},
+	{name: "Java", cookies: []string{"JSESSIONID"}},
+	{name: "Tomcat", bodies: []*regexp.Regexp{regexp.MustCompile(`Apache Tomcat(?:/` + version + `)?`)}},
+	{name: "Spring Boot", bodies: []*regexp.Regexp{regexp.MustCompile(`Whitelabel Error Page`)}},
+	{name: "Shopify", headers: map[string]*regexp.Regexp{"X-Shopid": regexp.MustCompile(`.`)}},
+}
+
+// Detect returns the technologies recognized in a response with the given
+// header and body, in a fixed order.
+func Detect(header http.Header, body []byte) []Technology {
+	var cookies []string
+	for _, line := range header.Values("Set-Cookie") {
+		name, _, _ := strings.Cut(line, "=")
+		cookies = append(cookies, strings.TrimSpace(name))
+	}
+
+	var found []Technology
+	for _, sig := range signatures {
+		if tech, ok := sig.match(header, cookies, body); ok {
+			found = append(found, tech)
+		}
+	}
+	return found
+}
+
+// Names returns the String of every technology detected in a response with
+// the given header and body.
+func Names(header http.Header, body []byte) []string {
+	found := Detect(header, body)
+	if len(found) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(found))
+	for i, tech := range found {
+		names[i] = tech.String()
+	}
+	return names
+}
+
+// match reports whether the response matches s, preferring the version
+// revealed by any of its patterns.
+func (s signature) match(header http.Header, cookies []string, body []byte) (Technology, bool) {
+	tech := Technology{Name: s.name}
+	matched := false
+	see := func(submatches []string) {
+		if submatches == nil {
+			return
+		}
+		matched = true
+		if tech.Version == "" && len(submatches) > 1 {
+			tech.Version = submatches[1]
+		}
+	}
+
+	for name, pattern := range s.headers {
+		for _, value := range header.Values(name) {
+			see(pattern.FindStringSubmatch(value))
+		}
+	}
+	for _, prefix := range s.cookies {
+		for _, cookie := range cookies {
+			if strings.HasPrefix(cookie, prefix) {
+				matched = true
+			}
+		}
+	}
+	for _, pattern := range s.bodies {
+		if m := pattern.FindSubmatch(body); m != nil {
+			submatches := make([]string, len(m))
+			for i, b := range m {
+				submatches[i] = string(b)
+			}
+			see(submatches)
+		}
+	}
+	return tech, matched
+}
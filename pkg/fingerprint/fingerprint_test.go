@@ -0,0 +1,47 @@
+package fingerprint
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   []string
+	}{
+		{name: "nothing", header: http.Header{"Content-Type": {"text/html"}}, body: "<p>hello</p>"},
+		{
+			name:   "server and language versions",
+			header: http.Header{"Server": {"nginx/1.18.0 (Ubuntu)"}, "X-Powered-By": {"PHP/8.1.2"}},
+			want:   []string{"nginx 1.18.0", "PHP 8.1.2"},
+		},
+		{
+			name:   "cookies",
+			header: http.Header{"Set-Cookie": {"XSRF-TOKEN=abc; path=/", "laravel_session=def; path=/; httponly"}},
+			want:   []string{"Laravel"},
+		},
+		{
+			name:   "wordpress body",
+			header: http.Header{"Server": {"Apache"}},
+			body:   `<meta name="generator" content="WordPress 6.4.2" /><link rel="stylesheet" href="/wp-content/themes/x.css">`,
+			want:   []string{"Apache", "WordPress 6.4.2"},
+		},
+		{name: "spring error page", body: "<h1>Whitelabel Error Page</h1>", want: []string{"Spring Boot"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			if got := Names(header, []byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
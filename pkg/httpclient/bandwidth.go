@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BandwidthLimiter caps the rate at which response bodies are read by one or
+// more Clients. Bytes are paid for after they are read, so a single read can
+// briefly exceed the rate; the reads that follow wait until the average is
+// back under it.
+type BandwidthLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBandwidthLimiter returns a limiter allowing bytesPerSecond bytes per
+// second, with bursts of up to one second's worth.
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	rate := float64(bytesPerSecond)
+	return &BandwidthLimiter{rate: rate, burst: rate, tokens: rate, last: time.Now()}
+}
+
+// wait takes n bytes from the budget and sleeps until they are covered, or
+// until ctx ends.
+func (l *BandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit / l.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitedBody reads a response body through a BandwidthLimiter.
+type limitedBody struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	limiter *BandwidthLimiter
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// Small reads keep the waits short and the rate smooth.
+	const maxChunk = 32 * 1024
+	if len(p) > maxChunk {
+		p = p[:maxChunk]
+	}
+
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.wait(b.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// ParseBandwidth parses a rate such as "5MB/s", "512KB", or "1048576" into
+// bytes per second. Units are B, KB, MB, and GB in powers of 1000 and KiB,
+// MiB, and GiB in powers of 1024; the "/s" suffix is optional.
+func ParseBandwidth(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "/s"), "ps")
+
+	number := strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	unit := strings.ToUpper(strings.TrimSpace(trimmed[len(number):]))
+
+	multipliers := map[string]float64{
+		"": 1, "B": 1,
+		"K": 1e3, "KB": 1e3, "M": 1e6, "MB": 1e6, "G": 1e9, "GB": 1e9,
+		"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30,
+	}
+	multiplier, ok := multipliers[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid bandwidth %q (e.g. 5MB/s)", value)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n*multiplier < 1 {
+		return 0, fmt.Errorf("invalid bandwidth %q (e.g. 5MB/s)", value)
+	}
+	return int64(n * multiplier), nil
+}
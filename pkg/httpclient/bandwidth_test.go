@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := map[string]int64{
+		"5MB/s":    5_000_000,
+		"512KiB/s": 512 * 1024,
+		"1.5 MB":   1_500_000,
+		"2000":     2000,
+		"10kbps":   10_000,
+	}
+	for input, want := range tests {
+		got, err := ParseBandwidth(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseBandwidth(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "MB/s", "5XB/s", "0", "-1MB"} {
+		if _, err := ParseBandwidth(input); err == nil {
+			t.Fatalf("ParseBandwidth(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestClientCapsBandwidth(t *testing.T) {
+	body := strings.Repeat("x", 1500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	// The first 1000 bytes are the initial burst; the other 500 take 0.5s.
+	client, err := NewWithOptions(Options{Timeout: 5 * time.Second, Bandwidth: NewBandwidthLimiter(1000)})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(data) != len(body) {
+		t.Fatalf("read body: %d bytes, %v", len(data), err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the body to be read at about 1000B/s, took %s", elapsed)
+	}
+}
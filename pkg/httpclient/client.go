@@ -1,50 +1,149 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 )
 
 // Client provides an HTTP client that can be shared between workers.
 type Client struct {
-	client *http.Client
+	client    *http.Client
+	bandwidth *BandwidthLimiter
 }
 
 // RequestOptions customises individual HTTP requests issued by the client.
 type RequestOptions struct {
+	// Headers are added to the request. A Host header replaces the host sent
+	// to the server while the connection still goes to the URL's host.
 	Headers http.Header
 	Cookie  string
+	// Body is sent as the request body when it is not empty.
+	Body []byte
+	// BodyFile names a file sent as the request body when Body is empty. It
+	// is opened and streamed for every request, so large payloads are never
+	// held in memory, and its size is sent as the Content-Length.
+	BodyFile string
+}
+
+// Options configures the transport and redirect policy of a Client.
+type Options struct {
+	Timeout         time.Duration
+	FollowRedirects bool
+	// Proxy is an http, https, or socks5 proxy URL. When empty, the standard
+	// HTTP_PROXY/HTTPS_PROXY environment variables are honoured.
+	Proxy string
+	// DNSCache, when set, resolves hostnames for the dialer. It can be
+	// shared between Clients.
+	DNSCache *DNSCache
+	// DisableKeepAlives closes the connection after every request instead of
+	// reusing it.
+	DisableKeepAlives bool
+	// Network is "tcp4" or "tcp6" to connect over IPv4 or IPv6 only. Empty
+	// allows both.
+	Network string
+	// SNI, when set, is sent as the TLS server name instead of the URL's
+	// host, and the server certificate is verified against it.
+	SNI string
+	// TLSFingerprint, when set, makes TLS connections with the ClientHello of
+	// a browser (see TLSFingerprints) instead of Go's own, which some CDNs
+	// and firewalls block. It applies to direct connections only, so proxies,
+	// including those from the environment, are not used with it.
+	TLSFingerprint string
+	// Bandwidth, when set, caps the rate at which response bodies are read.
+	// It can be shared between Clients to cap them together.
+	Bandwidth *BandwidthLimiter
+	// AllowRedirect, when set, is asked about the URL of every redirect
+	// before FollowRedirects follows it. A redirect it rejects is not
+	// followed and the redirect response is returned instead.
+	AllowRedirect func(*url.URL) bool
 }
 
 // New creates a Client configured with the provided timeout. It reuses a
 // single http.Transport to allow connection pooling across concurrent
 // requests.
 func New(timeout time.Duration, followRedirects bool) *Client {
+	client, _ := NewWithOptions(Options{Timeout: timeout, FollowRedirects: followRedirects})
+	return client
+}
+
+// NewWithOptions creates a Client from opts. It returns an error when the
+// options cannot be applied, for example when the proxy URL is invalid.
+func NewWithOptions(opts Options) (*Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if trimmed := strings.TrimSpace(opts.Proxy); trimmed != "" {
+		proxyURL, err := url.Parse(trimmed)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dial := (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	switch opts.Network {
+	case "", "tcp":
+	case "tcp4", "tcp6":
+		dial = forceNetwork(dial, opts.Network)
+	default:
+		return nil, fmt.Errorf("invalid network %q", opts.Network)
+	}
+	if opts.DNSCache != nil {
+		dial = opts.DNSCache.dialContext(dial)
+	}
+
 	transport := &http.Transport{
-		Proxy:                 http.ProxyFromEnvironment,
-		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		Proxy:                 proxy,
+		DialContext:           dial,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     opts.DisableKeepAlives,
+	}
+	if opts.SNI != "" {
+		transport.TLSClientConfig = &tls.Config{ServerName: opts.SNI}
+	}
+	if opts.TLSFingerprint != "" {
+		if strings.TrimSpace(opts.Proxy) != "" {
+			return nil, fmt.Errorf("a TLS fingerprint cannot be used with a proxy, which makes its own TLS connection to the target")
+		}
+		fingerprint, err := ParseTLSFingerprint(opts.TLSFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		dialer, err := newFingerprintDialer(fingerprint, opts.SNI, dial)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = nil
+		transport.DialTLSContext = dialer.DialTLSContext
+		transport.ForceAttemptHTTP2 = false
 	}
 
 	httpClient := &http.Client{
-		Timeout:   timeout,
+		Timeout:   opts.Timeout,
 		Transport: transport,
 	}
 
 	const maxRedirects = 5
 
-	if followRedirects {
+	if opts.FollowRedirects {
 		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			if len(via) >= maxRedirects {
 				return fmt.Errorf("stopped after %d redirects", maxRedirects)
 			}
+			if opts.AllowRedirect != nil && !opts.AllowRedirect(req.URL) {
+				return http.ErrUseLastResponse
+			}
 			return nil
 		}
 	} else {
@@ -53,7 +152,18 @@ func New(timeout time.Duration, followRedirects bool) *Client {
 		}
 	}
 
-	return &Client{client: httpClient}
+	return &Client{client: httpClient, bandwidth: opts.Bandwidth}, nil
+}
+
+// forceNetwork wraps dial so TCP connections use network, which makes
+// addresses of the other family fail to dial.
+func forceNetwork(dial func(ctx context.Context, network, addr string) (net.Conn, error), network string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, requested, addr string) (net.Conn, error) {
+		if requested == "tcp" {
+			requested = network
+		}
+		return dial(ctx, requested, addr)
+	}
 }
 
 // Head issues an HTTP HEAD request using the shared client.
@@ -67,16 +177,44 @@ func (c *Client) Request(ctx context.Context, method, url string, opts *RequestO
 		method = http.MethodHead
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	var body io.Reader
+	var file *os.File
+	if opts != nil && len(opts.Body) > 0 {
+		body = bytes.NewReader(opts.Body)
+	} else if opts != nil && opts.BodyFile != "" {
+		var err error
+		file, err = os.Open(opts.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("open request body: %w", err)
+		}
+		body = file
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
+		if file != nil {
+			file.Close()
+		}
 		return nil, err
 	}
 
+	if file != nil {
+		if err := streamFile(req, file); err != nil {
+			return nil, err
+		}
+	}
+
 	if opts != nil {
 		for key, values := range opts.Headers {
 			if key == "" {
 				continue
 			}
+			if http.CanonicalHeaderKey(key) == "Host" {
+				if len(values) > 0 {
+					req.Host = values[0]
+				}
+				continue
+			}
 			for _, value := range values {
 				req.Header.Add(key, value)
 			}
@@ -91,6 +229,34 @@ func (c *Client) Request(ctx context.Context, method, url string, opts *RequestO
 	if err != nil {
 		return nil, err
 	}
+	if c.bandwidth != nil {
+		resp.Body = &limitedBody{ctx: ctx, body: resp.Body, limiter: c.bandwidth}
+	}
 
 	return resp, nil
 }
+
+// streamFile makes file the body of req with its size as the Content-Length.
+// GetBody reopens the file so redirects that keep the body can resend it. The
+// transport closes the body once it has been sent.
+func streamFile(req *http.Request, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open request body: %w", err)
+	}
+
+	name := file.Name()
+	req.ContentLength = info.Size()
+	if req.ContentLength == 0 {
+		// A zero ContentLength with a non-nil body means unknown length.
+		file.Close()
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		return nil
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return os.Open(name)
+	}
+	return nil
+}
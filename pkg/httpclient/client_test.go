@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestStreamsBodyFile(t *testing.T) {
+	payload := strings.Repeat("hydro", 4096)
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, []byte(payload), 0o600); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+
+	type received struct {
+		length int64
+		body   string
+	}
+	got := make(chan received, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{length: r.ContentLength, body: string(body)}
+		if r.URL.Path == "/old" {
+			// 307 resends the body, which needs GetBody to reopen the file.
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewWithOptions(Options{Timeout: 2 * time.Second, FollowRedirects: true})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), http.MethodPost, server.URL+"/old", &RequestOptions{BodyFile: path})
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+
+	for i := 0; i < 2; i++ {
+		r := <-got
+		if r.length != int64(len(payload)) || r.body != payload {
+			t.Fatalf("request %d: expected %d byte body, got Content-Length %d and %d bytes", i+1, len(payload), r.length, len(r.body))
+		}
+	}
+}
+
+func TestRequestFailsForMissingBodyFile(t *testing.T) {
+	client := New(time.Second, false)
+	_, err := client.Request(context.Background(), http.MethodPost, "http://127.0.0.1:1/", &RequestOptions{BodyFile: filepath.Join(t.TempDir(), "missing")})
+	if err == nil || !strings.Contains(err.Error(), "open request body") {
+		t.Fatalf("expected an open error, got %v", err)
+	}
+}
+
+func TestClientRestrictsNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse server URL: %v", err)
+	}
+	// The test server listens on 127.0.0.1 only.
+	target := "http://localhost:" + parsed.Port() + "/"
+
+	for _, tt := range []struct {
+		network string
+		ok      bool
+	}{{"tcp4", true}, {"tcp6", false}} {
+		client, err := NewWithOptions(Options{Timeout: 2 * time.Second, Network: tt.network})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		resp, err := client.Request(context.Background(), http.MethodGet, target, nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Fatalf("%s: expected success %t, got %v", tt.network, tt.ok, err)
+		}
+	}
+
+	if _, err := NewWithOptions(Options{Network: "udp"}); err == nil {
+		t.Fatal("expected an invalid network to be rejected")
+	}
+}
+
+func TestClientSendsSNIOverride(t *testing.T) {
+	names := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			names <- hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewWithOptions(Options{Timeout: 2 * time.Second, SNI: "front.example.com"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	// The test certificate is not trusted, so only the ClientHello matters.
+	if resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil); err == nil {
+		resp.Body.Close()
+	}
+
+	if name := <-names; name != "front.example.com" {
+		t.Fatalf("expected the SNI override, got %q", name)
+	}
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultNegativeDNSTTL is how long a DNSCache remembers that a host does not
+// exist.
+const DefaultNegativeDNSTTL = 5 * time.Second
+
+// DNSCache caches host lookups for the dialer of one or more Clients so scans
+// that put a hostname in every URL resolve it once per TTL. The standard
+// resolver does not report record TTLs, so entries expire after fixed TTLs.
+// Lookups that fail because the host does not exist are cached for the
+// negative TTL; other failures, such as timeouts, are not cached.
+type DNSCache struct {
+	resolver    *net.Resolver
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]dnsEntry
+	inflight map[string]*dnsLookup
+	stats    DNSStats
+}
+
+// DNSStats counts the lookups served by a DNSCache.
+type DNSStats struct {
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
+	NegativeHits int64 `json:"negative_hits"`
+}
+
+type dnsEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+type dnsLookup struct {
+	done  chan struct{}
+	addrs []string
+	err   error
+}
+
+// NewDNSCache returns a cache keeping successful lookups for ttl and lookups
+// of hosts that do not exist for negativeTTL. A negativeTTL of zero or less
+// disables negative caching.
+func NewDNSCache(ttl, negativeTTL time.Duration) *DNSCache {
+	return &DNSCache{
+		resolver:    net.DefaultResolver,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		entries:     make(map[string]dnsEntry),
+		inflight:    make(map[string]*dnsLookup),
+	}
+}
+
+// Stats returns the lookup counters so far.
+func (c *DNSCache) Stats() DNSStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// LookupHost returns the addresses of host, from the cache when a fresh entry
+// exists. Concurrent lookups of the same host share one query.
+func (c *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[host]; ok && c.now().Before(entry.expires) {
+		if entry.err != nil {
+			c.stats.NegativeHits++
+		} else {
+			c.stats.Hits++
+		}
+		c.mu.Unlock()
+		return entry.addrs, entry.err
+	}
+
+	if lookup, ok := c.inflight[host]; ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		select {
+		case <-lookup.done:
+			return lookup.addrs, lookup.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	lookup := &dnsLookup{done: make(chan struct{})}
+	c.inflight[host] = lookup
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// The query is not tied to ctx so that a cancelled request does not
+	// fail the lookups waiting on it.
+	lookup.addrs, lookup.err = c.resolver.LookupHost(context.WithoutCancel(ctx), host)
+
+	c.mu.Lock()
+	delete(c.inflight, host)
+	switch {
+	case lookup.err == nil && c.ttl > 0:
+		c.entries[host] = dnsEntry{addrs: lookup.addrs, expires: c.now().Add(c.ttl)}
+	case isNotFound(lookup.err) && c.negativeTTL > 0:
+		c.entries[host] = dnsEntry{err: lookup.err, expires: c.now().Add(c.negativeTTL)}
+	}
+	c.mu.Unlock()
+	close(lookup.done)
+
+	return lookup.addrs, lookup.err
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// dialContext wraps dial so hostnames are resolved through the cache. The
+// addresses are tried in order until one connects.
+func (c *DNSCache) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+
+		addrs, err := c.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var firstErr error
+		for _, ip := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if firstErr == nil {
+			firstErr = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		return nil, firstErr
+	}
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDNSCacheReusesLookupsUntilExpiry(t *testing.T) {
+	cache := NewDNSCache(time.Minute, DefaultNegativeDNSTTL)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.LookupHost(context.Background(), "localhost"); err != nil {
+			t.Fatalf("lookup: %v", err)
+		}
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits != 2 {
+		t.Fatalf("expected 1 miss and 2 hits, got %+v", stats)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.LookupHost(context.Background(), "localhost"); err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if stats := cache.Stats(); stats.Misses != 2 {
+		t.Fatalf("expected the expired entry to be looked up again, got %+v", stats)
+	}
+}
+
+func TestClientDialsThroughDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse server URL: %v", err)
+	}
+	target := "http://localhost:" + parsed.Port() + "/"
+
+	cache := NewDNSCache(time.Minute, DefaultNegativeDNSTTL)
+	client, err := NewWithOptions(Options{Timeout: 2 * time.Second, DNSCache: cache})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Request(context.Background(), http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Fatalf("expected the second connection to use the cached lookup, got %+v", stats)
+	}
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// TLS fingerprints accepted by Options.TLSFingerprint.
+const (
+	FingerprintChrome  = "chrome"
+	FingerprintFirefox = "firefox"
+	// FingerprintRandom picks the chrome or firefox fingerprint for every
+	// connection.
+	FingerprintRandom = "random"
+)
+
+// TLSFingerprints lists the supported TLS ClientHello fingerprints.
+func TLSFingerprints() []string {
+	return []string{FingerprintChrome, FingerprintFirefox, FingerprintRandom}
+}
+
+// randomHellos are the browser ClientHellos FingerprintRandom chooses from:
+// those of the named fingerprints.
+var randomHellos = []utls.ClientHelloID{
+	utls.HelloChrome_Auto,
+	utls.HelloFirefox_Auto,
+}
+
+// ParseTLSFingerprint validates a fingerprint name. An empty name keeps Go's
+// own ClientHello.
+func ParseTLSFingerprint(name string) (string, error) {
+	switch fingerprint := strings.ToLower(strings.TrimSpace(name)); fingerprint {
+	case "", FingerprintChrome, FingerprintFirefox, FingerprintRandom:
+		return fingerprint, nil
+	default:
+		return "", fmt.Errorf("unknown TLS fingerprint %q (choose from %s)", name, strings.Join(TLSFingerprints(), ", "))
+	}
+}
+
+// fingerprintDialer opens TLS connections whose ClientHello mimics a browser,
+// for targets whose CDN or firewall rejects Go's own ClientHello.
+type fingerprintDialer struct {
+	dial  func(ctx context.Context, network, addr string) (net.Conn, error)
+	hello func() utls.ClientHelloID
+	// serverName replaces the host of the dialled address as the TLS server
+	// name when set.
+	serverName string
+	// rootCAs verifies server certificates; nil uses the system roots.
+	rootCAs *x509.CertPool
+	// handshakeTimeout bounds the TLS handshake, as
+	// http.Transport.TLSHandshakeTimeout does for Go's own handshakes.
+	handshakeTimeout time.Duration
+}
+
+func newFingerprintDialer(fingerprint, serverName string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (*fingerprintDialer, error) {
+	d := &fingerprintDialer{dial: dial, serverName: serverName, handshakeTimeout: 10 * time.Second}
+
+	switch fingerprint {
+	case FingerprintChrome:
+		d.hello = func() utls.ClientHelloID { return utls.HelloChrome_Auto }
+	case FingerprintFirefox:
+		d.hello = func() utls.ClientHelloID { return utls.HelloFirefox_Auto }
+	case FingerprintRandom:
+		d.hello = func() utls.ClientHelloID { return randomHellos[rand.IntN(len(randomHellos))] }
+	default:
+		return nil, fmt.Errorf("unknown TLS fingerprint %q (choose from %s)", fingerprint, strings.Join(TLSFingerprints(), ", "))
+	}
+
+	return d, nil
+}
+
+// DialTLSContext connects to addr and completes a TLS handshake with the
+// browser ClientHello. The ALPN extension only offers http/1.1: the transport
+// cannot speak HTTP/2 over a connection it did not set up itself.
+func (d *fingerprintDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	serverName := d.serverName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		serverName = host
+	}
+
+	spec, err := utls.UTLSIdToSpec(d.hello())
+	if err != nil {
+		return nil, fmt.Errorf("build TLS fingerprint: %w", err)
+	}
+	for _, ext := range spec.Extensions {
+		if alpn, ok := ext.(*utls.ALPNExtension); ok {
+			alpn.AlpnProtocols = []string{"http/1.1"}
+		}
+	}
+
+	conn, err := d.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := utls.UClient(conn, &utls.Config{ServerName: serverName, RootCAs: d.rootCAs}, utls.HelloCustom)
+	if err := tlsConn.ApplyPreset(&spec); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("apply TLS fingerprint: %w", err)
+	}
+
+	// The transport only reports handshakes it performs itself, so the
+	// handshake is reported here to keep the TLS timing of results.
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+
+	handshakeCtx, cancel := context.WithTimeout(ctx, d.handshakeTimeout)
+	defer cancel()
+	err = tlsConn.HandshakeContext(handshakeCtx)
+
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(connectionState(tlsConn.ConnectionState()), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// connectionState converts the fields of a uTLS connection state that
+// crypto/tls consumers commonly read.
+func connectionState(state utls.ConnectionState) tls.ConnectionState {
+	return tls.ConnectionState{
+		Version:            state.Version,
+		HandshakeComplete:  state.HandshakeComplete,
+		DidResume:          state.DidResume,
+		CipherSuite:        state.CipherSuite,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		ServerName:         state.ServerName,
+		PeerCertificates:   state.PeerCertificates,
+		VerifiedChains:     state.VerifiedChains,
+	}
+}
@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// extensionRecordSizeLimit is sent by Firefox but not by crypto/tls.
+const extensionRecordSizeLimit = 0x001c
+
+// isGREASE reports whether v is one of the reserved GREASE values Chrome
+// scatters through its ClientHello.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func TestTLSFingerprintClientHello(t *testing.T) {
+	tests := []struct {
+		fingerprint string
+		check       func(t *testing.T, hello *tls.ClientHelloInfo)
+	}{
+		{
+			fingerprint: "",
+			check: func(t *testing.T, hello *tls.ClientHelloInfo) {
+				if slices.ContainsFunc(hello.CipherSuites, isGREASE) || slices.Contains(hello.Extensions, extensionRecordSizeLimit) {
+					t.Fatalf("expected Go's own ClientHello, got ciphers %x extensions %x", hello.CipherSuites, hello.Extensions)
+				}
+			},
+		},
+		{
+			fingerprint: FingerprintChrome,
+			check: func(t *testing.T, hello *tls.ClientHelloInfo) {
+				if !slices.ContainsFunc(hello.CipherSuites, isGREASE) || !slices.ContainsFunc(hello.Extensions, isGREASE) {
+					t.Fatalf("expected GREASE values in a Chrome ClientHello, got ciphers %x extensions %x", hello.CipherSuites, hello.Extensions)
+				}
+			},
+		},
+		{
+			fingerprint: FingerprintFirefox,
+			check: func(t *testing.T, hello *tls.ClientHelloInfo) {
+				if slices.ContainsFunc(hello.CipherSuites, isGREASE) || !slices.Contains(hello.Extensions, extensionRecordSizeLimit) {
+					t.Fatalf("expected a Firefox ClientHello, got ciphers %x extensions %x", hello.CipherSuites, hello.Extensions)
+				}
+			},
+		},
+		{
+			fingerprint: FingerprintRandom,
+			check: func(t *testing.T, hello *tls.ClientHelloInfo) {
+				if !slices.Contains(hello.Extensions, extensionRecordSizeLimit) && !slices.ContainsFunc(hello.CipherSuites, isGREASE) {
+					t.Fatalf("expected a browser ClientHello, got ciphers %x extensions %x", hello.CipherSuites, hello.Extensions)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		name := tt.fingerprint
+		if name == "" {
+			name = "go"
+		}
+		t.Run(name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				hello *tls.ClientHelloInfo
+			)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, r.Proto)
+			}))
+			server.TLS = &tls.Config{
+				GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+					mu.Lock()
+					hello = info
+					mu.Unlock()
+					return nil, nil
+				},
+			}
+			server.StartTLS()
+			defer server.Close()
+
+			client, err := NewWithOptions(Options{Timeout: 5 * time.Second, TLSFingerprint: tt.fingerprint})
+			if err != nil {
+				t.Fatalf("new client: %v", err)
+			}
+			trustServer(t, client, server, tt.fingerprint)
+
+			var handshakes int
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+					if err == nil && state.HandshakeComplete {
+						handshakes++
+					}
+				},
+			})
+			resp, err := client.Request(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK || string(body) != "HTTP/1.1" {
+				t.Fatalf("expected an HTTP/1.1 response, got %d %q", resp.StatusCode, body)
+			}
+			if handshakes != 1 {
+				t.Fatalf("expected the handshake to be traced once, got %d", handshakes)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if hello == nil {
+				t.Fatal("server saw no ClientHello")
+			}
+			if tt.fingerprint != "" && !slices.Equal(hello.SupportedProtos, []string{"http/1.1"}) {
+				t.Fatalf("expected ALPN to offer only http/1.1, got %v", hello.SupportedProtos)
+			}
+			tt.check(t, hello)
+		})
+	}
+}
+
+// trustServer makes client trust the test certificate of server.
+func trustServer(t *testing.T, client *Client, server *httptest.Server, fingerprint string) {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	transport := client.client.Transport.(*http.Transport)
+	if fingerprint == "" {
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+		return
+	}
+
+	dialer, err := newFingerprintDialer(fingerprint, "", (&net.Dialer{Timeout: 5 * time.Second}).DialContext)
+	if err != nil {
+		t.Fatalf("new fingerprint dialer: %v", err)
+	}
+	dialer.rootCAs = roots
+	transport.DialTLSContext = dialer.DialTLSContext
+}
+
+func TestTLSFingerprintVerifiesCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewWithOptions(Options{Timeout: 5 * time.Second, TLSFingerprint: FingerprintChrome})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if resp, err := client.Request(context.Background(), http.MethodGet, server.URL, nil); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the untrusted test certificate to be rejected")
+	}
+}
+
+func TestTLSFingerprintOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "chrome", opts: Options{TLSFingerprint: "Chrome"}},
+		{name: "unknown fingerprint", opts: Options{TLSFingerprint: "opera"}, wantErr: true},
+		{name: "proxy", opts: Options{TLSFingerprint: "firefox", Proxy: "http://127.0.0.1:8080"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewWithOptions error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSFingerprintRandomPicksChromeOrFirefox(t *testing.T) {
+	dialer, err := newFingerprintDialer(FingerprintRandom, "", nil)
+	if err != nil {
+		t.Fatalf("new fingerprint dialer: %v", err)
+	}
+
+	seen := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		seen[dialer.hello().Client]++
+	}
+
+	for client := range seen {
+		if client != utls.HelloChrome_Auto.Client && client != utls.HelloFirefox_Auto.Client {
+			t.Fatalf("expected only Chrome and Firefox ClientHellos, got %v", seen)
+		}
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected both browsers to be picked, got %v", seen)
+	}
+}
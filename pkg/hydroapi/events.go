@@ -0,0 +1,126 @@
+package hydroapi
+
+import (
+	"slices"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// StageEvent matches engine.StageEvent and is passed to OnStageChange
+// callbacks.
+type StageEvent = engine.StageEvent
+
+// Summary describes a finished scan and is passed to OnComplete callbacks.
+type Summary struct {
+	Hits   int
+	Errors int
+	// Err is the context error when the scan was stopped before it finished.
+	Err error
+	// Stats counts the responses by status code, the failed requests by
+	// error type, and the requests of each worker.
+	Stats engine.StatsSnapshot
+}
+
+// callbacks holds the event subscriptions registered on an API.
+type callbacks struct {
+	hit      []func(Result)
+	err      []func(Result)
+	stage    []func(StageEvent)
+	complete []func(Summary)
+}
+
+// OnHit registers fn to be called with every result delivered by a scan that
+// has no error. Hit and error callbacks are called one at a time, in delivery
+// order, from the goroutine feeding the results channel, so a slow callback
+// slows the scan down. Callbacks registered while a scan is running apply
+// from the next scan.
+func (a *API) OnHit(fn func(Result)) {
+	if fn == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.callbacks.hit = append(a.callbacks.hit, fn)
+}
+
+// OnError registers fn to be called with every delivered result that carries
+// an error, such as failed requests and output sink failures.
+func (a *API) OnError(fn func(Result)) {
+	if fn == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.callbacks.err = append(a.callbacks.err, fn)
+}
+
+// OnStageChange registers fn to be called as each stage of each target starts
+// and ends. It is called from the engine goroutine and may run concurrently
+// with hit and error callbacks.
+func (a *API) OnStageChange(fn func(StageEvent)) {
+	if fn == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.callbacks.stage = append(a.callbacks.stage, fn)
+}
+
+// OnComplete registers fn to be called once a scan has finished or been
+// stopped, after every hit and error callback. StopScan returns only after the
+// completion callbacks ran, and a new scan may be started from within them.
+func (a *API) OnComplete(fn func(Summary)) {
+	if fn == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.callbacks.complete = append(a.callbacks.complete, fn)
+}
+
+// snapshot returns a copy of the registered callbacks for use by one scan.
+func (c callbacks) snapshot() callbacks {
+	return callbacks{
+		hit:      slices.Clone(c.hit),
+		err:      slices.Clone(c.err),
+		stage:    slices.Clone(c.stage),
+		complete: slices.Clone(c.complete),
+	}
+}
+
+// onStage chains the stage callbacks onto next, which may be nil.
+func (c callbacks) onStage(next func(StageEvent)) func(StageEvent) {
+	if len(c.stage) == 0 {
+		return next
+	}
+	return func(event StageEvent) {
+		if next != nil {
+			next(event)
+		}
+		for _, fn := range c.stage {
+			fn(event)
+		}
+	}
+}
+
+// deliver passes res to the hit or error callbacks and counts it in summary.
+func (c callbacks) deliver(res Result, summary *Summary) {
+	if res.Err != nil {
+		summary.Errors++
+		for _, fn := range c.err {
+			fn(res)
+		}
+		return
+	}
+
+	summary.Hits++
+	for _, fn := range c.hit {
+		fn(res)
+	}
+}
+
+func (c callbacks) finish(summary Summary) {
+	for _, fn := range c.complete {
+		fn(summary)
+	}
+}
@@ -20,10 +20,14 @@ type Result = engine.Result
 // primitives with a small interface that is easy to embed inside other Go
 // programs.
 type API struct {
-	mu      sync.Mutex
-	cancel  context.CancelFunc
-	done    chan struct{}
-	running bool
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	done      chan struct{}
+	running   bool
+	pauser    *engine.Pauser
+	stats     *engine.Stats
+	plugins   plugins
+	callbacks callbacks
 }
 
 // New returns a ready-to-use API instance.
@@ -32,21 +36,35 @@ func New() *API {
 }
 
 // StartScan launches a scan with the provided configuration. Results are
-// streamed to the supplied channel until the scan completes or StopScan is
-// called. The channel is closed automatically when the scan stops. It is an
-// error to invoke StartScan while another scan is running on the same API
-// instance.
+// streamed to the supplied channel and to the OnHit and OnError callbacks
+// until the scan completes or StopScan is called. The channel is closed
+// automatically when the scan stops. results may be nil when the callbacks
+// are used instead. It is an error to invoke StartScan while another scan is
+// running on the same API instance.
 func (a *API) StartScan(ctx context.Context, cfg Config, results chan Result) error {
-	if results == nil {
-		return errors.New("results channel cannot be nil")
-	}
+	return a.start(ctx, cfg, results, nil)
+}
 
+// start launches a scan like StartScan and passes its summary to done, when
+// set, after results is closed.
+func (a *API) start(ctx context.Context, cfg Config, results chan Result, done func(Summary)) error {
 	a.mu.Lock()
 	if a.running {
 		a.mu.Unlock()
 		return errors.New("a scan is already running")
 	}
 
+	active := a.plugins.snapshot()
+	events := a.callbacks.snapshot()
+	cfg.TransformPayload = active.transform(cfg.TransformPayload)
+	cfg.OnStage = events.onStage(cfg.OnStage)
+	if cfg.Pauser == nil {
+		cfg.Pauser = &engine.Pauser{}
+	}
+	if cfg.Stats == nil {
+		cfg.Stats = &engine.Stats{}
+	}
+
 	scanCtx, cancel := context.WithCancel(ctx)
 	stream, err := engine.Run(scanCtx, engine.Config(cfg))
 	if err != nil {
@@ -55,23 +73,56 @@ func (a *API) StartScan(ctx context.Context, cfg Config, results chan Result) er
 		return err
 	}
 
-	done := make(chan struct{})
+	finished := make(chan struct{})
 	a.cancel = cancel
-	a.done = done
+	a.done = finished
+	a.pauser = cfg.Pauser
+	a.stats = cfg.Stats
 	a.running = true
 	a.mu.Unlock()
 
 	go func() {
-		defer close(results)
-		defer a.finalize(done)
+		var summary Summary
+		send := func(batch []Result) bool {
+			for _, res := range batch {
+				if scanCtx.Err() != nil {
+					return false
+				}
+				if results != nil {
+					select {
+					case <-scanCtx.Done():
+						return false
+					case results <- res:
+					}
+				}
+				events.deliver(res, &summary)
+			}
+			return true
+		}
 
+		stopped := false
 		for res := range stream {
-			select {
-			case <-scanCtx.Done():
-				return
-			case results <- Result(res):
+			if !send(active.process(scanCtx, Result(res))) {
+				active.flush()
+				stopped = true
+				break
 			}
 		}
+		if !stopped {
+			send(active.flush())
+		}
+		summary.Err = scanCtx.Err()
+		summary.Stats = cfg.Stats.Snapshot()
+
+		a.finalize(finished, func() {
+			if results != nil {
+				close(results)
+			}
+			events.finish(summary)
+			if done != nil {
+				done(summary)
+			}
+		})
 	}()
 
 	return nil
@@ -99,12 +150,88 @@ func (a *API) StopScan() {
 	}
 }
 
-func (a *API) finalize(done chan struct{}) {
+// Pause stops the running scan from sending new requests without tearing it
+// down, for example to yield bandwidth to other work. Requests already in
+// flight complete and their results are still delivered. Calling Pause when
+// no scan is running is a no-op.
+func (a *API) Pause() {
+	if pauser := a.currentPauser(); pauser != nil {
+		pauser.Pause()
+	}
+}
+
+// Resume lets a scan paused with Pause continue where it stopped. Calling
+// Resume when no scan is paused is a no-op.
+func (a *API) Resume() {
+	if pauser := a.currentPauser(); pauser != nil {
+		pauser.Resume()
+	}
+}
+
+// Paused reports whether a scan is running and paused.
+func (a *API) Paused() bool {
+	pauser := a.currentPauser()
+	return pauser != nil && pauser.Paused()
+}
+
+// Stats returns the status code, error type, and per-worker counts of the
+// running scan, or of the last one once it has finished.
+func (a *API) Stats() engine.StatsSnapshot {
+	a.mu.Lock()
+	stats := a.stats
+	a.mu.Unlock()
+
+	return stats.Snapshot()
+}
+
+func (a *API) currentPauser() *engine.Pauser {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.running {
+		return nil
+	}
+	return a.pauser
+}
+
+// finalize marks the scan as finished and runs cleanup before StopScan is
+// released.
+func (a *API) finalize(done chan struct{}, cleanup func()) {
 	a.mu.Lock()
 	a.running = false
 	a.cancel = nil
 	a.done = nil
+	a.pauser = nil
 	a.mu.Unlock()
 
+	cleanup()
 	close(done)
 }
+
+// RunAll runs a scan with cfg to completion and returns the results without
+// errors together with a summary. Registered plugins and callbacks apply as
+// with StartScan. The returned error is set when the scan could not start or
+// was stopped early, by ctx or StopScan; the results gathered until then are
+// still returned.
+func (a *API) RunAll(ctx context.Context, cfg Config) ([]Result, Summary, error) {
+	results := make(chan Result)
+	summaries := make(chan Summary, 1)
+	if err := a.start(ctx, cfg, results, func(summary Summary) { summaries <- summary }); err != nil {
+		return nil, Summary{}, err
+	}
+
+	var hits []Result
+	for res := range results {
+		if res.Err == nil {
+			hits = append(hits, res)
+		}
+	}
+
+	summary := <-summaries
+	return hits, summary, summary.Err
+}
+
+// RunAll runs a scan on a new API instance. See API.RunAll.
+func RunAll(ctx context.Context, cfg Config) ([]Result, Summary, error) {
+	return New().RunAll(ctx, cfg)
+}
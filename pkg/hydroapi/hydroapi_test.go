@@ -0,0 +1,248 @@
+package hydroapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPauseHoldsBackRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var words strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&words, "word%d\n", i)
+	}
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordlist, []byte(words.String()), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	api := New()
+	api.Pause()
+	if api.Paused() {
+		t.Fatal("expected Pause without a running scan to be a no-op")
+	}
+
+	results := make(chan Result)
+	cfg := Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Method: http.MethodGet, Concurrency: 2, Timeout: 2 * time.Second}
+	if err := api.StartScan(context.Background(), cfg, results); err != nil {
+		t.Fatalf("start scan: %v", err)
+	}
+
+	received := 0
+	<-results
+	received++
+
+	api.Pause()
+	if !api.Paused() {
+		t.Fatal("expected the scan to be paused")
+	}
+
+	// Requests already in flight when Pause was called still deliver results.
+	settle := time.After(200 * time.Millisecond)
+	for draining := true; draining; {
+		select {
+		case <-results:
+			received++
+		case <-settle:
+			draining = false
+		}
+	}
+
+	sent := requests.Load()
+	select {
+	case res := <-results:
+		t.Fatalf("unexpected result while paused: %+v", res)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if requests.Load() != sent {
+		t.Fatalf("expected no requests while paused, went from %d to %d", sent, requests.Load())
+	}
+	if sent >= 20 {
+		t.Fatalf("expected the pause to hold back requests, but all %d were sent", sent)
+	}
+
+	api.Resume()
+	for range results {
+		received++
+	}
+
+	if received != 20 {
+		t.Fatalf("expected all 20 results after resuming, got %d", received)
+	}
+	if api.Paused() {
+		t.Fatal("expected Paused to be false once the scan finished")
+	}
+}
+
+func TestStopScanEndsPausedScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordlist, []byte("a\nb\nc\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	api := New()
+	results := make(chan Result, 10)
+	cfg := Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Method: http.MethodGet}
+	if err := api.StartScan(context.Background(), cfg, results); err != nil {
+		t.Fatalf("start scan: %v", err)
+	}
+	api.Pause()
+
+	stopped := make(chan struct{})
+	go func() {
+		api.StopScan()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StopScan did not end a paused scan")
+	}
+}
+
+func TestCallbacksReceiveScanEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	api := New()
+
+	var mu sync.Mutex
+	var hits, failures []string
+	var stages []StageEvent
+	api.OnHit(func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits = append(hits, res.URL)
+	})
+	api.OnError(func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, res.Err.Error())
+	})
+	api.OnStageChange(func(event StageEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		stages = append(stages, event)
+	})
+	complete := make(chan Summary, 1)
+	api.OnComplete(func(summary Summary) {
+		complete <- summary
+	})
+	if err := api.Register(failingSink{}); err != nil {
+		t.Fatalf("register sink: %v", err)
+	}
+
+	cfg, err := NewConfig(
+		WithTarget(server.URL+"/FUZZ"),
+		WithWordlistReader(strings.NewReader("a\nb\nc\n")),
+		WithMethod(http.MethodGet),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if err := api.StartScan(context.Background(), cfg, nil); err != nil {
+		t.Fatalf("start scan: %v", err)
+	}
+
+	var summary Summary
+	select {
+	case summary = <-complete:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnComplete was not called")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if summary.Hits != 3 || summary.Errors != 3 || summary.Err != nil {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if len(hits) != 3 || len(failures) != 3 {
+		t.Fatalf("expected 3 hits and 3 errors, got %v and %v", hits, failures)
+	}
+	if len(stages) != 2 || stages[0].Done || !stages[1].Done || stages[0].Stage != "primary" {
+		t.Fatalf("unexpected stage events: %+v", stages)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) WriteResult(Result) error { return errors.New("disk full") }
+
+func (failingSink) Flush() error { return nil }
+
+func TestRunAllCollectsHits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	api := New()
+	if err := api.Register(failingSink{}); err != nil {
+		t.Fatalf("register sink: %v", err)
+	}
+
+	cfg, err := NewConfig(
+		WithTarget(server.URL+"/FUZZ"),
+		WithWordlistReader(strings.NewReader("a\nb\n")),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	hits, summary, err := api.RunAll(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("RunAll: %v", err)
+	}
+	if len(hits) != 2 || summary.Hits != 2 || summary.Errors != 2 {
+		t.Fatalf("unexpected outcome: %d hits, summary %+v", len(hits), summary)
+	}
+	if _, _, err := api.RunAll(context.Background(), cfg); err != nil {
+		t.Fatalf("expected a second RunAll to start once the first returned: %v", err)
+	}
+}
+
+func TestRunAllReportsCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg, err := NewConfig(
+		WithTarget(server.URL+"/FUZZ"),
+		WithWordlistReader(strings.NewReader("a\nb\n")),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	if _, _, err := RunAll(ctx, cfg); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package hydroapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/templater"
+)
+
+// Defaults applied by NewConfig. They match the command-line defaults.
+const (
+	DefaultConcurrency = 10
+	DefaultTimeout     = 10 * time.Second
+	DefaultMethod      = http.MethodHead
+)
+
+// Option configures a Config built by NewConfig.
+type Option func(*Config) error
+
+// NewConfig builds a validated scan configuration from opts. Settings that
+// are not given use DefaultConcurrency, DefaultTimeout, and DefaultMethod. A
+// target and exactly one wordlist source are required.
+func NewConfig(opts ...Option) (Config, error) {
+	cfg := Config{
+		Concurrency: DefaultConcurrency,
+		Timeout:     DefaultTimeout,
+		Method:      DefaultMethod,
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if cfg.URL == "" {
+		return Config{}, errors.New("a target is required (use WithTarget)")
+	}
+	if cfg.Wordlist == "" && cfg.Words == nil {
+		return Config{}, errors.New("a wordlist is required (use WithWordlist, WithWordlistReader, or WithWordlistProvider)")
+	}
+	if cfg.Wordlist != "" && cfg.Words != nil {
+		return Config{}, errors.New("only one wordlist source can be set")
+	}
+
+	return cfg, nil
+}
+
+// WithTarget sets the target URL. It must be an http or https URL and contain
+// the FUZZ placeholder, which is replaced by each payload.
+func WithTarget(target string) Option {
+	return func(cfg *Config) error {
+		target = strings.TrimSpace(target)
+		parsed, err := url.Parse(strings.ReplaceAll(target, templater.DefaultPlaceholder, "x"))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid target %q: want an http or https URL", target)
+		}
+		if !strings.Contains(target, templater.DefaultPlaceholder) {
+			return fmt.Errorf("invalid target %q: missing the %s placeholder", target, templater.DefaultPlaceholder)
+		}
+		cfg.URL = target
+		return nil
+	}
+}
+
+// WithWordlist reads payloads from the file at path, one per line.
+func WithWordlist(path string) Option {
+	return func(cfg *Config) error {
+		if strings.TrimSpace(path) == "" {
+			return errors.New("wordlist path is empty")
+		}
+		cfg.Wordlist = path
+		return nil
+	}
+}
+
+// WithWordlistReader reads payloads from r, one per line. r is read completely
+// when the option is applied, so the Config can be used for several scans. Use
+// WithWordlistProvider and engine.ReaderWordlist to stream a large reader
+// instead.
+func WithWordlistReader(r io.Reader) Option {
+	return func(cfg *Config) error {
+		if r == nil {
+			return errors.New("wordlist reader is nil")
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("read wordlist: %w", err)
+		}
+		cfg.Words = engine.SliceWordlist(strings.Split(string(data), "\n"))
+		return nil
+	}
+}
+
+// WithWordlistProvider reads payloads from p, such as one built with
+// engine.SliceWordlist or engine.ChannelWordlist.
+func WithWordlistProvider(p engine.WordlistProvider) Option {
+	return func(cfg *Config) error {
+		if p == nil {
+			return errors.New("wordlist provider is nil")
+		}
+		cfg.Words = p
+		return nil
+	}
+}
+
+// WithMethod sets the HTTP method used for every request.
+func WithMethod(method string) Option {
+	return func(cfg *Config) error {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" || strings.ContainsAny(method, " \t\r\n") {
+			return fmt.Errorf("invalid method %q", method)
+		}
+		cfg.Method = method
+		return nil
+	}
+}
+
+// WithConcurrency sets the number of requests in flight at the same time.
+func WithConcurrency(n int) Option {
+	return func(cfg *Config) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency must be at least 1, got %d", n)
+		}
+		cfg.Concurrency = n
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each request.
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *Config) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive, got %s", d)
+		}
+		cfg.Timeout = d
+		return nil
+	}
+}
+
+// WithThrottle sets the minimum delay between dispatched requests.
+func WithThrottle(d time.Duration) Option {
+	return func(cfg *Config) error {
+		if d < 0 {
+			return fmt.Errorf("throttle must not be negative, got %s", d)
+		}
+		cfg.Throttle = d
+		return nil
+	}
+}
+
+// WithProxy sends requests through an http, https, or socks5 proxy.
+func WithProxy(proxy string) Option {
+	return func(cfg *Config) error {
+		parsed, err := url.Parse(strings.TrimSpace(proxy))
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", proxy)
+		}
+		cfg.Proxy = parsed.String()
+		return nil
+	}
+}
+
+// WithFollowRedirects makes requests follow up to five redirects.
+func WithFollowRedirects() Option {
+	return func(cfg *Config) error {
+		cfg.FollowRedirects = true
+		return nil
+	}
+}
+
+// WithMatcher keeps only the successful results m accepts; errors are always
+// delivered. m is called by the engine workers and must be safe for
+// concurrent use. Several matchers must all accept a result.
+func WithMatcher(m Matcher) Option {
+	return func(cfg *Config) error {
+		if m == nil {
+			return errors.New("matcher is nil")
+		}
+
+		next := cfg.ClassifyResult
+		cfg.ClassifyResult = func(ctx context.Context, res Result) (bool, error) {
+			if next != nil {
+				if ok, err := next(ctx, res); err != nil || !ok {
+					return ok, err
+				}
+			}
+			return m.Match(res), nil
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package hydroapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statusMatcher int
+
+func (m statusMatcher) Match(res Result) bool {
+	return res.StatusCode == int(m)
+}
+
+func TestNewConfigAppliesDefaults(t *testing.T) {
+	cfg, err := NewConfig(WithTarget("http://example.com/FUZZ"), WithWordlist("words.txt"))
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	if cfg.Method != DefaultMethod || cfg.Concurrency != DefaultConcurrency || cfg.Timeout != DefaultTimeout {
+		t.Fatalf("unexpected defaults: method=%q concurrency=%d timeout=%s", cfg.Method, cfg.Concurrency, cfg.Timeout)
+	}
+	if cfg.URL != "http://example.com/FUZZ" || cfg.Wordlist != "words.txt" {
+		t.Fatalf("unexpected target or wordlist: %q %q", cfg.URL, cfg.Wordlist)
+	}
+}
+
+func TestNewConfigRejectsInvalidSettings(t *testing.T) {
+	target := WithTarget("http://example.com/FUZZ")
+	words := WithWordlist("words.txt")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "missing target", opts: []Option{words}, want: "target is required"},
+		{name: "missing wordlist", opts: []Option{target}, want: "wordlist is required"},
+		{name: "two wordlists", opts: []Option{target, words, WithWordlistReader(strings.NewReader("a\n"))}, want: "only one wordlist"},
+		{name: "bad scheme", opts: []Option{WithTarget("ftp://example.com/FUZZ"), words}, want: "http or https"},
+		{name: "no placeholder", opts: []Option{WithTarget("http://example.com/"), words}, want: "placeholder"},
+		{name: "zero concurrency", opts: []Option{target, words, WithConcurrency(0)}, want: "concurrency"},
+		{name: "negative timeout", opts: []Option{target, words, WithTimeout(-time.Second)}, want: "timeout"},
+		{name: "empty method", opts: []Option{target, words, WithMethod(" ")}, want: "method"},
+		{name: "bad proxy", opts: []Option{target, words, WithProxy("localhost")}, want: "proxy"},
+		{name: "nil matcher", opts: []Option{target, words, WithMatcher(nil)}, want: "matcher"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewConfigScansWordlistReader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg, err := NewConfig(
+		WithTarget(server.URL+"/FUZZ"),
+		WithWordlistReader(strings.NewReader("admin\nlogin\nbackup\n")),
+		WithMethod(http.MethodGet),
+		WithConcurrency(2),
+		WithMatcher(statusMatcher(http.StatusOK)),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	results := make(chan Result)
+	if err := New().StartScan(context.Background(), cfg, results); err != nil {
+		t.Fatalf("start scan: %v", err)
+	}
+
+	var urls []string
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		urls = append(urls, res.URL)
+	}
+
+	if len(urls) != 1 || urls[0] != server.URL+"/admin" {
+		t.Fatalf("expected only the admin hit, got %v", urls)
+	}
+}
@@ -0,0 +1,151 @@
+package hydroapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ResultHook observes every result produced by a scan, including errors and
+// results rejected by a Matcher.
+type ResultHook interface {
+	HandleResult(ctx context.Context, res Result)
+}
+
+// PayloadTransformer rewrites each expanded payload into zero or more payloads
+// before request URLs are built. Returning nil drops the payload.
+type PayloadTransformer interface {
+	TransformPayload(payload string) []string
+}
+
+// Matcher decides whether a successful result is delivered to the results
+// channel and output sinks. All registered matchers must accept a result.
+type Matcher interface {
+	Match(res Result) bool
+}
+
+// OutputSink receives every matched result. Flush is called once the scan has
+// finished. Errors are reported on the results channel.
+type OutputSink interface {
+	WriteResult(res Result) error
+	Flush() error
+}
+
+// plugins holds the extensions registered on an API.
+type plugins struct {
+	hooks        []ResultHook
+	transformers []PayloadTransformer
+	matchers     []Matcher
+	sinks        []OutputSink
+}
+
+// Register adds a Go plugin to the API. The plugin is used for every role it
+// implements: ResultHook, PayloadTransformer, Matcher, and OutputSink. Plugins
+// registered while a scan is running apply from the next scan.
+func (a *API) Register(plugin any) error {
+	if plugin == nil {
+		return errors.New("plugin cannot be nil")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	registered := false
+	if hook, ok := plugin.(ResultHook); ok {
+		a.plugins.hooks = append(a.plugins.hooks, hook)
+		registered = true
+	}
+	if transformer, ok := plugin.(PayloadTransformer); ok {
+		a.plugins.transformers = append(a.plugins.transformers, transformer)
+		registered = true
+	}
+	if matcher, ok := plugin.(Matcher); ok {
+		a.plugins.matchers = append(a.plugins.matchers, matcher)
+		registered = true
+	}
+	if sink, ok := plugin.(OutputSink); ok {
+		a.plugins.sinks = append(a.plugins.sinks, sink)
+		registered = true
+	}
+
+	if !registered {
+		return fmt.Errorf("%T implements none of ResultHook, PayloadTransformer, Matcher or OutputSink", plugin)
+	}
+	return nil
+}
+
+// snapshot returns a copy of the registered plugins for use by one scan.
+func (p plugins) snapshot() plugins {
+	return plugins{
+		hooks:        append([]ResultHook(nil), p.hooks...),
+		transformers: append([]PayloadTransformer(nil), p.transformers...),
+		matchers:     append([]Matcher(nil), p.matchers...),
+		sinks:        append([]OutputSink(nil), p.sinks...),
+	}
+}
+
+// transform chains the registered transformers onto next, which may be nil.
+func (p plugins) transform(next func(string) ([]string, error)) func(string) ([]string, error) {
+	if len(p.transformers) == 0 {
+		return next
+	}
+
+	return func(payload string) ([]string, error) {
+		payloads := []string{payload}
+		if next != nil {
+			var err error
+			if payloads, err = next(payload); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, transformer := range p.transformers {
+			var out []string
+			for _, candidate := range payloads {
+				out = append(out, transformer.TransformPayload(candidate)...)
+			}
+			payloads = out
+		}
+		return payloads, nil
+	}
+}
+
+// process runs the hooks, matchers, and sinks for res. It returns the results
+// to deliver: res itself when it matched, followed by any sink errors.
+func (p plugins) process(ctx context.Context, res Result) (deliver []Result) {
+	for _, hook := range p.hooks {
+		hook.HandleResult(ctx, res)
+	}
+
+	if res.Err != nil {
+		return []Result{res}
+	}
+	if res.HasVerdict && !res.Verdict {
+		return nil
+	}
+
+	for _, matcher := range p.matchers {
+		if !matcher.Match(res) {
+			return nil
+		}
+	}
+
+	deliver = append(deliver, res)
+	for _, sink := range p.sinks {
+		if err := sink.WriteResult(res); err != nil {
+			deliver = append(deliver, Result{URL: res.URL, Err: fmt.Errorf("output sink: %w", err)})
+		}
+	}
+	return deliver
+}
+
+// flush flushes every sink and returns the failures as results.
+func (p plugins) flush() []Result {
+	var failures []Result
+	for _, sink := range p.sinks {
+		if err := sink.Flush(); err != nil {
+			failures = append(failures, Result{Err: fmt.Errorf("flush output sink: %w", err)})
+		}
+	}
+	return failures
+}
@@ -0,0 +1,106 @@
+package hydroapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// backupPlugin probes a .bak variant of every payload, keeps only 200
+// responses, and collects what it sees.
+type backupPlugin struct {
+	mu      sync.Mutex
+	seen    int
+	written []string
+	flushed bool
+}
+
+func (p *backupPlugin) TransformPayload(payload string) []string {
+	return []string{payload, payload + ".bak"}
+}
+
+func (p *backupPlugin) Match(res Result) bool {
+	return res.StatusCode == http.StatusOK
+}
+
+func (p *backupPlugin) HandleResult(_ context.Context, _ Result) {
+	p.mu.Lock()
+	p.seen++
+	p.mu.Unlock()
+}
+
+func (p *backupPlugin) WriteResult(res Result) error {
+	p.mu.Lock()
+	p.written = append(p.written, res.URL)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *backupPlugin) Flush() error {
+	p.flushed = true
+	return nil
+}
+
+func TestRegisteredPluginsShapeScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".bak") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordlist, []byte("admin\nconfig\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	plugin := &backupPlugin{}
+	api := New()
+	if err := api.Register(plugin); err != nil {
+		t.Fatalf("register plugin: %v", err)
+	}
+
+	results := make(chan Result)
+	cfg := Config{URL: server.URL + "/FUZZ", Wordlist: wordlist, Method: http.MethodGet, Concurrency: 2, Timeout: 2 * time.Second}
+	if err := api.StartScan(context.Background(), cfg, results); err != nil {
+		t.Fatalf("start scan: %v", err)
+	}
+
+	var delivered []string
+	for res := range results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %v", res.Err)
+		}
+		delivered = append(delivered, strings.TrimPrefix(res.URL, server.URL))
+	}
+	sort.Strings(delivered)
+
+	if strings.Join(delivered, ",") != "/admin.bak,/config.bak" {
+		t.Fatalf("unexpected delivered results: %v", delivered)
+	}
+	if plugin.seen != 4 {
+		t.Fatalf("expected the hook to see all 4 results, saw %d", plugin.seen)
+	}
+	if len(plugin.written) != 2 || !plugin.flushed {
+		t.Fatalf("expected sink to receive 2 results and be flushed, got %v (flushed=%t)", plugin.written, plugin.flushed)
+	}
+}
+
+func TestRegisterRejectsUnknownPlugins(t *testing.T) {
+	api := New()
+	if err := api.Register(struct{}{}); err == nil {
+		t.Fatal("expected a value implementing no plugin interface to be rejected")
+	}
+	if err := api.Register(nil); err == nil {
+		t.Fatal("expected nil plugin to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package matcher
+
+import (
+	"bytes"
+
+	"golang.org/x/net/html/charset"
+)
+
+// DecodeBody returns body transcoded to UTF-8 from the charset named by a
+// byte order mark, the charset parameter of contentType, or a meta tag of the
+// document, in that order. Bodies that are UTF-8 already are returned as is,
+// and undeclared ones that are not are read as windows-1252, as browsers do.
+func DecodeBody(body []byte, contentType string) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+	// windows-1252 is also the guess for undeclared bodies; it reads ASCII
+	// unchanged, so those are not copied.
+	if name == "utf-8" || (name == "windows-1252" && isASCII(body)) {
+		return body
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+	return bytes.TrimPrefix(decoded, []byte("\ufeff"))
+}
+
+func isASCII(body []byte) bool {
+	for _, b := range body {
+		if b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package matcher
 
 import (
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"unicode"
@@ -9,6 +10,14 @@ import (
 	"hydr0g3n/pkg/engine"
 )
 
+// Names of the rules reported in MatchOutcome.Rules.
+const (
+	RuleStatus      = "status"
+	RuleSize        = "size"
+	RuleSimilarity  = "similarity"
+	RuleMatchPlugin = "match-plugin"
+)
+
 // Options defines the configuration for matching engine results.
 type Options struct {
 	Statuses            []int
@@ -16,6 +25,16 @@ type Options struct {
 	BaselineBody        []byte
 	SimilarityThreshold float64
 	ShingleSize         int
+	// HostBaselines holds baseline bodies keyed by the host and port of
+	// result URLs. A result whose host has one is compared with it instead
+	// of BaselineBody.
+	HostBaselines map[string][]byte
+	// DecodeCharset transcodes bodies to UTF-8 with DecodeBody before they
+	// are compared, so pages in other charsets tokenize into words.
+	// BaselineContentType is the Content-Type of BaselineBody; host
+	// baselines are decoded from their byte order mark or meta tags alone.
+	DecodeCharset       bool
+	BaselineContentType string
 }
 
 // SizeRange describes optional minimum and maximum bounds for the response size.
@@ -36,6 +55,9 @@ type Matcher struct {
 	hasBaseline bool
 	threshold   float64
 	shingleSize int
+	// hostBaselines are the shingles of Options.HostBaselines.
+	hostBaselines map[string]map[string]struct{}
+	decode        bool
 }
 
 // MatchOutcome describes the result of evaluating a response against the matcher rules.
@@ -43,11 +65,15 @@ type MatchOutcome struct {
 	Matched       bool
 	Similarity    float64
 	HasSimilarity bool
+	// Rules names the rules a matching result satisfied: "status", "size",
+	// "similarity", and "match-plugin" for the verdict of a matcher plugin.
+	// Rules that were not configured are left out.
+	Rules []string
 }
 
 // New creates a Matcher from the provided options.
 func New(opts Options) Matcher {
-	m := Matcher{size: opts.Size}
+	m := Matcher{size: opts.Size, decode: opts.DecodeCharset}
 	if len(opts.Statuses) > 0 {
 		m.statuses = make(map[int]struct{}, len(opts.Statuses))
 		for _, code := range opts.Statuses {
@@ -63,17 +89,28 @@ func New(opts Options) Matcher {
 		shingleSize = 5
 	}
 	m.shingleSize = shingleSize
-	if opts.SimilarityThreshold > 0 && len(opts.BaselineBody) > 0 {
+	if opts.SimilarityThreshold > 0 {
 		threshold := opts.SimilarityThreshold
 		if threshold > 1 {
 			threshold = 1
 		}
-		baseline := buildShingles(opts.BaselineBody, shingleSize)
-		if len(baseline) > 0 {
+		if baseline := buildShingles(m.decodeBody(opts.BaselineBody, opts.BaselineContentType), shingleSize); len(baseline) > 0 {
 			m.baseline = baseline
 			m.threshold = threshold
 			m.hasBaseline = true
 		}
+		for host, body := range opts.HostBaselines {
+			baseline := buildShingles(m.decodeBody(body, ""), shingleSize)
+			if len(baseline) == 0 {
+				continue
+			}
+			if m.hostBaselines == nil {
+				m.hostBaselines = make(map[string]map[string]struct{})
+			}
+			m.hostBaselines[host] = baseline
+			m.threshold = threshold
+			m.hasBaseline = true
+		}
 	}
 	return m
 }
@@ -87,7 +124,8 @@ func (m Matcher) Matches(res engine.Result) bool {
 }
 
 // Evaluate determines whether the result passes all configured filters and returns
-// additional metadata produced during evaluation.
+// additional metadata produced during evaluation. A result rejected by a matcher
+// plugin never matches.
 func (m Matcher) Evaluate(res engine.Result) MatchOutcome {
 	outcome := MatchOutcome{Matched: true}
 
@@ -95,49 +133,80 @@ func (m Matcher) Evaluate(res engine.Result) MatchOutcome {
 		return outcome
 	}
 
-	if m.hasStatus {
-		if _, ok := m.statuses[res.StatusCode]; !ok {
+	if res.HasVerdict {
+		if !res.Verdict {
 			outcome.Matched = false
 			return outcome
 		}
+		outcome.Rules = append(outcome.Rules, RuleMatchPlugin)
+	}
+
+	if m.hasStatus {
+		if _, ok := m.statuses[res.StatusCode]; !ok {
+			return MatchOutcome{}
+		}
+		outcome.Rules = append(outcome.Rules, RuleStatus)
 	}
 
 	if m.hasSizeAny {
 		size := res.ContentLength
 		if size < 0 {
-			outcome.Matched = false
-			return outcome
+			return MatchOutcome{}
 		}
 		if m.size.HasMin && size < m.size.Min {
-			outcome.Matched = false
-			return outcome
+			return MatchOutcome{}
 		}
 		if m.size.HasMax && size > m.size.Max {
-			outcome.Matched = false
-			return outcome
+			return MatchOutcome{}
 		}
+		outcome.Rules = append(outcome.Rules, RuleSize)
 	}
 
 	if m.hasBaseline && m.threshold > 0 {
-		if len(res.Body) == 0 {
+		baseline := m.baselineFor(res.URL)
+		if len(res.Body) == 0 || len(baseline) == 0 {
 			return outcome
 		}
-		shingles := buildShingles(res.Body, m.shingleSize)
+		shingles := buildShingles(m.decodeBody(res.Body, res.ResponseHeader.Get("Content-Type")), m.shingleSize)
 		if len(shingles) == 0 {
 			return outcome
 		}
-		similarity := jaccardSimilarity(m.baseline, shingles)
+		similarity := jaccardSimilarity(baseline, shingles)
 		outcome.Similarity = similarity
 		outcome.HasSimilarity = true
 		if similarity >= m.threshold {
 			outcome.Matched = false
+			outcome.Rules = nil
 			return outcome
 		}
+		outcome.Rules = append(outcome.Rules, RuleSimilarity)
 	}
 
 	return outcome
 }
 
+// baselineFor returns the baseline shingles results from rawURL are
+// compared with.
+func (m Matcher) baselineFor(rawURL string) map[string]struct{} {
+	if len(m.hostBaselines) > 0 {
+		if u, err := url.Parse(rawURL); err == nil {
+			if baseline, ok := m.hostBaselines[u.Host]; ok {
+				return baseline
+			}
+		}
+	}
+	return m.baseline
+}
+
+// decodeBody returns body as DecodeBody does when charset decoding is
+// enabled.
+func (m Matcher) decodeBody(body []byte, contentType string) []byte {
+	if !m.decode {
+		return body
+	}
+	return DecodeBody(body, contentType)
+}
+
 func buildShingles(body []byte, size int) map[string]struct{} {
 	if size <= 0 {
 		size = 1
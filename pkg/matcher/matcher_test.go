@@ -2,6 +2,8 @@ package matcher
 
 import (
 	"errors"
+	"net/http"
+	"reflect"
 	"testing"
 
 	"hydr0g3n/pkg/engine"
@@ -176,6 +178,25 @@ func TestMatcherEvaluateReportsSimilarity(t *testing.T) {
 	}
 }
 
+func TestMatcherUsesHostBaselines(t *testing.T) {
+	matcher := New(Options{
+		SimilarityThreshold: 0.8,
+		HostBaselines: map[string][]byte{
+			"a.example.com": []byte("custom not found page for host a with its own wording"),
+		},
+	})
+
+	onA := engine.Result{URL: "https://a.example.com/missing", StatusCode: 200, Body: []byte("custom not found page for host a with its own wording")}
+	if matcher.Matches(onA) {
+		t.Fatalf("expected the baseline of the host to filter its soft 404")
+	}
+
+	onB := engine.Result{URL: "https://b.example.com/missing", StatusCode: 200, Body: onA.Body}
+	if outcome := matcher.Evaluate(onB); !outcome.Matched || outcome.HasSimilarity {
+		t.Fatalf("expected a host without a baseline to pass unscored, got %+v", outcome)
+	}
+}
+
 func TestMatcherEvaluateStatusAndSize(t *testing.T) {
 	matcher := New(Options{
 		Statuses: []int{200, 204},
@@ -196,6 +217,8 @@ func TestMatcherEvaluateStatusAndSize(t *testing.T) {
 		{name: "status mismatch", result: engine.Result{StatusCode: 500, ContentLength: 25}, matched: false},
 		{name: "below minimum", result: engine.Result{StatusCode: 200, ContentLength: 5}, matched: false},
 		{name: "above maximum", result: engine.Result{StatusCode: 200, ContentLength: 200}, matched: false},
+		{name: "plugin accepts", result: engine.Result{StatusCode: 200, ContentLength: 25, Verdict: true, HasVerdict: true}, matched: true},
+		{name: "plugin rejects", result: engine.Result{StatusCode: 200, ContentLength: 25, HasVerdict: true}, matched: false},
 	}
 
 	for _, tt := range tests {
@@ -211,6 +234,30 @@ func TestMatcherEvaluateStatusAndSize(t *testing.T) {
 	}
 }
 
+func TestMatcherEvaluateReportsRules(t *testing.T) {
+	baseline := []byte("not found page with repeated boilerplate content")
+	matcher := New(Options{
+		Statuses:            []int{200},
+		BaselineBody:        baseline,
+		SimilarityThreshold: 0.5,
+	})
+
+	outcome := matcher.Evaluate(engine.Result{StatusCode: 200, Body: []byte("admin console login form"), Verdict: true, HasVerdict: true})
+	if want := []string{RuleMatchPlugin, RuleStatus, RuleSimilarity}; !reflect.DeepEqual(outcome.Rules, want) {
+		t.Fatalf("rules = %v, want %v", outcome.Rules, want)
+	}
+
+	outcome = matcher.Evaluate(engine.Result{StatusCode: 200, Body: baseline})
+	if outcome.Matched || outcome.Rules != nil {
+		t.Fatalf("similar body: matched=%v rules=%v, want no match and no rules", outcome.Matched, outcome.Rules)
+	}
+
+	outcome = New(Options{}).Evaluate(engine.Result{StatusCode: 200})
+	if !outcome.Matched || outcome.Rules != nil {
+		t.Fatalf("no rules: matched=%v rules=%v, want a match without rules", outcome.Matched, outcome.Rules)
+	}
+}
+
 func TestJaccardSimilarity(t *testing.T) {
 	baseline := buildShingles([]byte("this is a sample baseline response"), 2)
 	similar := buildShingles([]byte("this is a sample baseline response with extras"), 2)
@@ -226,3 +273,67 @@ func TestJaccardSimilarity(t *testing.T) {
 		t.Fatalf("expected zero similarity, got %f", diff)
 	}
 }
+
+func TestDecodeBody(t *testing.T) {
+	// "Привет мир" in windows-1251.
+	cp1251 := []byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2, ' ', 0xec, 0xe8, 0xf0}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+	}{
+		{name: "content type", body: cp1251, contentType: "text/html; charset=windows-1251", want: "Привет мир"},
+		{name: "meta tag", body: append([]byte(`<meta charset="windows-1251">`), cp1251...), contentType: "text/html", want: `<meta charset="windows-1251">Привет мир`},
+		{name: "utf-8", body: []byte("Привет мир"), contentType: "text/html; charset=utf-8", want: "Привет мир"},
+		{name: "undeclared ascii", body: []byte("plain text"), want: "plain text"},
+		{name: "utf-16 bom", body: []byte{0xff, 0xfe, 'o', 0, 'k', 0}, want: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(DecodeBody(tt.body, tt.contentType)); got != tt.want {
+				t.Fatalf("DecodeBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherDecodesCharsetBeforeSimilarity(t *testing.T) {
+	// "Страница не найдена, вернитесь на главную" in windows-1251.
+	page := []byte{
+		0xd1, 0xf2, 0xf0, 0xe0, 0xed, 0xe8, 0xf6, 0xe0, ' ', 0xed, 0xe5, ' ',
+		0xed, 0xe0, 0xe9, 0xe4, 0xe5, 0xed, 0xe0, ',', ' ',
+		0xe2, 0xe5, 0xf0, 0xed, 0xe8, 0xf2, 0xe5, 0xf1, 0xfc, ' ', 0xed, 0xe0, ' ',
+		0xe3, 0xeb, 0xe0, 0xe2, 0xed, 0xf3, 0xfe,
+	}
+	// The same page with its first word changed to "Запись".
+	other := append([]byte{0xc7, 0xe0, 0xef, 0xe8, 0xf1, 0xfc}, page[8:]...)
+	contentType := "text/html; charset=windows-1251"
+	res := engine.Result{
+		StatusCode:     200,
+		Body:           other,
+		ResponseHeader: http.Header{"Content-Type": {contentType}},
+	}
+
+	raw := New(Options{SimilarityThreshold: 0.3, ShingleSize: 1, BaselineBody: page})
+	if outcome := raw.Evaluate(res); outcome.HasSimilarity {
+		t.Fatalf("expected undecoded bytes to yield no words to score, got %+v", outcome)
+	}
+
+	decoded := New(Options{
+		SimilarityThreshold: 0.3,
+		ShingleSize:         1,
+		BaselineBody:        page,
+		DecodeCharset:       true,
+		BaselineContentType: contentType,
+	})
+	outcome := decoded.Evaluate(res)
+	if !outcome.HasSimilarity || outcome.Similarity < 0.5 {
+		t.Fatalf("expected decoded pages to share most words, got %+v", outcome)
+	}
+	if outcome.Matched {
+		t.Fatalf("expected the decoded page to be filtered as similar to the baseline")
+	}
+}
@@ -0,0 +1,537 @@
+// Package openapi reads OpenAPI 3 and Swagger 2 documents, in YAML or JSON,
+// and turns their operations into request templates with a placeholder at
+// every parameter position.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxExampleDepth bounds how deeply nested schemas are expanded into example
+// values, so recursive schemas terminate.
+const maxExampleDepth = 6
+
+// maxRefHops bounds how many $ref indirections are followed in a row.
+const maxRefHops = 16
+
+// methods are the operations of a path item, in the order they are listed.
+var methods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions,
+	http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// Spec is a parsed OpenAPI or Swagger document.
+type Spec struct {
+	doc map[string]any
+}
+
+// Request is a request template generated from an operation. URL holds the
+// placeholder where payloads go, and Body, when set, is the example body
+// sent with every request.
+type Request struct {
+	// Operation names the route the template was built from, as
+	// "GET /users/{id}".
+	Operation string
+	Method    string
+	URL       string
+	Headers   http.Header
+	Body      []byte
+}
+
+// Safe reports whether r uses a method that does not change the state of
+// the server.
+func (r Request) Safe() bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// Load reads and parses the document at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read openapi spec: %w", err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("openapi spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Parse parses an OpenAPI 3 or Swagger 2 document in YAML or JSON.
+func Parse(data []byte) (*Spec, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, errors.New("not an OpenAPI or Swagger document")
+	}
+	if _, ok := doc["paths"].(map[string]any); !ok {
+		return nil, errors.New("document has no paths")
+	}
+	return &Spec{doc: doc}, nil
+}
+
+// BaseURL returns the URL the paths of s are relative to. override, when set,
+// replaces the server of the document, keeping the server's path when the
+// document only gives a path.
+func (s *Spec) BaseURL(override string) (string, error) {
+	server := s.server()
+	override = strings.TrimSpace(override)
+	if override != "" {
+		if strings.HasPrefix(server, "/") {
+			return strings.TrimSuffix(override, "/") + server, nil
+		}
+		return override, nil
+	}
+
+	u, err := url.Parse(server)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.New("the spec names no absolute server URL; give one with -u")
+	}
+	return server, nil
+}
+
+// server returns the first server of the document with its variables set to
+// their defaults, or "" when it names none.
+func (s *Spec) server() string {
+	if host, ok := s.doc["host"].(string); ok || s.doc["swagger"] != nil {
+		base, _ := s.doc["basePath"].(string)
+		if !ok {
+			return base
+		}
+		scheme := "https"
+		if schemes, _ := s.doc["schemes"].([]any); len(schemes) > 0 {
+			if first, ok := schemes[0].(string); ok {
+				scheme = first
+			}
+		}
+		return scheme + "://" + host + base
+	}
+
+	servers, _ := s.doc["servers"].([]any)
+	if len(servers) == 0 {
+		return ""
+	}
+	first, _ := servers[0].(map[string]any)
+	server, _ := first["url"].(string)
+	variables, _ := first["variables"].(map[string]any)
+	for name, v := range variables {
+		variable, _ := v.(map[string]any)
+		server = strings.ReplaceAll(server, "{"+name+"}", scalar(variable["default"]))
+	}
+	return server
+}
+
+// Requests returns the request templates of every operation of s, against
+// base. Every path and query parameter of an operation gets a template with
+// placeholder in its place and example values in the others; an operation
+// without parameters gets placeholder appended to its path.
+func (s *Spec) Requests(base, placeholder string) []Request {
+	paths := s.doc["paths"].(map[string]any)
+	routes := make([]string, 0, len(paths))
+	for route := range paths {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	base = strings.TrimSuffix(base, "/")
+	var requests []Request
+	for _, route := range routes {
+		item, _ := s.resolve(paths[route]).(map[string]any)
+		for _, method := range methods {
+			op, ok := s.resolve(item[strings.ToLower(method)]).(map[string]any)
+			if !ok {
+				continue
+			}
+			params := s.parameters(item["parameters"], op["parameters"])
+			requests = append(requests, s.operation(base, route, method, op, params, placeholder)...)
+		}
+	}
+	return requests
+}
+
+// parameter is a parameter of an operation with its example value.
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	value    string
+}
+
+// parameters merges the parameters of a path item with those of one of its
+// operations, which take precedence.
+func (s *Spec) parameters(lists ...any) []parameter {
+	var params []parameter
+	for _, list := range lists {
+		entries, _ := list.([]any)
+		for _, entry := range entries {
+			p, ok := s.resolve(entry).(map[string]any)
+			if !ok {
+				continue
+			}
+			param := parameter{in: scalar(p["in"]), name: scalar(p["name"])}
+			param.required, _ = p["required"].(bool)
+			param.value = s.parameterExample(p)
+
+			if i := slices.IndexFunc(params, func(q parameter) bool { return q.name == param.name && q.in == param.in }); i >= 0 {
+				params[i] = param
+			} else {
+				params = append(params, param)
+			}
+		}
+	}
+	return params
+}
+
+// parameterExample returns the example value of the parameter p.
+func (s *Spec) parameterExample(p map[string]any) string {
+	if v, ok := p["example"]; ok {
+		return scalar(v)
+	}
+	if v, ok := firstExample(s.resolve(p["examples"])); ok {
+		return scalar(v)
+	}
+	schema, ok := p["schema"].(map[string]any)
+	if !ok {
+		// Swagger 2 describes non-body parameters inline.
+		schema = p
+	}
+	return scalar(s.example(schema, 0))
+}
+
+// operation returns the request templates of one operation.
+func (s *Spec) operation(base, route, method string, op map[string]any, params []parameter, placeholder string) []Request {
+	headers := http.Header{}
+	var query []parameter
+	var positions []parameter
+	for _, p := range params {
+		switch p.in {
+		case "path":
+			positions = append(positions, p)
+		case "query":
+			positions = append(positions, p)
+			query = append(query, p)
+		case "header":
+			if p.required && p.value != "" {
+				headers.Set(p.name, p.value)
+			}
+		}
+	}
+
+	contentType, body := s.body(op, params)
+	if contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	build := func(fuzzed *parameter) string {
+		path := route
+		for _, p := range params {
+			if p.in != "path" {
+				continue
+			}
+			value := url.PathEscape(p.value)
+			if fuzzed != nil && p == *fuzzed {
+				value = placeholder
+			}
+			path = strings.ReplaceAll(path, "{"+p.name+"}", value)
+		}
+
+		var pairs []string
+		for _, p := range query {
+			switch {
+			case fuzzed != nil && p == *fuzzed:
+				pairs = append(pairs, url.QueryEscape(p.name)+"="+placeholder)
+			case p.required:
+				pairs = append(pairs, url.QueryEscape(p.name)+"="+url.QueryEscape(p.value))
+			}
+		}
+		if fuzzed == nil {
+			path = strings.TrimSuffix(path, "/") + "/" + placeholder
+		}
+		if len(pairs) == 0 {
+			return base + path
+		}
+		return base + path + "?" + strings.Join(pairs, "&")
+	}
+
+	template := Request{Operation: method + " " + route, Method: method, Body: body}
+	if len(headers) > 0 {
+		template.Headers = headers
+	}
+	if len(positions) == 0 {
+		template.URL = build(nil)
+		return []Request{template}
+	}
+
+	requests := make([]Request, 0, len(positions))
+	for _, p := range positions {
+		template.URL = build(&p)
+		requests = append(requests, template)
+	}
+	return requests
+}
+
+// body returns the content type and example body of op. JSON is preferred
+// when an operation accepts several media types.
+func (s *Spec) body(op map[string]any, params []parameter) (string, []byte) {
+	if requestBody, ok := s.resolve(op["requestBody"]).(map[string]any); ok {
+		content, _ := requestBody["content"].(map[string]any)
+		mediaTypes := make([]string, 0, len(content))
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Slice(mediaTypes, func(i, j int) bool {
+			return jsonMediaType(mediaTypes[i]) && !jsonMediaType(mediaTypes[j]) ||
+				jsonMediaType(mediaTypes[i]) == jsonMediaType(mediaTypes[j]) && mediaTypes[i] < mediaTypes[j]
+		})
+		if len(mediaTypes) == 0 {
+			return "", nil
+		}
+
+		mediaType := mediaTypes[0]
+		media, _ := content[mediaType].(map[string]any)
+		value, ok := media["example"]
+		if !ok {
+			value, ok = firstExample(s.resolve(media["examples"]))
+		}
+		if !ok {
+			schema, _ := media["schema"].(map[string]any)
+			value = s.example(schema, 0)
+		}
+		return mediaType, encode(mediaType, value)
+	}
+
+	// Swagger 2 describes the body as a parameter of its own, or as form
+	// fields.
+	entries, _ := op["parameters"].([]any)
+	for _, entry := range entries {
+		p, ok := s.resolve(entry).(map[string]any)
+		if ok && p["in"] == "body" {
+			schema, _ := p["schema"].(map[string]any)
+			return "application/json", encode("application/json", s.example(schema, 0))
+		}
+	}
+	form := url.Values{}
+	for _, p := range params {
+		if p.in == "formData" {
+			form.Set(p.name, p.value)
+		}
+	}
+	if len(form) > 0 {
+		return "application/x-www-form-urlencoded", []byte(form.Encode())
+	}
+	return "", nil
+}
+
+// example returns an example value for schema: its own example, default, or
+// first enum value, or one built from its type.
+func (s *Spec) example(schema map[string]any, depth int) any {
+	schema, _ = s.resolve(schema).(map[string]any)
+	if schema == nil {
+		return nil
+	}
+	for _, key := range []string{"example", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if values, _ := schema["enum"].([]any); len(values) > 0 {
+		return values[0]
+	}
+	if examples, _ := schema["examples"].([]any); len(examples) > 0 {
+		return examples[0]
+	}
+	if depth > maxExampleDepth {
+		return nil
+	}
+
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		parts, _ := schema[key].([]any)
+		if len(parts) == 0 {
+			continue
+		}
+		if key != "allOf" {
+			first, _ := parts[0].(map[string]any)
+			return s.example(first, depth+1)
+		}
+		merged := map[string]any{}
+		for _, part := range parts {
+			sub, _ := part.(map[string]any)
+			if obj, ok := s.example(sub, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj := map[string]any{}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range properties {
+			sub, _ := property.(map[string]any)
+			obj[name] = s.example(sub, depth+1)
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		return []any{s.example(items, depth+1)}
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "string":
+		switch schema["format"] {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com/"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// schemaType returns the type of schema, inferring objects from their
+// properties. OpenAPI 3.1 lists of types yield their first non-null type.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// resolve follows the local $ref of v, if any, to the value it points at.
+func (s *Spec) resolve(v any) any {
+	for range maxRefHops {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		v = s.pointer(ref)
+	}
+	return nil
+}
+
+// pointer returns the value at the local JSON pointer ref, or nil when it
+// points outside the document or at nothing.
+func (s *Spec) pointer(ref string) any {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var v any = s.doc
+	for _, token := range strings.Split(path, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[token]
+	}
+	return v
+}
+
+// firstExample returns the value of the first entry, by name, of an
+// examples map.
+func firstExample(v any) (any, bool) {
+	examples, _ := v.(map[string]any)
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example, ok := examples[name].(map[string]any); ok {
+			if value, ok := example["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// encode renders an example value as a body of mediaType.
+func encode(mediaType string, value any) []byte {
+	if value == nil {
+		return nil
+	}
+	if s, ok := value.(string); ok && !jsonMediaType(mediaType) {
+		return []byte(s)
+	}
+	if mediaType == "application/x-www-form-urlencoded" {
+		if obj, ok := value.(map[string]any); ok {
+			form := url.Values{}
+			for k, v := range obj {
+				form.Set(k, scalar(v))
+			}
+			return []byte(form.Encode())
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func jsonMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// scalar renders v as a parameter value. Objects and arrays are rendered as
+// JSON.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+servers:
+  - url: https://{region}.api.example.com/v1
+    variables:
+      region:
+        default: eu
+paths:
+  /health:
+    get: {}
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: integer}
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema: {type: string, enum: [name, tag]}
+        - name: X-Tenant
+          in: header
+          required: true
+          example: acme
+    delete: {}
+  /pets:
+    post:
+      requestBody:
+        content:
+          text/plain:
+            schema: {type: string}
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string, example: rex}
+        tags:
+          type: array
+          items: {type: string}
+        owner: {$ref: '#/components/schemas/Pet'}
+`
+
+func TestRequests(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	base, err := spec.BaseURL("")
+	if err != nil {
+		t.Fatalf("BaseURL: %v", err)
+	}
+	if base != "https://eu.api.example.com/v1" {
+		t.Fatalf("BaseURL = %q", base)
+	}
+
+	requests := spec.Requests(base, "FUZZ")
+	var got []string
+	for _, r := range requests {
+		got = append(got, r.Method+" "+r.URL)
+	}
+	want := []string{
+		"GET https://eu.api.example.com/v1/health/FUZZ",
+		"POST https://eu.api.example.com/v1/pets/FUZZ",
+		"GET https://eu.api.example.com/v1/pets/FUZZ",
+		"GET https://eu.api.example.com/v1/pets/1?fields=FUZZ",
+		"DELETE https://eu.api.example.com/v1/pets/FUZZ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Requests =\n%q\nwant\n%q", got, want)
+	}
+
+	if tenant := requests[2].Headers.Get("X-Tenant"); tenant != "acme" {
+		t.Fatalf("X-Tenant header = %q, want acme", tenant)
+	}
+	if requests[4].Safe() || !requests[2].Safe() {
+		t.Fatal("Safe misclassified DELETE or GET")
+	}
+
+	post := requests[1]
+	if ct := post.Headers.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	if len(post.Body) == 0 || post.Body[0] != '{' {
+		t.Fatalf("POST body = %q, want a JSON object", post.Body)
+	}
+	if post.Operation != "POST /pets" {
+		t.Fatalf("Operation = %q", post.Operation)
+	}
+}
+
+func TestSwagger2(t *testing.T) {
+	spec, err := Parse([]byte(`{
+		"swagger": "2.0",
+		"basePath": "/api",
+		"paths": {
+			"/login": {
+				"post": {
+					"parameters": [
+						{"name": "user", "in": "formData", "type": "string", "default": "admin"},
+						{"name": "next", "in": "query", "type": "string"}
+					]
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := spec.BaseURL(""); err == nil {
+		t.Fatal("BaseURL accepted a spec without a host")
+	}
+	base, err := spec.BaseURL("http://127.0.0.1:8080/")
+	if err != nil || base != "http://127.0.0.1:8080/api" {
+		t.Fatalf("BaseURL = %q, %v", base, err)
+	}
+
+	requests := spec.Requests(base, "FUZZ")
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	r := requests[0]
+	if r.URL != "http://127.0.0.1:8080/api/login?next=FUZZ" || r.Method != http.MethodPost {
+		t.Fatalf("request = %s %s", r.Method, r.URL)
+	}
+	if string(r.Body) != "user=admin" || r.Headers.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Fatalf("body = %q (%s)", r.Body, r.Headers.Get("Content-Type"))
+	}
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	if _, err := Parse([]byte("name: not a spec\n")); err == nil {
+		t.Fatal("Parse accepted a document that is not a spec")
+	}
+}
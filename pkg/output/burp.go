@@ -3,9 +3,11 @@ package output
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -29,6 +31,9 @@ type BurpWriter struct {
 	started bool
 	closed  bool
 	method  string
+	// appended is set when the header was written by an earlier run whose
+	// export this writer continues.
+	appended bool
 }
 
 type burpHost struct {
@@ -82,6 +87,60 @@ func NewBurpFile(path, method string) (*BurpWriter, error) {
 	return writer, nil
 }
 
+// AppendBurpFile opens the Burp export at path for a resumed run, keeping the
+// items an earlier run wrote. The closing tag, or whatever an interrupted run
+// left after its last complete item, is cut off so the new items follow. A
+// missing or empty export is started from scratch.
+func AppendBurpFile(path, method string) (*BurpWriter, error) {
+	keep, started, err := burpExportLength(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open burp export: %w", err)
+	}
+	if err := file.Truncate(keep); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("truncate burp export: %w", err)
+	}
+	if _, err := file.Seek(keep, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("seek burp export: %w", err)
+	}
+
+	writer := NewBurpWriter(file, method)
+	writer.closer = file
+	writer.started = started
+	writer.appended = started
+
+	return writer, nil
+}
+
+// burpExportLength returns how much of the Burp export at path to keep when
+// appending to it, and whether that part already holds the header.
+func burpExportLength(path string) (int64, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read burp export: %w", err)
+	}
+
+	if end := bytes.LastIndex(data, []byte("</items>")); end >= 0 {
+		return int64(end), true, nil
+	}
+	if end := bytes.LastIndex(data, []byte("</item>")); end >= 0 {
+		return int64(end + len("</item>")), true, nil
+	}
+	if start := bytes.Index(data, []byte("<items>")); start >= 0 {
+		return int64(start + len("<items>")), true, nil
+	}
+	return 0, false, nil
+}
+
 func (b *BurpWriter) Write(res engine.Result) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -128,12 +187,22 @@ func (b *BurpWriter) Close() error {
 		return err
 	}
 
-	if err := b.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "items"}}); err != nil {
-		return err
-	}
+	if b.appended {
+		// The encoder never saw the start tag an earlier run wrote.
+		if err := b.enc.Flush(); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(b.writer, "\n</items>"); err != nil {
+			return err
+		}
+	} else {
+		if err := b.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "items"}}); err != nil {
+			return err
+		}
 
-	if err := b.enc.Flush(); err != nil {
-		return err
+		if err := b.enc.Flush(); err != nil {
+			return err
+		}
 	}
 
 	if b.flush != nil {
@@ -360,13 +429,69 @@ func newBurpFinding(item burpItem) burpFinding {
 	return finding
 }
 
+const (
+	// burpQueueSize is how many findings wait for Burp before further ones
+	// are dropped.
+	burpQueueSize = 1024
+	// burpMaxAttempts is how often a finding is posted before it is given
+	// up.
+	burpMaxAttempts = 5
+	// burpRetryDelay is the first delay before a failed delivery is retried;
+	// it doubles up to burpMaxRetryDelay.
+	burpRetryDelay    = 250 * time.Millisecond
+	burpMaxRetryDelay = 10 * time.Second
+	// burpDrainTimeout is how long Close waits for the findings still
+	// queued.
+	burpDrainTimeout = 30 * time.Second
+)
+
+// burpLimits bounds how a BurpPoster queues and retries findings.
+type burpLimits struct {
+	queueSize    int
+	maxAttempts  int
+	retryDelay   time.Duration
+	drainTimeout time.Duration
+}
+
+var defaultBurpLimits = burpLimits{
+	queueSize:    burpQueueSize,
+	maxAttempts:  burpMaxAttempts,
+	retryDelay:   burpRetryDelay,
+	drainTimeout: burpDrainTimeout,
+}
+
+// BurpPoster streams findings as JSON to a Burp extension endpoint. Findings
+// are delivered in order by a background goroutine, so a slow or unavailable
+// Burp does not hold up the scan: a failed delivery is retried a few times
+// with backoff while later findings wait in a queue, and once the queue is
+// full further findings are dropped with a warning.
 type BurpPoster struct {
 	endpoint string
 	method   string
 	client   *http.Client
+	limits   burpLimits
+	queue    chan []byte
+	done     chan struct{}
+	once     sync.Once
+	// warn receives a warning when findings start being dropped.
+	warn io.Writer
+
+	// ctx is cancelled once the drain timeout of Close passes, aborting the
+	// delivery in flight and the findings still queued.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	undelivered int
+	dropped     int
+	err         error
 }
 
-func NewBurpPoster(host, method string) (*BurpPoster, error) {
+// NewBurpPoster returns a BurpPoster for the extension listening at host,
+// such as http://127.0.0.1:1337, or nil when host is empty. Warnings are
+// written to warn when it is not nil. Close must be called to deliver the
+// queued findings.
+func NewBurpPoster(host, method string, warn io.Writer) (*BurpPoster, error) {
 	endpoint, err := normalizeBurpEndpoint(host)
 	if err != nil {
 		return nil, err
@@ -375,20 +500,34 @@ func NewBurpPoster(host, method string) (*BurpPoster, error) {
 		return nil, nil
 	}
 
+	return newBurpPoster(endpoint, method, warn, defaultBurpLimits), nil
+}
+
+func newBurpPoster(endpoint, method string, warn io.Writer, limits burpLimits) *BurpPoster {
 	normalizedMethod := strings.ToUpper(strings.TrimSpace(method))
 	if normalizedMethod == "" {
 		normalizedMethod = http.MethodHead
 	}
 
-	return &BurpPoster{
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &BurpPoster{
 		endpoint: endpoint,
 		method:   normalizedMethod,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-	}, nil
+		limits: limits,
+		queue:  make(chan []byte, limits.queueSize),
+		done:   make(chan struct{}),
+		warn:   warn,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go b.deliver()
+	return b
 }
 
+// Write queues res for delivery to Burp, dropping it when the queue is full.
 func (b *BurpPoster) Write(res engine.Result) error {
 	if b == nil {
 		return nil
@@ -404,9 +543,107 @@ func (b *BurpPoster) Write(res engine.Result) error {
 		return fmt.Errorf("marshal burp finding: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, b.endpoint, bytes.NewReader(payload))
+	select {
+	case b.queue <- payload:
+		return nil
+	default:
+	}
+
+	b.mu.Lock()
+	b.dropped++
+	first := b.dropped == 1
+	b.mu.Unlock()
+
+	if first && b.warn != nil {
+		fmt.Fprintf(b.warn, "burp host %s is not keeping up; dropping findings while %d are queued\n", b.endpoint, b.limits.queueSize)
+	}
+	return nil
+}
+
+// Close waits up to the drain timeout for the queued findings to be
+// delivered and reports those that were not.
+func (b *BurpPoster) Close() error {
+	if b == nil {
+		return nil
+	}
+
+	b.once.Do(func() {
+		close(b.queue)
+		timer := time.AfterFunc(b.limits.drainTimeout, b.cancel)
+		<-b.done
+		timer.Stop()
+		b.cancel()
+	})
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.undelivered > 0 && b.dropped > 0:
+		return fmt.Errorf("%d findings were not delivered to burp host %s and %d were dropped while its queue was full: %w", b.undelivered, b.endpoint, b.dropped, b.err)
+	case b.undelivered > 0:
+		return fmt.Errorf("%d findings were not delivered to burp host %s: %w", b.undelivered, b.endpoint, b.err)
+	case b.dropped > 0:
+		return fmt.Errorf("%d findings were dropped while the queue of burp host %s was full", b.dropped, b.endpoint)
+	}
+	return nil
+}
+
+// deliver posts the queued findings in order until the queue is closed.
+func (b *BurpPoster) deliver() {
+	defer close(b.done)
+
+	for payload := range b.queue {
+		delay := b.limits.retryDelay
+		for attempt := 1; ; attempt++ {
+			err := b.post(payload)
+			if err == nil {
+				break
+			}
+			var permanent *burpRejectedError
+			if errors.As(err, &permanent) || attempt >= b.limits.maxAttempts || !b.sleep(delay) {
+				b.mu.Lock()
+				b.undelivered++
+				if b.err == nil {
+					b.err = err
+				}
+				b.mu.Unlock()
+				break
+			}
+			delay = min(delay*2, burpMaxRetryDelay)
+		}
+	}
+}
+
+// sleep waits for delay, reporting false when the drain timeout passes
+// first.
+func (b *BurpPoster) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-b.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// burpRejectedError is a finding Burp answered with a client error other
+// than 408 or 429, which retrying would not change.
+type burpRejectedError struct {
+	err error
+}
+
+func (e *burpRejectedError) Error() string { return e.err.Error() }
+
+func (e *burpRejectedError) Unwrap() error { return e.err }
+
+// post sends one finding to the endpoint.
+func (b *BurpPoster) post(payload []byte) error {
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodPost, b.endpoint, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("create burp request: %w", err)
+		return &burpRejectedError{fmt.Errorf("create burp request: %w", err)}
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -418,11 +655,15 @@ func (b *BurpPoster) Write(res engine.Result) error {
 
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		snippet := strings.TrimSpace(string(body))
-		if snippet != "" {
-			return fmt.Errorf("burp host %s responded with %s: %s", b.endpoint, resp.Status, snippet)
+		err := fmt.Errorf("burp host %s responded with %s", b.endpoint, resp.Status)
+		if snippet := strings.TrimSpace(string(body)); snippet != "" {
+			err = fmt.Errorf("burp host %s responded with %s: %s", b.endpoint, resp.Status, snippet)
 		}
-		return fmt.Errorf("burp host %s responded with %s", b.endpoint, resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return &burpRejectedError{err}
+		}
+		return err
 	}
 
 	_, _ = io.Copy(io.Discard, resp.Body)
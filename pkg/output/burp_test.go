@@ -0,0 +1,291 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// testBurpLimits keep retries and the drain short.
+var testBurpLimits = burpLimits{
+	queueSize:    8,
+	maxAttempts:  3,
+	retryDelay:   time.Millisecond,
+	drainTimeout: time.Second,
+}
+
+// burpServer records the URLs of the findings it accepts. respond picks the
+// status of each request, counting from 1.
+func burpServer(t *testing.T, respond func(n int) int) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		n    int
+		urls []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var finding burpFinding
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &finding); err != nil {
+			t.Errorf("decode finding: %v", err)
+		}
+
+		mu.Lock()
+		n++
+		status := respond(n)
+		if status < 300 {
+			urls = append(urls, finding.URL)
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), urls...)
+	}
+}
+
+func TestBurpPosterDelivery(t *testing.T) {
+	tests := []struct {
+		name      string
+		respond   func(n int) int
+		delivered []string
+		wantErr   string
+	}{
+		{
+			name:      "in order",
+			respond:   func(int) int { return http.StatusOK },
+			delivered: []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name: "retries server errors",
+			respond: func(n int) int {
+				if n == 1 {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusOK
+			},
+			delivered: []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name:    "gives up after the last attempt",
+			respond: func(int) int { return http.StatusServiceUnavailable },
+			wantErr: "2 findings were not delivered",
+		},
+		{
+			name: "does not retry rejected findings",
+			respond: func(n int) int {
+				if n == 1 {
+					return http.StatusBadRequest
+				}
+				return http.StatusOK
+			},
+			delivered: []string{"http://target/b"},
+			wantErr:   "1 findings were not delivered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, delivered := burpServer(t, tt.respond)
+			poster := newBurpPoster(server.URL, "", nil, testBurpLimits)
+
+			for _, url := range []string{"http://target/a", "http://target/b"} {
+				if err := poster.Write(engine.Result{URL: url, StatusCode: http.StatusOK}); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+
+			err := poster.Close()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if got := delivered(); strings.Join(got, ",") != strings.Join(tt.delivered, ",") {
+				t.Fatalf("expected %v delivered, got %v", tt.delivered, got)
+			}
+		})
+	}
+}
+
+func TestBurpPosterDropsFindingsWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	limits := testBurpLimits
+	limits.queueSize = 1
+	poster := newBurpPoster(server.URL, "", &warnings, limits)
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 10; i++ {
+			if err := poster.Write(engine.Result{URL: "http://target/a", StatusCode: http.StatusOK}); err != nil {
+				t.Errorf("write: %v", err)
+			}
+		}
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked while Burp was not answering")
+	}
+	close(release)
+
+	err := poster.Close()
+	if err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Fatalf("expected Close to report dropped findings, got %v", err)
+	}
+	if got := strings.Count(warnings.String(), "dropping findings"); got != 1 {
+		t.Fatalf("expected one warning, got %q", warnings.String())
+	}
+}
+
+func TestBurpPosterCloseEnforcesDrainTimeout(t *testing.T) {
+	stop := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stop:
+		}
+	}))
+	defer server.Close()
+	defer close(stop)
+
+	limits := testBurpLimits
+	limits.drainTimeout = 100 * time.Millisecond
+	poster := newBurpPoster(server.URL, "", nil, limits)
+	for i := 0; i < 3; i++ {
+		if err := poster.Write(engine.Result{URL: "http://target/a", StatusCode: http.StatusOK}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	start := time.Now()
+	err := poster.Close()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected Close to give up after the drain timeout, took %v", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "3 findings were not delivered") {
+		t.Fatalf("expected the queued findings to be reported, got %v", err)
+	}
+}
+
+// burpExportURLs decodes the Burp export at path and returns its item URLs.
+func burpExportURLs(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	var export struct {
+		Items []burpItem `xml:"item"`
+	}
+	if err := xml.Unmarshal(data, &export); err != nil {
+		t.Fatalf("decode export: %v\n%s", err, data)
+	}
+	var urls []string
+	for _, item := range export.Items {
+		urls = append(urls, item.URL)
+	}
+	return urls
+}
+
+func TestAppendBurpFileKeepsEarlierItems(t *testing.T) {
+	tests := []struct {
+		name string
+		// earlier writes the export of the earlier run, if any.
+		earlier func(t *testing.T, path string)
+		want    []string
+	}{
+		{
+			name:    "no earlier export",
+			earlier: func(t *testing.T, path string) {},
+			want:    []string{"http://target/b"},
+		},
+		{
+			name: "finished export",
+			earlier: func(t *testing.T, path string) {
+				writer, err := NewBurpFile(path, "GET")
+				if err != nil {
+					t.Fatalf("create export: %v", err)
+				}
+				if err := writer.Write(engine.Result{URL: "http://target/a", StatusCode: 200}); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+				if err := writer.Close(); err != nil {
+					t.Fatalf("close: %v", err)
+				}
+			},
+			want: []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name: "interrupted export",
+			earlier: func(t *testing.T, path string) {
+				// Items are flushed as they are written; the closing tag
+				// is missing and a crash cut the last item short.
+				writer, err := NewBurpFile(path, "GET")
+				if err != nil {
+					t.Fatalf("create export: %v", err)
+				}
+				if err := writer.Write(engine.Result{URL: "http://target/a", StatusCode: 200}); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+				writer.closer.Close()
+				file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatalf("open export: %v", err)
+				}
+				file.WriteString("\n  <item>\n    <time>")
+				file.Close()
+			},
+			want: []string{"http://target/a", "http://target/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.xml")
+			tt.earlier(t, path)
+
+			writer, err := AppendBurpFile(path, "GET")
+			if err != nil {
+				t.Fatalf("append export: %v", err)
+			}
+			if err := writer.Write(engine.Result{URL: "http://target/b", StatusCode: 200}); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			if got := burpExportURLs(t, path); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected items %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,10 +2,15 @@ package output
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +24,11 @@ type JSONLWriter struct {
 	flush             func() error
 	closer            io.Closer
 	includeSimilarity bool
+	headers           []string
+
+	// rotate, when set, is called after every entry to move a rotated
+	// output on to its next file.
+	rotate func() error
 }
 
 // RunHeader describes metadata emitted as the first JSONL entry for a run.
@@ -30,6 +40,41 @@ type RunHeader struct {
 	StartedAt string   `json:"started_at,omitempty"`
 	Config    []string `json:"config,omitempty"`
 	Payloads  []string `json:"payloads,omitempty"`
+	WAF       []string `json:"waf,omitempty"`
+	Stack     []string `json:"technologies,omitempty"`
+}
+
+// RunFooter summarizes a run and is emitted as the last JSONL entry.
+type RunFooter struct {
+	Type              string           `json:"type"`
+	RunID             string           `json:"run_id"`
+	Requests          int64            `json:"requests"`
+	Hits              int              `json:"hits"`
+	HitsByStatus      map[int]int      `json:"hits_by_status,omitempty"`
+	Errors            int              `json:"errors"`
+	ErrorTypes        map[string]int64 `json:"error_types,omitempty"`
+	DurationMS        int64            `json:"duration_ms"`
+	AvgLatencyMS      float64          `json:"avg_latency_ms"`
+	RequestsPerSecond float64          `json:"requests_per_second"`
+
+	// RateLimit describes the throttling the target applied, if any.
+	RateLimit *engine.RateLimitReport `json:"rate_limit,omitempty"`
+	// RobotsSkipped counts the URLs skipped because robots.txt disallowed
+	// them.
+	RobotsSkipped int64 `json:"robots_skipped,omitempty"`
+	// TruncatedBodies counts the responses whose body was longer than the
+	// part captured for matching.
+	TruncatedBodies int `json:"truncated_bodies,omitempty"`
+	// ErrorGroups lists the distinct request errors, most frequent first.
+	ErrorGroups []ErrorGroup `json:"error_groups,omitempty"`
+}
+
+// ErrorGroup counts the requests that failed with the same error.
+type ErrorGroup struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+	// Examples lists the first URLs that failed with the error.
+	Examples []string `json:"examples"`
 }
 
 // NewJSONLWriter returns a JSONLWriter that writes to w.
@@ -42,18 +87,75 @@ func NewJSONLWriter(w io.Writer, includeSimilarity bool) *JSONLWriter {
 	}
 }
 
-// NewJSONLFile creates a JSONLWriter that manages the lifecycle of the file at path.
+// NewJSONLFile creates a JSONLWriter that manages the lifecycle of the file at
+// path. The output is gzip-compressed when path ends in .gz.
 func NewJSONLFile(path string, includeSimilarity bool) (*JSONLWriter, error) {
 	file, err := os.Create(path)
 	if err != nil {
 		return nil, fmt.Errorf("create output file: %w", err)
 	}
 
+	return newJSONLFileWriter(path, file, includeSimilarity), nil
+}
+
+// AppendJSONLFile creates a JSONLWriter that appends to the file at path,
+// creating it when missing. It is used when a run resumes from its own output.
+// A .gz file gets a new gzip stream appended, which readers decompress along
+// with the earlier ones. Entries a crash left unreadable are dropped first.
+func AppendJSONLFile(path string, includeSimilarity bool) (*JSONLWriter, error) {
+	file, err := openAppendFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open output file: %w", err)
+	}
+
+	return newJSONLFileWriter(path, file, includeSimilarity), nil
+}
+
+// RotatingJSONLFile creates a JSONLWriter that splits its output across
+// numbered files, starting the next one once the current file holds limit
+// bytes; see RotatedPath for their names. When appending, it continues the
+// last file of a previous run.
+func RotatingJSONLFile(path string, includeSimilarity bool, limit int64, appending bool) (*JSONLWriter, error) {
+	file, err := openRotatingFile(path, limit, appending)
+	if err != nil {
+		return nil, fmt.Errorf("open output file: %w", err)
+	}
+
 	writer := NewJSONLWriter(file, includeSimilarity)
 	writer.closer = file
+	writer.rotate = file.rotate
 	return writer, nil
 }
 
+func newJSONLFileWriter(path string, file *os.File, includeSimilarity bool) *JSONLWriter {
+	out := wrapOutputFile(path, file)
+	writer := NewJSONLWriter(out, includeSimilarity)
+	writer.closer = out
+	return writer
+}
+
+// DefaultHeaders are the response headers added to result entries by
+// --output-include-headers, chosen for filtering results downstream.
+var DefaultHeaders = []string{"Content-Type", "Location", "Server"}
+
+// IncludeHeaders adds the named response headers to every result entry. Names
+// are matched case-insensitively and listed once. It must be called before
+// the first Write.
+func (j *JSONLWriter) IncludeHeaders(names []string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.headers = j.headers[:0]
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			name = http.CanonicalHeaderKey(name)
+			if !slices.Contains(j.headers, name) {
+				j.headers = append(j.headers, name)
+			}
+		}
+	}
+}
+
 // WriteHeader writes a metadata entry describing the run before any results.
 func (j *JSONLWriter) WriteHeader(header RunHeader) error {
 	if header.Type == "" {
@@ -76,23 +178,125 @@ func (j *JSONLWriter) WriteHeader(header RunHeader) error {
 	return nil
 }
 
+// WriteFooter writes the entry summarizing the run after all results.
+func (j *JSONLWriter) WriteFooter(footer RunFooter) error {
+	if footer.Type == "" {
+		footer.Type = "summary"
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.enc.Encode(footer); err != nil {
+		return err
+	}
+
+	if j.flush != nil {
+		if err := j.flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Write appends a result entry to the stream.
 func (j *JSONLWriter) Write(res engine.Result) error {
+	return j.write(res, false)
+}
+
+// WriteMatch appends a result entry marked as a match of the run's matchers.
+func (j *JSONLWriter) WriteMatch(res engine.Result) error {
+	return j.write(res, true)
+}
+
+func (j *JSONLWriter) write(res engine.Result, match bool) error {
 	entry := struct {
-		URL        string   `json:"url"`
-		Status     int      `json:"status"`
-		Size       int64    `json:"size"`
-		LatencyMS  float64  `json:"latency_ms"`
-		Similarity *float64 `json:"similarity,omitempty"`
-		Error      string   `json:"error,omitempty"`
+		URL        string            `json:"url"`
+		Method     string            `json:"method,omitempty"`
+		Word       string            `json:"word,omitempty"`
+		Payload    string            `json:"payload,omitempty"`
+		Status     int               `json:"status"`
+		Size       int64             `json:"size"`
+		Encoding   string            `json:"content_encoding,omitempty"`
+		Compressed int64             `json:"compressed_size,omitempty"`
+		Decoded    int64             `json:"decompressed_size,omitempty"`
+		Redirects  []jsonlRedirect   `json:"redirect_chain,omitempty"`
+		FinalURL   string            `json:"final_url,omitempty"`
+		RemoteIP   string            `json:"remote_ip,omitempty"`
+		BodySHA256 string            `json:"body_sha256,omitempty"`
+		Words      int               `json:"words,omitempty"`
+		Lines      int               `json:"lines,omitempty"`
+		Truncated  bool              `json:"body_truncated,omitempty"`
+		LatencyMS  float64           `json:"latency_ms"`
+		Timing     *jsonlTiming      `json:"timing,omitempty"`
+		Headers    map[string]string `json:"headers,omitempty"`
+		Similarity *float64          `json:"similarity,omitempty"`
+		Confidence *float64          `json:"confidence,omitempty"`
+		Tags       []string          `json:"tags,omitempty"`
+		Notes      []string          `json:"notes,omitempty"`
+		FollowUpOf string            `json:"follow_up_of,omitempty"`
+		Bypass     string            `json:"bypass,omitempty"`
+		Listing    string            `json:"listing,omitempty"`
+		Severity   string            `json:"severity,omitempty"`
+		Stack      []string          `json:"technologies,omitempty"`
+		Screenshot string            `json:"screenshot,omitempty"`
+		Probe      int               `json:"probe_status,omitempty"`
+		Matched    bool              `json:"matched"`
+		Rules      []string          `json:"matched_rules,omitempty"`
+		Error      string            `json:"error,omitempty"`
+
+		// GraphQL is the introspection probe of a GraphQL endpoint.
+		GraphQL *engine.GraphQLReport `json:"graphql,omitempty"`
 	}{
-		URL:    res.URL,
-		Status: res.StatusCode,
-		Size:   res.ContentLength,
+		URL:        res.URL,
+		Method:     res.RequestMethod,
+		Word:       res.Word,
+		Payload:    res.Payload,
+		Status:     res.StatusCode,
+		Size:       res.ContentLength,
+		Encoding:   res.ContentEncoding,
+		Compressed: res.CompressedSize,
+		Decoded:    res.DecompressedSize,
+		RemoteIP:   res.RemoteIP,
+		BodySHA256: res.BodySHA256,
+		Words:      res.BodyWords,
+		Lines:      res.BodyLines,
+		Truncated:  res.BodyTruncated,
+		Tags:       res.Tags,
+		Notes:      res.Notes,
+		FollowUpOf: res.FollowUpOf,
+		Bypass:     res.Bypass,
+		Listing:    res.Listing,
+		Severity:   res.Severity,
+		Stack:      res.Technologies,
+		Screenshot: res.Screenshot,
+		GraphQL:    res.GraphQL,
+		Probe:      res.ProbeStatus,
+		Matched:    match,
+		Rules:      res.MatchedRules,
+	}
+
+	if len(res.RedirectChain) > 0 {
+		entry.Redirects = make([]jsonlRedirect, len(res.RedirectChain))
+		for i, hop := range res.RedirectChain {
+			entry.Redirects[i] = jsonlRedirect{URL: hop.URL, Status: hop.StatusCode}
+		}
+		entry.FinalURL = res.RequestURL
 	}
 
 	if res.Duration > 0 {
-		entry.LatencyMS = float64(res.Duration) / float64(time.Millisecond)
+		entry.LatencyMS = milliseconds(res.Duration)
+	}
+
+	if res.Timing != (engine.Timing{}) {
+		entry.Timing = &jsonlTiming{
+			DNSMS:      milliseconds(res.Timing.DNS),
+			ConnectMS:  milliseconds(res.Timing.Connect),
+			TLSMS:      milliseconds(res.Timing.TLSHandshake),
+			TTFBMS:     milliseconds(res.Timing.TTFB),
+			ConnReused: res.Timing.ConnReused,
+		}
 	}
 
 	if j.includeSimilarity && res.HasSimilarity {
@@ -100,6 +304,11 @@ func (j *JSONLWriter) Write(res engine.Result) error {
 		entry.Similarity = &similarity
 	}
 
+	if res.HasConfidence {
+		confidence := res.Confidence
+		entry.Confidence = &confidence
+	}
+
 	if res.Err != nil {
 		entry.Error = res.Err.Error()
 	}
@@ -107,6 +316,17 @@ func (j *JSONLWriter) Write(res engine.Result) error {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
+	for _, name := range j.headers {
+		values := res.ResponseHeader.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if entry.Headers == nil {
+			entry.Headers = make(map[string]string, len(j.headers))
+		}
+		entry.Headers[name] = strings.Join(values, ", ")
+	}
+
 	if err := j.enc.Encode(entry); err != nil {
 		return err
 	}
@@ -117,6 +337,10 @@ func (j *JSONLWriter) Write(res engine.Result) error {
 		}
 	}
 
+	if j.rotate != nil {
+		return j.rotate()
+	}
+
 	return nil
 }
 
@@ -137,3 +361,151 @@ func (j *JSONLWriter) Close() error {
 
 	return nil
 }
+
+// LoadJSONLAttempts reads a JSONL results file written by a previous run and
+// returns the set of URLs that were attempted successfully. Entries that ended
+// in an error are left out so they are retried. The numbered files an output
+// was rotated into are read along with it, and files ending in .gz are
+// decompressed.
+func LoadJSONLAttempts(path string) (map[string]struct{}, error) {
+	results, err := loadJSONLParts(path, "resume")
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make(map[string]struct{}, len(results))
+	for _, res := range results {
+		attempts[res.URL] = struct{}{}
+	}
+	return attempts, nil
+}
+
+// ReadJSONLAttempts parses JSONL results from r. Run headers, other typed
+// records, and follow-up requests are ignored.
+func ReadJSONLAttempts(r io.Reader) (map[string]struct{}, error) {
+	results, err := ReadJSONLResults(r)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make(map[string]struct{}, len(results))
+	for _, res := range results {
+		attempts[res.URL] = struct{}{}
+	}
+	return attempts, nil
+}
+
+// JSONLResult is a result entry read back from a JSONL results file.
+type JSONLResult struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+	Status int    `json:"status"`
+	Size   int64  `json:"size"`
+	// Match is set on the entries written by WriteMatch.
+	Match bool `json:"matched"`
+
+	// BodySHA256 is the hash of the captured body, empty when the body was
+	// not read.
+	BodySHA256 string `json:"body_sha256"`
+}
+
+// LoadJSONLResults reads the result entries of a JSONL results file written
+// by a previous run, in file order. Entries that ended in an error are left
+// out. The numbered files an output was rotated into are read after it, and
+// files ending in .gz are decompressed.
+func LoadJSONLResults(path string) ([]JSONLResult, error) {
+	return loadJSONLParts(path, "results")
+}
+
+// loadJSONLParts reads the result entries of the JSONL file at path and of
+// every numbered file rotated from it, stopping at the first missing one; see
+// RotatedPath. kind names the file in errors.
+func loadJSONLParts(path, kind string) ([]JSONLResult, error) {
+	var results []JSONLResult
+	for index := 0; ; index++ {
+		part := RotatedPath(path, index)
+		file, err := openJSONL(part)
+		if index > 0 && errors.Is(err, os.ErrNotExist) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("open %s file: %w", kind, err)
+		}
+
+		entries, err := ReadJSONLResults(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s file %s: %w", kind, part, err)
+		}
+		results = append(results, entries...)
+	}
+}
+
+// ReadJSONLResults parses JSONL result entries from r. Run headers, other
+// typed records, follow-up requests, and errors are ignored, and so is a last
+// entry a crash left without its end.
+func ReadJSONLResults(r io.Reader) ([]JSONLResult, error) {
+	var results []JSONLResult
+	reader := bufio.NewReader(r)
+	lineNumber := 0
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			lineNumber++
+
+			var entry struct {
+				JSONLResult
+				Type       string `json:"type"`
+				FollowUpOf string `json:"follow_up_of"`
+				Error      string `json:"error"`
+				// LegacyMatch is how files written before "matched"
+				// marked matches.
+				LegacyMatch bool `json:"match"`
+			}
+			decodeErr := json.Unmarshal(trimmed, &entry)
+			if decodeErr != nil && errors.Is(err, io.EOF) {
+				// A last line without its newline was cut short by a
+				// crash.
+				break
+			}
+			if decodeErr != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, decodeErr)
+			}
+			entry.Match = entry.Match || entry.LegacyMatch
+
+			if entry.Type == "" && entry.URL != "" && entry.FollowUpOf == "" && entry.Error == "" {
+				results = append(results, entry.JSONLResult)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// jsonlTiming is the per-phase latency breakdown of a result entry.
+type jsonlTiming struct {
+	DNSMS      float64 `json:"dns_ms"`
+	ConnectMS  float64 `json:"connect_ms"`
+	TLSMS      float64 `json:"tls_ms"`
+	TTFBMS     float64 `json:"ttfb_ms"`
+	ConnReused bool    `json:"conn_reused"`
+}
+
+// jsonlRedirect is one redirect followed before the final response.
+type jsonlRedirect struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,275 @@
+package output
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gzipFlushInterval is the longest compressed output waits before it is
+// flushed to the file, and so the most output a crash can lose.
+const gzipFlushInterval = time.Second
+
+// IsGzipPath reports whether path names a gzip-compressed file, by its .gz
+// extension.
+func IsGzipPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".gz")
+}
+
+// gzipFile compresses everything written to it into a file. Compressed data
+// is flushed to the file gzipFlushInterval after it was written instead of
+// after every write, which keeps the compression ratio of long streams while
+// a file cut short by a crash still decompresses up to the last flush.
+type gzipFile struct {
+	mu     sync.Mutex
+	gz     *gzip.Writer
+	file   *os.File
+	timer  *time.Timer
+	closed bool
+	// err is the first failed flush, reported by Close.
+	err error
+}
+
+func newGzipFile(file *os.File) *gzipFile {
+	return &gzipFile{gz: gzip.NewWriter(file), file: file}
+}
+
+func (g *gzipFile) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	n, err := g.gz.Write(p)
+	if err == nil && g.timer == nil && !g.closed {
+		g.timer = time.AfterFunc(gzipFlushInterval, g.sync)
+	}
+	return n, err
+}
+
+// sync flushes the compressed data written so far to the file.
+func (g *gzipFile) sync() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.timer = nil
+	if g.closed {
+		return
+	}
+	if err := g.gz.Flush(); err != nil && g.err == nil {
+		g.err = err
+	}
+}
+
+// Close ends the gzip stream and closes the file.
+func (g *gzipFile) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	err := g.gz.Close()
+	if closeErr := g.file.Close(); err == nil {
+		err = closeErr
+	}
+	if g.err != nil {
+		return g.err
+	}
+	return err
+}
+
+// openJSONL opens a JSONL file for reading, decompressing it when path ends
+// in .gz. Every gzip stream appended by a resumed run is read, and a stream
+// cut short by a crash reads as ending at its last flush.
+func openJSONL(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil || !IsGzipPath(path) {
+		return file, err
+	}
+
+	gz, err := gzip.NewReader(file)
+	if errors.Is(err, io.EOF) {
+		// An empty file holds no streams yet.
+		return file, nil
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &gzipReader{gz: gz, file: file}, nil
+}
+
+// gzipReader reads a gzip file whose end may have been cut short.
+type gzipReader struct {
+	gz   *gzip.Reader
+	file *os.File
+}
+
+func (r *gzipReader) Read(p []byte) (int, error) {
+	n, err := r.gz.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *gzipReader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
+
+// repairOutputFile cuts off what a crash left unreadable at the end of the
+// output file at path, so that a resumed run can append to it. That is a last
+// entry written only in part and, in a .gz file, a gzip stream without its
+// trailer: readers can not get past such a stream to one appended after it,
+// so a damaged .gz file is rewritten as a single stream of the entries read
+// back from it. A missing file is left alone.
+func repairOutputFile(path string) error {
+	if IsGzipPath(path) {
+		return repairGzipFile(path)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	keep, err := lastLineEnd(file, size)
+	if err != nil || keep == size {
+		return err
+	}
+	return file.Truncate(keep)
+}
+
+// lastLineEnd returns the offset just past the last newline among the first
+// size bytes of file, or 0 when there is none.
+func lastLineEnd(file *os.File, size int64) (int64, error) {
+	buf := make([]byte, 32*1024)
+	for end := size; end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		chunk := buf[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+func repairGzipFile(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	keep, intact, err := readableLength(file)
+	if err != nil || intact {
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if info, err := file.Stat(); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if keep > 0 {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		out := gzip.NewWriter(tmp)
+		if _, err := io.CopyN(out, gz, keep); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readableLength decompresses the gzip file r and returns how many bytes of
+// it can be read back up to the end of the last complete line. intact reports
+// whether that is all of it, with every stream ending properly.
+func readableLength(r io.Reader) (keep int64, intact bool, err error) {
+	gz, err := gzip.NewReader(r)
+	if errors.Is(err, io.EOF) {
+		// An empty file holds no streams yet.
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, damaged(err)
+	}
+	defer gz.Close()
+
+	var lines lineCounter
+	_, err = io.Copy(&lines, gz)
+	if err != nil {
+		return lines.end, false, damaged(err)
+	}
+	return lines.end, lines.end == lines.n, nil
+}
+
+// damaged returns nil for the errors a gzip file cut short by a crash, or
+// with a stream appended after such an end, reads with, and err otherwise.
+func damaged(err error) error {
+	var corrupt flate.CorruptInputError
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, gzip.ErrHeader) ||
+		errors.Is(err, gzip.ErrChecksum) || errors.As(err, &corrupt) {
+		return nil
+	}
+	return err
+}
+
+// lineCounter counts the bytes written to it and where the last line ends.
+type lineCounter struct {
+	n   int64
+	end int64
+}
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		c.end = c.n + int64(i) + 1
+	}
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,379 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// resultLine is the JSONL entry of a result for url.
+func resultLine(url string) string {
+	return fmt.Sprintf(`{"url":%q,"method":"GET","status":200,"size":1}`+"\n", url)
+}
+
+// writeCrashed writes content to path the way a run that crashed after its
+// last flush leaves it: a .gz file holds a gzip stream without its trailer.
+func writeCrashed(t *testing.T, path, content string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer file.Close()
+
+	if !IsGzipPath(path) {
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return
+	}
+
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := gz.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+}
+
+func loadURLs(t *testing.T, path string) []string {
+	t.Helper()
+
+	results, err := LoadJSONLResults(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var urls []string
+	for _, res := range results {
+		urls = append(urls, res.URL)
+	}
+	return urls
+}
+
+func TestJSONLResumeAfterCrash(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		// before are the URLs read back before the resumed run appends.
+		before []string
+	}{
+		{
+			name:    "gzip stream without trailer",
+			file:    "results.jsonl.gz",
+			content: resultLine("http://target/a") + resultLine("http://target/b"),
+			before:  []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name:    "gzip entry cut short",
+			file:    "results.jsonl.gz",
+			content: resultLine("http://target/a") + `{"url":"http://tar`,
+			before:  []string{"http://target/a"},
+		},
+		{
+			name:    "plain entry cut short",
+			file:    "results.jsonl",
+			content: resultLine("http://target/a") + `{"url":"http://tar`,
+			before:  []string{"http://target/a"},
+		},
+		{
+			name:    "gzip nothing flushed",
+			file:    "results.jsonl.gz",
+			content: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeCrashed(t, path, tt.content)
+
+			if got := loadURLs(t, path); !slices.Equal(got, tt.before) {
+				t.Fatalf("expected %v before resuming, got %v", tt.before, got)
+			}
+			attempts, err := LoadJSONLAttempts(path)
+			if err != nil {
+				t.Fatalf("load attempts: %v", err)
+			}
+			if len(attempts) != len(tt.before) {
+				t.Fatalf("expected %d attempts, got %v", len(tt.before), attempts)
+			}
+
+			writer, err := AppendJSONLFile(path, false)
+			if err != nil {
+				t.Fatalf("append: %v", err)
+			}
+			if err := writer.Write(engine.Result{URL: "http://target/c", StatusCode: 200}); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			want := append(slices.Clone(tt.before), "http://target/c")
+			if got := loadURLs(t, path); !slices.Equal(got, want) {
+				t.Fatalf("expected %v after resuming, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestJSONLAppendKeepsIntactGzipStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl.gz")
+
+	for _, url := range []string{"http://target/a", "http://target/b"} {
+		writer, err := AppendJSONLFile(path, false)
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		if err := writer.Write(engine.Result{URL: url, StatusCode: 200}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	}
+
+	if got, want := loadURLs(t, path), []string{"http://target/a", "http://target/b"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+// writeRotated writes a result for each URL to a rotated output at path,
+// starting a new file after every entry.
+func writeRotated(t *testing.T, path string, appending bool, urls ...string) {
+	t.Helper()
+
+	writer, err := RotatingJSONLFile(path, false, 1, appending)
+	if err != nil {
+		t.Fatalf("open rotated output: %v", err)
+	}
+	for _, url := range urls {
+		if err := writer.Write(engine.Result{URL: url, StatusCode: 200}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestJSONLLoadsRotatedFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		// run writes the output at path.
+		run   func(t *testing.T, path string)
+		parts int
+		want  []string
+	}{
+		{
+			name: "rotated",
+			file: "results.jsonl",
+			run: func(t *testing.T, path string) {
+				writeRotated(t, path, false, "http://target/a", "http://target/b", "http://target/c")
+			},
+			parts: 3,
+			want:  []string{"http://target/a", "http://target/b", "http://target/c"},
+		},
+		{
+			name: "rotated gzip",
+			file: "results.jsonl.gz",
+			run: func(t *testing.T, path string) {
+				writeRotated(t, path, false, "http://target/a", "http://target/b")
+			},
+			parts: 2,
+			want:  []string{"http://target/a", "http://target/b"},
+		},
+		{
+			name: "appended",
+			file: "results.jsonl.gz",
+			run: func(t *testing.T, path string) {
+				writeRotated(t, path, false, "http://target/a", "http://target/b")
+				writeRotated(t, path, true, "http://target/c", "http://target/d")
+			},
+			// The resumed run finishes the last file before starting one.
+			parts: 3,
+			want:  []string{"http://target/a", "http://target/b", "http://target/c", "http://target/d"},
+		},
+		{
+			name: "replaced by a shorter run",
+			file: "results.jsonl",
+			run: func(t *testing.T, path string) {
+				writeRotated(t, path, false, "http://target/a", "http://target/b", "http://target/c")
+				writeRotated(t, path, false, "http://target/d")
+			},
+			parts: 1,
+			want:  []string{"http://target/d"},
+		},
+		{
+			name: "resumed after a crash",
+			file: "results.jsonl.gz",
+			run: func(t *testing.T, path string) {
+				writeRotated(t, path, false, "http://target/a")
+				writeCrashed(t, RotatedPath(path, 1), resultLine("http://target/b")+`{"url":"http://tar`)
+				writeRotated(t, path, true, "http://target/c")
+			},
+			parts: 2,
+			want:  []string{"http://target/a", "http://target/b", "http://target/c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			tt.run(t, path)
+
+			if _, err := os.Stat(RotatedPath(path, tt.parts-1)); err != nil {
+				t.Fatalf("expected %d files: %v", tt.parts, err)
+			}
+			if _, err := os.Stat(RotatedPath(path, tt.parts)); err == nil {
+				t.Fatalf("expected only %d files", tt.parts)
+			}
+
+			if got := loadURLs(t, path); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			attempts, err := LoadJSONLAttempts(path)
+			if err != nil {
+				t.Fatalf("load attempts: %v", err)
+			}
+			for _, url := range tt.want {
+				if _, ok := attempts[url]; !ok {
+					t.Fatalf("expected %s among the attempts, got %v", url, attempts)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONLLoadRequiresFirstFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	writeCrashed(t, RotatedPath(path, 1), resultLine("http://target/a"))
+
+	if _, err := LoadJSONLResults(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the missing first file to be reported, got %v", err)
+	}
+}
+
+func TestJSONLIncludeHeaders(t *testing.T) {
+	response := http.Header{
+		"Content-Type": {"text/html"},
+		"Location":     {"/login"},
+		"Server":       {"nginx"},
+		"X-Powered-By": {"PHP/8.2"},
+		"Set-Cookie":   {"a=1", "b=2"},
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		// listed is the number of distinct headers looked up.
+		listed int
+		want   map[string]string
+	}{
+		{name: "none", names: nil, want: nil},
+		{
+			name:   "defaults",
+			names:  DefaultHeaders,
+			listed: 3,
+			want:   map[string]string{"Content-Type": "text/html", "Location": "/login", "Server": "nginx"},
+		},
+		{
+			name:   "case-insensitive names listed once",
+			names:  append([]string{" x-powered-by", "SERVER", ""}, DefaultHeaders...),
+			listed: 4,
+			want:   map[string]string{"Content-Type": "text/html", "Location": "/login", "Server": "nginx", "X-Powered-By": "PHP/8.2"},
+		},
+		{name: "repeated values joined", names: []string{"set-cookie"}, listed: 1, want: map[string]string{"Set-Cookie": "a=1, b=2"}},
+		{name: "missing header left out", names: []string{"X-Frame-Options"}, listed: 1, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewJSONLWriter(&buf, false)
+			writer.IncludeHeaders(tt.names)
+			if len(writer.headers) != tt.listed {
+				t.Fatalf("expected %d headers, got %v", tt.listed, writer.headers)
+			}
+			if err := writer.Write(engine.Result{URL: "http://target/a", StatusCode: 200, ResponseHeader: response}); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			var entry struct {
+				Headers map[string]string `json:"headers"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("decode entry: %v", err)
+			}
+			if !maps.Equal(entry.Headers, tt.want) {
+				t.Fatalf("expected headers %v, got %v", tt.want, entry.Headers)
+			}
+		})
+	}
+}
+
+func TestReadJSONLResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines string
+		want  []JSONLResult
+	}{
+		{
+			name:  "result entry",
+			lines: `{"url":"http://target/a","method":"GET","status":200,"size":5,"body_sha256":"abc","matched":true}`,
+			want:  []JSONLResult{{URL: "http://target/a", Method: "GET", Status: 200, Size: 5, BodySHA256: "abc", Match: true}},
+		},
+		{
+			name:  "legacy match field",
+			lines: `{"url":"http://target/a","status":200,"match":true}`,
+			want:  []JSONLResult{{URL: "http://target/a", Status: 200, Match: true}},
+		},
+		{
+			name: "other entries ignored",
+			lines: `{"type":"run","run_id":"x"}
+{"url":"http://target/a","status":200}
+{"url":"http://target/a/","status":200,"follow_up_of":"http://target/a"}
+{"url":"http://target/b","error":"timeout"}
+
+{"type":"summary","run_id":"x"}`,
+			want: []JSONLResult{{URL: "http://target/a", Status: 200}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJSONLResults(strings.NewReader(tt.lines + "\n"))
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadJSONLResultsReportsMalformedLines(t *testing.T) {
+	_, err := ReadJSONLResults(strings.NewReader("{\"url\":\"http://target/a\"}\nnot json\n{\"url\":\"http://target/b\"}\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error for line 2, got %v", err)
+	}
+}
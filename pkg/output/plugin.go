@@ -0,0 +1,156 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+)
+
+const (
+	// pluginSinkQueue is the number of results buffered for a sink plugin
+	// before Write starts to block.
+	pluginSinkQueue = 256
+	// pluginSinkShutdown bounds how long Close waits for a sink plugin to exit
+	// after its stdin is closed before killing it.
+	pluginSinkShutdown = 10 * time.Second
+)
+
+// PluginSink streams results as JSONL, in the same format as the JSONL output
+// file, to the stdin of an external program that handles delivery, such as a
+// ticketing system or SIEM. Results are written by a background goroutine;
+// when the program falls behind and the queue fills up, Write blocks so the
+// scan slows down instead of dropping results.
+type PluginSink struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
+	writer *JSONLWriter
+
+	queue     chan engine.Result
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// StartPluginSink launches the program at path and returns a sink that feeds
+// its stdin. The program runs until Close is called or ctx is cancelled.
+func StartPluginSink(ctx context.Context, path string, includeSimilarity bool) (*PluginSink, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("output plugin path is empty")
+	}
+
+	cmd := exec.CommandContext(ctx, path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("open output plugin stdin: %w", err)
+	}
+
+	s := &PluginSink{
+		cmd:    cmd,
+		stdin:  stdin,
+		stderr: &bytes.Buffer{},
+		writer: NewJSONLWriter(stdin, includeSimilarity),
+		queue:  make(chan engine.Result, pluginSinkQueue),
+		done:   make(chan struct{}),
+	}
+	cmd.Stderr = s.stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start output plugin: %w", err)
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+// IncludeHeaders adds the named response headers to every hit sent to the
+// program. It must be called before Write.
+func (s *PluginSink) IncludeHeaders(names []string) {
+	s.writer.IncludeHeaders(names)
+}
+
+// WriteHeader sends the run metadata entry. It must be called before Write.
+func (s *PluginSink) WriteHeader(header RunHeader) error {
+	if err := s.writer.WriteHeader(header); err != nil {
+		return fmt.Errorf("write output plugin: %w", err)
+	}
+	return nil
+}
+
+// Write queues res for the plugin, blocking while the queue is full. It
+// returns the error that stopped the plugin, if any.
+func (s *PluginSink) Write(res engine.Result) error {
+	if err := s.failure(); err != nil {
+		return err
+	}
+
+	select {
+	case s.queue <- res:
+		return nil
+	case <-s.done:
+		return s.failure()
+	}
+}
+
+// Close waits for queued results to be delivered, closes the plugin's stdin,
+// and waits for it to exit. A plugin that does not exit within a few seconds
+// is killed.
+func (s *PluginSink) Close() error {
+	s.closeOnce.Do(func() { close(s.queue) })
+	<-s.done
+
+	err := s.failure()
+	if closeErr := s.writer.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("write output plugin: %w", closeErr)
+	}
+	_ = s.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- s.cmd.Wait() }()
+
+	var waitErr error
+	select {
+	case waitErr = <-exited:
+	case <-time.After(pluginSinkShutdown):
+		_ = s.cmd.Process.Kill()
+		waitErr = <-exited
+	}
+
+	if waitErr != nil {
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			return fmt.Errorf("output plugin: %s: %w", msg, waitErr)
+		}
+		return fmt.Errorf("output plugin: %w", waitErr)
+	}
+	return err
+}
+
+func (s *PluginSink) run() {
+	defer close(s.done)
+
+	for res := range s.queue {
+		if err := s.writer.Write(res); err != nil {
+			s.mu.Lock()
+			s.err = fmt.Errorf("write output plugin: %w", err)
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (s *PluginSink) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+)
+
+// writeSinkScript writes a shell script acting as an output plugin.
+func writeSinkScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("output plugin tests use shell scripts")
+	}
+
+	path := filepath.Join(t.TempDir(), "sink.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("write plugin script: %v", err)
+	}
+	return path
+}
+
+func TestPluginSink(t *testing.T) {
+	tests := []struct {
+		name string
+		// script is run with $OUT naming the file it may write stdin to.
+		script  string
+		results int
+		// wantLines is the number of JSONL lines the plugin should receive,
+		// or -1 when it is not checked.
+		wantLines int
+		wantErr   string
+	}{
+		{name: "delivers header and results", script: `cat > "$OUT"`, results: 3, wantLines: 4},
+		{name: "delivers a large backlog", script: `cat > "$OUT"`, results: pluginSinkQueue * 3, wantLines: pluginSinkQueue*3 + 1},
+		{name: "reports exit status and stderr", script: `cat > "$OUT"; echo "ticket API down" >&2; exit 3`, results: 1, wantLines: 2, wantErr: "ticket API down"},
+		{name: "reports a plugin that stops reading", script: `exit 0`, results: pluginSinkQueue * 4, wantLines: -1, wantErr: "write output plugin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "delivered.jsonl")
+			t.Setenv("OUT", out)
+			path := writeSinkScript(t, tt.script)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+			defer cancel()
+
+			sink, err := StartPluginSink(ctx, path, false)
+			if err != nil {
+				t.Fatalf("start sink: %v", err)
+			}
+
+			errs := []error{sink.WriteHeader(RunHeader{RunID: "run-1"})}
+			for i := 0; i < tt.results; i++ {
+				if err := sink.Write(engine.Result{URL: "https://example.com/" + strings.Repeat("a", i%8), StatusCode: 200}); err != nil {
+					errs = append(errs, err)
+					break
+				}
+			}
+			errs = append(errs, sink.Close())
+
+			var firstErr error
+			for _, err := range errs {
+				if err != nil {
+					firstErr = err
+					break
+				}
+			}
+			switch {
+			case tt.wantErr == "" && firstErr != nil:
+				t.Fatalf("unexpected error: %v", firstErr)
+			case tt.wantErr != "" && (firstErr == nil || !strings.Contains(firstErr.Error(), tt.wantErr)):
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, firstErr)
+			}
+			if ctx.Err() != nil {
+				t.Fatal("sink did not finish before the deadline")
+			}
+
+			if tt.wantLines < 0 {
+				return
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("read delivered output: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != tt.wantLines {
+				t.Fatalf("expected %d delivered lines, got %d", tt.wantLines, len(lines))
+			}
+			if !strings.Contains(lines[0], `"type":"run"`) || !strings.Contains(lines[0], `"run_id":"run-1"`) {
+				t.Fatalf("expected the run header first, got %s", lines[0])
+			}
+		})
+	}
+}
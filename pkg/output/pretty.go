@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +20,7 @@ const (
 	statusColumnWidth     = 8
 	sizeColumnWidth       = 10
 	latencyColumnWidth    = 12
+	severityColumnWidth   = 8
 	similarityColumnWidth = 12
 )
 
@@ -43,6 +46,29 @@ func ParseViewMode(v string) (ViewMode, error) {
 	}
 }
 
+// SortOrder controls the order in which table rows are printed.
+type SortOrder int
+
+const (
+	// SortFound prints every row as soon as its result arrives.
+	SortFound SortOrder = iota
+	// SortSeverity holds the rows until Flush and prints the most severe
+	// first, keeping the order found among rows of the same severity.
+	SortSeverity
+)
+
+// ParseSortOrder validates and returns a SortOrder.
+func ParseSortOrder(v string) (SortOrder, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "found":
+		return SortFound, nil
+	case "severity":
+		return SortSeverity, nil
+	default:
+		return SortFound, fmt.Errorf("unknown sort order %q (choose from found, severity)", v)
+	}
+}
+
 // ColorMode controls whether ANSI color is applied to pretty output.
 type ColorMode int
 
@@ -183,6 +209,7 @@ type PrettyOptions struct {
 	ColorMode      ColorMode
 	ColorPreset    ColorPreset
 	TargetURL      string
+	Sort           SortOrder
 }
 
 // PrettyWriter renders engine results using the configured view mode.
@@ -197,6 +224,8 @@ type PrettyWriter struct {
 	colorEnabled bool
 	tableWidths  []int
 	tree         *treePrinter
+	// sorted holds the table rows until Flush with SortSeverity.
+	sorted []engine.Result
 }
 
 // NewPrettyWriter returns a PrettyWriter that writes to w.
@@ -205,7 +234,7 @@ func NewPrettyWriter(w io.Writer, opts PrettyOptions) *PrettyWriter {
 		w:           w,
 		opts:        opts,
 		palette:     paletteCatalog[ColorPresetDefault],
-		tableWidths: []int{urlColumnWidth, statusColumnWidth, sizeColumnWidth, latencyColumnWidth},
+		tableWidths: []int{urlColumnWidth, statusColumnWidth, sizeColumnWidth, latencyColumnWidth, severityColumnWidth},
 	}
 
 	if palette, ok := paletteCatalog[opts.ColorPreset]; ok {
@@ -240,6 +269,10 @@ func (p *PrettyWriter) Write(res engine.Result) error {
 		}
 		return nil
 	}
+	if p.opts.Sort == SortSeverity {
+		p.sorted = append(p.sorted, res)
+		return nil
+	}
 
 	return p.writeTableRow(res)
 }
@@ -257,6 +290,18 @@ func (p *PrettyWriter) Flush() error {
 		}
 	}
 
+	if len(p.sorted) > 0 {
+		sort.SliceStable(p.sorted, func(i, j int) bool {
+			return engine.SeverityRank(p.sorted[i].Severity) > engine.SeverityRank(p.sorted[j].Severity)
+		})
+		for _, res := range p.sorted {
+			if err := p.writeTableRow(res); err != nil {
+				return err
+			}
+		}
+		p.sorted = nil
+	}
+
 	if p.flusher != nil {
 		return p.flusher()
 	}
@@ -285,7 +330,7 @@ func (p *PrettyWriter) writeTableRow(res engine.Result) error {
 }
 
 func (p *PrettyWriter) printTableHeader() error {
-	headers := []string{"URL", "STATUS", "SIZE", "LATENCY"}
+	headers := []string{"URL", "STATUS", "SIZE", "LATENCY", "SEVERITY"}
 	if p.opts.ShowSimilarity {
 		headers = append(headers, "SIMILARITY")
 	}
@@ -317,6 +362,7 @@ func (p *PrettyWriter) renderTableRow(res engine.Result) string {
 		formatStatus(res),
 		formatSize(res),
 		formatLatency(res.Duration),
+		formatSeverity(res),
 	}
 	if p.opts.ShowSimilarity {
 		columns = append(columns, formatSimilarity(res))
@@ -344,6 +390,10 @@ func (p *PrettyWriter) renderTableRow(res engine.Result) string {
 				formatted = wrapColor(formatted, p.palette.Latency, p.palette.Reset)
 			}
 		case 4:
+			if p.colorEnabled {
+				formatted = wrapColor(formatted, p.severityColor(res), p.palette.Reset)
+			}
+		case 5:
 			if p.colorEnabled && p.palette.Similarity != "" {
 				formatted = wrapColor(formatted, p.palette.Similarity, p.palette.Reset)
 			}
@@ -351,10 +401,29 @@ func (p *PrettyWriter) renderTableRow(res engine.Result) string {
 		builder.WriteString(formatted)
 		if i < len(columns)-1 {
 			builder.WriteString("  ")
-		} else {
-			builder.WriteByte('\n')
 		}
 	}
+	if redirects := formatRedirects(res); redirects != "" {
+		builder.WriteString("  ")
+		builder.WriteString(redirects)
+	}
+	if listing := p.formatListing(res); listing != "" {
+		builder.WriteString("  ")
+		builder.WriteString(listing)
+	}
+	if graphQL := p.formatGraphQL(res); graphQL != "" {
+		builder.WriteString("  ")
+		builder.WriteString(graphQL)
+	}
+	if stack := formatTechnologies(res); stack != "" {
+		builder.WriteString("  ")
+		builder.WriteString(stack)
+	}
+	if annotations := formatAnnotations(res); annotations != "" {
+		builder.WriteString("  ")
+		builder.WriteString(annotations)
+	}
+	builder.WriteByte('\n')
 
 	return builder.String()
 }
@@ -427,6 +496,7 @@ func (p *PrettyWriter) formatTreeMetrics(res engine.Result) string {
 	status := formatStatus(res)
 	size := formatSize(res)
 	latency := formatLatency(res.Duration)
+	severity := formatSeverity(res)
 	similarity := ""
 	if p.opts.ShowSimilarity {
 		similarity = formatSimilarity(res)
@@ -436,17 +506,34 @@ func (p *PrettyWriter) formatTreeMetrics(res engine.Result) string {
 		status = wrapColor(status, p.statusColor(res), p.palette.Reset)
 		size = wrapColor(size, p.palette.Size, p.palette.Reset)
 		latency = wrapColor(latency, p.palette.Latency, p.palette.Reset)
+		severity = wrapColor(severity, p.severityColor(res), p.palette.Reset)
 		if p.opts.ShowSimilarity {
 			similarity = wrapColor(similarity, p.palette.Similarity, p.palette.Reset)
 		}
 	}
 
-	parts := []string{status, size, latency}
+	parts := []string{status, size, latency, severity}
 	if p.opts.ShowSimilarity {
 		parts = append(parts, similarity)
 	}
 
-	return "[" + strings.Join(parts, " • ") + "]"
+	metrics := "[" + strings.Join(parts, " • ") + "]"
+	if redirects := formatRedirects(res); redirects != "" {
+		metrics += " " + redirects
+	}
+	if listing := p.formatListing(res); listing != "" {
+		metrics += " " + listing
+	}
+	if graphQL := p.formatGraphQL(res); graphQL != "" {
+		metrics += " " + graphQL
+	}
+	if stack := formatTechnologies(res); stack != "" {
+		metrics += " " + stack
+	}
+	if annotations := formatAnnotations(res); annotations != "" {
+		metrics += " " + annotations
+	}
+	return metrics
 }
 
 func (p *PrettyWriter) statusColor(res engine.Result) string {
@@ -530,6 +617,95 @@ func formatSimilarity(res engine.Result) string {
 	return fmt.Sprintf("%.3f", res.Similarity)
 }
 
+// formatRedirects renders the redirects followed by res as
+// "301 → 302 → https://example.com/landed", or "" when there were none.
+func formatRedirects(res engine.Result) string {
+	if len(res.RedirectChain) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(res.RedirectChain)+1)
+	for _, hop := range res.RedirectChain {
+		parts = append(parts, strconv.Itoa(hop.StatusCode))
+	}
+	parts = append(parts, res.RequestURL)
+	return strings.Join(parts, " → ")
+}
+
+// formatSeverity renders the severity of res, or "-" when it has none.
+func formatSeverity(res engine.Result) string {
+	if res.Severity == "" {
+		return "-"
+	}
+	return res.Severity
+}
+
+// severityColor returns the color of the severity of res, reusing the
+// status colors from calm to alarming.
+func (p *PrettyWriter) severityColor(res engine.Result) string {
+	switch res.Severity {
+	case engine.SeverityCritical, engine.SeverityHigh:
+		return p.palette.StatusError
+	case engine.SeverityMedium:
+		return p.palette.StatusClientErr
+	case engine.SeverityLow:
+		return p.palette.StatusRedirect
+	default:
+		return p.palette.Latency
+	}
+}
+
+// formatListing renders the open directory listing of res as
+// "[medium] directory listing (apache)", or "" when there is none.
+func (p *PrettyWriter) formatListing(res engine.Result) string {
+	if res.Listing == "" {
+		return ""
+	}
+	listing := fmt.Sprintf("[%s] directory listing (%s)", engine.ListingSeverity, res.Listing)
+	if p.colorEnabled && p.palette.StatusServerErr != "" {
+		listing = wrapColor(listing, p.palette.StatusServerErr, p.palette.Reset)
+	}
+	return listing
+}
+
+// formatGraphQL renders the GraphQL probe of res as "[medium] graphql
+// introspection enabled (12 queries, 3 mutations)" or "graphql introspection
+// disabled", or "" when res was not probed.
+func (p *PrettyWriter) formatGraphQL(res engine.Result) string {
+	if res.GraphQL == nil {
+		return ""
+	}
+	if !res.GraphQL.Introspection {
+		return "graphql introspection disabled"
+	}
+	graphQL := fmt.Sprintf("[%s] graphql introspection enabled (%d queries, %d mutations)",
+		engine.IntrospectionSeverity, len(res.GraphQL.Queries), len(res.GraphQL.Mutations))
+	if p.colorEnabled && p.palette.StatusServerErr != "" {
+		graphQL = wrapColor(graphQL, p.palette.StatusServerErr, p.palette.Reset)
+	}
+	return graphQL
+}
+
+// formatTechnologies renders the technologies fingerprinted in res as
+// "{nginx 1.18.0, PHP}", or "" when there are none.
+func formatTechnologies(res engine.Result) string {
+	if len(res.Technologies) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(res.Technologies, ", ") + "}"
+}
+
+// formatAnnotations renders plugin tags and notes as "#tag #tag note; note".
+func formatAnnotations(res engine.Result) string {
+	parts := make([]string, 0, len(res.Tags)+1)
+	for _, tag := range res.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if len(res.Notes) > 0 {
+		parts = append(parts, strings.Join(res.Notes, "; "))
+	}
+	return strings.Join(parts, " ")
+}
+
 func truncate(s string, width int) string {
 	if len(s) <= width {
 		return s
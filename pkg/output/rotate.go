@@ -0,0 +1,185 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseSize parses a file size such as "500MB", "2GiB", or "1048576" into
+// bytes. Units are B, KB, MB, and GB in powers of 1000 and KiB, MiB, and GiB
+// in powers of 1024.
+func ParseSize(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+
+	number := strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	unit := strings.ToUpper(strings.TrimSpace(trimmed[len(number):]))
+
+	multipliers := map[string]float64{
+		"": 1, "B": 1,
+		"K": 1e3, "KB": 1e3, "M": 1e6, "MB": 1e6, "G": 1e9, "GB": 1e9,
+		"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30,
+	}
+	multiplier, ok := multipliers[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500MB)", value)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n*multiplier < 1 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500MB)", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+// RotatedPath returns the path of the index-th file of an output rotated from
+// path. The first file keeps path itself and later ones are numbered before
+// the extensions, so results.jsonl.gz continues as results.1.jsonl.gz.
+func RotatedPath(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+
+	dir, name := filepath.Split(path)
+	stem, ext := name, ""
+	if dot := strings.Index(name[1:], "."); dot >= 0 {
+		stem, ext = name[:dot+1], name[dot+1:]
+	}
+	return fmt.Sprintf("%s%s.%d%s", dir, stem, index, ext)
+}
+
+// rotatingFile writes an output across numbered files, moving on to the next
+// one once the current file holds limit bytes. The limit counts the bytes
+// written before compression, so compressed files end up smaller.
+type rotatingFile struct {
+	path    string
+	limit   int64
+	index   int
+	out     io.WriteCloser
+	written int64
+}
+
+// openRotatingFile starts a rotated output at path. When appending, it
+// continues the last numbered file a previous run left behind; otherwise the
+// numbered files are removed, since readers would take them for part of the
+// new output.
+func openRotatingFile(path string, limit int64, appending bool) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, limit: limit}
+	if !appending {
+		if err := removeRotatedFiles(path); err != nil {
+			return nil, err
+		}
+		out, err := createOutputFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r.out = out
+		return r, nil
+	}
+
+	for {
+		_, err := os.Stat(RotatedPath(path, r.index+1))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.index++
+	}
+
+	current := RotatedPath(path, r.index)
+	file, err := openAppendFile(current)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil {
+		r.written = info.Size()
+	}
+	r.out = wrapOutputFile(current, file)
+	return r, nil
+}
+
+// removeRotatedFiles removes the numbered files an earlier output rotated
+// from path left behind, up to the first one missing.
+func removeRotatedFiles(path string) error {
+	for index := 1; ; index++ {
+		err := os.Remove(RotatedPath(path, index))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("remove earlier rotated output: %w", err)
+		}
+	}
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.out == nil {
+		out, err := createOutputFile(RotatedPath(r.path, r.index+1))
+		if err != nil {
+			return 0, err
+		}
+		r.index++
+		r.out = out
+		r.written = 0
+	}
+
+	n, err := r.out.Write(p)
+	r.written += int64(n)
+	return n, err
+}
+
+// rotate closes the current file when it is full, so that the next write
+// starts the next numbered file. It is called between entries so that none
+// is split across files.
+func (r *rotatingFile) rotate() error {
+	if r.out == nil || r.written < r.limit {
+		return nil
+	}
+
+	err := r.out.Close()
+	r.out = nil
+	return err
+}
+
+func (r *rotatingFile) Close() error {
+	if r.out == nil {
+		return nil
+	}
+	return r.out.Close()
+}
+
+// createOutputFile creates the output file at path, compressing it when path
+// ends in .gz.
+func createOutputFile(path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return wrapOutputFile(path, file), nil
+}
+
+// openAppendFile opens the output file at path for a resumed run to append
+// to, creating it when missing. What a crash left unreadable at the end of the
+// file is cut off first; see repairOutputFile.
+func openAppendFile(path string) (*os.File, error) {
+	if err := repairOutputFile(path); err != nil {
+		return nil, fmt.Errorf("repair %s: %w", path, err)
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// wrapOutputFile compresses file when path ends in .gz.
+func wrapOutputFile(path string, file *os.File) io.WriteCloser {
+	if IsGzipPath(path) {
+		return newGzipFile(file)
+	}
+	return file
+}
@@ -0,0 +1,244 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"hydr0g3n/pkg/engine"
+)
+
+const (
+	// streamClientQueue is the number of events buffered for each client.
+	// A client that falls further behind is disconnected so that it never
+	// slows the scan down.
+	streamClientQueue = 256
+	// streamShutdown bounds how long Close waits for clients to receive the
+	// last events.
+	streamShutdown = 5 * time.Second
+)
+
+// Stream serves the results of a running scan as server-sent events, so that
+// dashboards and browser UIs can follow it live. Clients subscribe with a GET
+// request to /events and receive a "run" event with the run header, a "hit"
+// event for every hit, and a "summary" event when the scan ends. Event data is
+// the JSONL entry of the JSONL output file.
+//
+// Without a token anyone who can reach the address may subscribe, but pages on
+// other origins can not read the events. With a token, clients must present it
+// and browser UIs on any origin may subscribe.
+type Stream struct {
+	server   *http.Server
+	listener net.Listener
+	token    string
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	writer  *JSONLWriter
+	header  []byte
+	clients map[chan []byte]struct{}
+	closed  bool
+}
+
+// StartStream listens on addr, such as 127.0.0.1:8787, and serves the event
+// stream until Close is called. When token is set, clients must send it as a
+// bearer token or, since browsers' EventSource can not set headers, as the
+// token query parameter.
+func StartStream(addr, token string, includeSimilarity bool) (*Stream, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen for stream: %w", err)
+	}
+
+	s := &Stream{
+		listener: listener,
+		token:    token,
+		clients:  make(map[chan []byte]struct{}),
+	}
+	s.writer = NewJSONLWriter(&s.buf, includeSimilarity)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.serveEvents)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() { _ = s.server.Serve(listener) }()
+
+	return s, nil
+}
+
+// Addr returns the address the stream listens on.
+func (s *Stream) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// IncludeHeaders adds the named response headers to every hit event. It must
+// be called before Write.
+func (s *Stream) IncludeHeaders(names []string) {
+	s.writer.IncludeHeaders(names)
+}
+
+// WriteHeader sends the run metadata event. It is also sent to every client
+// that subscribes later.
+func (s *Stream) WriteHeader(header RunHeader) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	s.header = s.event("run")
+	s.broadcast(s.header)
+	return nil
+}
+
+// Write sends res to every client as a hit event.
+func (s *Stream) Write(res engine.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.WriteMatch(res); err != nil {
+		return err
+	}
+	s.broadcast(s.event("hit"))
+	return nil
+}
+
+// WriteFooter sends the event summarizing the run.
+func (s *Stream) WriteFooter(footer RunFooter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.WriteFooter(footer); err != nil {
+		return err
+	}
+	s.broadcast(s.event("summary"))
+	return nil
+}
+
+// Close ends the stream of every client, waiting briefly for them to receive
+// the events already sent, and stops listening.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for client := range s.clients {
+		close(client)
+		delete(s.clients, client)
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamShutdown)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = s.server.Close()
+	}
+	return err
+}
+
+// event frames the entry just encoded into the buffer as an event of the
+// given name. It must be called with s.mu held.
+func (s *Stream) event(name string) []byte {
+	data := bytes.TrimSpace(s.buf.Bytes())
+	s.buf.Reset()
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", name, data))
+}
+
+// broadcast queues event for every client, disconnecting the clients whose
+// queue is full. It must be called with s.mu held.
+func (s *Stream) broadcast(event []byte) {
+	for client := range s.clients {
+		select {
+		case client <- event:
+		default:
+			close(client)
+			delete(s.clients, client)
+		}
+	}
+}
+
+func (s *Stream) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="hydro"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan []byte, streamClientQueue)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		http.Error(w, "scan finished", http.StatusServiceUnavailable)
+		return
+	}
+	if s.header != nil {
+		client <- s.header
+	}
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if s.token != "" {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-client:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(event); err != nil {
+				s.unsubscribe(client)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			s.unsubscribe(client)
+			return
+		}
+	}
+}
+
+// authorized reports whether r carries the stream token, when one is set.
+func (s *Stream) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+
+	given := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+func (s *Stream) unsubscribe(client chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[client]; ok {
+		close(client)
+		delete(s.clients, client)
+	}
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+
+	"hydr0g3n/pkg/engine"
+)
+
+func startTestStream(t *testing.T, token string) *Stream {
+	t.Helper()
+
+	stream, err := StartStream("127.0.0.1:0", token, false)
+	if err != nil {
+		t.Fatalf("start stream: %v", err)
+	}
+	t.Cleanup(func() { stream.Close() })
+	return stream
+}
+
+// readEvent reads the next event from an event stream and returns its name
+// and data.
+func readEvent(t *testing.T, r *bufio.Reader) (name, data string) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		query  string
+		bearer string
+		status int
+		cors   string
+	}{
+		{name: "no token", status: http.StatusOK},
+		{name: "token missing", token: "s3cret", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", bearer: "guess", status: http.StatusUnauthorized},
+		{name: "bearer token", token: "s3cret", bearer: "s3cret", status: http.StatusOK, cors: "*"},
+		{name: "query token", token: "s3cret", query: "?token=s3cret", status: http.StatusOK, cors: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := startTestStream(t, tt.token)
+
+			req, err := http.NewRequest(http.MethodGet, "http://"+stream.Addr()+"/events"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("subscribe: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.cors {
+				t.Fatalf("expected Access-Control-Allow-Origin %q, got %q", tt.cors, got)
+			}
+		})
+	}
+}
+
+func TestStreamSendsEventsToSubscribers(t *testing.T) {
+	stream := startTestStream(t, "")
+	if err := stream.WriteHeader(RunHeader{Type: "run", RunID: "run-1"}); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+
+	// The client subscribes after the header was sent.
+	resp, err := http.Get("http://" + stream.Addr() + "/events")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", got)
+	}
+
+	if err := stream.Write(engine.Result{URL: "http://target/admin", StatusCode: http.StatusOK}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := stream.WriteFooter(RunFooter{Type: "summary", RunID: "run-1", Hits: 1}); err != nil {
+		t.Fatalf("write footer: %v", err)
+	}
+
+	events := bufio.NewReader(resp.Body)
+	for _, want := range []struct{ name, data string }{
+		{name: "run", data: `"run_id":"run-1"`},
+		{name: "hit", data: `"url":"http://target/admin"`},
+		{name: "summary", data: `"hits":1`},
+	} {
+		name, data := readEvent(t, events)
+		if name != want.name || !strings.Contains(data, want.data) {
+			t.Fatalf("expected a %s event with %s, got %s: %s", want.name, want.data, name, data)
+		}
+	}
+}
+
+func TestStreamDisconnectsSlowClients(t *testing.T) {
+	stream := startTestStream(t, "")
+
+	slow := make(chan []byte, 1)
+	fast := make(chan []byte, 2)
+	stream.mu.Lock()
+	stream.clients[slow] = struct{}{}
+	stream.clients[fast] = struct{}{}
+	stream.mu.Unlock()
+
+	for _, url := range []string{"http://target/a", "http://target/b"} {
+		if err := stream.Write(engine.Result{URL: url, StatusCode: http.StatusOK}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	stream.mu.Lock()
+	_, slowSubscribed := stream.clients[slow]
+	_, fastSubscribed := stream.clients[fast]
+	stream.mu.Unlock()
+	if slowSubscribed || !fastSubscribed {
+		t.Fatalf("expected only the slow client to be dropped, slow subscribed %t, fast subscribed %t", slowSubscribed, fastSubscribed)
+	}
+
+	<-slow
+	if _, ok := <-slow; ok {
+		t.Fatal("expected the slow client's queue to be closed after the events it held")
+	}
+	if len(fast) != 2 {
+		t.Fatalf("expected the fast client to get both hits, got %d", len(fast))
+	}
+}
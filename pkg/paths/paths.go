@@ -0,0 +1,138 @@
+// Package paths locates the directories hydro reads configuration from and
+// writes caches and data to. On Unix-like systems the XDG base directory
+// variables are honored; macOS and Windows fall back to their native
+// locations when those variables are unset.
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// AppName is the directory name used beneath each base directory.
+const AppName = "hydro"
+
+// resolver computes directories for a given platform and environment.
+type resolver struct {
+	goos   string
+	lookup func(string) (string, bool)
+	home   func() (string, error)
+}
+
+func defaultResolver() resolver {
+	return resolver{goos: runtime.GOOS, lookup: os.LookupEnv, home: os.UserHomeDir}
+}
+
+// ConfigDir returns the directory that holds hydro configuration, such as
+// ~/.config/hydro, ~/Library/Application Support/hydro or %AppData%\hydro.
+func ConfigDir() (string, error) {
+	return defaultResolver().configDir()
+}
+
+// CacheDir returns the directory for files hydro can download again, such as
+// ~/.cache/hydro, ~/Library/Caches/hydro or %LocalAppData%\hydro\cache.
+func CacheDir() (string, error) {
+	return defaultResolver().cacheDir()
+}
+
+// DataDir returns the directory for data hydro creates and keeps, such as
+// ~/.local/share/hydro, ~/Library/Application Support/hydro or
+// %LocalAppData%\hydro.
+func DataDir() (string, error) {
+	return defaultResolver().dataDir()
+}
+
+// ProfileDir returns the directory that holds user-defined profiles.
+func ProfileDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles"), nil
+}
+
+// WordlistCacheDir returns the directory that downloaded wordlists are stored
+// in.
+func WordlistCacheDir() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wordlists"), nil
+}
+
+// DefaultResumeDB returns the path of the resume database used when no
+// explicit path is given.
+func DefaultResumeDB() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "resume.db"), nil
+}
+
+func (r resolver) configDir() (string, error) {
+	switch r.goos {
+	case "windows":
+		return r.windowsDir("APPDATA")
+	case "darwin":
+		return r.unixDir("XDG_CONFIG_HOME", "Library", "Application Support")
+	default:
+		return r.unixDir("XDG_CONFIG_HOME", ".config")
+	}
+}
+
+func (r resolver) cacheDir() (string, error) {
+	switch r.goos {
+	case "windows":
+		dir, err := r.windowsDir("LOCALAPPDATA")
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "cache"), nil
+	case "darwin":
+		return r.unixDir("XDG_CACHE_HOME", "Library", "Caches")
+	default:
+		return r.unixDir("XDG_CACHE_HOME", ".cache")
+	}
+}
+
+func (r resolver) dataDir() (string, error) {
+	switch r.goos {
+	case "windows":
+		return r.windowsDir("LOCALAPPDATA")
+	case "darwin":
+		return r.unixDir("XDG_DATA_HOME", "Library", "Application Support")
+	default:
+		return r.unixDir("XDG_DATA_HOME", ".local", "share")
+	}
+}
+
+// unixDir returns the directory named by the XDG variable xdgVar, or the
+// fallback path beneath the home directory. The XDG specification requires
+// relative values to be ignored.
+func (r resolver) unixDir(xdgVar string, fallback ...string) (string, error) {
+	if dir, ok := r.lookup(xdgVar); ok && filepath.IsAbs(dir) {
+		return filepath.Join(dir, AppName), nil
+	}
+
+	home, err := r.home()
+	if err != nil {
+		return "", err
+	}
+	if home == "" {
+		return "", errors.New("home directory is not set")
+	}
+
+	return filepath.Join(append(append([]string{home}, fallback...), AppName)...), nil
+}
+
+func (r resolver) windowsDir(envVar string) (string, error) {
+	dir, ok := r.lookup(envVar)
+	if !ok || dir == "" {
+		return "", errors.New("%" + envVar + "% is not set")
+	}
+	return filepath.Join(dir, AppName), nil
+}
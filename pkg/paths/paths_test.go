@@ -0,0 +1,93 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func testResolver(goos string, env map[string]string) resolver {
+	return resolver{
+		goos: goos,
+		lookup: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+		home: func() (string, error) {
+			return "/home/user", nil
+		},
+	}
+}
+
+func TestResolverLinuxDefaults(t *testing.T) {
+	r := testResolver("linux", nil)
+
+	cases := []struct {
+		name string
+		get  func() (string, error)
+		want string
+	}{
+		{"config", r.configDir, filepath.Join("/home/user", ".config", "hydro")},
+		{"cache", r.cacheDir, filepath.Join("/home/user", ".cache", "hydro")},
+		{"data", r.dataDir, filepath.Join("/home/user", ".local", "share", "hydro")},
+	}
+
+	for _, tc := range cases {
+		got, err := tc.get()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s dir = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestResolverHonorsAbsoluteXDGVariables(t *testing.T) {
+	r := testResolver("linux", map[string]string{
+		"XDG_CONFIG_HOME": "/xdg/config",
+		"XDG_CACHE_HOME":  "relative/cache",
+		"XDG_DATA_HOME":   "/xdg/data",
+	})
+
+	if got, _ := r.configDir(); got != filepath.Join("/xdg/config", "hydro") {
+		t.Fatalf("config dir = %q", got)
+	}
+	if got, _ := r.cacheDir(); got != filepath.Join("/home/user", ".cache", "hydro") {
+		t.Fatalf("expected relative XDG_CACHE_HOME to be ignored, got %q", got)
+	}
+	if got, _ := r.dataDir(); got != filepath.Join("/xdg/data", "hydro") {
+		t.Fatalf("data dir = %q", got)
+	}
+}
+
+func TestResolverDarwinDefaults(t *testing.T) {
+	r := testResolver("darwin", nil)
+
+	if got, _ := r.configDir(); got != filepath.Join("/home/user", "Library", "Application Support", "hydro") {
+		t.Fatalf("config dir = %q", got)
+	}
+	if got, _ := r.cacheDir(); got != filepath.Join("/home/user", "Library", "Caches", "hydro") {
+		t.Fatalf("cache dir = %q", got)
+	}
+}
+
+func TestResolverWindows(t *testing.T) {
+	r := testResolver("windows", map[string]string{
+		"APPDATA":      "/roaming",
+		"LOCALAPPDATA": "/local",
+	})
+
+	if got, _ := r.configDir(); got != filepath.Join("/roaming", "hydro") {
+		t.Fatalf("config dir = %q", got)
+	}
+	if got, _ := r.cacheDir(); got != filepath.Join("/local", "hydro", "cache") {
+		t.Fatalf("cache dir = %q", got)
+	}
+	if got, _ := r.dataDir(); got != filepath.Join("/local", "hydro") {
+		t.Fatalf("data dir = %q", got)
+	}
+
+	if _, err := testResolver("windows", nil).configDir(); err == nil {
+		t.Fatal("expected an error when %APPDATA% is unset")
+	}
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Transports accepted by Open.
+const (
+	TransportExec    = "exec"
+	TransportJSONRPC = "jsonrpc"
+	TransportGRPC    = "grpc"
+)
+
+// Client is a plugin that stays running for the whole scan.
+type Client interface {
+	Call(ctx context.Context, event MatchEvent) (Response, error)
+	Classify(ctx context.Context, event ResponseEvent) (Verdict, error)
+	Transform(ctx context.Context, event TransformEvent) (TransformResponse, error)
+	Notify(ctx context.Context, event LifecycleEvent) error
+	Close() error
+}
+
+var (
+	_ Client = execClient{}
+	_ Client = (*Process)(nil)
+	_ Client = (*GRPCClient)(nil)
+)
+
+// Open prepares the plugin at path for the named transport. The exec
+// transport runs the plugin once per match event and does not receive
+// lifecycle events; jsonrpc and grpc start it once for the whole scan.
+func Open(ctx context.Context, transport, path string) (Client, error) {
+	switch strings.ToLower(strings.TrimSpace(transport)) {
+	case "", TransportExec:
+		if strings.TrimSpace(path) == "" {
+			return nil, errors.New("plugin path is empty")
+		}
+		return execClient{path: path}, nil
+	case TransportJSONRPC:
+		process, err := Start(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return process, nil
+	case TransportGRPC:
+		client, err := StartGRPC(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unsupported plugin transport %q (expected %s, %s or %s)", transport, TransportExec, TransportJSONRPC, TransportGRPC)
+	}
+}
+
+// execClient adapts Call to the Client interface.
+type execClient struct {
+	path string
+}
+
+func (c execClient) Call(ctx context.Context, event MatchEvent) (Response, error) {
+	return Call(ctx, c.path, event)
+}
+
+func (execClient) Classify(context.Context, ResponseEvent) (Verdict, error) {
+	return Verdict{}, fmt.Errorf("matcher plugins need the %s or %s transport", TransportJSONRPC, TransportGRPC)
+}
+
+func (execClient) Transform(context.Context, TransformEvent) (TransformResponse, error) {
+	return TransformResponse{}, fmt.Errorf("payload transforms need the %s or %s transport", TransportJSONRPC, TransportGRPC)
+}
+
+func (execClient) Notify(context.Context, LifecycleEvent) error {
+	return nil
+}
+
+func (execClient) Close() error {
+	return nil
+}
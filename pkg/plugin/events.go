@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"time"
+
+	"hydr0g3n/pkg/engine"
+	"hydr0g3n/pkg/httpclient"
+)
+
+// Lifecycle event types sent to persistent and gRPC plugins in addition to
+// per-hit match events.
+const (
+	EventRunStart    = "run_start"
+	EventStageStart  = "stage_start"
+	EventStageEnd    = "stage_end"
+	EventRunComplete = "run_complete"
+	EventError       = "error"
+)
+
+// LifecycleEvent tells a plugin about the progress of a run so that it can
+// keep state across hits and emit a report when the run ends. Type selects
+// which of the optional fields are set.
+type LifecycleEvent struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	RunID     string    `json:"run_id,omitempty"`
+	// Target and Method are set for run_start, stage_start and stage_end.
+	Target string `json:"target,omitempty"`
+	Method string `json:"method,omitempty"`
+	// Stage is "quick" or "primary" for stage_start and stage_end.
+	Stage string `json:"stage,omitempty"`
+	// Summary is set for run_complete.
+	Summary *RunSummary `json:"summary,omitempty"`
+	// URL and Error describe the failure for error events.
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// RunSummary holds the statistics reported with run_complete.
+type RunSummary struct {
+	Requests   int   `json:"requests"`
+	Hits       int   `json:"hits"`
+	Errors     int   `json:"errors"`
+	DurationMS int64 `json:"duration_ms"`
+	// DNS counts the lookups served by the DNS cache, when it is enabled.
+	DNS *httpclient.DNSStats `json:"dns,omitempty"`
+	// Stats counts the responses by status code, the failed requests by
+	// error type, and the requests of each worker.
+	Stats *engine.StatsSnapshot `json:"stats,omitempty"`
+}
@@ -0,0 +1,714 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// ProtocolVersion is the version of the gRPC plugin contract. A plugin must
+// answer the handshake with the same version.
+const ProtocolVersion = 1
+
+// Capabilities a gRPC plugin can advertise during the handshake.
+const (
+	CapabilityMatch     = "match"
+	CapabilityClassify  = "classify"
+	CapabilityTransform = "transform"
+	CapabilityLifecycle = "lifecycle"
+)
+
+// grpcServiceName is the fully qualified name of the plugin service.
+const grpcServiceName = "hydro.plugin.v1.Plugin"
+
+// handshakePrefix starts the line a launched plugin prints on stdout to
+// announce its address: hydro-plugin|<version>|<network>|<address>.
+const handshakePrefix = "hydro-plugin"
+
+// handshakeTimeout bounds how long StartGRPC waits for a launched plugin to
+// announce its address and answer the handshake.
+const handshakeTimeout = 10 * time.Second
+
+// jsonCodec encodes gRPC messages as JSON so that plugins can be written
+// without generated protobuf code. Clients select it with the content type
+// application/grpc+json. It is the only codec of the transport: there is no
+// .proto definition, and protobuf-encoded messages are not understood.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// HandshakeRequest opens a gRPC plugin session.
+type HandshakeRequest struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+}
+
+// HandshakeResponse describes the plugin and the capabilities it accepted.
+type HandshakeResponse struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Name            string   `json:"name,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+}
+
+// Event is streamed to a gRPC plugin for every result it should inspect and,
+// when the lifecycle capability was accepted, for every lifecycle event.
+// Lifecycle events carry no ID and are not answered.
+type Event struct {
+	ID        uint64          `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Match     *MatchEvent     `json:"match,omitempty"`
+	Classify  *ResponseEvent  `json:"classify,omitempty"`
+	Transform *TransformEvent `json:"transform,omitempty"`
+	Lifecycle *LifecycleEvent `json:"lifecycle,omitempty"`
+}
+
+// EventResult answers the Event with the same ID.
+type EventResult struct {
+	ID        uint64             `json:"id"`
+	Response  *Response          `json:"response,omitempty"`
+	Verdict   *Verdict           `json:"verdict,omitempty"`
+	Transform *TransformResponse `json:"transform,omitempty"`
+	Error     *RPCError          `json:"error,omitempty"`
+}
+
+// ShutdownRequest asks a gRPC plugin to stop once pending events are answered.
+type ShutdownRequest struct{}
+
+// ShutdownResponse acknowledges a ShutdownRequest.
+type ShutdownResponse struct{}
+
+// Info describes a plugin as reported by its handshake.
+type Info struct {
+	Name         string
+	Capabilities []string
+}
+
+// Handler processes events in a plugin served with ServeGRPC.
+type Handler interface {
+	Match(ctx context.Context, event MatchEvent) (Response, error)
+}
+
+// LifecycleHandler is implemented by handlers that want lifecycle events. It
+// is advertised automatically by ServeGRPC.
+type LifecycleHandler interface {
+	HandleLifecycle(ctx context.Context, event LifecycleEvent) error
+}
+
+// ClassifyHandler is implemented by matcher plugins. It is advertised
+// automatically by ServeGRPC.
+type ClassifyHandler interface {
+	Classify(ctx context.Context, event ResponseEvent) (Verdict, error)
+}
+
+// TransformHandler is implemented by handlers that rewrite payloads. It is
+// advertised automatically by ServeGRPC.
+type TransformHandler interface {
+	Transform(ctx context.Context, event TransformEvent) (TransformResponse, error)
+}
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(ctx context.Context, event MatchEvent) (Response, error)
+
+// Match calls f.
+func (f HandlerFunc) Match(ctx context.Context, event MatchEvent) (Response, error) {
+	return f(ctx, event)
+}
+
+// GRPCClient is a connection to a plugin speaking the gRPC contract. Match
+// events are sent over a single bidirectional stream and may be in flight
+// concurrently.
+type GRPCClient struct {
+	conn   *grpc.ClientConn
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+	info   Info
+
+	cmd    *exec.Cmd
+	stderr *lockedBuffer
+
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan EventResult
+	err     error
+	closed  bool
+
+	done chan struct{}
+}
+
+// DialGRPC connects to a plugin already listening on target, performs the
+// versioned handshake, and opens the event stream.
+func DialGRPC(ctx context.Context, target string) (*GRPCClient, error) {
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(jsonCodec{}.Name())),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect plugin: %w", err)
+	}
+
+	var hs HandshakeResponse
+	req := HandshakeRequest{ProtocolVersion: ProtocolVersion, Capabilities: []string{CapabilityMatch, CapabilityClassify, CapabilityTransform, CapabilityLifecycle}}
+	if err := conn.Invoke(ctx, "/"+grpcServiceName+"/Handshake", &req, &hs); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("plugin handshake: %w", err)
+	}
+	if hs.ProtocolVersion != ProtocolVersion {
+		conn.Close()
+		return nil, fmt.Errorf("plugin speaks protocol version %d, hydro requires %d", hs.ProtocolVersion, ProtocolVersion)
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stream, err := conn.NewStream(streamCtx, &grpcServiceDesc.Streams[0], "/"+grpcServiceName+"/Events")
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, fmt.Errorf("open plugin event stream: %w", err)
+	}
+
+	c := &GRPCClient{
+		conn:    conn,
+		stream:  stream,
+		cancel:  cancel,
+		info:    Info{Name: hs.Name, Capabilities: hs.Capabilities},
+		pending: make(map[uint64]chan EventResult),
+		done:    make(chan struct{}),
+	}
+	go c.recvLoop()
+
+	return c, nil
+}
+
+// StartGRPC launches the plugin at path with args and connects to it. The
+// plugin announces where it listens by printing a single line on stdout,
+// hydro-plugin|<version>|<network>|<address>, as ServeGRPCPlugin does.
+func StartGRPC(ctx context.Context, path string, args ...string) (*GRPCClient, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("plugin path is empty")
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), "HYDRO_PLUGIN_PROTOCOL=grpc")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("open plugin stdout: %w", err)
+	}
+
+	stderr := &lockedBuffer{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin: %w", err)
+	}
+
+	fail := func(err error) (*GRPCClient, error) {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lines <- line
+		// Keep draining so a chatty plugin never blocks on a full pipe.
+		_, _ = io.Copy(io.Discard, stdout)
+	}()
+
+	var line string
+	select {
+	case line = <-lines:
+	case <-time.After(handshakeTimeout):
+		return fail(errors.New("plugin did not announce its address in time"))
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	}
+
+	network, address, err := parseHandshakeLine(line)
+	if err != nil {
+		return fail(err)
+	}
+
+	target := address
+	if network == "unix" {
+		target = "unix:" + address
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	client, err := DialGRPC(dialCtx, target)
+	if err != nil {
+		return fail(err)
+	}
+	client.cmd = cmd
+	client.stderr = stderr
+
+	return client, nil
+}
+
+func parseHandshakeLine(line string) (network, address string, err error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 4 || parts[0] != handshakePrefix {
+		return "", "", fmt.Errorf("invalid plugin handshake %q", strings.TrimSpace(line))
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid plugin protocol version %q", parts[1])
+	}
+	if version != ProtocolVersion {
+		return "", "", fmt.Errorf("plugin speaks protocol version %d, hydro requires %d", version, ProtocolVersion)
+	}
+
+	switch parts[2] {
+	case "tcp", "unix":
+	default:
+		return "", "", fmt.Errorf("unsupported plugin network %q", parts[2])
+	}
+
+	return parts[2], parts[3], nil
+}
+
+// Info returns the plugin description received during the handshake.
+func (c *GRPCClient) Info() Info {
+	return c.info
+}
+
+// Supports reports whether the plugin accepted capability.
+func (c *GRPCClient) Supports(capability string) bool {
+	for _, name := range c.info.Capabilities {
+		if name == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Call sends event to the plugin and waits for its Response.
+func (c *GRPCClient) Call(ctx context.Context, event MatchEvent) (Response, error) {
+	var resp Response
+
+	result, err := c.roundTrip(ctx, CapabilityMatch, Event{Type: CapabilityMatch, Match: &event})
+	if err != nil {
+		return resp, err
+	}
+	if result.Response != nil {
+		resp = *result.Response
+	}
+	return resp, nil
+}
+
+// Classify asks a matcher plugin whether a response is a hit.
+func (c *GRPCClient) Classify(ctx context.Context, event ResponseEvent) (Verdict, error) {
+	var verdict Verdict
+
+	result, err := c.roundTrip(ctx, CapabilityClassify, Event{Type: CapabilityClassify, Classify: &event})
+	if err != nil {
+		return verdict, err
+	}
+	if result.Verdict != nil {
+		verdict = *result.Verdict
+	}
+	return verdict, nil
+}
+
+// Transform asks the plugin to rewrite a payload.
+func (c *GRPCClient) Transform(ctx context.Context, event TransformEvent) (TransformResponse, error) {
+	var resp TransformResponse
+
+	result, err := c.roundTrip(ctx, CapabilityTransform, Event{Type: CapabilityTransform, Transform: &event})
+	if err != nil {
+		return resp, err
+	}
+	if result.Transform != nil {
+		resp = *result.Transform
+	}
+	return resp, nil
+}
+
+// roundTrip sends event with a fresh ID and waits for its result.
+func (c *GRPCClient) roundTrip(ctx context.Context, capability string, event Event) (EventResult, error) {
+	if !c.Supports(capability) {
+		return EventResult{}, fmt.Errorf("plugin %q does not support %s events", c.info.Name, capability)
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return EventResult{}, err
+	}
+	if c.closed {
+		c.mu.Unlock()
+		return EventResult{}, errors.New("plugin is closed")
+	}
+	c.nextID++
+	event.ID = c.nextID
+	reply := make(chan EventResult, 1)
+	c.pending[event.ID] = reply
+	c.mu.Unlock()
+
+	c.sendMu.Lock()
+	err := c.stream.SendMsg(&event)
+	c.sendMu.Unlock()
+	if err != nil {
+		c.forget(event.ID)
+		return EventResult{}, fmt.Errorf("send plugin event: %w", err)
+	}
+
+	select {
+	case result, ok := <-reply:
+		if !ok {
+			return EventResult{}, c.failure()
+		}
+		if result.Error != nil {
+			return EventResult{}, result.Error
+		}
+		return result, nil
+	case <-ctx.Done():
+		c.forget(event.ID)
+		return EventResult{}, ctx.Err()
+	}
+}
+
+// Notify streams a lifecycle event to the plugin. Events are dropped silently
+// when the plugin did not accept the lifecycle capability.
+func (c *GRPCClient) Notify(ctx context.Context, event LifecycleEvent) error {
+	if !c.Supports(CapabilityLifecycle) {
+		return nil
+	}
+
+	c.mu.Lock()
+	err := c.err
+	if err == nil && c.closed {
+		err = errors.New("plugin is closed")
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if err := c.stream.SendMsg(&Event{Type: event.Type, Lifecycle: &event}); err != nil {
+		return fmt.Errorf("send plugin event: %w", err)
+	}
+	return nil
+}
+
+// Close ends the event stream, asks the plugin to shut down, and closes the
+// connection. A plugin launched by StartGRPC that does not exit within a few
+// seconds is killed.
+func (c *GRPCClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.sendMu.Lock()
+	_ = c.stream.CloseSend()
+	c.sendMu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-time.After(shutdownTimeout):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	shutdownErr := c.conn.Invoke(ctx, "/"+grpcServiceName+"/Shutdown", &ShutdownRequest{}, &ShutdownResponse{})
+	cancel()
+
+	c.cancel()
+	_ = c.conn.Close()
+
+	if c.cmd == nil {
+		if shutdownErr != nil {
+			return fmt.Errorf("plugin shutdown: %w", shutdownErr)
+		}
+		return nil
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- c.cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-exited:
+	case <-time.After(shutdownTimeout):
+		_ = c.cmd.Process.Kill()
+		err = <-exited
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+			return fmt.Errorf("plugin error: %s: %w", msg, err)
+		}
+		return fmt.Errorf("plugin error: %w", err)
+	}
+	return nil
+}
+
+func (c *GRPCClient) recvLoop() {
+	defer close(c.done)
+
+	var err error
+	for {
+		var result EventResult
+		if err = c.stream.RecvMsg(&result); err != nil {
+			break
+		}
+
+		c.mu.Lock()
+		reply, ok := c.pending[result.ID]
+		delete(c.pending, result.ID)
+		c.mu.Unlock()
+
+		if ok {
+			reply <- result
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = errors.New("plugin closed the event stream")
+	} else {
+		err = fmt.Errorf("plugin event stream: %w", err)
+	}
+
+	c.mu.Lock()
+	c.err = err
+	for id, reply := range c.pending {
+		close(reply)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *GRPCClient) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *GRPCClient) failure() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return errors.New("plugin closed the event stream")
+}
+
+// ServeGRPC serves the plugin contract for handler on lis until a client
+// requests shutdown or lis fails. Events are handled concurrently.
+func ServeGRPC(lis net.Listener, info Info, handler Handler) error {
+	info.Capabilities = append([]string(nil), info.Capabilities...)
+	if _, ok := handler.(ClassifyHandler); ok {
+		info.Capabilities = append(info.Capabilities, CapabilityClassify)
+	}
+	if _, ok := handler.(TransformHandler); ok {
+		info.Capabilities = append(info.Capabilities, CapabilityTransform)
+	}
+	if _, ok := handler.(LifecycleHandler); ok {
+		info.Capabilities = append(info.Capabilities, CapabilityLifecycle)
+	}
+
+	server := grpc.NewServer()
+	svc := &grpcService{info: info, handler: handler, server: server}
+	server.RegisterService(&grpcServiceDesc, svc)
+
+	err := server.Serve(lis)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// ServeGRPCPlugin is the entry point for plugins written in Go. It listens on
+// a loopback port, announces the address on stdout for StartGRPC, and serves
+// handler until hydro shuts the plugin down.
+func ServeGRPCPlugin(info Info, handler Handler) error {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "%s|%d|tcp|%s\n", handshakePrefix, ProtocolVersion, lis.Addr())
+
+	return ServeGRPC(lis, info, handler)
+}
+
+// grpcService implements the server side of the plugin contract.
+type grpcService struct {
+	info    Info
+	handler Handler
+	server  *grpc.Server
+}
+
+func (s *grpcService) handshake(req *HandshakeRequest) (*HandshakeResponse, error) {
+	if req.ProtocolVersion != ProtocolVersion {
+		return nil, status.Errorf(codes.FailedPrecondition, "plugin speaks protocol version %d, client requested %d", ProtocolVersion, req.ProtocolVersion)
+	}
+
+	offered := make(map[string]struct{}, len(req.Capabilities))
+	for _, name := range req.Capabilities {
+		offered[name] = struct{}{}
+	}
+
+	resp := &HandshakeResponse{ProtocolVersion: ProtocolVersion, Name: s.info.Name}
+	for _, name := range s.info.Capabilities {
+		if _, ok := offered[name]; ok {
+			resp.Capabilities = append(resp.Capabilities, name)
+		}
+	}
+	return resp, nil
+}
+
+func (s *grpcService) events(stream grpc.ServerStream) error {
+	var (
+		wg     sync.WaitGroup
+		sendMu sync.Mutex
+	)
+	defer wg.Wait()
+
+	for {
+		var event Event
+		if err := stream.RecvMsg(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if event.Lifecycle != nil {
+			// Lifecycle events are handled in order and never answered.
+			if lh, ok := s.handler.(LifecycleHandler); ok {
+				_ = lh.HandleLifecycle(stream.Context(), *event.Lifecycle)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(event Event) {
+			defer wg.Done()
+
+			result := EventResult{ID: event.ID}
+			var err error
+			switch {
+			case event.Type == CapabilityMatch && event.Match != nil:
+				var resp Response
+				if resp, err = s.handler.Match(stream.Context(), *event.Match); err == nil {
+					result.Response = &resp
+				}
+			case event.Type == CapabilityClassify && event.Classify != nil:
+				ch, ok := s.handler.(ClassifyHandler)
+				if !ok {
+					err = &RPCError{Code: -32601, Message: "classify is not supported"}
+					break
+				}
+				var verdict Verdict
+				if verdict, err = ch.Classify(stream.Context(), *event.Classify); err == nil {
+					result.Verdict = &verdict
+				}
+			case event.Type == CapabilityTransform && event.Transform != nil:
+				th, ok := s.handler.(TransformHandler)
+				if !ok {
+					err = &RPCError{Code: -32601, Message: "transform is not supported"}
+					break
+				}
+				var resp TransformResponse
+				if resp, err = th.Transform(stream.Context(), *event.Transform); err == nil {
+					result.Transform = &resp
+				}
+			default:
+				err = &RPCError{Code: -32601, Message: fmt.Sprintf("unsupported event type %q", event.Type)}
+			}
+			if err != nil {
+				var rpcErr *RPCError
+				if !errors.As(err, &rpcErr) {
+					rpcErr = &RPCError{Code: -32000, Message: err.Error()}
+				}
+				result.Error = rpcErr
+			}
+
+			sendMu.Lock()
+			_ = stream.SendMsg(&result)
+			sendMu.Unlock()
+		}(event)
+	}
+}
+
+func (s *grpcService) shutdown() *ShutdownResponse {
+	// Stop after the response has been written so the client sees it.
+	go s.server.GracefulStop()
+	return &ShutdownResponse{}
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Handshake",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req HandshakeRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				return srv.(*grpcService).handshake(&req)
+			},
+		},
+		{
+			MethodName: "Shutdown",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req ShutdownRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				return srv.(*grpcService).shutdown(), nil
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			ServerStreams: true,
+			ClientStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(*grpcService).events(stream)
+			},
+		},
+	},
+}
@@ -0,0 +1,281 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func serveTestGRPC(t *testing.T, info Info) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- ServeGRPC(lis, info, HandlerFunc(helperMatch)) }()
+	t.Cleanup(func() {
+		lis.Close()
+		<-done
+	})
+
+	return lis.Addr().String()
+}
+
+func TestGRPCClientExchangesEvents(t *testing.T) {
+	addr := serveTestGRPC(t, Info{Name: "verifier", Capabilities: []string{CapabilityMatch, "future"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := DialGRPC(ctx, addr)
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+
+	if info := client.Info(); info.Name != "verifier" || len(info.Capabilities) != 1 || !client.Supports(CapabilityMatch) {
+		t.Fatalf("expected only the offered capabilities to be accepted, got %+v", info)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status := http.StatusOK
+			if i%2 == 1 {
+				status = http.StatusForbidden
+			}
+			resp, err := client.Call(ctx, MatchEvent{URL: fmt.Sprintf("https://example.com/%d", i), StatusCode: status})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if resp.Verify == nil || *resp.Verify != (status == http.StatusOK) {
+				errs <- fmt.Errorf("call %d: unexpected response %+v", i, resp)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	_, err = client.Call(ctx, MatchEvent{StatusCode: http.StatusInternalServerError})
+	rpcErr, ok := err.(*RPCError)
+	if !ok || rpcErr.Code != -32000 || rpcErr.Message != "upstream failed" {
+		t.Fatalf("expected structured plugin error, got %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("close plugin: %v", err)
+	}
+}
+
+func TestGRPCClientRequiresMatchCapability(t *testing.T) {
+	addr := serveTestGRPC(t, Info{Name: "sink-only"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := DialGRPC(ctx, addr)
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Call(ctx, MatchEvent{}); err == nil || !strings.Contains(err.Error(), "does not support") {
+		t.Fatalf("expected capability error, got %v", err)
+	}
+}
+
+func TestStartGRPCLaunchesPlugin(t *testing.T) {
+	t.Setenv("HYDRO_PLUGIN_HELPER", "1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := StartGRPC(ctx, os.Args[0], "-test.run=^TestHelperProcess$")
+	if err != nil {
+		t.Fatalf("start plugin: %v", err)
+	}
+
+	resp, err := client.Call(ctx, MatchEvent{StatusCode: http.StatusOK})
+	if err != nil || resp.Verify == nil || !*resp.Verify {
+		t.Fatalf("unexpected response %+v, %v", resp, err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("close plugin: %v", err)
+	}
+}
+
+// lifecycleRecorder is a gRPC handler that records lifecycle events.
+type lifecycleRecorder struct {
+	mu     sync.Mutex
+	events []LifecycleEvent
+}
+
+func (r *lifecycleRecorder) Match(context.Context, MatchEvent) (Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Response{Verify: boolPtr(len(r.events) == 2)}, nil
+}
+
+func (r *lifecycleRecorder) HandleLifecycle(_ context.Context, event LifecycleEvent) error {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	return nil
+}
+
+func TestGRPCClientStreamsLifecycleEvents(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	recorder := &lifecycleRecorder{}
+	go ServeGRPC(lis, Info{Name: "reporter", Capabilities: []string{CapabilityMatch}}, recorder)
+	defer lis.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := DialGRPC(ctx, lis.Addr().String())
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+	if !client.Supports(CapabilityLifecycle) {
+		t.Fatalf("expected lifecycle capability, got %+v", client.Info())
+	}
+
+	if err := client.Notify(ctx, LifecycleEvent{Type: EventRunStart, RunID: "abc"}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	summary := &RunSummary{Requests: 10, Hits: 2}
+	if err := client.Notify(ctx, LifecycleEvent{Type: EventRunComplete, RunID: "abc", Summary: summary}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	resp, err := client.Call(ctx, MatchEvent{})
+	if err != nil || resp.Verify == nil || !*resp.Verify {
+		t.Fatalf("expected both events to be handled before the match, got %+v, %v", resp, err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("close plugin: %v", err)
+	}
+
+	if got := recorder.events[1]; got.Summary == nil || got.Summary.Hits != 2 || got.RunID != "abc" {
+		t.Fatalf("unexpected run_complete event: %+v", got)
+	}
+}
+
+type transformHandler struct{}
+
+func (transformHandler) Match(ctx context.Context, event MatchEvent) (Response, error) {
+	return helperMatch(ctx, event)
+}
+
+func (transformHandler) Transform(_ context.Context, event TransformEvent) (TransformResponse, error) {
+	return helperTransform(event), nil
+}
+
+func TestGRPCClientTransformsPayloads(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	plain, err := DialGRPC(ctx, serveTestGRPC(t, Info{Name: "verifier", Capabilities: []string{CapabilityMatch}}))
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+	defer plain.Close()
+	if _, err := plain.Transform(ctx, TransformEvent{Payload: "admin"}); err == nil {
+		t.Fatal("expected transform to fail without the transform capability")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go ServeGRPC(lis, Info{Name: "encoder"}, transformHandler{})
+	defer lis.Close()
+
+	client, err := DialGRPC(ctx, lis.Addr().String())
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Transform(ctx, TransformEvent{Payload: "admin"})
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if strings.Join(resp.Payloads, ",") != "admin,admin.bak" {
+		t.Fatalf("unexpected payloads: %v", resp.Payloads)
+	}
+}
+
+type classifyHandler struct{}
+
+func (classifyHandler) Match(ctx context.Context, event MatchEvent) (Response, error) {
+	return helperMatch(ctx, event)
+}
+
+func (classifyHandler) Classify(_ context.Context, event ResponseEvent) (Verdict, error) {
+	return helperClassify(event), nil
+}
+
+func TestGRPCClientClassifiesResponses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go ServeGRPC(lis, Info{Name: "classifier"}, classifyHandler{})
+	defer lis.Close()
+
+	client, err := DialGRPC(ctx, lis.Addr().String())
+	if err != nil {
+		t.Fatalf("dial plugin: %v", err)
+	}
+	defer client.Close()
+
+	if !client.Supports(CapabilityClassify) {
+		t.Fatal("expected the classify capability to be advertised")
+	}
+
+	hit, err := client.Classify(ctx, ResponseEvent{StatusCode: 200, Headers: map[string][]string{"X-Hit": {"1"}}})
+	if err != nil || !hit.Match {
+		t.Fatalf("expected a match, got %+v, %v", hit, err)
+	}
+	miss, err := client.Classify(ctx, ResponseEvent{StatusCode: 200})
+	if err != nil || miss.Match {
+		t.Fatalf("expected no match, got %+v, %v", miss, err)
+	}
+}
+
+func TestParseHandshakeLine(t *testing.T) {
+	network, address, err := parseHandshakeLine("hydro-plugin|1|tcp|127.0.0.1:4000\n")
+	if err != nil || network != "tcp" || address != "127.0.0.1:4000" {
+		t.Fatalf("unexpected result %q %q %v", network, address, err)
+	}
+
+	for _, line := range []string{"", "hello", "hydro-plugin|2|tcp|127.0.0.1:4000", "hydro-plugin|1|udp|127.0.0.1:4000"} {
+		if _, _, err := parseHandshakeLine(line); err == nil {
+			t.Fatalf("expected %q to be rejected", line)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policies for combining the verdicts of several verification plugins.
+const (
+	// PolicyAll keeps a hit only when every plugin verifies it.
+	PolicyAll = "all"
+	// PolicyAny keeps a hit when at least one plugin verifies it.
+	PolicyAny = "any"
+	// PolicyWeighted keeps a hit when the weighted confidence reaches a
+	// threshold.
+	PolicyWeighted = "weighted"
+)
+
+// ParsePolicy validates a policy name. An empty name selects PolicyAll.
+func ParsePolicy(name string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(name)); policy {
+	case "":
+		return PolicyAll, nil
+	case PolicyAll, PolicyAny, PolicyWeighted:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported plugin policy %q (want %s, %s or %s)", name, PolicyAll, PolicyAny, PolicyWeighted)
+	}
+}
+
+// Vote is one plugin's verdict on a hit.
+type Vote struct {
+	Response Response
+	// Weight is the share of the plugin in the aggregated confidence. Values
+	// of zero or less count as 1.
+	Weight float64
+}
+
+// score returns the confidence of the vote between 0 and 1. An explicit
+// confidence wins; otherwise a plugin that did not reject the hit scores 1.
+func (v Vote) score() float64 {
+	if c := v.Response.Confidence; c != nil {
+		return min(max(*c, 0), 1)
+	}
+	if v.Response.Verify != nil && !*v.Response.Verify {
+		return 0
+	}
+	return 1
+}
+
+func (v Vote) passed() bool {
+	if v.Response.Verify != nil {
+		return *v.Response.Verify
+	}
+	return v.score() >= 0.5
+}
+
+func (v Vote) weight() float64 {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// Aggregate combines votes according to policy. It returns whether the hit is
+// kept and the weighted mean confidence of the votes. threshold is only used by
+// PolicyWeighted. Without votes the hit is kept with confidence 1.
+func Aggregate(policy string, threshold float64, votes []Vote) (bool, float64) {
+	if len(votes) == 0 {
+		return true, 1
+	}
+
+	var total, weights float64
+	passed := 0
+	for _, vote := range votes {
+		total += vote.score() * vote.weight()
+		weights += vote.weight()
+		if vote.passed() {
+			passed++
+		}
+	}
+	confidence := total / weights
+
+	switch policy {
+	case PolicyAny:
+		return passed > 0, confidence
+	case PolicyWeighted:
+		return confidence >= threshold, confidence
+	default:
+		return passed == len(votes), confidence
+	}
+}
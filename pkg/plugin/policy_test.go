@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	pass := Vote{Response: Response{Verify: boolPtr(true)}}
+	fail := Vote{Response: Response{Verify: boolPtr(false)}}
+	unsure := Vote{Response: Response{Confidence: floatPtr(0.4)}, Weight: 3}
+
+	tests := []struct {
+		name       string
+		policy     string
+		threshold  float64
+		votes      []Vote
+		kept       bool
+		confidence float64
+	}{
+		{name: "no votes", policy: PolicyAll, kept: true, confidence: 1},
+		{name: "all passes", policy: PolicyAll, votes: []Vote{pass, pass}, kept: true, confidence: 1},
+		{name: "all rejects", policy: PolicyAll, votes: []Vote{pass, fail}, kept: false, confidence: 0.5},
+		{name: "any accepts", policy: PolicyAny, votes: []Vote{pass, fail}, kept: true, confidence: 0.5},
+		{name: "any rejects", policy: PolicyAny, votes: []Vote{fail, fail}, kept: false, confidence: 0},
+		{name: "weighted below threshold", policy: PolicyWeighted, threshold: 0.6, votes: []Vote{pass, unsure}, kept: false, confidence: 0.55},
+		{name: "weighted reaches threshold", policy: PolicyWeighted, threshold: 0.5, votes: []Vote{pass, unsure}, kept: true, confidence: 0.55},
+		{name: "silent plugin passes", policy: PolicyAll, votes: []Vote{{}}, kept: true, confidence: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, confidence := Aggregate(tt.policy, tt.threshold, tt.votes)
+			if kept != tt.kept {
+				t.Fatalf("kept=%v want %v", kept, tt.kept)
+			}
+			if math.Abs(confidence-tt.confidence) > 1e-9 {
+				t.Fatalf("confidence=%v want %v", confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if policy, err := ParsePolicy(""); err != nil || policy != PolicyAll {
+		t.Fatalf("expected the empty policy to default to %q, got %q, %v", PolicyAll, policy, err)
+	}
+	if policy, err := ParsePolicy(" Weighted "); err != nil || policy != PolicyWeighted {
+		t.Fatalf("expected %q, got %q, %v", PolicyWeighted, policy, err)
+	}
+	if _, err := ParsePolicy("majority"); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
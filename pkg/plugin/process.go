@@ -0,0 +1,390 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSON-RPC methods understood by persistent plugins.
+const (
+	MethodMatch     = "match"
+	MethodClassify  = "classify"
+	MethodTransform = "transform"
+	MethodShutdown  = "shutdown"
+)
+
+// shutdownTimeout bounds how long Close waits for a plugin to exit after the
+// shutdown request before killing it.
+const shutdownTimeout = 5 * time.Second
+
+// rpcRequest is a JSON-RPC 2.0 request sent to a persistent plugin. Requests
+// without an id are notifications and are not answered.
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response read from a persistent plugin.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is the error object a persistent plugin returns for a failed call.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// Process is a plugin that is started once and answers JSON-RPC 2.0 requests
+// over stdin/stdout for the lifetime of a run. Each message is framed with a
+// Content-Length header, as in the Language Server Protocol:
+//
+//	Content-Length: 42\r\n
+//	\r\n
+//	{"jsonrpc":"2.0","id":1,"method":"match",...}
+//
+// Calls may be issued concurrently; responses are matched to requests by id.
+type Process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *lockedBuffer
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan rpcResponse
+	err     error
+	closed  bool
+
+	done chan struct{}
+}
+
+// Start launches the plugin at path with args in persistent mode. The process
+// runs until Close is called or ctx is cancelled.
+func Start(ctx context.Context, path string, args ...string) (*Process, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("plugin path is empty")
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("open plugin stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("open plugin stdout: %w", err)
+	}
+
+	p := &Process{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &lockedBuffer{},
+		pending: make(map[uint64]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = p.stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin: %w", err)
+	}
+
+	go p.readLoop(bufio.NewReader(stdout))
+
+	return p, nil
+}
+
+// Call sends event to the plugin and waits for its Response.
+func (p *Process) Call(ctx context.Context, event MatchEvent) (Response, error) {
+	var resp Response
+
+	raw, err := p.call(ctx, MethodMatch, event)
+	if err != nil {
+		return resp, err
+	}
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return resp, nil
+	}
+
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return resp, fmt.Errorf("decode plugin response: %w", err)
+	}
+	return resp, nil
+}
+
+// Classify asks a matcher plugin whether a response is a hit.
+func (p *Process) Classify(ctx context.Context, event ResponseEvent) (Verdict, error) {
+	var verdict Verdict
+
+	raw, err := p.call(ctx, MethodClassify, event)
+	if err != nil {
+		return verdict, err
+	}
+	if err := json.Unmarshal(raw, &verdict); err != nil {
+		return verdict, fmt.Errorf("decode plugin response: %w", err)
+	}
+	return verdict, nil
+}
+
+// Transform asks the plugin to rewrite a payload.
+func (p *Process) Transform(ctx context.Context, event TransformEvent) (TransformResponse, error) {
+	var resp TransformResponse
+
+	raw, err := p.call(ctx, MethodTransform, event)
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return resp, fmt.Errorf("decode plugin response: %w", err)
+	}
+	return resp, nil
+}
+
+// Notify sends a lifecycle event as a JSON-RPC notification whose method is
+// the event type. Plugins ignore notifications they do not understand.
+func (p *Process) Notify(ctx context.Context, event LifecycleEvent) error {
+	p.mu.Lock()
+	err := p.err
+	if err == nil && p.closed {
+		err = errors.New("plugin is closed")
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return p.write(rpcRequest{JSONRPC: "2.0", Method: event.Type, Params: event})
+}
+
+// Close asks the plugin to shut down, closes its stdin, and waits for it to
+// exit. A plugin that does not exit within a few seconds is killed.
+func (p *Process) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	alive := p.err == nil
+	p.mu.Unlock()
+
+	if alive {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		_, _ = p.call(ctx, MethodShutdown, nil)
+		cancel()
+	}
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.done:
+	case <-time.After(shutdownTimeout):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+
+	if err := p.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(p.stderr.String()); msg != "" {
+			return fmt.Errorf("plugin error: %s: %w", msg, err)
+		}
+		return fmt.Errorf("plugin error: %w", err)
+	}
+	return nil
+}
+
+func (p *Process) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	p.mu.Lock()
+	if p.err != nil {
+		err := p.err
+		p.mu.Unlock()
+		return nil, err
+	}
+	if p.closed && method != MethodShutdown {
+		p.mu.Unlock()
+		return nil, errors.New("plugin is closed")
+	}
+	p.nextID++
+	id := p.nextID
+	reply := make(chan rpcResponse, 1)
+	p.pending[id] = reply
+	p.mu.Unlock()
+
+	if err := p.write(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		p.forget(id)
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-reply:
+		if !ok {
+			return nil, p.failure()
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		p.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Process) write(req rpcRequest) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode plugin payload: %w", err)
+	}
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(p.stdin, "Content-Length: %d\r\n\r\n", len(payload)); err != nil {
+		return fmt.Errorf("write plugin stdin: %w", err)
+	}
+	if _, err := p.stdin.Write(payload); err != nil {
+		return fmt.Errorf("write plugin stdin: %w", err)
+	}
+	return nil
+}
+
+func (p *Process) forget(id uint64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+// readLoop delivers responses to waiting calls until stdout is closed or a
+// malformed message is read, then fails every outstanding call.
+func (p *Process) readLoop(r *bufio.Reader) {
+	defer close(p.done)
+
+	var err error
+	for {
+		var payload []byte
+		payload, err = readFrame(r)
+		if err != nil {
+			break
+		}
+
+		var resp rpcResponse
+		if err = json.Unmarshal(payload, &resp); err != nil {
+			err = fmt.Errorf("decode plugin message: %w", err)
+			break
+		}
+
+		p.mu.Lock()
+		reply, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+
+		if ok {
+			reply <- resp
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = errors.New("plugin exited")
+	}
+	if msg := strings.TrimSpace(p.stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+
+	p.mu.Lock()
+	p.err = err
+	for id, reply := range p.pending {
+		close(reply)
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+}
+
+func (p *Process) failure() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	return errors.New("plugin exited")
+}
+
+// readFrame reads one Content-Length framed message from r.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("read plugin header: %w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if length < 0 {
+				return nil, errors.New("plugin message is missing Content-Length")
+			}
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed plugin header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("read plugin message: %w", err)
+	}
+	return payload, nil
+}
+
+// lockedBuffer collects plugin stderr so it can be read while the plugin is
+// still running.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
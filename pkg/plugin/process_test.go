@@ -0,0 +1,270 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess acts as a persistent plugin when run by startHelper. It
+// verifies events with status 200, fails events with status 500, and exits on
+// shutdown.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("HYDRO_PLUGIN_HELPER") != "1" {
+		return
+	}
+	if os.Getenv("HYDRO_PLUGIN_PROTOCOL") == "grpc" {
+		info := Info{Name: "helper", Capabilities: []string{CapabilityMatch}}
+		if err := ServeGRPCPlugin(info, HandlerFunc(helperMatch)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	var notified []string
+	r := bufio.NewReader(os.Stdin)
+	for {
+		payload, err := readFrame(r)
+		if err != nil {
+			os.Exit(0)
+		}
+
+		var req struct {
+			ID     uint64     `json:"id"`
+			Method string     `json:"method"`
+			Params MatchEvent `json:"params"`
+		}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if req.ID == 0 {
+			notified = append(notified, req.Method)
+			continue
+		}
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch {
+		case req.Method == MethodShutdown:
+			resp["result"] = nil
+		case req.Method == MethodClassify:
+			var params struct {
+				Params ResponseEvent `json:"params"`
+			}
+			_ = json.Unmarshal(payload, &params)
+			resp["result"] = helperClassify(params.Params)
+		case req.Method == MethodTransform:
+			var params struct {
+				Params TransformEvent `json:"params"`
+			}
+			_ = json.Unmarshal(payload, &params)
+			resp["result"] = helperTransform(params.Params)
+		case req.Params.URL == "events":
+			resp["result"] = Response{Request: &RequestSpec{URL: strings.Join(notified, ",")}}
+		default:
+			result, err := helperMatch(context.Background(), req.Params)
+			if err != nil {
+				resp["error"] = err
+			} else {
+				resp["result"] = result
+			}
+		}
+
+		data, _ := json.Marshal(resp)
+		fmt.Fprintf(os.Stdout, "Content-Length: %d\r\n\r\n%s", len(data), data)
+
+		if req.Method == MethodShutdown {
+			os.Exit(0)
+		}
+	}
+}
+
+func helperMatch(_ context.Context, event MatchEvent) (Response, error) {
+	if event.StatusCode == http.StatusInternalServerError {
+		return Response{}, &RPCError{Code: -32000, Message: "upstream failed"}
+	}
+	return Response{Verify: boolPtr(event.StatusCode == http.StatusOK)}, nil
+}
+
+func helperClassify(event ResponseEvent) Verdict {
+	return Verdict{Match: event.StatusCode == http.StatusOK && len(event.Headers["X-Hit"]) > 0}
+}
+
+func helperTransform(event TransformEvent) TransformResponse {
+	if event.Payload == "drop" {
+		return TransformResponse{}
+	}
+	return TransformResponse{Payloads: []string{event.Payload, event.Payload + ".bak"}}
+}
+
+func startHelper(t *testing.T) *Process {
+	t.Helper()
+	t.Setenv("HYDRO_PLUGIN_HELPER", "1")
+
+	p, err := Start(context.Background(), os.Args[0], "-test.run=^TestHelperProcess$")
+	if err != nil {
+		t.Fatalf("start plugin: %v", err)
+	}
+	return p
+}
+
+func TestProcessHandlesManyConcurrentCalls(t *testing.T) {
+	p := startHelper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			status := http.StatusOK
+			if i%2 == 1 {
+				status = http.StatusNotFound
+			}
+
+			resp, err := p.Call(ctx, MatchEvent{URL: fmt.Sprintf("https://example.com/%d", i), StatusCode: status})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if resp.Verify == nil || *resp.Verify != (status == http.StatusOK) {
+				errs <- fmt.Errorf("call %d: unexpected response %+v", i, resp)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("close plugin: %v", err)
+	}
+	if _, err := p.Call(ctx, MatchEvent{}); err == nil {
+		t.Fatal("expected calls after Close to fail")
+	}
+}
+
+func TestProcessReturnsRPCErrors(t *testing.T) {
+	p := startHelper(t)
+	defer p.Close()
+
+	_, err := p.Call(context.Background(), MatchEvent{StatusCode: http.StatusInternalServerError})
+	if err == nil || !strings.Contains(err.Error(), "upstream failed") {
+		t.Fatalf("expected plugin error, got %v", err)
+	}
+
+	resp, err := p.Call(context.Background(), MatchEvent{StatusCode: http.StatusOK})
+	if err != nil || resp.Verify == nil || !*resp.Verify {
+		t.Fatalf("expected plugin to keep serving after an error, got %+v, %v", resp, err)
+	}
+}
+
+func TestProcessSendsLifecycleNotifications(t *testing.T) {
+	p := startHelper(t)
+	defer p.Close()
+
+	ctx := context.Background()
+	for _, eventType := range []string{EventRunStart, EventStageStart, EventStageEnd, EventRunComplete} {
+		if err := p.Notify(ctx, LifecycleEvent{Type: eventType, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("notify %s: %v", eventType, err)
+		}
+	}
+
+	resp, err := p.Call(ctx, MatchEvent{URL: "events"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if resp.Request == nil || resp.Request.URL != "run_start,stage_start,stage_end,run_complete" {
+		t.Fatalf("expected notifications to arrive in order before the call, got %+v", resp.Request)
+	}
+}
+
+func TestProcessTransformsPayloads(t *testing.T) {
+	p := startHelper(t)
+	defer p.Close()
+
+	resp, err := p.Transform(context.Background(), TransformEvent{Payload: "admin"})
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if strings.Join(resp.Payloads, ",") != "admin,admin.bak" {
+		t.Fatalf("unexpected payloads: %v", resp.Payloads)
+	}
+
+	resp, err = p.Transform(context.Background(), TransformEvent{Payload: "drop"})
+	if err != nil || len(resp.Payloads) != 0 {
+		t.Fatalf("expected payload to be dropped, got %v, %v", resp.Payloads, err)
+	}
+}
+
+func TestProcessClassifiesConcurrentResponses(t *testing.T) {
+	p := startHelper(t)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			event := ResponseEvent{StatusCode: http.StatusOK, BodySHA256: "e3b0c442"}
+			if i%2 == 0 {
+				event.Headers = map[string][]string{"X-Hit": {"1"}}
+			}
+
+			verdict, err := p.Classify(ctx, event)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if verdict.Match != (i%2 == 0) {
+				errs <- fmt.Errorf("classify %d: unexpected verdict %+v", i, verdict)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessReportsExitedPlugin(t *testing.T) {
+	p, err := Start(context.Background(), "/bin/sh", "-c", "echo boom >&2; exit 3")
+	if err != nil {
+		t.Fatalf("start plugin: %v", err)
+	}
+
+	_, err = p.Call(context.Background(), MatchEvent{})
+	if err == nil {
+		t.Fatal("expected call to an exited plugin to fail")
+	}
+	if closeErr := p.Close(); closeErr == nil {
+		t.Fatal("expected Close to report the non-zero exit")
+	}
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
@@ -19,13 +19,62 @@ type MatchEvent struct {
 	StatusCode    int    `json:"status_code"`
 	ContentLength int64  `json:"content_length"`
 	DurationMS    int64  `json:"duration_ms"`
-	Body          []byte `json:"body,omitempty"`
-	Error         string `json:"error,omitempty"`
+	// Word is the wordlist entry behind the request and Payload the value
+	// substituted into the target.
+	Word    string              `json:"word,omitempty"`
+	Payload string              `json:"payload,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    []byte              `json:"body,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	// FollowUpOf is set when the event describes the outcome of a follow-up
+	// request the plugin asked for, and holds the URL of the original hit.
+	FollowUpOf string `json:"follow_up_of,omitempty"`
+}
+
+// ResponseEvent describes a single response to a matcher plugin, which
+// decides whether it is a hit. Only a hash of the body is sent to keep the
+// exchange cheap enough for every request.
+type ResponseEvent struct {
+	URL           string              `json:"url"`
+	Method        string              `json:"method"`
+	StatusCode    int                 `json:"status_code"`
+	ContentLength int64               `json:"content_length"`
+	DurationMS    int64               `json:"duration_ms"`
+	Word          string              `json:"word,omitempty"`
+	Payload       string              `json:"payload,omitempty"`
+	Headers       map[string][]string `json:"headers,omitempty"`
+	BodySHA256    string              `json:"body_sha256"`
+	BodySize      int                 `json:"body_size"`
+	BodyWords     int                 `json:"body_words"`
+	BodyLines     int                 `json:"body_lines"`
+}
+
+// Verdict is a matcher plugin's decision for a ResponseEvent.
+type Verdict struct {
+	Match bool `json:"match"`
+}
+
+// TransformEvent asks a payload-transformer plugin to rewrite one payload
+// before request URLs are built.
+type TransformEvent struct {
+	Payload string `json:"payload"`
+}
+
+// TransformResponse lists the payloads that replace TransformEvent.Payload.
+// An empty list drops the payload.
+type TransformResponse struct {
+	Payloads []string `json:"payloads"`
 }
 
 // Response captures the values returned by the plugin.
 type Response struct {
-	Verify  *bool        `json:"verify,omitempty"`
+	Verify *bool `json:"verify,omitempty"`
+	// Confidence is an optional score between 0 and 1 used when the verdicts
+	// of several plugins are aggregated.
+	Confidence *float64 `json:"confidence,omitempty"`
+	// Tags and Note annotate the hit, for example with why it is interesting.
+	Tags    []string     `json:"tags,omitempty"`
+	Note    string       `json:"note,omitempty"`
 	Request *RequestSpec `json:"request,omitempty"`
 }
 
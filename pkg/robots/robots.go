@@ -0,0 +1,249 @@
+// Package robots fetches and applies robots.txt files as described by RFC
+// 9309, so scans can skip the paths a site asks crawlers to leave alone.
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"hydr0g3n/pkg/httpclient"
+)
+
+// Agent is the product token hydro looks for in User-agent lines before
+// falling back to the rules for every crawler.
+const Agent = "hydro"
+
+// maxSize is how much of a robots.txt file is parsed; RFC 9309 requires at
+// least 500 KiB.
+const maxSize = 500 << 10
+
+// rule is an Allow or Disallow line.
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// Rules are the rules of a robots.txt file that apply to one agent.
+type Rules struct {
+	rules []rule
+	// disallowAll is set when the file could not be fetched because the
+	// server failed, which RFC 9309 treats as a complete disallow.
+	disallowAll bool
+}
+
+// Parse returns the rules of data that apply to agent: those of the groups
+// naming it, or of the groups for * when none does.
+func Parse(data []byte, agent string) *Rules {
+	agent = strings.ToLower(agent)
+
+	var (
+		named, wildcard []rule
+		agents          []string
+		inRules         bool
+		namedMatched    bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A User-agent line after rules starts a new group.
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			r := rule{allow: key == "allow", pattern: value}
+			for _, name := range agents {
+				switch name {
+				case agent:
+					named = append(named, r)
+					namedMatched = true
+				case "*":
+					wildcard = append(wildcard, r)
+				}
+			}
+		}
+	}
+
+	if namedMatched {
+		return &Rules{rules: named}
+	}
+	return &Rules{rules: wildcard}
+}
+
+// Allowed reports whether the path and query of rawURL may be requested.
+// The longest matching pattern wins, and Allow wins a tie. A nil Rules
+// allows everything.
+func (r *Rules) Allowed(rawURL string) bool {
+	if r == nil {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	target := u.EscapedPath()
+	if target == "" {
+		target = "/"
+	}
+	if target == "/robots.txt" {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if len(rule.pattern) < longest || !match(rule.pattern, target) {
+			continue
+		}
+		if len(rule.pattern) > longest || rule.allow {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// match reports whether target starts with pattern, where * matches any run
+// of characters and a trailing $ anchors the end of target.
+func match(pattern, target string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(target, parts[0]) {
+		return false
+	}
+	rest := target[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// Fetch downloads the robots.txt of the origin of rawURL and returns the
+// rules for agent. A missing file allows everything and a server error
+// disallows everything, as RFC 9309 requires; a request that fails is
+// reported as an error.
+func Fetch(ctx context.Context, client *httpclient.Client, rawURL, agent string) (*Rules, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("robots.txt: %q has no host", rawURL)
+	}
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+
+	resp, err := client.Request(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", robotsURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &Rules{disallowAll: true}, nil
+	case resp.StatusCode >= 400:
+		return &Rules{}, nil
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("fetch %s: redirected with status %d", robotsURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", robotsURL, err)
+	}
+	return Parse(data, agent), nil
+}
+
+// Policy applies the robots.txt rules of several hosts and counts the URLs
+// they disallowed. Its rules are loaded before a scan starts; Allowed is
+// then safe for concurrent use.
+type Policy struct {
+	agent   string
+	origins map[string]*Rules
+	skipped atomic.Int64
+}
+
+// NewPolicy returns an empty Policy for agent.
+func NewPolicy(agent string) *Policy {
+	return &Policy{agent: agent, origins: make(map[string]*Rules)}
+}
+
+// Load fetches the robots.txt of the origin of rawURL unless it was already
+// loaded.
+func (p *Policy) Load(ctx context.Context, client *httpclient.Client, rawURL string) error {
+	key := origin(rawURL)
+	if _, ok := p.origins[key]; ok {
+		return nil
+	}
+	rules, err := Fetch(ctx, client, rawURL, p.agent)
+	if err != nil {
+		return err
+	}
+	p.origins[key] = rules
+	return nil
+}
+
+// Allowed reports whether rawURL may be requested, counting it as skipped
+// otherwise. URLs of origins that were not loaded are allowed.
+func (p *Policy) Allowed(rawURL string) bool {
+	if p == nil {
+		return true
+	}
+	if p.origins[origin(rawURL)].Allowed(rawURL) {
+		return true
+	}
+	p.skipped.Add(1)
+	return false
+}
+
+// Skipped returns how many URLs Allowed rejected.
+func (p *Policy) Skipped() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.skipped.Load()
+}
+
+// origin returns the lowercased scheme and host of rawURL.
+func origin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
@@ -0,0 +1,82 @@
+package robots
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hydr0g3n/pkg/httpclient"
+)
+
+const sample = `
+# every crawler
+User-agent: *
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.bak$
+Disallow: /search?q=
+
+User-agent: otherbot
+Disallow: /
+`
+
+func TestRulesAllowed(t *testing.T) {
+	rules := Parse([]byte(sample), Agent)
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/", true},
+		{"https://example.com/admin", false},
+		{"https://example.com/admin/users", false},
+		{"https://example.com/admin/public/logo.png", true},
+		{"https://example.com/backup.bak", false},
+		{"https://example.com/backup.bak.txt", true},
+		{"https://example.com/search?q=x", false},
+		{"https://example.com/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.url); got != tt.want {
+			t.Errorf("Allowed(%q) = %t, want %t", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParsePrefersNamedGroup(t *testing.T) {
+	rules := Parse([]byte("User-agent: *\nDisallow: /\n\nUser-agent: Hydro\nDisallow: /private\n"), Agent)
+	if !rules.Allowed("https://example.com/public") || rules.Allowed("https://example.com/private") {
+		t.Fatal("the group naming hydro was not used")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	robotsStatus := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(robotsStatus)
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+
+	client := httpclient.New(time.Second, false)
+	policy := NewPolicy(Agent)
+	if err := policy.Load(context.Background(), client, server.URL+"/FUZZ"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if policy.Allowed(server.URL+"/private/x") || !policy.Allowed(server.URL+"/public") {
+		t.Fatal("Policy did not apply robots.txt")
+	}
+	if !policy.Allowed("https://other.example.com/private") {
+		t.Fatal("Policy rejected a URL of an origin it did not load")
+	}
+	if policy.Skipped() != 1 {
+		t.Fatalf("Skipped = %d, want 1", policy.Skipped())
+	}
+
+	robotsStatus = http.StatusServiceUnavailable
+	rules, err := Fetch(context.Background(), client, server.URL, Agent)
+	if err != nil || rules.Allowed(server.URL+"/public") {
+		t.Fatalf("a failing robots.txt did not disallow everything (%v)", err)
+	}
+}
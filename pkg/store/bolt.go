@@ -0,0 +1,515 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltRunsBucket     = []byte("runs")
+	boltAttemptsBucket = []byte("path_attempted")
+	boltHitsBucket     = []byte("hits")
+)
+
+// Bolt implements Store on top of an embedded bbolt key-value database. It
+// avoids SQLite's per-statement overhead, and writes issued concurrently by
+// the workers are grouped into shared transactions so a scan pays for one
+// fsync per batch rather than one per request.
+type Bolt struct {
+	db     *bolt.DB
+	writer *boltWriter
+}
+
+// BoltRun records activity for a run persisted in a Bolt database.
+type BoltRun struct {
+	db       *bolt.DB
+	writer   *boltWriter
+	id       int64
+	runID    string
+	scopeKey string
+	// continued is set when StartRun found the run already recorded.
+	continued bool
+}
+
+type boltRunRecord struct {
+	ID          int64           `json:"id"`
+	RunID       string          `json:"run_id"`
+	StartedAt   string          `json:"started_at"`
+	TargetURL   string          `json:"target_url,omitempty"`
+	Wordlist    string          `json:"wordlist,omitempty"`
+	Concurrency int             `json:"concurrency,omitempty"`
+	TimeoutMs   int64           `json:"timeout_ms,omitempty"`
+	Profile     string          `json:"profile,omitempty"`
+	Beginner    bool            `json:"beginner,omitempty"`
+	BinaryName  string          `json:"binary_name,omitempty"`
+	Config      []ConfigValue   `json:"config,omitempty"`
+	Payloads    []PayloadSource `json:"payloads,omitempty"`
+	FinishedAt  string          `json:"finished_at,omitempty"`
+}
+
+type boltAttemptRecord struct {
+	RunID       int64  `json:"run_id"`
+	AttemptedAt string `json:"attempted_at"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+type boltHitRecord struct {
+	RunID         int64    `json:"run_id"`
+	Path          string   `json:"path"`
+	StatusCode    int      `json:"status_code"`
+	ContentLength int64    `json:"content_length"`
+	DurationMs    int64    `json:"duration_ms"`
+	Confidence    *float64 `json:"confidence,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Notes         []string `json:"notes,omitempty"`
+	Screenshot    string   `json:"screenshot,omitempty"`
+	RecordedAt    string   `json:"recorded_at"`
+}
+
+// OpenBolt initializes (or connects to) the Bolt database located at the given path.
+func OpenBolt(path string) (*Bolt, error) {
+	if path == "" {
+		return nil, errors.New("bolt path must not be empty")
+	}
+
+	if err := ensureDir(path); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltRunsBucket, boltAttemptsBucket, boltHitsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("create bucket %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init bolt schema: %w", err)
+	}
+
+	return &Bolt{db: db, writer: newBoltWriter(db)}, nil
+}
+
+// Close releases any resources associated with the database.
+func (b *Bolt) Close() error {
+	if b == nil || b.db == nil {
+		return nil
+	}
+
+	b.writer.stop()
+	return b.db.Close()
+}
+
+// StartRun records metadata for a new execution and returns a handle for recording activity.
+func (b *Bolt) StartRun(ctx context.Context, meta RunMetadata) (Recorder, error) {
+	if b == nil {
+		return nil, errors.New("bolt store is nil")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	runIdentifier := strings.TrimSpace(meta.RunID)
+	if runIdentifier == "" {
+		runIdentifier = meta.hash()
+	}
+
+	startedAt := meta.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now().UTC()
+	}
+
+	record := boltRunRecord{
+		RunID:       runIdentifier,
+		StartedAt:   startedAt.Format(time.RFC3339Nano),
+		TargetURL:   meta.TargetURL,
+		Wordlist:    meta.Wordlist,
+		Concurrency: meta.Concurrency,
+		TimeoutMs:   int64(meta.Timeout / time.Millisecond),
+		Profile:     meta.Profile,
+		Beginner:    meta.Beginner,
+		BinaryName:  meta.BinaryName,
+		Config:      meta.ConfigValues(),
+		Payloads:    meta.PayloadSources(),
+	}
+
+	var continued bool
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(boltRunsBucket)
+
+		// Reuse the numeric identifier of an existing run so repeated runs
+		// with the same identifier refresh their metadata.
+		if existing := runs.Get([]byte(runIdentifier)); existing != nil {
+			var previous boltRunRecord
+			if err := json.Unmarshal(existing, &previous); err != nil {
+				return fmt.Errorf("decode run metadata: %w", err)
+			}
+			record.ID = previous.ID
+			continued = true
+		} else {
+			seq, err := runs.NextSequence()
+			if err != nil {
+				return fmt.Errorf("obtain run id: %w", err)
+			}
+			record.ID = int64(seq)
+		}
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encode run metadata: %w", err)
+		}
+
+		return runs.Put([]byte(runIdentifier), encoded)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store run metadata: %w", err)
+	}
+
+	return &BoltRun{db: b.db, writer: b.writer, id: record.ID, runID: runIdentifier, scopeKey: meta.attemptScopeKey(runIdentifier), continued: continued}, nil
+}
+
+// ID returns the numeric run identifier within the database.
+func (r *BoltRun) ID() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.id
+}
+
+// RunID returns the stable identifier associated with the run.
+func (r *BoltRun) RunID() string {
+	if r == nil {
+		return ""
+	}
+	return r.runID
+}
+
+// Continued reports whether the run was recorded before StartRun returned it.
+func (r *BoltRun) Continued() bool {
+	return r != nil && r.continued
+}
+
+// MarkAttempt records that a path has been attempted. It returns true if the
+// path is new within the run's deduplication scope.
+func (r *BoltRun) MarkAttempt(ctx context.Context, path string) (bool, error) {
+	if r == nil {
+		return false, errors.New("run is nil")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	encoded, err := json.Marshal(boltAttemptRecord{
+		RunID:       r.id,
+		AttemptedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return false, fmt.Errorf("encode path attempt: %w", err)
+	}
+
+	key := boltAttemptKey(r.scopeKey, path)
+	inserted := false
+	err = r.writer.update(ctx, func(tx *bolt.Tx) error {
+		attempts := tx.Bucket(boltAttemptsBucket)
+		inserted = attempts.Get(key) == nil
+		return attempts.Put(key, encoded)
+	})
+	if err != nil {
+		return false, fmt.Errorf("insert path attempt: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// RecordOutcome stores the status code or error observed for an attempted path.
+func (r *BoltRun) RecordOutcome(ctx context.Context, path string, outcome AttemptOutcome) error {
+	if r == nil {
+		return errors.New("run is nil")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := boltAttemptKey(r.scopeKey, path)
+	err := r.writer.update(ctx, func(tx *bolt.Tx) error {
+		attempts := tx.Bucket(boltAttemptsBucket)
+
+		record := boltAttemptRecord{RunID: r.id, AttemptedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+		if existing := attempts.Get(key); existing != nil {
+			if err := json.Unmarshal(existing, &record); err != nil {
+				return fmt.Errorf("decode path attempt: %w", err)
+			}
+		}
+
+		record.StatusCode = outcome.StatusCode
+		record.Error = outcome.Error
+		record.CompletedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encode path attempt: %w", err)
+		}
+		return attempts.Put(key, encoded)
+	})
+	if err != nil {
+		return fmt.Errorf("record attempt outcome: %w", err)
+	}
+
+	return nil
+}
+
+// RecordHit saves information about a confirmed hit for the run.
+func (r *BoltRun) RecordHit(ctx context.Context, hit HitRecord) error {
+	if r == nil {
+		return errors.New("run is nil")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	durationMs := hit.Duration.Milliseconds()
+	if hit.Duration < 0 {
+		durationMs = 0
+	}
+
+	record := boltHitRecord{
+		RunID:         r.id,
+		Path:          hit.Path,
+		StatusCode:    hit.StatusCode,
+		ContentLength: hit.ContentLength,
+		DurationMs:    durationMs,
+		Tags:          hit.Tags,
+		Notes:         hit.Notes,
+		Screenshot:    hit.Screenshot,
+		RecordedAt:    time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if hit.HasConfidence {
+		confidence := hit.Confidence
+		record.Confidence = &confidence
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode hit: %w", err)
+	}
+
+	err = r.writer.update(ctx, func(tx *bolt.Tx) error {
+		hits := tx.Bucket(boltHitsBucket)
+		seq, err := hits.NextSequence()
+		if err != nil {
+			return err
+		}
+		return hits.Put(boltKey(seq), encoded)
+	})
+	if err != nil {
+		return fmt.Errorf("insert hit: %w", err)
+	}
+
+	return nil
+}
+
+// Finish records that the run went through all of its requests.
+func (r *BoltRun) Finish(ctx context.Context) error {
+	if r == nil {
+		return errors.New("run is nil")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(boltRunsBucket)
+		existing := runs.Get([]byte(r.runID))
+		if existing == nil {
+			return fmt.Errorf("run %s not found", r.runID)
+		}
+
+		var record boltRunRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return fmt.Errorf("decode run metadata: %w", err)
+		}
+		record.FinishedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encode run metadata: %w", err)
+		}
+		return runs.Put([]byte(r.runID), encoded)
+	})
+	if err != nil {
+		return fmt.Errorf("record run finished: %w", err)
+	}
+
+	return nil
+}
+
+// boltWriter commits writes from concurrent workers in shared transactions.
+// Writes queue up while the previous transaction is being synced, and the
+// next transaction commits everything queued so far, so a busy scan pays for
+// one fsync per group instead of one per request without delaying a lone
+// writer.
+type boltWriter struct {
+	db       *bolt.DB
+	requests chan *boltWrite
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type boltWrite struct {
+	fn   func(*bolt.Tx) error
+	errc chan error
+}
+
+// maxBoltGroup bounds how many writes share a single transaction.
+const maxBoltGroup = 256
+
+var errBoltClosed = errors.New("bolt store is closed")
+
+func newBoltWriter(db *bolt.DB) *boltWriter {
+	w := &boltWriter{
+		db:       db,
+		requests: make(chan *boltWrite),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// update runs fn in a write transaction shared with other queued writes and
+// returns once that transaction has committed.
+func (w *boltWriter) update(ctx context.Context, fn func(*bolt.Tx) error) error {
+	req := &boltWrite{fn: fn, errc: make(chan error, 1)}
+	select {
+	case w.requests <- req:
+	case <-w.quit:
+		return errBoltClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-req.errc
+}
+
+func (w *boltWriter) stop() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+		<-w.done
+	})
+}
+
+func (w *boltWriter) run() {
+	defer close(w.done)
+
+	group := make([]*boltWrite, 0, maxBoltGroup)
+	for {
+		select {
+		case req := <-w.requests:
+			group = append(group[:0], req)
+		case <-w.quit:
+			return
+		}
+
+	collect:
+		for len(group) < maxBoltGroup {
+			select {
+			case req := <-w.requests:
+				group = append(group, req)
+			default:
+				break collect
+			}
+		}
+
+		w.commit(group)
+	}
+}
+
+// commit applies group in one transaction. A write that fails rolls the
+// transaction back; it receives its error and the remaining writes are
+// committed without it.
+func (w *boltWriter) commit(group []*boltWrite) {
+	for len(group) > 0 {
+		failed := -1
+		var failure error
+		err := w.db.Update(func(tx *bolt.Tx) error {
+			for i, req := range group {
+				if err := req.fn(tx); err != nil {
+					failed, failure = i, err
+					return err
+				}
+			}
+			return nil
+		})
+
+		if failed < 0 {
+			for _, req := range group {
+				req.errc <- err
+			}
+			return
+		}
+
+		group[failed].errc <- failure
+		group = append(group[:failed], group[failed+1:]...)
+	}
+}
+
+// boltMagic is the value bbolt stores at the start of each meta page.
+const boltMagic = 0xED0CDAED
+
+// isBoltFile reports whether path holds a bbolt database by checking the magic
+// number of its first meta page.
+func isBoltFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	// The meta page follows the 16-byte page header and begins with the
+	// magic number, written in the byte order of the host that created it.
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	magic := header[16:20]
+	return binary.LittleEndian.Uint32(magic) == boltMagic || binary.BigEndian.Uint32(magic) == boltMagic
+}
+
+func boltKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// boltAttemptKey namespaces a path by its deduplication scope. Global attempts
+// are keyed by the bare path.
+func boltAttemptKey(scopeKey, path string) []byte {
+	if scopeKey == "" {
+		return []byte(path)
+	}
+	return []byte(scopeKey + "\x00" + path)
+}
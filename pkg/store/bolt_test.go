@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBoltMarkAttemptDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "runs.bolt")
+
+	db, err := Open(BackendBolt, path)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+
+	run, err := db.StartRun(ctx, RunMetadata{RunID: "bolt-run", TargetURL: "https://example.com/FUZZ"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+	if run.Continued() {
+		t.Fatal("expected a new run not to be continued")
+	}
+
+	inserted, err := run.MarkAttempt(ctx, "https://example.com/admin")
+	if err != nil {
+		t.Fatalf("mark attempt: %v", err)
+	}
+	if !inserted {
+		t.Fatal("expected first attempt to be new")
+	}
+
+	inserted, err = run.MarkAttempt(ctx, "https://example.com/admin")
+	if err != nil {
+		t.Fatalf("mark attempt: %v", err)
+	}
+	if inserted {
+		t.Fatal("expected repeated attempt to be deduplicated")
+	}
+
+	if err := run.RecordHit(ctx, HitRecord{Path: "https://example.com/admin", StatusCode: 200}); err != nil {
+		t.Fatalf("record hit: %v", err)
+	}
+
+	firstID := run.(*BoltRun).ID()
+	if err := db.Close(); err != nil {
+		t.Fatalf("close bolt: %v", err)
+	}
+
+	db, err = OpenBolt(path)
+	if err != nil {
+		t.Fatalf("reopen bolt: %v", err)
+	}
+	defer db.Close()
+
+	resumed, err := db.StartRun(ctx, RunMetadata{RunID: "bolt-run"})
+	if err != nil {
+		t.Fatalf("resume run: %v", err)
+	}
+	if got := resumed.(*BoltRun).ID(); got != firstID {
+		t.Fatalf("expected resumed run to keep id %d, got %d", firstID, got)
+	}
+	if !resumed.Continued() {
+		t.Fatal("expected the resumed run to be continued")
+	}
+
+	inserted, err = resumed.MarkAttempt(ctx, "https://example.com/admin")
+	if err != nil {
+		t.Fatalf("mark attempt after reopen: %v", err)
+	}
+	if inserted {
+		t.Fatal("expected attempt to persist across reopen")
+	}
+}
+
+func TestOpenRejectsUnknownBackend(t *testing.T) {
+	if _, err := Open("leveldb", filepath.Join(t.TempDir(), "runs.db")); err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+}
+
+func TestBoltConcurrentMarkAttemptReportsOneInsert(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenBolt(filepath.Join(t.TempDir(), "runs.bolt"))
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	defer db.Close()
+
+	run, err := db.StartRun(ctx, RunMetadata{RunID: "batched"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	const workers = 32
+	var (
+		wg       sync.WaitGroup
+		inserted atomic.Int32
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := run.MarkAttempt(ctx, "https://example.com/admin")
+			if err != nil {
+				t.Errorf("mark attempt: %v", err)
+				return
+			}
+			if ok {
+				inserted.Add(1)
+			}
+			if err := run.RecordOutcome(ctx, "https://example.com/admin", AttemptOutcome{StatusCode: 200}); err != nil {
+				t.Errorf("record outcome: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := inserted.Load(); got != 1 {
+		t.Fatalf("expected exactly one new attempt across %d writers, got %d", workers, got)
+	}
+}
+
+func TestOpenSQLiteReadOnlyRejectsBoltDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.bolt")
+
+	db, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close bolt: %v", err)
+	}
+
+	_, err = OpenSQLiteReadOnly(path)
+	if err == nil || !strings.Contains(err.Error(), "Bolt database") {
+		t.Fatalf("expected Bolt database error, got %v", err)
+	}
+}
+
+func BenchmarkMarkAttemptParallel(b *testing.B) {
+	for _, backend := range Backends() {
+		b.Run(backend, func(b *testing.B) {
+			ctx := context.Background()
+			db, err := Open(backend, filepath.Join(b.TempDir(), "runs.db"))
+			if err != nil {
+				b.Fatalf("open %s: %v", backend, err)
+			}
+			defer db.Close()
+
+			run, err := db.StartRun(ctx, RunMetadata{RunID: "bench"})
+			if err != nil {
+				b.Fatalf("start run: %v", err)
+			}
+
+			var seq atomic.Int64
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					path := "https://example.com/" + strconv.FormatInt(seq.Add(1), 10)
+					if _, err := run.MarkAttempt(ctx, path); err != nil {
+						b.Errorf("mark attempt: %v", err)
+						return
+					}
+					if err := run.RecordOutcome(ctx, path, AttemptOutcome{StatusCode: 404}); err != nil {
+						b.Errorf("record outcome: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RunHistory is a recorded run and the hits it found.
+type RunHistory struct {
+	RunID     string
+	TargetURL string
+	StartedAt time.Time
+	// FinishedAt is when the run went through all of its requests, zero
+	// for a run that was interrupted or is still running.
+	FinishedAt time.Time
+	// Attempted counts the paths marked as attempted by the run and
+	// Completed those of them that got a response or an error.
+	Attempted int
+	Completed int
+	Hits      []HitRecord
+	// Config is the normalized configuration of the run, sorted by key.
+	Config []ConfigValue
+}
+
+// ConfigValue returns the value of the configuration entry key, if the run
+// recorded one.
+func (r RunHistory) ConfigValue(key string) (string, bool) {
+	for _, entry := range r.Config {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	return "", false
+}
+
+// ErrRunNotFound is returned by LoadRun for a run identifier the database
+// has no run for.
+var ErrRunNotFound = errors.New("run not found")
+
+// TargetHistory returns the runs recorded against targetURL, oldest first,
+// with their hits in the order they were found.
+func (s *SQLite) TargetHistory(ctx context.Context, targetURL string) ([]RunHistory, error) {
+	return s.queryRuns(ctx, `target_url = ?`, targetURL)
+}
+
+// ListRuns returns every run in the database, oldest first.
+func (s *SQLite) ListRuns(ctx context.Context) ([]RunHistory, error) {
+	return s.queryRuns(ctx, `1 = 1`)
+}
+
+// LoadRun returns the run recorded under runID with its hits and
+// configuration.
+func (s *SQLite) LoadRun(ctx context.Context, runID string) (RunHistory, error) {
+	runs, err := s.queryRuns(ctx, `run_id = ?`, runID)
+	if err != nil {
+		return RunHistory{}, err
+	}
+	if len(runs) == 0 {
+		return RunHistory{}, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	return runs[0], nil
+}
+
+// queryRuns returns the runs matching where, oldest first, with their hits
+// and configuration.
+func (s *SQLite) queryRuns(ctx context.Context, where string, args ...any) ([]RunHistory, error) {
+	if s == nil {
+		return nil, errors.New("sqlite store is nil")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, COALESCE(run_id, ''), started_at, COALESCE(target_url, ''), COALESCE(finished_at, ''),
+       (SELECT COUNT(*) FROM path_attempted WHERE path_attempted.run_id = runs.id),
+       (SELECT COUNT(*) FROM path_attempted WHERE path_attempted.run_id = runs.id AND completed_at IS NOT NULL)
+FROM runs WHERE `+where+` ORDER BY started_at, id
+`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+
+	var (
+		runs []RunHistory
+		ids  []int64
+	)
+	for rows.Next() {
+		var (
+			run        RunHistory
+			id         int64
+			startedAt  string
+			finishedAt string
+		)
+		if err := rows.Scan(&id, &run.RunID, &startedAt, &run.TargetURL, &finishedAt, &run.Attempted, &run.Completed); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan run: %w", err)
+		}
+		run.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+		run.FinishedAt, _ = time.Parse(time.RFC3339Nano, finishedAt)
+		runs = append(runs, run)
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+
+	for i, id := range ids {
+		hits, err := s.runHits(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		runs[i].Hits = hits
+
+		config, err := s.runConfig(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		runs[i].Config = config
+	}
+
+	return runs, nil
+}
+
+func (s *SQLite) runConfig(ctx context.Context, runPK int64) ([]ConfigValue, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM run_config WHERE run_id = ? ORDER BY key`, runPK)
+	if err != nil {
+		return nil, fmt.Errorf("query run config: %w", err)
+	}
+	defer rows.Close()
+
+	var values []ConfigValue
+	for rows.Next() {
+		var value ConfigValue
+		if err := rows.Scan(&value.Key, &value.Value); err != nil {
+			return nil, fmt.Errorf("scan run config: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query run config: %w", err)
+	}
+
+	return values, nil
+}
+
+func (s *SQLite) runHits(ctx context.Context, runPK int64) ([]HitRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT path, COALESCE(status_code, 0), COALESCE(content_length, 0), COALESCE(duration_ms, 0), confidence, tags, notes, COALESCE(screenshot, '')
+FROM hits WHERE run_id = ? ORDER BY id
+`, runPK)
+	if err != nil {
+		return nil, fmt.Errorf("query hits: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []HitRecord
+	for rows.Next() {
+		var (
+			hit        HitRecord
+			durationMs int64
+			confidence sql.NullFloat64
+			tags       sql.NullString
+			notes      sql.NullString
+		)
+		if err := rows.Scan(&hit.Path, &hit.StatusCode, &hit.ContentLength, &durationMs, &confidence, &tags, &notes, &hit.Screenshot); err != nil {
+			return nil, fmt.Errorf("scan hit: %w", err)
+		}
+		hit.Duration = time.Duration(durationMs) * time.Millisecond
+		hit.Confidence, hit.HasConfidence = confidence.Float64, confidence.Valid
+		if hit.Tags, err = decodeAnnotations(tags); err != nil {
+			return nil, err
+		}
+		if hit.Notes, err = decodeAnnotations(notes); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query hits: %w", err)
+	}
+
+	return hits, nil
+}
+
+// decodeAnnotations reverses annotationColumn.
+func decodeAnnotations(column sql.NullString) ([]string, error) {
+	if !column.Valid || column.String == "" {
+		return nil, nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(column.String), &values); err != nil {
+		return nil, fmt.Errorf("decode hit annotations: %w", err)
+	}
+	return values, nil
+}
@@ -3,10 +3,14 @@ package store
 import (
 	"context"
 	"crypto/sha1"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -23,9 +27,12 @@ type SQLite struct {
 
 // Run represents a persisted execution within the database.
 type Run struct {
-	db    *sql.DB
-	id    int64
-	runID string
+	db       *sql.DB
+	id       int64
+	runID    string
+	scopeKey string
+	// continued is set when StartRun found the run already recorded.
+	continued bool
 }
 
 // RunMetadata captures contextual information for a fuzzing execution.
@@ -41,6 +48,7 @@ type RunMetadata struct {
 	RunID       string
 	ConfigList  []string
 	PayloadList []string
+	DedupScope  string
 }
 
 // HitRecord stores information about a detected hit.
@@ -49,6 +57,15 @@ type HitRecord struct {
 	StatusCode    int
 	ContentLength int64
 	Duration      time.Duration
+	// Confidence is the aggregated plugin confidence, recorded only when
+	// HasConfidence is set.
+	Confidence    float64
+	HasConfidence bool
+	// Tags and Notes are annotations attached by verification plugins.
+	Tags  []string
+	Notes []string
+	// Screenshot is the path of the image captured of the hit, if any.
+	Screenshot string
 }
 
 // OpenSQLite initializes (or connects to) the SQLite database located at the given path.
@@ -61,33 +78,82 @@ func OpenSQLite(path string) (*SQLite, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite", path)
+	// Pragmas are supplied through the DSN so that every pooled connection is
+	// configured identically. WAL mode lets readers (for example a report or a
+	// live dashboard) query the database while a scan is writing to it.
+	db, err := sql.Open("sqlite", sqliteDSN(path,
+		"busy_timeout(5000)",
+		"foreign_keys(1)",
+		"journal_mode(WAL)",
+		"synchronous(NORMAL)",
+	))
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db: %w", err)
 	}
 
-	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect sqlite db: %w", err)
+	}
 
-	pragmas := []string{
-		"PRAGMA foreign_keys = ON",
-		"PRAGMA busy_timeout = 5000",
+	if err := initSchema(db); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	for _, pragma := range pragmas {
-		if _, err := db.Exec(pragma); err != nil {
-			db.Close()
-			return nil, fmt.Errorf("set pragma %q: %w", pragma, err)
-		}
+	return &SQLite{db: db}, nil
+}
+
+// OpenSQLiteReadOnly connects to an existing SQLite database without taking
+// write locks. It is intended for inspecting a database that may be written to
+// concurrently by a running scan.
+func OpenSQLiteReadOnly(path string) (*SQLite, error) {
+	if path == "" {
+		return nil, errors.New("sqlite path must not be empty")
 	}
 
-	if err := initSchema(db); err != nil {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open sqlite db: %w", err)
+	}
+	if isBoltFile(path) {
+		return nil, fmt.Errorf("open sqlite db: %s is a Bolt database; only SQLite resume databases (--resume-backend sqlite) can be inspected", path)
+	}
+
+	db, err := sql.Open("sqlite", sqliteDSN(path,
+		"busy_timeout(5000)",
+		"query_only(1)",
+	))
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite db: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
 		db.Close()
-		return nil, err
+		return nil, fmt.Errorf("connect sqlite db: %w", err)
 	}
 
 	return &SQLite{db: db}, nil
 }
 
+// sqliteDSN builds a file: URI for path so that characters such as '?' and
+// '#' in the file name are not mistaken for the start of the query string.
+func sqliteDSN(path string, pragmas ...string) string {
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	escaped := strings.Join(segments, "/")
+	if strings.HasPrefix(escaped, "/") {
+		escaped = "//" + escaped
+	}
+
+	query := url.Values{}
+	for _, pragma := range pragmas {
+		query.Add("_pragma", pragma)
+	}
+	return "file:" + escaped + "?" + query.Encode()
+}
+
 // Close releases any resources associated with the database connection.
 func (s *SQLite) Close() error {
 	if s == nil || s.db == nil {
@@ -98,7 +164,7 @@ func (s *SQLite) Close() error {
 }
 
 // StartRun records metadata for a new execution and returns a handle for recording activity.
-func (s *SQLite) StartRun(ctx context.Context, meta RunMetadata) (*Run, error) {
+func (s *SQLite) StartRun(ctx context.Context, meta RunMetadata) (Recorder, error) {
 	if s == nil {
 		return nil, errors.New("sqlite store is nil")
 	}
@@ -122,7 +188,7 @@ func (s *SQLite) StartRun(ctx context.Context, meta RunMetadata) (*Run, error) {
 	// Try updating an existing row first so repeated runs with the same identifier
 	// refresh their metadata.
 	res, err := s.db.ExecContext(ctx, `
-UPDATE runs SET started_at = ?, target_url = ?, wordlist = ?, concurrency = ?, timeout_ms = ?, profile = ?, beginner = ?, binary_name = ?
+UPDATE runs SET started_at = ?, target_url = ?, wordlist = ?, concurrency = ?, timeout_ms = ?, profile = ?, beginner = ?, binary_name = ?, finished_at = NULL
 WHERE run_id = ?
 `, startedAt.Format(time.RFC3339Nano), meta.TargetURL, meta.Wordlist, meta.Concurrency, timeoutMs, meta.Profile, beginner, meta.BinaryName, runIdentifier)
 	if err != nil {
@@ -134,6 +200,7 @@ WHERE run_id = ?
 		return nil, fmt.Errorf("run metadata rows affected: %w", err)
 	}
 
+	var runPK int64
 	if rows == 0 {
 		res, err = s.db.ExecContext(ctx, `
 INSERT INTO runs (run_id, started_at, target_url, wordlist, concurrency, timeout_ms, profile, beginner, binary_name)
@@ -143,20 +210,60 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 			return nil, fmt.Errorf("insert run metadata: %w", err)
 		}
 
-		runPK, err := res.LastInsertId()
+		runPK, err = res.LastInsertId()
 		if err != nil {
 			return nil, fmt.Errorf("obtain run id: %w", err)
 		}
+	} else {
+		if err := s.db.QueryRowContext(ctx, `SELECT id FROM runs WHERE run_id = ?`, runIdentifier).Scan(&runPK); err != nil {
+			return nil, fmt.Errorf("lookup run id: %w", err)
+		}
+	}
 
-		return &Run{db: s.db, id: runPK, runID: runIdentifier}, nil
+	if err := s.storeRunInputs(ctx, runPK, meta); err != nil {
+		return nil, err
 	}
 
-	var runPK int64
-	if err := s.db.QueryRowContext(ctx, `SELECT id FROM runs WHERE run_id = ?`, runIdentifier).Scan(&runPK); err != nil {
-		return nil, fmt.Errorf("lookup run id: %w", err)
+	return &Run{db: s.db, id: runPK, runID: runIdentifier, scopeKey: meta.attemptScopeKey(runIdentifier), continued: rows > 0}, nil
+}
+
+// storeRunInputs replaces the normalized configuration and payload sources
+// recorded for a run so the run can be reproduced or audited later.
+func (s *SQLite) storeRunInputs(ctx context.Context, runPK int64, meta RunMetadata) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin run inputs: %w", err)
 	}
+	defer tx.Rollback()
 
-	return &Run{db: s.db, id: runPK, runID: runIdentifier}, nil
+	if _, err := tx.ExecContext(ctx, `DELETE FROM run_config WHERE run_id = ?`, runPK); err != nil {
+		return fmt.Errorf("clear run config: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM run_payloads WHERE run_id = ?`, runPK); err != nil {
+		return fmt.Errorf("clear run payloads: %w", err)
+	}
+
+	for _, entry := range meta.ConfigValues() {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO run_config (run_id, key, value) VALUES (?, ?, ?)
+`, runPK, entry.Key, entry.Value); err != nil {
+			return fmt.Errorf("insert run config: %w", err)
+		}
+	}
+
+	for _, source := range meta.PayloadSources() {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO run_payloads (run_id, source, checksum) VALUES (?, ?, ?)
+`, runPK, source.Source, source.Checksum); err != nil {
+			return fmt.Errorf("insert run payload: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit run inputs: %w", err)
+	}
+
+	return nil
 }
 
 // ID returns the run identifier within the database.
@@ -175,6 +282,11 @@ func (r *Run) RunID() string {
 	return r.runID
 }
 
+// Continued reports whether the run was recorded before StartRun returned it.
+func (r *Run) Continued() bool {
+	return r != nil && r.continued
+}
+
 // Hash returns the deterministic identifier derived from the supplied metadata.
 func (m RunMetadata) Hash() string {
 	return m.hash()
@@ -198,6 +310,61 @@ func (m RunMetadata) PayloadEntries() []string {
 	return append([]string(nil), entries...)
 }
 
+// ConfigValue is a single normalized configuration entry split into its key
+// and value.
+type ConfigValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PayloadSource identifies a payload list used by a run together with a
+// SHA-256 checksum of its contents. Checksum is empty when the source could
+// not be read.
+type PayloadSource struct {
+	Source   string `json:"source"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// ConfigValues returns the normalized configuration entries split on the first
+// "=" separator, sorted by key.
+func (m RunMetadata) ConfigValues() []ConfigValue {
+	entries := m.ConfigEntries()
+	sort.Strings(entries)
+
+	values := make([]ConfigValue, 0, len(entries))
+	for _, entry := range entries {
+		key, value, _ := strings.Cut(entry, "=")
+		values = append(values, ConfigValue{Key: strings.TrimSpace(key), Value: value})
+	}
+	return values
+}
+
+// PayloadSources returns the normalized payload entries along with checksums
+// of the files they reference.
+func (m RunMetadata) PayloadSources() []PayloadSource {
+	entries := m.PayloadEntries()
+
+	sources := make([]PayloadSource, 0, len(entries))
+	for _, entry := range entries {
+		sources = append(sources, PayloadSource{Source: entry, Checksum: fileChecksum(entry)})
+	}
+	return sources
+}
+
+func fileChecksum(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 func (m RunMetadata) normalizedConfig() []string {
 	configList := normalizeList(m.ConfigList)
 	if len(configList) == 0 {
@@ -283,9 +450,26 @@ func hashFromLists(configList, payloadList []string) string {
 }
 
 func ensureRunIDColumn(db *sql.DB) error {
-	rows, err := db.Query(`PRAGMA table_info(runs)`)
+	hasColumn, err := tableHasColumn(db, "runs", "run_id")
+	if err != nil {
+		return err
+	}
+
+	if hasColumn {
+		return nil
+	}
+
+	if _, err := db.Exec(`ALTER TABLE runs ADD COLUMN run_id TEXT`); err != nil {
+		return fmt.Errorf("add run_id column: %w", err)
+	}
+
+	return nil
+}
+
+func tableHasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
 	if err != nil {
-		return fmt.Errorf("inspect runs table: %w", err)
+		return false, fmt.Errorf("inspect %s table: %w", table, err)
 	}
 	defer rows.Close()
 
@@ -301,25 +485,136 @@ func ensureRunIDColumn(db *sql.DB) error {
 		)
 
 		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
-			return fmt.Errorf("scan table info: %w", err)
+			return false, fmt.Errorf("scan table info: %w", err)
 		}
 
-		if strings.EqualFold(name, "run_id") {
+		if strings.EqualFold(name, column) {
 			hasColumn = true
 			break
 		}
 	}
 
 	if rowsErr := rows.Err(); rowsErr != nil {
-		return fmt.Errorf("iterate table info: %w", rowsErr)
+		return false, fmt.Errorf("iterate table info: %w", rowsErr)
+	}
+
+	return hasColumn, nil
+}
+
+func ensureAttemptOutcomeColumns(db *sql.DB) error {
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{name: "status_code", ddl: `ALTER TABLE path_attempted ADD COLUMN status_code INTEGER`},
+		{name: "error", ddl: `ALTER TABLE path_attempted ADD COLUMN error TEXT`},
+		{name: "completed_at", ddl: `ALTER TABLE path_attempted ADD COLUMN completed_at TEXT`},
+	}
+
+	for _, column := range columns {
+		hasColumn, err := tableHasColumn(db, "path_attempted", column.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := db.Exec(column.ddl); err != nil {
+			return fmt.Errorf("add %s column: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureHitColumns adds the plugin verdict and screenshot columns to hits
+// tables created before hits carried them.
+func ensureHitColumns(db *sql.DB) error {
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{name: "confidence", ddl: `ALTER TABLE hits ADD COLUMN confidence REAL`},
+		{name: "tags", ddl: `ALTER TABLE hits ADD COLUMN tags TEXT`},
+		{name: "notes", ddl: `ALTER TABLE hits ADD COLUMN notes TEXT`},
+		{name: "screenshot", ddl: `ALTER TABLE hits ADD COLUMN screenshot TEXT`},
+	}
+
+	for _, column := range columns {
+		hasColumn, err := tableHasColumn(db, "hits", column.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		if _, err := db.Exec(column.ddl); err != nil {
+			return fmt.Errorf("add %s column: %w", column.name, err)
+		}
 	}
 
+	return nil
+}
+
+// ensureRunFinishedColumn adds the finished_at column to runs tables created
+// before runs recorded that they finished.
+func ensureRunFinishedColumn(db *sql.DB) error {
+	hasColumn, err := tableHasColumn(db, "runs", "finished_at")
+	if err != nil {
+		return err
+	}
 	if hasColumn {
 		return nil
 	}
 
-	if _, err := db.Exec(`ALTER TABLE runs ADD COLUMN run_id TEXT`); err != nil {
-		return fmt.Errorf("add run_id column: %w", err)
+	if _, err := db.Exec(`ALTER TABLE runs ADD COLUMN finished_at TEXT`); err != nil {
+		return fmt.Errorf("add finished_at column: %w", err)
+	}
+	return nil
+}
+
+// migrateAttemptScope rebuilds path_attempted tables created before attempts
+// were namespaced by deduplication scope. Existing rows are kept in the global
+// scope, which matches their previous behaviour.
+func migrateAttemptScope(db *sql.DB) error {
+	hasColumn, err := tableHasColumn(db, "path_attempted", "scope_key")
+	if err != nil {
+		return err
+	}
+
+	if hasColumn {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin attempt scope migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`ALTER TABLE path_attempted RENAME TO path_attempted_legacy`,
+		`CREATE TABLE path_attempted (
+                        scope_key TEXT NOT NULL DEFAULT '',
+                        path TEXT NOT NULL,
+                        run_id INTEGER NOT NULL,
+                        attempted_at TEXT NOT NULL,
+                        PRIMARY KEY(scope_key, path),
+                        FOREIGN KEY(run_id) REFERENCES runs(id)
+                )`,
+		`INSERT INTO path_attempted (scope_key, path, run_id, attempted_at)
+                SELECT '', path, run_id, attempted_at FROM path_attempted_legacy`,
+		`DROP TABLE path_attempted_legacy`,
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate attempt scope: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit attempt scope migration: %w", err)
 	}
 
 	return nil
@@ -388,7 +683,8 @@ WHERE run_id IS NULL OR run_id = ''
 	return nil
 }
 
-// MarkAttempt records that a path has been attempted. It returns true if the path is new.
+// MarkAttempt records that a path has been attempted. It returns true if the
+// path is new within the run's deduplication scope.
 func (r *Run) MarkAttempt(ctx context.Context, path string) (bool, error) {
 	if r == nil {
 		return false, errors.New("run is nil")
@@ -396,9 +692,9 @@ func (r *Run) MarkAttempt(ctx context.Context, path string) (bool, error) {
 
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	res, err := r.db.ExecContext(ctx, `
-INSERT OR IGNORE INTO path_attempted (path, run_id, attempted_at)
-VALUES (?, ?, ?)
-`, path, r.id, now)
+INSERT OR IGNORE INTO path_attempted (scope_key, path, run_id, attempted_at)
+VALUES (?, ?, ?, ?)
+`, r.scopeKey, path, r.id, now)
 	if err != nil {
 		return false, fmt.Errorf("insert path attempt: %w", err)
 	}
@@ -411,8 +707,8 @@ VALUES (?, ?, ?)
 	if rows == 0 {
 		// Update the metadata to reflect the latest run even if the path already existed.
 		if _, err := r.db.ExecContext(ctx, `
-UPDATE path_attempted SET run_id = ?, attempted_at = ? WHERE path = ?
-`, r.id, now, path); err != nil {
+UPDATE path_attempted SET run_id = ?, attempted_at = ? WHERE scope_key = ? AND path = ?
+`, r.id, now, r.scopeKey, path); err != nil {
 			return false, fmt.Errorf("update existing path attempt: %w", err)
 		}
 		return false, nil
@@ -421,6 +717,33 @@ UPDATE path_attempted SET run_id = ?, attempted_at = ? WHERE path = ?
 	return true, nil
 }
 
+// RecordOutcome stores the status code or error observed for an attempted path.
+func (r *Run) RecordOutcome(ctx context.Context, path string, outcome AttemptOutcome) error {
+	if r == nil {
+		return errors.New("run is nil")
+	}
+
+	var (
+		status   sql.NullInt64
+		errorMsg sql.NullString
+	)
+	if outcome.StatusCode > 0 {
+		status = sql.NullInt64{Int64: int64(outcome.StatusCode), Valid: true}
+	}
+	if outcome.Error != "" {
+		errorMsg = sql.NullString{String: outcome.Error, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+UPDATE path_attempted SET status_code = ?, error = ?, completed_at = ? WHERE scope_key = ? AND path = ?
+`, status, errorMsg, time.Now().UTC().Format(time.RFC3339Nano), r.scopeKey, path)
+	if err != nil {
+		return fmt.Errorf("record attempt outcome: %w", err)
+	}
+
+	return nil
+}
+
 // RecordHit saves information about a confirmed hit for the run.
 func (r *Run) RecordHit(ctx context.Context, hit HitRecord) error {
 	if r == nil {
@@ -433,10 +756,29 @@ func (r *Run) RecordHit(ctx context.Context, hit HitRecord) error {
 		durationMs = 0
 	}
 
-	_, err := r.db.ExecContext(ctx, `
-INSERT INTO hits (run_id, path, status_code, content_length, duration_ms, recorded_at)
-VALUES (?, ?, ?, ?, ?, ?)
-`, r.id, hit.Path, hit.StatusCode, hit.ContentLength, durationMs, recordedAt)
+	var confidence sql.NullFloat64
+	if hit.HasConfidence {
+		confidence = sql.NullFloat64{Float64: hit.Confidence, Valid: true}
+	}
+
+	tags, err := annotationColumn(hit.Tags)
+	if err != nil {
+		return err
+	}
+	notes, err := annotationColumn(hit.Notes)
+	if err != nil {
+		return err
+	}
+
+	var screenshot sql.NullString
+	if hit.Screenshot != "" {
+		screenshot = sql.NullString{String: hit.Screenshot, Valid: true}
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+INSERT INTO hits (run_id, path, status_code, content_length, duration_ms, confidence, tags, notes, screenshot, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, r.id, hit.Path, hit.StatusCode, hit.ContentLength, durationMs, confidence, tags, notes, screenshot, recordedAt)
 	if err != nil {
 		return fmt.Errorf("insert hit: %w", err)
 	}
@@ -444,6 +786,32 @@ VALUES (?, ?, ?, ?, ?, ?)
 	return nil
 }
 
+// Finish records that the run went through all of its requests.
+func (r *Run) Finish(ctx context.Context) error {
+	if r == nil {
+		return errors.New("run is nil")
+	}
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE runs SET finished_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), r.id); err != nil {
+		return fmt.Errorf("record run finished: %w", err)
+	}
+	return nil
+}
+
+// annotationColumn encodes plugin annotations as a JSON array, or NULL when
+// there are none.
+func annotationColumn(values []string) (sql.NullString, error) {
+	if len(values) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encode hit annotations: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
 func ensureDir(path string) error {
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "" {
@@ -467,12 +835,18 @@ func initSchema(db *sql.DB) error {
                         timeout_ms INTEGER,
                         profile TEXT,
                         beginner INTEGER,
-                        binary_name TEXT
+                        binary_name TEXT,
+                        finished_at TEXT
                 )`,
 		`CREATE TABLE IF NOT EXISTS path_attempted (
-                        path TEXT PRIMARY KEY,
+                        scope_key TEXT NOT NULL DEFAULT '',
+                        path TEXT NOT NULL,
                         run_id INTEGER NOT NULL,
                         attempted_at TEXT NOT NULL,
+                        status_code INTEGER,
+                        error TEXT,
+                        completed_at TEXT,
+                        PRIMARY KEY(scope_key, path),
                         FOREIGN KEY(run_id) REFERENCES runs(id)
                 )`,
 		`CREATE TABLE IF NOT EXISTS hits (
@@ -482,10 +856,28 @@ func initSchema(db *sql.DB) error {
                         status_code INTEGER,
                         content_length INTEGER,
                         duration_ms INTEGER,
+                        confidence REAL,
+                        tags TEXT,
+                        notes TEXT,
+                        screenshot TEXT,
                         recorded_at TEXT NOT NULL,
                         FOREIGN KEY(run_id) REFERENCES runs(id)
                 )`,
+		`CREATE TABLE IF NOT EXISTS run_config (
+                        run_id INTEGER NOT NULL,
+                        key TEXT NOT NULL,
+                        value TEXT NOT NULL,
+                        FOREIGN KEY(run_id) REFERENCES runs(id)
+                )`,
+		`CREATE TABLE IF NOT EXISTS run_payloads (
+                        run_id INTEGER NOT NULL,
+                        source TEXT NOT NULL,
+                        checksum TEXT,
+                        FOREIGN KEY(run_id) REFERENCES runs(id)
+                )`,
 		`CREATE INDEX IF NOT EXISTS idx_hits_run_id ON hits(run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_run_config_run_id ON run_config(run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_run_payloads_run_id ON run_payloads(run_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_runs_run_id ON runs(run_id)`,
 	}
 
@@ -503,5 +895,21 @@ func initSchema(db *sql.DB) error {
 		return err
 	}
 
+	if err := migrateAttemptScope(db); err != nil {
+		return err
+	}
+
+	if err := ensureAttemptOutcomeColumns(db); err != nil {
+		return err
+	}
+
+	if err := ensureHitColumns(db); err != nil {
+		return err
+	}
+
+	if err := ensureRunFinishedColumn(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,529 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenSQLiteUsesWAL(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatalf("query journal mode: %v", err)
+	}
+
+	if !strings.EqualFold(mode, "wal") {
+		t.Fatalf("expected WAL journal mode, got %q", mode)
+	}
+}
+
+func TestOpenSQLiteHandlesURIMetacharactersInPath(t *testing.T) {
+	for _, name := range []string{"runs?.db", "runs#1.db", "run%20s.db", "with space.db"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+
+			db, err := OpenSQLite(path)
+			if err != nil {
+				t.Fatalf("open sqlite: %v", err)
+			}
+			defer db.Close()
+
+			var mode string
+			if err := db.db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+				t.Fatalf("query journal mode: %v", err)
+			}
+			if !strings.EqualFold(mode, "wal") {
+				t.Fatalf("expected WAL journal mode, got %q", mode)
+			}
+
+			if _, err := os.Stat(path); err != nil {
+				t.Fatalf("expected database at %q: %v", path, err)
+			}
+
+			ro, err := OpenSQLiteReadOnly(path)
+			if err != nil {
+				t.Fatalf("open read-only: %v", err)
+			}
+			defer ro.Close()
+			if err := ro.db.Ping(); err != nil {
+				t.Fatalf("ping read-only: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadOnlyConnectionReadsDuringWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "runs.db")
+
+	writer, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer writer.Close()
+
+	run, err := writer.StartRun(ctx, RunMetadata{TargetURL: "https://example.com/FUZZ", Wordlist: "words.txt"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	reader, err := OpenSQLiteReadOnly(path)
+	if err != nil {
+		t.Fatalf("open read-only sqlite: %v", err)
+	}
+	defer reader.Close()
+
+	tx, err := writer.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin write transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO hits (run_id, path, status_code, content_length, duration_ms, recorded_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.(*Run).ID(), "/admin", 200, 10, 5, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		t.Fatalf("insert hit: %v", err)
+	}
+
+	var runs int
+	if err := reader.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM runs`).Scan(&runs); err != nil {
+		t.Fatalf("read while writing: %v", err)
+	}
+	if runs != 1 {
+		t.Fatalf("expected 1 run, got %d", runs)
+	}
+
+	if _, err := reader.db.ExecContext(ctx, `DELETE FROM runs`); err == nil {
+		t.Fatal("expected read-only connection to reject writes")
+	}
+}
+
+func TestMarkAttemptHonoursDedupScope(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	const url = "https://example.com/admin"
+
+	tests := []struct {
+		scope    string
+		first    RunMetadata
+		second   RunMetadata
+		wantSkip bool
+	}{
+		{
+			scope:    DedupScopeRun,
+			first:    RunMetadata{RunID: "run-a", TargetURL: "https://example.com/FUZZ"},
+			second:   RunMetadata{RunID: "run-b", TargetURL: "https://example.com/FUZZ"},
+			wantSkip: false,
+		},
+		{
+			scope:    DedupScopeTarget,
+			first:    RunMetadata{RunID: "run-c", TargetURL: "https://example.com/FUZZ"},
+			second:   RunMetadata{RunID: "run-d", TargetURL: "https://example.com/FUZZ"},
+			wantSkip: true,
+		},
+		{
+			scope:    DedupScopeGlobal,
+			first:    RunMetadata{RunID: "run-e", TargetURL: "https://example.com/FUZZ"},
+			second:   RunMetadata{RunID: "run-f", TargetURL: "https://other.example/FUZZ"},
+			wantSkip: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			tt.first.DedupScope = tt.scope
+			tt.second.DedupScope = tt.scope
+
+			first, err := db.StartRun(ctx, tt.first)
+			if err != nil {
+				t.Fatalf("start first run: %v", err)
+			}
+			if _, err := first.MarkAttempt(ctx, url); err != nil {
+				t.Fatalf("mark first attempt: %v", err)
+			}
+
+			second, err := db.StartRun(ctx, tt.second)
+			if err != nil {
+				t.Fatalf("start second run: %v", err)
+			}
+			inserted, err := second.MarkAttempt(ctx, url)
+			if err != nil {
+				t.Fatalf("mark second attempt: %v", err)
+			}
+
+			if inserted == tt.wantSkip {
+				t.Fatalf("scope %s: expected skip=%t, got inserted=%t", tt.scope, tt.wantSkip, inserted)
+			}
+		})
+	}
+}
+
+func TestOpenSQLiteMigratesLegacyAttempts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	stmts := []string{
+		`CREATE TABLE runs (id INTEGER PRIMARY KEY AUTOINCREMENT, run_id TEXT, started_at TEXT NOT NULL, target_url TEXT, wordlist TEXT, concurrency INTEGER, timeout_ms INTEGER, profile TEXT, beginner INTEGER, binary_name TEXT)`,
+		`CREATE TABLE path_attempted (path TEXT PRIMARY KEY, run_id INTEGER NOT NULL, attempted_at TEXT NOT NULL)`,
+		`INSERT INTO runs (id, run_id, started_at) VALUES (1, 'legacy', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO path_attempted (path, run_id, attempted_at) VALUES ('https://example.com/admin', 1, '2024-01-01T00:00:00Z')`,
+	}
+	for _, stmt := range stmts {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("seed legacy db: %v", err)
+		}
+	}
+	legacy.Close()
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("open migrated sqlite: %v", err)
+	}
+	defer db.Close()
+
+	run, err := db.StartRun(context.Background(), RunMetadata{RunID: "legacy", DedupScope: DedupScopeGlobal})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	inserted, err := run.MarkAttempt(context.Background(), "https://example.com/admin")
+	if err != nil {
+		t.Fatalf("mark attempt: %v", err)
+	}
+	if inserted {
+		t.Fatal("expected legacy attempt to be preserved in the global scope")
+	}
+}
+
+func TestRecordOutcomeDistinguishesUnsentAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	run, err := db.StartRun(ctx, RunMetadata{RunID: "outcomes"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	for _, path := range []string{"/missing", "/broken", "/pending"} {
+		if _, err := run.MarkAttempt(ctx, path); err != nil {
+			t.Fatalf("mark attempt %s: %v", path, err)
+		}
+	}
+
+	if err := run.RecordOutcome(ctx, "/missing", AttemptOutcome{StatusCode: 404}); err != nil {
+		t.Fatalf("record 404 outcome: %v", err)
+	}
+	if err := run.RecordOutcome(ctx, "/broken", AttemptOutcome{Error: "connection reset"}); err != nil {
+		t.Fatalf("record error outcome: %v", err)
+	}
+
+	rows, err := db.db.QueryContext(ctx, `SELECT path, status_code, error, completed_at FROM path_attempted ORDER BY path`)
+	if err != nil {
+		t.Fatalf("query attempts: %v", err)
+	}
+	defer rows.Close()
+
+	type attempt struct {
+		status    sql.NullInt64
+		errorMsg  sql.NullString
+		completed sql.NullString
+	}
+	got := map[string]attempt{}
+	for rows.Next() {
+		var (
+			path string
+			a    attempt
+		)
+		if err := rows.Scan(&path, &a.status, &a.errorMsg, &a.completed); err != nil {
+			t.Fatalf("scan attempt: %v", err)
+		}
+		got[path] = a
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("iterate attempts: %v", err)
+	}
+
+	if a := got["/missing"]; !a.status.Valid || a.status.Int64 != 404 || a.errorMsg.Valid {
+		t.Fatalf("unexpected outcome for /missing: %+v", a)
+	}
+	if a := got["/broken"]; a.status.Valid || a.errorMsg.String != "connection reset" {
+		t.Fatalf("unexpected outcome for /broken: %+v", a)
+	}
+	if a := got["/pending"]; a.completed.Valid || a.status.Valid || a.errorMsg.Valid {
+		t.Fatalf("expected /pending to have no outcome, got %+v", a)
+	}
+}
+
+func TestStartRunPersistsConfigAndPayloads(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	wordlist := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(wordlist, []byte("admin\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	db, err := OpenSQLite(filepath.Join(dir, "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	meta := RunMetadata{
+		RunID:       "audit",
+		ConfigList:  []string{"method=GET", "concurrency=4", "target_url=https://example.com/?a=b"},
+		PayloadList: []string{wordlist},
+	}
+
+	// Starting the same run twice must not duplicate its inputs.
+	for i := 0; i < 2; i++ {
+		if _, err := db.StartRun(ctx, meta); err != nil {
+			t.Fatalf("start run: %v", err)
+		}
+	}
+
+	var configRows int
+	if err := db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM run_config`).Scan(&configRows); err != nil {
+		t.Fatalf("count config: %v", err)
+	}
+	if configRows != 3 {
+		t.Fatalf("expected 3 config rows, got %d", configRows)
+	}
+
+	var target string
+	if err := db.db.QueryRowContext(ctx, `SELECT value FROM run_config WHERE key = 'target_url'`).Scan(&target); err != nil {
+		t.Fatalf("lookup target config: %v", err)
+	}
+	if target != "https://example.com/?a=b" {
+		t.Fatalf("unexpected target value %q", target)
+	}
+
+	var source, checksum string
+	if err := db.db.QueryRowContext(ctx, `SELECT source, checksum FROM run_payloads`).Scan(&source, &checksum); err != nil {
+		t.Fatalf("lookup payload: %v", err)
+	}
+	if source != wordlist {
+		t.Fatalf("unexpected payload source %q", source)
+	}
+	// sha256("admin\n")
+	if want := "fc8252c8dc55839967c58b9ad755a59b61b67c13227ddae4bd3f78a38bf394f7"; checksum != want {
+		t.Fatalf("expected checksum %s, got %q", want, checksum)
+	}
+}
+
+func TestRecordHitStoresPluginVerdict(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	run, err := db.StartRun(ctx, RunMetadata{TargetURL: "https://example.com/FUZZ", Wordlist: "words.txt"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	if err := run.RecordHit(ctx, HitRecord{Path: "/admin", StatusCode: 200, Confidence: 0.75, HasConfidence: true, Tags: []string{"aws-key"}, Notes: []string{"contains AWS key"}}); err != nil {
+		t.Fatalf("record hit: %v", err)
+	}
+	if err := run.RecordHit(ctx, HitRecord{Path: "/login", StatusCode: 200, Screenshot: "shots/login.png"}); err != nil {
+		t.Fatalf("record hit: %v", err)
+	}
+
+	confidences := make(map[string]sql.NullFloat64)
+	annotations := make(map[string]string)
+	rows, err := db.db.QueryContext(ctx, `SELECT path, confidence, COALESCE(tags, '') || COALESCE(notes, '') FROM hits`)
+	if err != nil {
+		t.Fatalf("query hits: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			path       string
+			confidence sql.NullFloat64
+			annotated  string
+		)
+		if err := rows.Scan(&path, &confidence, &annotated); err != nil {
+			t.Fatalf("scan hit: %v", err)
+		}
+		confidences[path] = confidence
+		annotations[path] = annotated
+	}
+
+	if got := confidences["/admin"]; !got.Valid || got.Float64 != 0.75 {
+		t.Fatalf("expected confidence 0.75 for /admin, got %+v", got)
+	}
+	if got := confidences["/login"]; got.Valid {
+		t.Fatalf("expected no confidence for /login, got %+v", got)
+	}
+	if got, want := annotations["/admin"], `["aws-key"]["contains AWS key"]`; got != want {
+		t.Fatalf("annotations for /admin = %q, want %q", got, want)
+	}
+	if got := annotations["/login"]; got != "" {
+		t.Fatalf("expected no annotations for /login, got %q", got)
+	}
+
+	var screenshot sql.NullString
+	if err := db.db.QueryRowContext(ctx, `SELECT screenshot FROM hits WHERE path = '/login'`).Scan(&screenshot); err != nil {
+		t.Fatalf("query screenshot: %v", err)
+	}
+	if screenshot.String != "shots/login.png" {
+		t.Fatalf("screenshot for /login = %+v, want shots/login.png", screenshot)
+	}
+}
+
+func TestTargetHistoryListsRunsOldestFirst(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	record := func(runID, target string, startedAt time.Time, hits ...HitRecord) {
+		t.Helper()
+		run, err := db.StartRun(ctx, RunMetadata{RunID: runID, TargetURL: target, StartedAt: startedAt})
+		if err != nil {
+			t.Fatalf("start run %s: %v", runID, err)
+		}
+		for _, hit := range hits {
+			if err := run.RecordHit(ctx, hit); err != nil {
+				t.Fatalf("record hit: %v", err)
+			}
+		}
+	}
+
+	target := "https://example.com/FUZZ"
+	record("second", target, start.Add(time.Hour), HitRecord{Path: "https://example.com/admin", StatusCode: 403, Tags: []string{"panel"}})
+	record("first", target, start, HitRecord{Path: "https://example.com/admin", StatusCode: 200, Duration: 5 * time.Millisecond})
+	record("other", "https://other.example/FUZZ", start, HitRecord{Path: "https://other.example/admin", StatusCode: 200})
+
+	runs, err := db.TargetHistory(ctx, target)
+	if err != nil {
+		t.Fatalf("target history: %v", err)
+	}
+	if len(runs) != 2 || runs[0].RunID != "first" || runs[1].RunID != "second" {
+		t.Fatalf("expected runs first and second, got %+v", runs)
+	}
+	if !runs[0].StartedAt.Equal(start) {
+		t.Fatalf("started at = %v, want %v", runs[0].StartedAt, start)
+	}
+	if got := runs[0].Hits; len(got) != 1 || got[0].StatusCode != 200 || got[0].Duration != 5*time.Millisecond {
+		t.Fatalf("unexpected hits of the first run: %+v", got)
+	}
+	if got := runs[1].Hits; len(got) != 1 || got[0].StatusCode != 403 || len(got[0].Tags) != 1 || got[0].Tags[0] != "panel" {
+		t.Fatalf("unexpected hits of the second run: %+v", got)
+	}
+}
+
+func TestLoadRunReturnsConfig(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.StartRun(ctx, RunMetadata{RunID: "nightly", TargetURL: "https://example.com/FUZZ", ConfigList: []string{"method=GET"}}); err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	run, err := db.LoadRun(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("load run: %v", err)
+	}
+	if method, ok := run.ConfigValue("method"); !ok || method != "GET" {
+		t.Fatalf("method = %q, %v; want GET", method, ok)
+	}
+
+	if _, err := db.LoadRun(ctx, "missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("expected ErrRunNotFound, got %v", err)
+	}
+}
+
+func TestListRunsReportsProgress(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	run, err := db.StartRun(ctx, RunMetadata{RunID: "nightly", TargetURL: "https://example.com/FUZZ"})
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+	if run.Continued() {
+		t.Fatal("expected a new run not to be continued")
+	}
+	for _, path := range []string{"/a", "/b"} {
+		if _, err := run.MarkAttempt(ctx, path); err != nil {
+			t.Fatalf("mark attempt: %v", err)
+		}
+	}
+	if err := run.RecordOutcome(ctx, "/a", AttemptOutcome{StatusCode: 200}); err != nil {
+		t.Fatalf("record outcome: %v", err)
+	}
+
+	runs, err := db.ListRuns(ctx)
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Attempted != 2 || runs[0].Completed != 1 || !runs[0].FinishedAt.IsZero() {
+		t.Fatalf("unexpected runs before finishing: %+v", runs)
+	}
+
+	if err := run.Finish(ctx); err != nil {
+		t.Fatalf("finish run: %v", err)
+	}
+	finished, err := db.LoadRun(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("load run: %v", err)
+	}
+	if finished.FinishedAt.IsZero() {
+		t.Fatal("expected the run to be finished")
+	}
+
+	again, err := db.StartRun(ctx, RunMetadata{RunID: "nightly", TargetURL: "https://example.com/FUZZ"})
+	if err != nil {
+		t.Fatalf("restart run: %v", err)
+	}
+	if !again.Continued() {
+		t.Fatal("expected the restarted run to be continued")
+	}
+	restarted, err := db.LoadRun(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("load run: %v", err)
+	}
+	if !restarted.FinishedAt.IsZero() {
+		t.Fatal("expected a restarted run to be unfinished")
+	}
+}
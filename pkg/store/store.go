@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Backend names accepted by Open.
+const (
+	BackendSQLite = "sqlite"
+	BackendBolt   = "bolt"
+)
+
+// Deduplication scopes control which earlier attempts cause a path to be
+// skipped.
+const (
+	// DedupScopeRun only skips paths attempted under the same run identifier.
+	DedupScopeRun = "run"
+	// DedupScopeTarget skips paths attempted by any run against the same target.
+	DedupScopeTarget = "target"
+	// DedupScopeGlobal skips paths attempted by any run in the store.
+	DedupScopeGlobal = "global"
+)
+
+// Store persists run metadata and returns recorders for individual runs.
+type Store interface {
+	StartRun(ctx context.Context, meta RunMetadata) (Recorder, error)
+	Close() error
+}
+
+// Recorder captures the activity of a single run.
+type Recorder interface {
+	RunID() string
+	// Continued reports whether the run was already in the store, so this
+	// execution picks up where an earlier one stopped.
+	Continued() bool
+	MarkAttempt(ctx context.Context, path string) (bool, error)
+	RecordOutcome(ctx context.Context, path string, outcome AttemptOutcome) error
+	RecordHit(ctx context.Context, hit HitRecord) error
+	// Finish records that the run went through all of its requests. A run
+	// started again under the same identifier is unfinished until it
+	// finishes again.
+	Finish(ctx context.Context) error
+}
+
+// AttemptOutcome describes what happened when an attempted path was requested.
+// Attempts without a recorded outcome were marked but never completed.
+type AttemptOutcome struct {
+	StatusCode int
+	Error      string
+}
+
+// Backends lists the supported storage backends.
+func Backends() []string {
+	return []string{BackendSQLite, BackendBolt}
+}
+
+// Open initializes the store implementation identified by backend at path. An
+// empty backend selects SQLite.
+func Open(backend, path string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendSQLite:
+		db, err := OpenSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case BackendBolt, "bbolt", "boltdb":
+		db, err := OpenBolt(path)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown resume backend %q (choose from %s)", backend, strings.Join(Backends(), ", "))
+	}
+}
+
+// ParseDedupScope validates a deduplication scope, defaulting to DedupScopeRun.
+func ParseDedupScope(v string) (string, error) {
+	switch scope := strings.ToLower(strings.TrimSpace(v)); scope {
+	case "":
+		return DedupScopeRun, nil
+	case DedupScopeRun, DedupScopeTarget, DedupScopeGlobal:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown dedup scope %q (choose from run, target, global)", v)
+	}
+}
+
+// attemptScopeKey returns the key that namespaces attempted paths for the
+// configured deduplication scope. The global scope uses an empty key so that
+// attempts recorded before scopes existed continue to match.
+func (m RunMetadata) attemptScopeKey(runIdentifier string) string {
+	switch strings.ToLower(strings.TrimSpace(m.DedupScope)) {
+	case DedupScopeGlobal:
+		return ""
+	case DedupScopeTarget:
+		return "target:" + strings.TrimSpace(m.TargetURL)
+	default:
+		return "run:" + runIdentifier
+	}
+}
@@ -0,0 +1,130 @@
+package templater
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FormField is a field of a multipart form template.
+type FormField struct {
+	Name string
+	// Value is the value of the field, or the content of the file it
+	// uploads when File is set.
+	Value string
+	File  bool
+	// Filename and ContentType describe the uploaded file. ContentType
+	// defaults to application/octet-stream.
+	Filename    string
+	ContentType string
+}
+
+// ParseFormField parses a curl-style form field: name=value, or
+// name=@path[;filename=name][;type=mime] to upload the file at path, which is
+// read right away. The filename defaults to the base name of path.
+func ParseFormField(spec string) (FormField, error) {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return FormField{}, fmt.Errorf("form field %q: want name=value or name=@file", spec)
+	}
+	field := FormField{Name: strings.TrimSpace(name), Value: value}
+
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return field, nil
+	}
+
+	parts := strings.Split(path, ";")
+	path = parts[0]
+	field.File = true
+	field.Filename = filepath.Base(path)
+	for _, part := range parts[1:] {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "filename":
+			field.Filename = val
+		case "type":
+			field.ContentType = strings.TrimSpace(val)
+		default:
+			return FormField{}, fmt.Errorf("form field %q: unknown attribute %q", spec, key)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FormField{}, fmt.Errorf("form field %s: %w", field.Name, err)
+	}
+	field.Value = string(data)
+	return field, nil
+}
+
+// Form is a multipart form whose field values and filenames hold
+// placeholders replaced by payloads.
+type Form struct {
+	fields []FormField
+}
+
+// NewForm returns a form of fields, sent in order.
+func NewForm(fields []FormField) (*Form, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("form has no fields")
+	}
+	return &Form{fields: fields}, nil
+}
+
+// Expand returns the form encoded as multipart/form-data with the
+// placeholders in field values and filenames replaced by payload, and the
+// Content-Type naming its boundary. Every call uses a new random boundary.
+// The content of uploaded files is sent as is.
+func (f *Form) Expand(payload string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, field := range f.fields {
+		if !field.File {
+			if err := writer.WriteField(field.Name, substitute(field.Value, payload)); err != nil {
+				return nil, "", fmt.Errorf("write form field %s: %w", field.Name, err)
+			}
+			continue
+		}
+
+		contentType := field.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(field.Name), escapeQuotes(substitute(field.Filename, payload))))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("write form field %s: %w", field.Name, err)
+		}
+		if _, err := part.Write([]byte(field.Value)); err != nil {
+			return nil, "", fmt.Errorf("write form field %s: %w", field.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("write form: %w", err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// substitute replaces the DefaultPlaceholder in value with payload. Unlike
+// Expand it never appends the payload.
+func substitute(value, payload string) string {
+	value = strings.ReplaceAll(value, "{{"+DefaultPlaceholder+"}}", payload)
+	return strings.ReplaceAll(value, DefaultPlaceholder, payload)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,78 @@
+package templater
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFormField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shell.php")
+	if err := os.WriteFile(path, []byte("<?php ?>"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	field, err := ParseFormField("upload=@" + path + ";filename=FUZZ.php;type=image/png")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !field.File || field.Name != "upload" || field.Value != "<?php ?>" || field.Filename != "FUZZ.php" || field.ContentType != "image/png" {
+		t.Fatalf("unexpected field: %+v", field)
+	}
+
+	if field, err := ParseFormField("upload=@" + path); err != nil || field.Filename != "shell.php" {
+		t.Fatalf("expected the base name as filename, got %+v, %v", field, err)
+	}
+
+	for _, spec := range []string{"novalue", "=x", "f=@" + path + ";size=1", "f=@" + path + ".missing"} {
+		if _, err := ParseFormField(spec); err == nil {
+			t.Errorf("ParseFormField(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestFormExpand(t *testing.T) {
+	form, err := NewForm([]FormField{
+		{Name: "user", Value: "FUZZ-admin"},
+		{Name: "upload", File: true, Value: "FUZZ stays", Filename: `a"FUZZ.php`},
+	})
+	if err != nil {
+		t.Fatalf("new form: %v", err)
+	}
+
+	data, contentType, err := form.Expand("x")
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse content type %q: %v", contentType, err)
+	}
+
+	reader := multipart.NewReader(strings.NewReader(string(data)), params["boundary"])
+	var got []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		got = append(got, part.FormName()+"|"+part.FileName()+"|"+part.Header.Get("Content-Type")+"|"+string(body))
+	}
+
+	want := []string{"user|||x-admin", `upload|a"x.php|application/octet-stream|FUZZ stays`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("parts = %q, want %q", got, want)
+	}
+
+	if _, other, _ := form.Expand("x"); other == contentType {
+		t.Fatal("expected a new boundary for every request")
+	}
+}
@@ -0,0 +1,130 @@
+package templater
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONTemplate is a JSON document whose fields are replaced by payloads to
+// fuzz request bodies. Fields are addressed by dot-separated paths, such as
+// "user.name", where numeric segments index arrays ("items.0.id").
+type JSONTemplate struct {
+	root any
+}
+
+// ParseJSON parses the JSON document data as a template.
+func ParseJSON(data []byte) (*JSONTemplate, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var root any
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse JSON body: %w", err)
+	}
+	if decoder.More() {
+		return nil, errors.New("parse JSON body: more than one JSON value")
+	}
+	return &JSONTemplate{root: root}, nil
+}
+
+// StringFields returns the paths of the string values of the document,
+// sorted.
+func (t *JSONTemplate) StringFields() []string {
+	var fields []string
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		switch v := value.(type) {
+		case string:
+			fields = append(fields, prefix)
+		case map[string]any:
+			for key, child := range v {
+				walk(joinField(prefix, key), child)
+			}
+		case []any:
+			for i, child := range v {
+				walk(joinField(prefix, strconv.Itoa(i)), child)
+			}
+		}
+	}
+	walk("", t.root)
+
+	sort.Strings(fields)
+	return fields
+}
+
+// Has reports whether the document has a value at field.
+func (t *JSONTemplate) Has(field string) bool {
+	_, err := t.Expand(field, "")
+	return err == nil
+}
+
+// Expand returns the document with the value at field replaced by the string
+// payload. The template itself is left unchanged, so Expand may be called
+// from several goroutines at once.
+func (t *JSONTemplate) Expand(field, payload string) ([]byte, error) {
+	root, err := replaceField(t.root, strings.Split(field, "."), payload)
+	if err != nil {
+		return nil, fmt.Errorf("JSON field %q: %w", field, err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Payloads are sent as written, not with <, > and & escaped.
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("encode JSON body: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// replaceField returns a copy of value with the value at path replaced by
+// payload, sharing what is not on the path.
+func replaceField(value any, path []string, payload string) (any, error) {
+	if len(path) == 0 {
+		return payload, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("no key %q", path[0])
+		}
+		replaced, err := replaceField(child, path[1:], payload)
+		if err != nil {
+			return nil, err
+		}
+		copied := make(map[string]any, len(v))
+		for key, val := range v {
+			copied[key] = val
+		}
+		copied[path[0]] = replaced
+		return copied, nil
+	case []any:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(v) {
+			return nil, fmt.Errorf("no index %q in an array of %d", path[0], len(v))
+		}
+		replaced, err := replaceField(v[index], path[1:], payload)
+		if err != nil {
+			return nil, err
+		}
+		copied := append([]any(nil), v...)
+		copied[index] = replaced
+		return copied, nil
+	default:
+		return nil, fmt.Errorf("%q is not inside an object or array", path[0])
+	}
+}
+
+func joinField(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,59 @@
+package templater
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONTemplateExpand(t *testing.T) {
+	tpl, err := ParseJSON([]byte(`{"user":{"name":"alice","id":7},"tags":["a","b"]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	got, err := tpl.Expand("user.name", `<admin>"`)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	want := `{"tags":["a","b"],"user":{"id":7,"name":"<admin>\""}}`
+	if string(got) != want {
+		t.Fatalf("Expand returned %s, want %s", got, want)
+	}
+
+	if got, err := tpl.Expand("tags.1", "x"); err != nil || string(got) != `{"tags":["a","x"],"user":{"id":7,"name":"alice"}}` {
+		t.Fatalf("Expand of an array element returned %s, %v", got, err)
+	}
+
+	// The template is not modified by expanding it.
+	if got, _ := tpl.Expand("user.id", "1"); string(got) != `{"tags":["a","b"],"user":{"id":"1","name":"alice"}}` {
+		t.Fatalf("Expand after earlier expansions returned %s", got)
+	}
+
+	for _, field := range []string{"user.email", "tags.2", "user.name.first"} {
+		if tpl.Has(field) {
+			t.Errorf("Has(%q) = true, want false", field)
+		}
+	}
+}
+
+func TestJSONTemplateStringFields(t *testing.T) {
+	tpl, err := ParseJSON([]byte(`{"user":{"name":"alice","id":7},"tags":["a",{"k":"v"}],"ok":true}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	want := []string{"tags.0", "tags.1.k", "user.name"}
+	if got := tpl.StringFields(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("StringFields returned %v, want %v", got, want)
+	}
+}
+
+func TestHasPlaceholder(t *testing.T) {
+	tpl := New()
+	if !tpl.HasPlaceholder("https://target/{{FUZZ}}") || !tpl.HasPlaceholder("https://target/?q=FUZZ") {
+		t.Fatal("expected placeholders to be found")
+	}
+	if tpl.HasPlaceholder("https://target/api/users") {
+		t.Fatal("expected no placeholder in a fixed URL")
+	}
+}
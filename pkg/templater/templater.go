@@ -75,6 +75,16 @@ func (t *Templater) Expand(template, payload string) string {
 	return template + "/" + payload
 }
 
+// HasPlaceholder reports whether Expand substitutes payloads into template
+// rather than appending them to it.
+func (t *Templater) HasPlaceholder(template string) bool {
+	placeholder := DefaultPlaceholder
+	if t != nil && t.placeholder != "" {
+		placeholder = t.placeholder
+	}
+	return strings.Contains(template, placeholder) || strings.Contains(template, "%s")
+}
+
 // ExpandPayload returns the list of payloads obtained by expanding ffuf-style
 // brace expressions ("{a,b}") and numeric ranges ("[1-10]") found within the
 // provided payload string. When no expandable expressions are found, the
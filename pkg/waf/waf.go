@@ -0,0 +1,155 @@
+// Package waf recognizes web application firewalls and CDNs in front of a
+// target from the headers, cookies, and block pages of its responses.
+package waf
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// Detection names a firewall or CDN found in a response and the evidence it
+// was recognized by.
+type Detection struct {
+	Name     string
+	Evidence string
+}
+
+// signature recognizes one product. A response matches when any of its
+// headers, header values, cookies, or body markers is present.
+type signature struct {
+	name string
+	// headers are header names whose presence is enough.
+	headers []string
+	// values map header names to substrings of their value, matched
+	// without regard to case.
+	values map[string]string
+	// cookies are prefixes of cookie names set by the product.
+	cookies []string
+	// bodies are substrings of block pages.
+	bodies []string
+}
+
+var signatures = []signature{
+	{
+		name:    "Cloudflare",
+		headers: []string{"Cf-Ray", "Cf-Cache-Status"},
+		values:  map[string]string{"Server": "cloudflare"},
+		cookies: []string{"__cf_bm", "__cfduid"},
+		bodies:  []string{"Attention Required! | Cloudflare", "cf-chl-"},
+	},
+	{
+		name:    "Akamai",
+		headers: []string{"X-Akamai-Transformed", "Akamai-Grn"},
+		values:  map[string]string{"Server": "akamaighost"},
+		cookies: []string{"ak_bmsc", "bm_sz"},
+		bodies:  []string{"errors.edgesuite.net"},
+	},
+	{
+		name:    "AWS CloudFront",
+		headers: []string{"X-Amz-Cf-Id"},
+		values:  map[string]string{"Server": "cloudfront", "Via": "cloudfront"},
+		bodies:  []string{"Generated by cloudfront (CloudFront)"},
+	},
+	{
+		name:    "AWS WAF",
+		cookies: []string{"aws-waf-token"},
+		bodies:  []string{"AWS WAF"},
+	},
+	{
+		name:    "Fastly",
+		headers: []string{"X-Fastly-Request-Id", "Fastly-Debug-Digest"},
+		values:  map[string]string{"X-Served-By": "cache-"},
+	},
+	{
+		name:    "Imperva Incapsula",
+		headers: []string{"X-Iinfo"},
+		values:  map[string]string{"X-Cdn": "incapsula"},
+		cookies: []string{"incap_ses_", "visid_incap_"},
+		bodies:  []string{"Incapsula incident ID"},
+	},
+	{
+		name:    "Sucuri",
+		headers: []string{"X-Sucuri-Id"},
+		values:  map[string]string{"Server": "sucuri"},
+		bodies:  []string{"Sucuri WebSite Firewall"},
+	},
+	{
+		name:    "F5 BIG-IP",
+		values:  map[string]string{"Server": "big-ip"},
+		cookies: []string{"BIGipServer", "TS01"},
+		bodies:  []string{"The requested URL was rejected. Please consult with your administrator."},
+	},
+	{
+		name:   "ModSecurity",
+		values: map[string]string{"Server": "mod_security"},
+		bodies: []string{"This error was generated by Mod_Security", "Mod_Security"},
+	},
+	{
+		name:    "Azure Front Door",
+		headers: []string{"X-Azure-Ref"},
+	},
+	{
+		name:    "Barracuda",
+		cookies: []string{"barra_counter_session"},
+		bodies:  []string{"Barracuda Web Application Firewall"},
+	},
+}
+
+// Detect returns the firewalls and CDNs recognized in a response with the
+// given header and body, in a fixed order.
+func Detect(header http.Header, body []byte) []Detection {
+	var cookies []string
+	for _, line := range header.Values("Set-Cookie") {
+		name, _, _ := strings.Cut(line, "=")
+		cookies = append(cookies, strings.TrimSpace(name))
+	}
+
+	var detections []Detection
+	for _, sig := range signatures {
+		if evidence := sig.match(header, cookies, body); evidence != "" {
+			detections = append(detections, Detection{Name: sig.name, Evidence: evidence})
+		}
+	}
+	return detections
+}
+
+// match returns the evidence the response matches s by, or "".
+func (s signature) match(header http.Header, cookies []string, body []byte) string {
+	for _, name := range s.headers {
+		if header.Get(name) != "" {
+			return name + " header"
+		}
+	}
+	for name, value := range s.values {
+		if strings.Contains(strings.ToLower(header.Get(name)), value) {
+			return name + ": " + header.Get(name)
+		}
+	}
+	for _, prefix := range s.cookies {
+		for _, cookie := range cookies {
+			if strings.HasPrefix(cookie, prefix) {
+				return cookie + " cookie"
+			}
+		}
+	}
+	for _, marker := range s.bodies {
+		if bytes.Contains(body, []byte(marker)) {
+			return "block page"
+		}
+	}
+	return ""
+}
+
+// BlockPage reports whether body is the block page of a known firewall and
+// returns the firewall's name.
+func BlockPage(body []byte) (string, bool) {
+	for _, sig := range signatures {
+		for _, marker := range sig.bodies {
+			if bytes.Contains(body, []byte(marker)) {
+				return sig.name, true
+			}
+		}
+	}
+	return "", false
+}
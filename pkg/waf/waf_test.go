@@ -0,0 +1,44 @@
+package waf
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   []string
+	}{
+		{name: "plain", header: http.Header{"Server": {"nginx"}}, body: "<html>not found</html>"},
+		{name: "cloudflare header", header: http.Header{"Cf-Ray": {"8a1b2c3d4e5f-AMS"}, "Server": {"cloudflare"}}, want: []string{"Cloudflare"}},
+		{name: "cloudfront via", header: http.Header{"Via": {"1.1 abc.cloudfront.net (CloudFront)"}}, want: []string{"AWS CloudFront"}},
+		{name: "incapsula cookie", header: http.Header{"Set-Cookie": {"incap_ses_123_456=abc; path=/"}}, want: []string{"Imperva Incapsula"}},
+		{name: "modsecurity block page", body: "<p>This error was generated by Mod_Security.</p>", want: []string{"ModSecurity"}},
+		{
+			name:   "several",
+			header: http.Header{"X-Amz-Cf-Id": {"abc"}, "Set-Cookie": {"aws-waf-token=xyz"}},
+			want:   []string{"AWS CloudFront", "AWS WAF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			got := Detect(header, []byte(tt.body))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %+v", tt.want, got)
+			}
+			for i, d := range got {
+				if d.Name != tt.want[i] || d.Evidence == "" {
+					t.Fatalf("expected %v with evidence, got %+v", tt.want, got)
+				}
+			}
+		})
+	}
+}
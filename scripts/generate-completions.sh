@@ -5,9 +5,14 @@ repo_root="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
 out_dir="${repo_root}/completions"
 mkdir -p "${out_dir}"
 
+# An empty config directory keeps the user profiles of whoever runs this out
+# of the --profile completions.
+config_home="$(mktemp -d)"
+trap 'rm -rf "${config_home}"' EXIT
+
 for shell in bash zsh fish; do
   out_file="${out_dir}/hydro.${shell}"
   echo "Generating ${shell} completions -> ${out_file}" >&2
-  go run ./cmd/hydro --completion-script "${shell}" >"${out_file}"
+  (cd "${repo_root}" && XDG_CONFIG_HOME="${config_home}" go run ./cmd/hydro --completion-script "${shell}") >"${out_file}"
   chmod 644 "${out_file}"
 done
@@ -5,14 +5,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -67,6 +71,7 @@ type jsonlHeader struct {
 }
 
 type jsonlEntry struct {
+	Type      string  `json:"type"`
 	URL       string  `json:"url"`
 	Status    int     `json:"status"`
 	Size      int64   `json:"size"`
@@ -152,6 +157,24 @@ func TestHydroEndToEndScanProducesJSONLAndSupportsPlugins(t *testing.T) {
 		t.Fatalf("expected 5 result entries, got %d", len(entries))
 	}
 
+	data, err := os.ReadFile(jsonlPath)
+	if err != nil {
+		t.Fatalf("read jsonl: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var footer struct {
+		Type         string         `json:"type"`
+		Requests     int            `json:"requests"`
+		Hits         int            `json:"hits"`
+		HitsByStatus map[string]int `json:"hits_by_status"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &footer); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if footer.Type != "summary" || footer.Requests != 5 || footer.Hits != 2 || footer.HitsByStatus["200"] != 2 {
+		t.Fatalf("unexpected run summary: %+v", footer)
+	}
+
 	mu.Lock()
 	recorded := make(map[string]int, len(requests))
 	for path, count := range requests {
@@ -278,27 +301,313 @@ func TestHydroResumeSkipsCompletedRequests(t *testing.T) {
 	}
 }
 
+func TestHydroResumeKeepsEarlierOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlistPath := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(wordlistPath, []byte("alpha\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	resumeDB := filepath.Join(dir, "resume.db")
+	outputPath := filepath.Join(dir, "results.jsonl")
+	burpPath := filepath.Join(dir, "export.xml")
+
+	runHydroCommand(t,
+		"-u", server.URL+"/FUZZ",
+		"-w", wordlistPath,
+		"--method", http.MethodGet,
+		"--match-status", "200",
+		"--no-baseline",
+		"--resume", resumeDB,
+		"--run-id", "e2e-keep",
+		"--output", outputPath,
+		"--burp-export", burpPath,
+		"--color-mode", "never",
+	)
+
+	// The run continues with a word it had not reached.
+	if err := os.WriteFile(wordlistPath, []byte("alpha\nbeta\n"), 0o600); err != nil {
+		t.Fatalf("extend wordlist: %v", err)
+	}
+	runHydroCommand(t, "resume", "--db", resumeDB, "e2e-keep")
+
+	want := []string{server.URL + "/alpha", server.URL + "/beta"}
+
+	_, entries := readJSONL(t, outputPath)
+	var urls []string
+	for _, entry := range entries {
+		if entry.URL != "" {
+			urls = append(urls, entry.URL)
+		}
+	}
+	if !slices.Equal(urls, want) {
+		t.Fatalf("expected %s to hold %v, got %v", outputPath, want, urls)
+	}
+
+	data, err := os.ReadFile(burpPath)
+	if err != nil {
+		t.Fatalf("read burp export: %v", err)
+	}
+	var export struct {
+		Items []struct {
+			URL string `xml:"url"`
+		} `xml:"item"`
+	}
+	if err := xml.Unmarshal(data, &export); err != nil {
+		t.Fatalf("decode burp export: %v\n%s", err, data)
+	}
+	urls = urls[:0]
+	for _, item := range export.Items {
+		urls = append(urls, item.URL)
+	}
+	if !slices.Equal(urls, want) {
+		t.Fatalf("expected %s to hold %v, got %v", burpPath, want, urls)
+	}
+}
+
+func TestHydroResumeFromJSONLSkipsRecordedURLs(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	wordlistPath := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(wordlistPath, []byte("alpha\nbeta\ngamma\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	previous := strings.Join([]string{
+		`{"type":"run","run_id":"earlier"}`,
+		fmt.Sprintf(`{"url":%q,"status":200,"size":2,"latency_ms":1}`, server.URL+"/api/alpha"),
+		fmt.Sprintf(`{"url":%q,"status":0,"size":0,"latency_ms":1,"error":"timeout"}`, server.URL+"/api/beta"),
+		"",
+	}, "\n")
+	resultsPath := filepath.Join(dir, "results.jsonl")
+	if err := os.WriteFile(resultsPath, []byte(previous), 0o600); err != nil {
+		t.Fatalf("write previous results: %v", err)
+	}
+
+	_, _ = runHydroCommand(t,
+		"-u", server.URL+"/api/FUZZ",
+		"-w", wordlistPath,
+		"--method", http.MethodGet,
+		"--no-baseline",
+		"--timeout", "2s",
+		"--resume-from", resultsPath,
+		"--output", resultsPath,
+		"--color-mode", "never",
+	)
+
+	mu.Lock()
+	recorded := copyMap(requests)
+	mu.Unlock()
+
+	if recorded["/api/alpha"] != 0 {
+		t.Fatalf("expected /api/alpha to be skipped, got %d requests", recorded["/api/alpha"])
+	}
+	for _, path := range []string{"/api/beta", "/api/gamma"} {
+		if recorded[path] != 1 {
+			t.Fatalf("expected %s to be requested once, got %d", path, recorded[path])
+		}
+	}
+
+	_, entries := readJSONL(t, resultsPath)
+	// The original two entries, a second run header, and the two new results.
+	if len(entries) != 5 {
+		t.Fatalf("expected previous results to be preserved and appended to, got %d entries", len(entries))
+	}
+}
+
+// jsonBodyMethods runs a --json-body scan against a server that records the
+// request methods it receives.
+func jsonBodyMethods(t *testing.T, env []string, extraArgs ...string) map[string]int {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		methods = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods[r.Method]++
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlistPath := filepath.Join(dir, "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("alice\nbob\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	bodyPath := filepath.Join(dir, "body.json")
+	if err := os.WriteFile(bodyPath, []byte(`{"name":"x"}`), 0o600); err != nil {
+		t.Fatalf("write body: %v", err)
+	}
+
+	args := append([]string{
+		"-u", server.URL + "/api/users",
+		"-w", wordlistPath,
+		"--json-body", bodyPath,
+		"--no-baseline",
+		"--output", filepath.Join(dir, "results.jsonl"),
+	}, extraArgs...)
+	runHydroCommandEnv(t, env, args...)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return copyMap(methods)
+}
+
+func TestHydroJSONBodyPostsWhenMethodComesFromEnvironment(t *testing.T) {
+	methods := jsonBodyMethods(t, []string{"HYDRO_METHOD=GET"})
+	if methods[http.MethodGet] != 0 || methods[http.MethodPost] == 0 {
+		t.Fatalf("expected HYDRO_METHOD not to override the POST default of --json-body, got %v", methods)
+	}
+
+	methods = jsonBodyMethods(t, nil, "--method", "GET")
+	if methods[http.MethodPost] != 0 || methods[http.MethodGet] == 0 {
+		t.Fatalf("expected an explicit --method to be honoured, got %v", methods)
+	}
+}
+
+func TestHydroJSONBodyPostsWhenMethodComesFromProfile(t *testing.T) {
+	// The api profile sets GET, which must not count as an explicit --method.
+	methods := jsonBodyMethods(t, nil, "--profile", "api")
+	if methods[http.MethodGet] != 0 || methods[http.MethodPost] == 0 {
+		t.Fatalf("expected the profile method not to override the POST default of --json-body, got %v", methods)
+	}
+}
+
 func runHydroCommand(t *testing.T, args ...string) (string, string) {
 	t.Helper()
 
+	return runHydroCommandEnv(t, nil, args...)
+}
+
+// runHydroCommandEnv runs hydro with env appended to the test's environment.
+// The scan may exit with status 1 because it found hits.
+func runHydroCommandEnv(t *testing.T, env []string, args ...string) (string, string) {
+	t.Helper()
+
+	stdout, stderr, code := runHydro(t, env, args...)
+	if code != 0 && code != 1 {
+		t.Fatalf("hydro command exited with status %d\nstdout:%s\nstderr:%s", code, stdout, stderr)
+	}
+
+	return stdout, stderr
+}
+
+// runHydro runs hydro with env appended to the test's environment and
+// returns its output and exit status.
+func runHydro(t *testing.T, env []string, args ...string) (string, string, int) {
+	t.Helper()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, hydroBinary, args...)
 	cmd.Dir = repoRoot
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			t.Fatalf("hydro command timed out; stdout=%s stderr=%s", stdout.String(), stderr.String())
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		t.Fatalf("hydro command timed out; stdout=%s stderr=%s", stdout.String(), stderr.String())
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("run hydro: %v", err)
+	}
+
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestHydroExitStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" {
+			_, _ = w.Write([]byte("admin"))
+			return
 		}
-		t.Fatalf("hydro command failed: %v\nstdout:%s\nstderr:%s", err, stdout.String(), stderr.String())
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	hits := filepath.Join(dir, "hits.txt")
+	if err := os.WriteFile(hits, []byte("admin\nmissing\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	misses := filepath.Join(dir, "misses.txt")
+	if err := os.WriteFile(misses, []byte("missing\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "hits", args: []string{"-u", server.URL + "/FUZZ", "-w", hits}, want: 1},
+		{name: "hits without failing", args: []string{"-u", server.URL + "/FUZZ", "-w", hits, "--fail-on-hits=false"}, want: 0},
+		{name: "no hits", args: []string{"-u", server.URL + "/FUZZ", "-w", misses}, want: 0},
+		{name: "usage error", args: []string{"-w", hits}, want: 2},
+		{name: "runtime error", args: []string{"-u", server.URL + "/FUZZ", "-w", filepath.Join(dir, "absent.txt")}, want: 3},
+		{name: "output error", args: []string{"-u", server.URL + "/FUZZ", "-w", hits, "--resume", filepath.Join(dir, "resume.db"), "--output", dir}, want: 3},
 	}
 
-	return stdout.String(), stderr.String()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--match-status", "200"}, tt.args...)
+			stdout, stderr, code := runHydro(t, nil, args...)
+			if code != tt.want {
+				t.Fatalf("expected exit status %d, got %d\nstdout:%s\nstderr:%s", tt.want, code, stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestHydroRejectsRotateSizeBeforeScanning(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlistPath := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	_, stderr, code := runHydro(t, nil, "-u", server.URL+"/FUZZ", "-w", wordlistPath, "--output", filepath.Join(dir, "results.jsonl"), "--output-rotate-size", "lots")
+	if code != 2 || !strings.Contains(stderr, "--output-rotate-size") {
+		t.Fatalf("expected a usage error for --output-rotate-size, got status %d\n%s", code, stderr)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("expected no requests before the usage error, got %d", n)
+	}
 }
 
 func readJSONL(t *testing.T, path string) (jsonlHeader, []jsonlEntry) {
@@ -325,12 +634,16 @@ func readJSONL(t *testing.T, path string) (jsonlHeader, []jsonlEntry) {
 		t.Fatalf("decode header: %v", err)
 	}
 
+	// The run summary written last is not an entry.
 	var entries []jsonlEntry
 	for scanner.Scan() {
 		var entry jsonlEntry
 		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
 			t.Fatalf("decode entry: %v", err)
 		}
+		if entry.Type == "summary" {
+			continue
+		}
 		entries = append(entries, entry)
 	}
 	if err := scanner.Err(); err != nil {
@@ -381,3 +694,234 @@ func copyMap(src map[string]int) map[string]int {
 	}
 	return dst
 }
+
+// dryRunConfig returns the config entries of the run header hydro plans with
+// args.
+func dryRunConfig(t *testing.T, args ...string) []string {
+	t.Helper()
+
+	wordlistPath := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+
+	args = append([]string{"-u", "http://127.0.0.1:1/FUZZ", "-w", wordlistPath, "--dry-run", "--output-format", "json"}, args...)
+	stdout, _ := runHydroCommand(t, args...)
+
+	var plan struct {
+		Config []string `json:"config"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &plan); err != nil {
+		t.Fatalf("decode dry run: %v\n%s", err, stdout)
+	}
+	return plan.Config
+}
+
+func TestHydroRunConfigOmitsUnsetCharsetDecoding(t *testing.T) {
+	if config := dryRunConfig(t); slices.ContainsFunc(config, func(entry string) bool {
+		return strings.HasPrefix(entry, "no_charset_decode=")
+	}) {
+		t.Fatalf("expected no charset entry by default, so run identifiers stay unchanged, got %v", config)
+	}
+
+	if config := dryRunConfig(t, "--no-charset-decode"); !slices.Contains(config, "no_charset_decode=true") {
+		t.Fatalf("expected no_charset_decode=true with --no-charset-decode, got %v", config)
+	}
+}
+
+func TestHydroRunConfigHashesSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlistPath := filepath.Join(dir, "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	outputPath := filepath.Join(dir, "results.jsonl")
+	resumePath := filepath.Join(dir, "resume.db")
+
+	secrets := []string{"session-secret", "password-secret", "hook-secret", "stream-secret"}
+	args := []string{
+		"--cookie", "sid=" + secrets[0],
+		"-d", "pw=" + secrets[1],
+		"--pre-hook", "echo '{}' # " + secrets[2],
+		"--stream-addr", "127.0.0.1:0",
+		"--stream-token", secrets[3],
+		"--method", http.MethodPost,
+	}
+
+	config := dryRunConfig(t, args...)
+	runHydroCommand(t, append([]string{"-u", server.URL + "/FUZZ", "-w", wordlistPath, "--output", outputPath, "--resume", resumePath}, args...)...)
+	header, _ := readJSONL(t, outputPath)
+	database, err := os.ReadFile(resumePath)
+	if err != nil {
+		t.Fatalf("read resume database: %v", err)
+	}
+
+	for _, secret := range secrets {
+		if strings.Contains(strings.Join(config, "\n"), secret) {
+			t.Fatalf("dry run config holds %q: %v", secret, config)
+		}
+		if strings.Contains(strings.Join(header.Config, "\n"), secret) {
+			t.Fatalf("JSONL header holds %q: %v", secret, header.Config)
+		}
+		if bytes.Contains(database, []byte(secret)) {
+			t.Fatalf("resume database holds %q", secret)
+		}
+	}
+	for _, key := range []string{"cookie=sha256:", "data=sha256:", "pre_hook=sha256:", "stream_token=sha256:"} {
+		if !slices.ContainsFunc(config, func(entry string) bool { return strings.HasPrefix(entry, key) }) {
+			t.Fatalf("expected a %s entry, got %v", key, config)
+		}
+	}
+}
+
+func TestHydroConfigValidateRequiresConfirmLegal(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "plain", args: nil, want: 0},
+		{name: "aggressive", args: []string{"--aggressive"}, want: 1},
+		{name: "allow dangerous", args: []string{"--allow-dangerous"}, want: 1},
+		{name: "allow dangerous confirmed", args: []string{"--allow-dangerous", "--confirm-legal"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := []string{"XDG_CONFIG_HOME=" + t.TempDir(), "HOME=" + t.TempDir()}
+			_, stderr, code := runHydro(t, env, append([]string{"config", "validate"}, tt.args...)...)
+			if code != tt.want {
+				t.Fatalf("expected exit status %d, got %d\nstderr:%s", tt.want, code, stderr)
+			}
+			if tt.want != 0 && !strings.Contains(stderr, "require --confirm-legal") {
+				t.Fatalf("expected the --confirm-legal problem, got %s", stderr)
+			}
+		})
+	}
+}
+
+func TestHydroMonitorReportsChanges(t *testing.T) {
+	var body atomic.Value
+	body.Store("admin")
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin" {
+			http.NotFound(w, r)
+			return
+		}
+		if status.Load() == 0 {
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	wordlistPath := filepath.Join(dir, "wordlist.txt")
+	if err := os.WriteFile(wordlistPath, []byte("admin\nmissing\n"), 0o600); err != nil {
+		t.Fatalf("write wordlist: %v", err)
+	}
+	// A HEAD scan records no body to compare later ones with.
+	results := make(map[string]string)
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		results[method] = filepath.Join(dir, method+".jsonl")
+		runHydroCommand(t, "-u", server.URL+"/FUZZ", "-w", wordlistPath, "--method", method, "--match-status", "200", "--output", results[method])
+	}
+
+	tests := []struct {
+		name   string
+		scan   string
+		status int32
+		body   string
+		change string
+		want   int
+	}{
+		{name: "unchanged", scan: http.MethodGet, status: http.StatusOK, body: "admin", want: 0},
+		{name: "unchanged after HEAD", scan: http.MethodHead, status: http.StatusOK, body: "admin", want: 0},
+		{name: "status", scan: http.MethodGet, status: http.StatusForbidden, body: "admin", change: "status", want: 1},
+		{name: "content", scan: http.MethodGet, status: http.StatusOK, body: "changed", change: "content", want: 1},
+		{name: "unavailable", scan: http.MethodGet, change: "unavailable", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status.Store(tt.status)
+			body.Store(tt.body)
+
+			stdout, stderr, code := runHydro(t, nil, "monitor", "--rounds", "1", "--match-status", "200", results[tt.scan])
+			if code != tt.want {
+				t.Fatalf("expected exit status %d, got %d\nstdout:%s\nstderr:%s", tt.want, code, stdout, stderr)
+			}
+			if tt.change == "" && stdout != "" {
+				t.Fatalf("expected no changes, got %q", stdout)
+			}
+			if (tt.change != "" && !strings.Contains(stdout, tt.change+" ")) || !strings.Contains(stderr, "round 1: 1 URLs") {
+				t.Fatalf("expected a %s change for the one finding\nstdout:%s\nstderr:%s", tt.change, stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestHydroPrintConfigSources(t *testing.T) {
+	home := t.TempDir()
+	env := []string{"HOME=" + home, "XDG_CONFIG_HOME=" + home, "HYDRO_CONCURRENCY=7"}
+	stdout, stderr, code := runHydro(t, env, "--print-config", "--profile", "stealth", "-u", "http://target/FUZZ", "--method", "GET", "--cookie", "sid=s3cret")
+	if code != 0 {
+		t.Fatalf("expected exit status 0, got %d\nstderr:%s", code, stderr)
+	}
+
+	rows := make(map[string]string)
+	for _, line := range strings.Split(stdout, "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 {
+			rows[fields[0]] = strings.Join(fields[1:], " ")
+		}
+	}
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{flag: "method", want: "GET command line"},
+		{flag: "concurrency", want: "7 environment (HYDRO_CONCURRENCY)"},
+		{flag: "throttle", want: "1s profile stealth"},
+		{flag: "cookie", want: "[redacted] command line"},
+		{flag: "proxy", want: `"" default`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			if got := rows[tt.flag]; got != tt.want {
+				t.Fatalf("expected --%s to print %q, got %q", tt.flag, tt.want, got)
+			}
+		})
+	}
+}
+
+// TestHydroCompletionScriptsAreCurrent fails when a flag changed without
+// running scripts/generate-completions.sh.
+func TestHydroCompletionScriptsAreCurrent(t *testing.T) {
+	env := []string{"XDG_CONFIG_HOME=" + t.TempDir()}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			stdout, stderr, code := runHydro(t, env, "--completion-script", shell)
+			if code != 0 {
+				t.Fatalf("expected exit status 0, got %d\nstderr:%s", code, stderr)
+			}
+
+			committed, err := os.ReadFile(filepath.Join(repoRoot, "completions", "hydro."+shell))
+			if err != nil {
+				t.Fatalf("read completions: %v", err)
+			}
+			if stdout != string(committed) {
+				t.Fatalf("completions/hydro.%s is out of date; run scripts/generate-completions.sh", shell)
+			}
+		})
+	}
+}